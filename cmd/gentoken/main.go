@@ -2,28 +2,43 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"backend-go-ticketing-gamify/internal/config"
+	"backend-go-ticketing-gamify/internal/jwtauth"
+	"backend-go-ticketing-gamify/internal/organizations"
+	"backend-go-ticketing-gamify/internal/server"
 )
 
 func main() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "supersecretkey" // Fallback to default in .env usually
+	cfg := config.Load()
+	if cfg.JWTSecret == "" {
+		cfg.JWTSecret = "supersecretkey" // Fallback to default in .env usually
+	}
+	keys, err := jwtauth.LoadKeySet(server.KeyConfig(cfg))
+	if err != nil {
+		log.Fatalf("load signing key: %v", err)
 	}
 
 	// Adit Admin ID from sample_data.go
 	uid := "6b062d3e-1c3f-4a6f-936e-73f8c4e78a60"
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	now := time.Now()
+	t, err := keys.Sign(jwt.MapClaims{
 		"sub":  uid,
+		"jti":  uuid.NewString(),
 		"name": "Adit Santoso",
 		"role": "admin",
-		"exp":  time.Now().Add(24 * time.Hour).Unix(),
+		"org":  organizations.DefaultID,
+		"iat":  now.Unix(),
+		"exp":  now.Add(24 * time.Hour).Unix(),
 	})
-
-	t, _ := token.SignedString([]byte(secret))
+	if err != nil {
+		log.Fatalf("sign token: %v", err)
+	}
 	fmt.Print(t)
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/config"
+	"backend-go-ticketing-gamify/internal/database"
+	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/imports"
+)
+
+func main() {
+	projectID := flag.String("project", "", "project to import into (required)")
+	source := flag.String("source", imports.SourceCSV, "format of the file: csv, jira or github")
+	file := flag.String("file", "", "export to import (required)")
+	mappingFile := flag.String("mapping", "", "JSON mapping of columns and values (required for csv)")
+	as := flag.String("as", "", "username of the admin or project manager running the import (required)")
+	dryRun := flag.Bool("dry-run", false, "validate and report without writing anything")
+	awardXP := flag.Bool("award-xp", false, "grant completion XP for tickets imported as done")
+	flag.Parse()
+	if *projectID == "" || *file == "" || *as == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var mapping imports.Mapping
+	if *mappingFile != "" {
+		data, err := os.ReadFile(*mappingFile)
+		if err != nil {
+			log.Fatalf("read mapping: %v", err)
+		}
+		if err := json.Unmarshal(data, &mapping); err != nil {
+			log.Fatalf("invalid mapping: %v", err)
+		}
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("open export: %v", err)
+	}
+	defer f.Close()
+
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := database.NewPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("failed to create db pool: %v", err)
+	}
+	defer pool.Close()
+
+	auditSvc := audit.NewService(audit.NewRepository(pool))
+	gamSvc := gamification.NewService(gamification.NewRepository(pool), auditSvc)
+	svc := imports.NewService(imports.NewRepository(pool), auditSvc, gamSvc)
+
+	actor, role, err := svc.ActorByUsername(ctx, *as)
+	if err != nil {
+		log.Fatalf("load user: %v", err)
+	}
+	if actor == nil {
+		log.Fatalf("no active user %q", *as)
+	}
+	if role != "admin" && role != "project_manager" {
+		log.Fatalf("%s is a %s; imports need an admin or project manager", *as, role)
+	}
+
+	report, err := svc.Import(ctx, *actor, imports.Options{
+		ProjectID: *projectID,
+		Source:    *source,
+		Mapping:   mapping,
+		DryRun:    *dryRun,
+		AwardXP:   *awardXP,
+	}, f)
+	if report != nil {
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		_ = out.Encode(report)
+	}
+	switch {
+	case errors.Is(err, imports.ErrImportRejected):
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	case err != nil:
+		log.Fatalf("import: %v", err)
+	}
+	if report.Rejected() {
+		// let scripts tell a clean dry run from one that would be rejected
+		os.Exit(1)
+	}
+}
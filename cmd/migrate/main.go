@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"backend-go-ticketing-gamify/internal/config"
+	"backend-go-ticketing-gamify/internal/database"
+)
+
+const usage = `usage: migrate <command>
+
+commands:
+  up        apply all pending migrations
+  down [N]  revert the latest N applied migrations (default 1)
+  status    list migrations; exits 1 when any is pending, modified or unknown`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := database.NewPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("failed to create db pool: %v", err)
+	}
+	defer pool.Close()
+
+	migrator, err := database.NewMigrator(pool)
+	if err != nil {
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, version := range applied {
+			log.Printf("applied %04d", version)
+		}
+		if err != nil {
+			log.Fatalf("migrate up: %v", err)
+		}
+		log.Printf("%d migrations applied", len(applied))
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				log.Fatalf("down: N must be a positive number, got %q", os.Args[2])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, version := range reverted {
+			log.Printf("reverted %04d", version)
+		}
+		if err != nil {
+			log.Fatalf("migrate down: %v", err)
+		}
+		log.Printf("%d migrations reverted", len(reverted))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("migrate status: %v", err)
+		}
+		clean := true
+		for _, st := range statuses {
+			state := "pending"
+			switch {
+			case st.Unknown:
+				state = "UNKNOWN (not in this build)"
+			case st.Modified:
+				state = "MODIFIED since applied"
+			case st.AppliedAt != nil:
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if st.AppliedAt == nil || st.Modified || st.Unknown {
+				clean = false
+			}
+			fmt.Printf("%04d_%-24s %s\n", st.Version, st.Name, state)
+		}
+		if !clean {
+			// let CI fail when the database is not exactly at this build's schema
+			pool.Close()
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
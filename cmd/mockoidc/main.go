@@ -0,0 +1,32 @@
+// Command mockoidc is a minimal OpenID Connect provider for local development
+// and testing SSO login. It signs in whoever fills the form on its authorize
+// page; never expose it outside a dev machine.
+//
+//	go run ./cmd/mockoidc -addr :9400 -client-id ticketing
+//
+// Then start the API with OIDC_ISSUER=http://localhost:9400 OIDC_CLIENT_ID=ticketing.
+// Tests use the same provider from internal/oidc/oidctest.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"backend-go-ticketing-gamify/internal/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", ":9400", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9400", "issuer URL (must match OIDC_ISSUER)")
+	clientID := flag.String("client-id", "ticketing", "accepted client_id")
+	secret := flag.String("client-secret", "", "required client secret (empty accepts public clients)")
+	flag.Parse()
+
+	p, err := oidctest.New(*issuer, *clientID, *secret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("mock OIDC provider %s listening on %s", p.Issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, p))
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os/signal"
 	"syscall"
+	// embedded zone database for user timezones; the runtime image has none
+	_ "time/tzdata"
 
 	"backend-go-ticketing-gamify/internal/config"
 	"backend-go-ticketing-gamify/internal/database"
@@ -27,7 +29,18 @@ func main() {
 	}
 	defer pool.Close()
 
-	srv := server.New(cfg, pool)
+	if cfg.MigrateOnStart {
+		applied, err := database.Migrate(ctx, pool)
+		if err != nil {
+			log.Fatalf("failed to migrate database: %v", err)
+		}
+		log.Printf("applied %d migrations", len(applied))
+	}
+
+	srv, err := server.New(cfg, pool)
+	if err != nil {
+		log.Fatalf("failed to build server: %v", err)
+	}
 
 	if err := srv.Start(ctx); err != nil {
 		log.Fatalf("fatal server error: %v", err)
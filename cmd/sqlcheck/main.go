@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/joho/godotenv"
+
+	"backend-go-ticketing-gamify/internal/database"
+	"backend-go-ticketing-gamify/internal/database/sqlscan"
+)
+
+// sqlcheck verifies the SQL in the Go sources against a real schema. It
+// applies the migrations to DATABASE_URL (use a throwaway database), then
+// asks Postgres to prepare every complete statement it finds in string
+// constants, so a reference to a missing table or column fails here instead
+// of at request time. Statements built with fmt verbs are skipped.
+//
+//	go run ./cmd/sqlcheck            # checks ./internal
+//	go run ./cmd/sqlcheck -list      # only prints the statements found
+func main() {
+	_ = godotenv.Load()
+
+	dir := flag.String("dir", "internal", "directory to scan for Go files")
+	list := flag.Bool("list", false, "print the statements found without checking them")
+	flag.Parse()
+
+	statements, err := sqlscan.Collect(*dir)
+	if err != nil {
+		log.Fatalf("scanning %s: %v", *dir, err)
+	}
+	if *list {
+		for _, st := range statements {
+			fmt.Printf("%s\n%s\n\n", st.Pos, st.SQL)
+		}
+		log.Printf("%d statements", len(statements))
+		return
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("DATABASE_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	pool, err := database.NewPool(ctx, dsn)
+	if err != nil {
+		log.Fatalf("failed to create db pool: %v", err)
+	}
+	defer pool.Close()
+	if _, err := database.Migrate(ctx, pool); err != nil {
+		log.Fatalf("failed to migrate: %v", err)
+	}
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		log.Fatalf("failed to connect to the database: %v", err)
+	}
+	defer conn.Close(context.Background())
+
+	failed := 0
+	for i, st := range statements {
+		name := "sqlcheck_" + strconv.Itoa(i)
+		if _, err := conn.Prepare(ctx, name, st.SQL); err != nil {
+			failed++
+			fmt.Printf("%s: %v\n", st.Pos, err)
+			continue
+		}
+		_ = conn.Deallocate(ctx, name)
+	}
+	log.Printf("%d statements checked, %d failed", len(statements), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
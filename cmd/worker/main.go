@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+	// embedded zone database for user timezones; the runtime image has none
+	_ "time/tzdata"
+
+	"backend-go-ticketing-gamify/internal/config"
+	"backend-go-ticketing-gamify/internal/database"
+	"backend-go-ticketing-gamify/internal/server"
+)
+
+// The worker runs the background jobs without serving HTTP. Run it next to
+// API servers started with JOBS_ENABLED=false.
+func main() {
+	cfg := config.Load()
+
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := database.NewPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("failed to create db pool: %v", err)
+	}
+	defer pool.Close()
+
+	srv, err := server.New(cfg, pool)
+	if err != nil {
+		log.Fatalf("failed to build worker: %v", err)
+	}
+
+	if err := srv.RunJobs(ctx); err != nil {
+		log.Fatalf("fatal worker error: %v", err)
+	}
+}
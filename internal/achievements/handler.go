@@ -1,10 +1,12 @@
 package achievements
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
+	"backend-go-ticketing-gamify/internal/middleware"
 	"backend-go-ticketing-gamify/internal/response"
 )
 
@@ -31,13 +33,22 @@ func (h *Handler) getAllAchievements(c *gin.Context) {
 }
 
 func (h *Handler) getUserProgress(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	userID := c.Param("userId")
 	if userID == "" {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "userId is required")
 		return
 	}
-	progress, err := h.service.GetUserProgress(c.Request.Context(), userID)
+	progress, err := h.service.GetUserProgress(c.Request.Context(), user.OrgID, userID)
 	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
@@ -48,13 +59,22 @@ func (h *Handler) getUserProgress(c *gin.Context) {
 }
 
 func (h *Handler) getUnlockedAchievements(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	userID := c.Param("userId")
 	if userID == "" {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "userId is required")
 		return
 	}
-	unlocked, err := h.service.GetUnlockedAchievements(c.Request.Context(), userID)
+	unlocked, err := h.service.GetUnlockedAchievements(c.Request.Context(), user.OrgID, userID)
 	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
@@ -2,7 +2,9 @@ package achievements
 
 import (
 	"context"
+	"errors"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -21,13 +23,15 @@ func (r *Repository) GetUserProgress(ctx context.Context, userID string) ([]Prog
 	// Get user's gamification stats
 	var ticketsClosed, currentStreak, totalXP, level int
 	const statsQuery = `
-		SELECT COALESCE(tickets_closed, 0), COALESCE(current_streak, 0), COALESCE(total_xp, 0), COALESCE(level, 1)
-		FROM gamification_stats
+		SELECT tickets_closed_count, streak_days, xp_total, level
+		FROM gamification_user_stats
 		WHERE user_id = $1`
 	err := r.db.QueryRow(ctx, statsQuery, userID).Scan(&ticketsClosed, &currentStreak, &totalXP, &level)
-	if err != nil {
-		// User may not have stats yet, use defaults
+	if errors.Is(err, pgx.ErrNoRows) {
+		// User has no stats yet, use defaults
 		ticketsClosed, currentStreak, totalXP, level = 0, 0, 0, 1
+	} else if err != nil {
+		return nil, err
 	}
 
 	achievements := DefaultAchievements()
@@ -86,3 +90,10 @@ func (r *Repository) GetUnlockedAchievements(ctx context.Context, userID string)
 	}
 	return unlocked, nil
 }
+
+// UserInOrg reports whether userID belongs to orgID.
+func (r *Repository) UserInOrg(ctx context.Context, orgID, userID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id::text = $1 AND org_id = $2)`, userID, orgID).Scan(&ok)
+	return ok, err
+}
@@ -1,6 +1,12 @@
 package achievements
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrUserNotFound is returned for users outside the caller's organization.
+var ErrUserNotFound = errors.New("user not found")
 
 // Service provides business logic for achievements.
 type Service struct {
@@ -17,12 +23,29 @@ func (s *Service) GetAllAchievements() []Achievement {
 	return DefaultAchievements()
 }
 
-// GetUserProgress returns user's progress toward all achievements.
-func (s *Service) GetUserProgress(ctx context.Context, userID string) ([]Progress, error) {
+// GetUserProgress returns the progress toward all achievements of a user in orgID.
+func (s *Service) GetUserProgress(ctx context.Context, orgID, userID string) ([]Progress, error) {
+	if err := s.requireUser(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
 	return s.repo.GetUserProgress(ctx, userID)
 }
 
-// GetUnlockedAchievements returns achievements the user has unlocked.
-func (s *Service) GetUnlockedAchievements(ctx context.Context, userID string) ([]Progress, error) {
+// GetUnlockedAchievements returns achievements a user in orgID has unlocked.
+func (s *Service) GetUnlockedAchievements(ctx context.Context, orgID, userID string) ([]Progress, error) {
+	if err := s.requireUser(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
 	return s.repo.GetUnlockedAchievements(ctx, userID)
 }
+
+func (s *Service) requireUser(ctx context.Context, orgID, userID string) error {
+	ok, err := s.repo.UserInOrg(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"backend-go-ticketing-gamify/internal/middleware"
 	"backend-go-ticketing-gamify/internal/response"
 )
 
@@ -27,7 +28,13 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 }
 
 func (h *Handler) getActivity(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	filter := Filter{
+		OrgID:      user.OrgID,
 		EntityType: c.Query("entityType"),
 	}
 	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
@@ -54,6 +61,11 @@ func (h *Handler) getActivity(c *gin.Context) {
 }
 
 func (h *Handler) getUserActivity(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	userID := c.Param("userId")
 	if userID == "" {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "userId is required")
@@ -61,6 +73,7 @@ func (h *Handler) getUserActivity(c *gin.Context) {
 	}
 
 	filter := Filter{
+		OrgID:  user.OrgID,
 		UserID: userID,
 	}
 	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
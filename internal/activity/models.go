@@ -16,6 +16,7 @@ type ActivityItem struct {
 
 // Filter for activity queries.
 type Filter struct {
+	OrgID      string
 	UserID     string
 	EntityType string
 	Limit      int
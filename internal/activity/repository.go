@@ -17,7 +17,7 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-// GetUserActivity returns activity log for a user.
+// GetUserActivity returns the activity log of an organization, or of one of its users.
 func (r *Repository) GetUserActivity(ctx context.Context, filter Filter) ([]ActivityItem, *time.Time, error) {
 	if filter.Limit <= 0 || filter.Limit > 100 {
 		filter.Limit = 50
@@ -34,9 +34,9 @@ func (r *Repository) GetUserActivity(ctx context.Context, filter Filter) ([]Acti
 	auditQuery := `
 		SELECT id, actor_id, action, entity_type, entity_id, created_at
 		FROM audit_log
-		WHERE 1=1`
-	args := []any{}
-	idx := 1
+		WHERE org_id = $1`
+	args := []any{filter.OrgID}
+	idx := 2
 
 	if filter.UserID != "" {
 		auditQuery += ` AND actor_id = $` + string(rune('0'+idx))
@@ -70,12 +70,12 @@ func (r *Repository) GetUserActivity(ctx context.Context, filter Filter) ([]Acti
 	xpQuery := `
 		SELECT id, user_id, note, xp_value, priority, created_at
 		FROM xp_events
-		WHERE 1=1`
-	args = []any{}
-	idx = 1
+		WHERE user_id IN (SELECT id FROM users WHERE org_id = $1)`
+	args = []any{filter.OrgID}
+	idx = 2
 
 	if filter.UserID != "" {
-		xpQuery += ` AND user_id = $1`
+		xpQuery += ` AND user_id = $2`
 		args = append(args, filter.UserID)
 		idx++
 	}
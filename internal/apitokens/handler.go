@@ -0,0 +1,180 @@
+package apitokens
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/response"
+)
+
+// Handler wires personal access token and service account routes.
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes mounts the caller's personal access tokens.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("", h.list)
+	router.GET("/scopes", h.scopes)
+	router.POST("", h.create)
+	router.DELETE("/:id", h.revoke)
+}
+
+// RegisterServiceAccountRoutes mounts admin management of service accounts.
+func (h *Handler) RegisterServiceAccountRoutes(router *gin.RouterGroup) {
+	router.GET("", h.listServiceAccounts)
+	router.POST("", h.createServiceAccount)
+	router.GET("/:id/tokens", h.listServiceAccountTokens)
+	router.POST("/:id/tokens", h.createServiceAccountToken)
+	router.DELETE("/:id/tokens/:tokenId", h.revokeServiceAccountToken)
+}
+
+func (h *Handler) list(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	tokens, err := h.service.ListTokens(c.Request.Context(), user.ID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, tokens)
+}
+
+func (h *Handler) scopes(c *gin.Context) {
+	response.OK(c, Scopes)
+}
+
+func (h *Handler) create(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload CreateTokenInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	token, err := h.service.CreateToken(c.Request.Context(), user.ID, payload)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.Created(c, token)
+}
+
+func (h *Handler) revoke(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	if err := h.service.RevokeToken(c.Request.Context(), user.ID, c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+	response.NoContent(c)
+}
+
+func (h *Handler) listServiceAccounts(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	accounts, err := h.service.ListServiceAccounts(c.Request.Context(), user.OrgID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, accounts)
+}
+
+func (h *Handler) createServiceAccount(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload CreateServiceAccountInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	account, err := h.service.CreateServiceAccount(c.Request.Context(), user.OrgID, user.ID, payload)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.Created(c, account)
+}
+
+func (h *Handler) listServiceAccountTokens(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	tokens, err := h.service.ListServiceAccountTokens(c.Request.Context(), user.OrgID, c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.OK(c, tokens)
+}
+
+func (h *Handler) createServiceAccountToken(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload CreateTokenInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	token, err := h.service.CreateServiceAccountToken(c.Request.Context(), user.OrgID, user.ID, c.Param("id"), payload)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.Created(c, token)
+}
+
+func (h *Handler) revokeServiceAccountToken(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	if err := h.service.RevokeServiceAccountToken(c.Request.Context(), user.OrgID, user.ID, c.Param("id"), c.Param("tokenId")); err != nil {
+		writeError(c, err)
+		return
+	}
+	response.NoContent(c)
+}
+
+func writeError(c *gin.Context, err error) {
+	var validation *ValidationError
+	switch {
+	case errors.As(err, &validation):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	case errors.Is(err, ErrTokenNotFound), errors.Is(err, ErrServiceAccountNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrUsernameTaken):
+		response.ErrorCode(c, http.StatusConflict, "username_taken", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -0,0 +1,178 @@
+package apitokens
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles API token and service account persistence.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+const tokenColumns = `id, user_id, name, prefix, scopes, expires_at, last_used_at, last_used_ip, revoked_at, created_by, created_at`
+
+func scanToken(row pgx.Row) (*Token, error) {
+	var t Token
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.Scopes, &t.ExpiresAt, &t.LastUsedAt, &t.LastUsedIP, &t.RevokedAt, &t.CreatedBy, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *Repository) CreateToken(ctx context.Context, userID, name, prefix, tokenHash string, scopes []string, expiresAt time.Time, createdBy string) (*Token, error) {
+	query := `
+INSERT INTO api_tokens (user_id, name, prefix, token_hash, scopes, expires_at, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING ` + tokenColumns
+	return scanToken(r.db.QueryRow(ctx, query, userID, name, prefix, tokenHash, scopes, expiresAt, createdBy))
+}
+
+// ListTokens returns a user's tokens, newest first, including revoked and expired ones.
+func (r *Repository) ListTokens(ctx context.Context, userID string) ([]Token, error) {
+	query := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []Token{}
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, rows.Err()
+}
+
+// RevokeToken revokes one of userID's tokens and returns it, or nil if no active token matched.
+func (r *Repository) RevokeToken(ctx context.Context, userID, tokenID string) (*Token, error) {
+	query := `
+UPDATE api_tokens SET revoked_at = now()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING ` + tokenColumns
+	t, err := scanToken(r.db.QueryRow(ctx, query, tokenID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return t, err
+}
+
+// RevokeAllTokens revokes every active token of a user.
+func (r *Repository) RevokeAllTokens(ctx context.Context, userID string) (int64, error) {
+	tag, err := r.db.Exec(ctx, `UPDATE api_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// FindActiveToken looks up an unrevoked, unexpired token by its hash.
+func (r *Repository) FindActiveToken(ctx context.Context, tokenHash string) (*tokenOwner, error) {
+	const query = `
+SELECT t.id, t.user_id, u.name, u.role, u.org_id, t.scopes, t.expires_at, t.last_used_at
+FROM api_tokens t
+JOIN users u ON u.id = t.user_id
+WHERE t.token_hash = $1
+  AND t.revoked_at IS NULL
+  AND (t.expires_at IS NULL OR t.expires_at > now())
+  AND u.deactivated_at IS NULL`
+	var o tokenOwner
+	if err := r.db.QueryRow(ctx, query, tokenHash).Scan(&o.TokenID, &o.UserID, &o.Name, &o.Role, &o.OrgID, &o.Scopes, &o.ExpiresAt, &o.LastUsedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *Repository) TouchToken(ctx context.Context, tokenID, ip string) error {
+	_, err := r.db.Exec(ctx, `UPDATE api_tokens SET last_used_at = now(), last_used_ip = NULLIF($2, '') WHERE id = $1`, tokenID, ip)
+	return err
+}
+
+var errUsernameTaken = errors.New("username already exists")
+
+func (r *Repository) CreateServiceAccount(ctx context.Context, orgID string, input CreateServiceAccountInput) (*ServiceAccount, error) {
+	// "!" is never a valid bcrypt hash, so password login always fails.
+	const query = `
+INSERT INTO users (org_id, name, username, password_hash, role, bio, is_service_account, email_verified)
+VALUES ($1, $2, $3, '!', $4, NULLIF($5, ''), true, false)
+RETURNING id, name, username, COALESCE(bio, ''), role, created_at`
+	var sa ServiceAccount
+	err := r.db.QueryRow(ctx, query, orgID, input.Name, input.Username, input.Role, input.Description).Scan(
+		&sa.ID, &sa.Name, &sa.Username, &sa.Description, &sa.Role, &sa.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, errUsernameTaken
+		}
+		return nil, err
+	}
+	return &sa, nil
+}
+
+const serviceAccountQuery = `
+SELECT u.id, u.name, u.username, COALESCE(u.bio, ''), u.role, u.created_at,
+       (SELECT count(*) FROM api_tokens t
+        WHERE t.user_id = u.id AND t.revoked_at IS NULL AND (t.expires_at IS NULL OR t.expires_at > now()))
+FROM users u
+WHERE u.is_service_account AND u.org_id = $1`
+
+func scanServiceAccount(row pgx.Row) (*ServiceAccount, error) {
+	var sa ServiceAccount
+	if err := row.Scan(&sa.ID, &sa.Name, &sa.Username, &sa.Description, &sa.Role, &sa.CreatedAt, &sa.ActiveTokens); err != nil {
+		return nil, err
+	}
+	return &sa, nil
+}
+
+func (r *Repository) ListServiceAccounts(ctx context.Context, orgID string) ([]ServiceAccount, error) {
+	rows, err := r.db.Query(ctx, serviceAccountQuery+` ORDER BY u.name`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []ServiceAccount{}
+	for rows.Next() {
+		sa, err := scanServiceAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *sa)
+	}
+	return accounts, rows.Err()
+}
+
+func (r *Repository) GetServiceAccount(ctx context.Context, orgID, id string) (*ServiceAccount, error) {
+	sa, err := scanServiceAccount(r.db.QueryRow(ctx, serviceAccountQuery+` AND u.id = $2`, orgID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return sa, err
+}
+
+// IsServiceAccount reports whether userID belongs to a service account of orgID.
+func (r *Repository) IsServiceAccount(ctx context.Context, orgID, userID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT is_service_account FROM users WHERE id = $1 AND org_id = $2`, userID, orgID).Scan(&ok)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return ok, err
+}
@@ -0,0 +1,275 @@
+package apitokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/middleware"
+)
+
+// Token prefixes make leaked tokens easy to recognise in logs and secret scanners.
+const (
+	personalPrefix = "tgp_"
+	servicePrefix  = "tgs_"
+
+	defaultExpiryDays = 90
+	maxExpiryDays     = 365
+	// touchInterval throttles last-used writes to one per token per minute.
+	touchInterval = time.Minute
+)
+
+var (
+	ErrTokenNotFound          = errors.New("token not found")
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	ErrUsernameTaken          = errUsernameTaken
+)
+
+// ValidationError reports bad input and maps to 400.
+type ValidationError struct{ Message string }
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(format string, args ...any) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{2,49}$`)
+
+// Service manages API tokens and service accounts.
+type Service struct {
+	repo  *Repository
+	audit *audit.Service
+}
+
+func NewService(repo *Repository, auditSvc *audit.Service) *Service {
+	return &Service{repo: repo, audit: auditSvc}
+}
+
+// VerifyAPIToken implements middleware.APITokenVerifier.
+func (s *Service) VerifyAPIToken(ctx context.Context, raw, ip string) (*middleware.UserContext, error) {
+	if !strings.HasPrefix(raw, personalPrefix) && !strings.HasPrefix(raw, servicePrefix) {
+		return nil, nil
+	}
+	owner, err := s.repo.FindActiveToken(ctx, hashToken(raw))
+	if err != nil || owner == nil {
+		return nil, err
+	}
+	if owner.LastUsedAt == nil || time.Since(*owner.LastUsedAt) > touchInterval {
+		_ = s.repo.TouchToken(ctx, owner.TokenID, ip)
+	}
+	user := &middleware.UserContext{
+		ID:         owner.UserID,
+		Name:       owner.Name,
+		Role:       owner.Role,
+		OrgID:      owner.OrgID,
+		APITokenID: owner.TokenID,
+		Scopes:     owner.Scopes,
+	}
+	if owner.ExpiresAt != nil {
+		user.ExpiresAt = *owner.ExpiresAt
+	}
+	return user, nil
+}
+
+// ListTokens returns the caller's personal access tokens.
+func (s *Service) ListTokens(ctx context.Context, userID string) ([]Token, error) {
+	return s.repo.ListTokens(ctx, userID)
+}
+
+// CreateToken issues a personal access token for userID.
+func (s *Service) CreateToken(ctx context.Context, userID string, input CreateTokenInput) (*CreatedToken, error) {
+	return s.createToken(ctx, userID, userID, personalPrefix, input)
+}
+
+// RevokeToken revokes one of the caller's tokens.
+func (s *Service) RevokeToken(ctx context.Context, userID, tokenID string) error {
+	return s.revokeToken(ctx, userID, userID, tokenID)
+}
+
+func (s *Service) ListServiceAccounts(ctx context.Context, orgID string) ([]ServiceAccount, error) {
+	return s.repo.ListServiceAccounts(ctx, orgID)
+}
+
+// CreateServiceAccount adds a token-only user. Username defaults to "svc-" plus the slugged name.
+func (s *Service) CreateServiceAccount(ctx context.Context, orgID, actorID string, input CreateServiceAccountInput) (*ServiceAccount, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return nil, invalid("name is required")
+	}
+	if input.Username == "" {
+		input.Username = "svc-" + slug(input.Name)
+	}
+	input.Username = strings.ToLower(strings.TrimSpace(input.Username))
+	if !usernamePattern.MatchString(input.Username) {
+		return nil, invalid("username must be 3-50 lowercase letters, digits, '.', '_' or '-'")
+	}
+	if input.Role == "" {
+		input.Role = "developer"
+	}
+	if !isAllowedRole(input.Role) {
+		return nil, invalid("role must be developer, project_manager or admin")
+	}
+	sa, err := s.repo.CreateServiceAccount(ctx, orgID, input)
+	if err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("service account %s created with role %s", sa.Username, sa.Role)
+		entityType := "user"
+		_ = s.audit.Log(ctx, "service_account_created", desc, &actorID, &entityType, &sa.ID)
+	}
+	return sa, nil
+}
+
+// ListServiceAccountTokens returns the tokens of a service account.
+func (s *Service) ListServiceAccountTokens(ctx context.Context, orgID, accountID string) ([]Token, error) {
+	if err := s.requireServiceAccount(ctx, orgID, accountID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTokens(ctx, accountID)
+}
+
+// CreateServiceAccountToken issues a token for a service account on behalf of an admin.
+func (s *Service) CreateServiceAccountToken(ctx context.Context, orgID, actorID, accountID string, input CreateTokenInput) (*CreatedToken, error) {
+	if err := s.requireServiceAccount(ctx, orgID, accountID); err != nil {
+		return nil, err
+	}
+	return s.createToken(ctx, actorID, accountID, servicePrefix, input)
+}
+
+// RevokeServiceAccountToken revokes a service account token on behalf of an admin.
+func (s *Service) RevokeServiceAccountToken(ctx context.Context, orgID, actorID, accountID, tokenID string) error {
+	if err := s.requireServiceAccount(ctx, orgID, accountID); err != nil {
+		return err
+	}
+	return s.revokeToken(ctx, actorID, accountID, tokenID)
+}
+
+func (s *Service) requireServiceAccount(ctx context.Context, orgID, accountID string) error {
+	ok, err := s.repo.IsServiceAccount(ctx, orgID, accountID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrServiceAccountNotFound
+	}
+	return nil
+}
+
+func (s *Service) createToken(ctx context.Context, actorID, userID, prefix string, input CreateTokenInput) (*CreatedToken, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return nil, invalid("name is required")
+	}
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	days := input.ExpiresInDays
+	if days == 0 {
+		days = defaultExpiryDays
+	}
+	if days < 0 || days > maxExpiryDays {
+		return nil, invalid("expiresInDays must be between 1 and %d", maxExpiryDays)
+	}
+
+	secret, err := newSecret(prefix)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+	// the prefix plus 8 characters identifies the token in listings without revealing it
+	token, err := s.repo.CreateToken(ctx, userID, input.Name, secret[:len(prefix)+8], hashToken(secret), scopes, expiresAt, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("API token %q created with scopes %s", token.Name, strings.Join(scopes, ", "))
+		entityType := "user"
+		_ = s.audit.Log(ctx, "api_token_created", desc, &actorID, &entityType, &userID)
+	}
+	return &CreatedToken{Token: *token, Secret: secret}, nil
+}
+
+func (s *Service) revokeToken(ctx context.Context, actorID, userID, tokenID string) error {
+	token, err := s.repo.RevokeToken(ctx, userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return ErrTokenNotFound
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("API token %q revoked", token.Name)
+		entityType := "user"
+		_ = s.audit.Log(ctx, "api_token_revoked", desc, &actorID, &entityType, &userID)
+	}
+	return nil
+}
+
+// normalizeScopes validates, de-duplicates and sorts requested scopes.
+func normalizeScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, invalid("at least one scope is required")
+	}
+	out := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if !slices.Contains(Scopes, scope) {
+			return nil, invalid("unknown scope %q", scope)
+		}
+		if !slices.Contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func newSecret(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// isAllowedRole reports whether role is a value of the user_role enum.
+func isAllowedRole(role string) bool {
+	switch role {
+	case "admin", "project_manager", "developer":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,74 @@
+package apitokens
+
+import "time"
+
+// Scopes API tokens can be granted. A ":write" scope also allows reads.
+var Scopes = []string{
+	"projects:read", "projects:write",
+	"tickets:read", "tickets:write",
+	"users:read", "users:write",
+	"gamification:read",
+	"reports:read",
+	"audit:read",
+}
+
+// Token is a personal access or service account token. The secret is only
+// returned once, at creation.
+type Token struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	LastUsedIP *string    `json:"lastUsedIp,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedBy  *string    `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedToken carries the plaintext secret of a freshly created token.
+type CreatedToken struct {
+	Token
+	Secret string `json:"token"`
+}
+
+// CreateTokenInput describes a new token.
+type CreateTokenInput struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// ExpiresInDays defaults to 90; tokens live at most a year.
+	ExpiresInDays int `json:"expiresInDays"`
+}
+
+// ServiceAccount is a non-human user that can only authenticate with tokens.
+type ServiceAccount struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Username     string    `json:"username"`
+	Description  string    `json:"description"`
+	Role         string    `json:"role"`
+	ActiveTokens int       `json:"activeTokens"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// CreateServiceAccountInput describes a new service account.
+type CreateServiceAccountInput struct {
+	Name        string `json:"name" binding:"required"`
+	Username    string `json:"username"`
+	Description string `json:"description"`
+	Role        string `json:"role"`
+}
+
+// tokenOwner is what the auth middleware needs about a verified token.
+type tokenOwner struct {
+	TokenID    string
+	UserID     string
+	Name       string
+	Role       string
+	OrgID      string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
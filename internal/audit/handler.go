@@ -7,6 +7,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"backend-go-ticketing-gamify/internal/export"
+	"backend-go-ticketing-gamify/internal/middleware"
 	"backend-go-ticketing-gamify/internal/response"
 )
 
@@ -21,6 +23,7 @@ func NewHandler(service *Service) *Handler {
 
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("", h.list)
+	router.GET("/export", h.export)
 }
 
 func (h *Handler) list(c *gin.Context) {
@@ -34,7 +37,12 @@ func (h *Handler) list(c *gin.Context) {
 			cursorPtr = &ts
 		}
 	}
-	entries, nextCursor, err := h.service.List(c.Request.Context(), limit, cursorPtr)
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	entries, nextCursor, err := h.service.List(c.Request.Context(), user.OrgID, limit, cursorPtr)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -45,3 +53,63 @@ func (h *Handler) list(c *gin.Context) {
 	}
 	response.WithMeta(c, http.StatusOK, entries, meta)
 }
+
+var exportColumns = []export.Column{
+	{Key: "id", Title: "ID"},
+	{Key: "createdAt", Title: "Time"},
+	{Key: "action", Title: "Action"},
+	{Key: "description", Title: "Description"},
+	{Key: "actorId", Title: "Actor ID"},
+	{Key: "entityType", Title: "Entity type"},
+	{Key: "entityId", Title: "Entity ID"},
+}
+
+// export streams the organization's audit log, optionally narrowed by action
+// and an RFC3339 from/to range, as CSV, XLSX or NDJSON.
+func (h *Handler) export(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	filter := ExportFilter{OrgID: user.OrgID, Action: c.Query("action")}
+	var ok bool
+	if filter.From, ok = queryTime(c, "from"); !ok {
+		return
+	}
+	if filter.To, ok = queryTime(c, "to"); !ok {
+		return
+	}
+	w, err := export.Start(c, format, "audit-log", exportColumns)
+	if err == nil {
+		err = h.service.Stream(c.Request.Context(), filter, func(e Entry) error {
+			return w.Row(e.ID, e.CreatedAt, e.Action, e.Description, e.ActorID, e.EntityType, e.EntityID)
+		})
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		export.Fail(c, "audit log", err)
+	}
+}
+
+// queryTime reads an optional RFC3339 query parameter. On a malformed value
+// it writes the error response and returns false.
+func queryTime(c *gin.Context, param string) (*time.Time, bool) {
+	v := c.Query(param)
+	if v == "" {
+		return nil, true
+	}
+	ts, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", param+" must be an RFC3339 time")
+		return nil, false
+	}
+	return &ts, true
+}
@@ -29,7 +29,8 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) List(ctx context.Context, limit int, cursor *time.Time) ([]Entry, *string, error) {
+// List returns orgID's entries, newest first.
+func (r *Repository) List(ctx context.Context, orgID string, limit int, cursor *time.Time) ([]Entry, *string, error) {
 	if limit <= 0 || limit > 200 {
 		limit = 50
 	}
@@ -40,7 +41,9 @@ func (r *Repository) List(ctx context.Context, limit int, cursor *time.Time) ([]
 	)
 	sb.WriteString(`SELECT id, action, description, actor_id, entity_type, entity_id, created_at
 FROM audit_log
-WHERE 1=1`)
+WHERE org_id = $1`)
+	args = append(args, orgID)
+	idx++
 	if cursor != nil {
 		sb.WriteString(fmt.Sprintf(" AND created_at < $%d", idx))
 		args = append(args, *cursor)
@@ -75,10 +78,77 @@ WHERE 1=1`)
 	return entries, nil, nil
 }
 
+// ExportFilter narrows an audit log export. Zero values match everything.
+type ExportFilter struct {
+	OrgID  string
+	Action string
+	From   *time.Time
+	To     *time.Time
+}
+
+// Stream calls fn for every entry matching filter, oldest first, without
+// holding them all in memory.
+func (r *Repository) Stream(ctx context.Context, filter ExportFilter, fn func(Entry) error) error {
+	var (
+		args []any
+		idx  = 1
+		sb   strings.Builder
+	)
+	sb.WriteString(`SELECT id, action, description, actor_id, entity_type, entity_id, created_at
+FROM audit_log
+WHERE org_id = $1`)
+	args = append(args, filter.OrgID)
+	idx++
+	if filter.Action != "" {
+		sb.WriteString(fmt.Sprintf(" AND action = $%d", idx))
+		args = append(args, filter.Action)
+		idx++
+	}
+	if filter.From != nil {
+		sb.WriteString(fmt.Sprintf(" AND created_at >= $%d", idx))
+		args = append(args, *filter.From)
+		idx++
+	}
+	if filter.To != nil {
+		sb.WriteString(fmt.Sprintf(" AND created_at < $%d", idx))
+		args = append(args, *filter.To)
+	}
+	sb.WriteString(" ORDER BY created_at, id")
+
+	rows, err := r.db.Query(ctx, sb.String(), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.Action, &e.Description, &e.ActorID, &e.EntityType, &e.EntityID, &e.CreatedAt); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Insert records an entry in the actor's organization. Entries without an
+// actor belong to no organization and are not listed anywhere; use
+// InsertSystem for those.
 func (r *Repository) Insert(ctx context.Context, entry Entry) error {
+	return r.insert(ctx, entry, nil)
+}
+
+// InsertSystem records an entry without an actor in orgID.
+func (r *Repository) InsertSystem(ctx context.Context, orgID string, entry Entry) error {
+	return r.insert(ctx, entry, &orgID)
+}
+
+func (r *Repository) insert(ctx context.Context, entry Entry, orgID *string) error {
 	const query = `
-INSERT INTO audit_log (id, action, description, actor_id, entity_type, entity_id)
-VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.db.Exec(ctx, query, entry.ID, entry.Action, entry.Description, entry.ActorID, entry.EntityType, entry.EntityID)
+INSERT INTO audit_log (id, action, description, actor_id, entity_type, entity_id, org_id)
+VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7::uuid, (SELECT org_id FROM users WHERE id = $4)))`
+	_, err := r.db.Exec(ctx, query, entry.ID, entry.Action, entry.Description, entry.ActorID, entry.EntityType, entry.EntityID, orgID)
 	return err
 }
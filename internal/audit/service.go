@@ -16,11 +16,16 @@ func NewService(repo *Repository) *Service {
 	return &Service{repo: repo}
 }
 
-func (s *Service) List(ctx context.Context, limit int, cursor *time.Time) ([]Entry, *string, error) {
+func (s *Service) List(ctx context.Context, orgID string, limit int, cursor *time.Time) ([]Entry, *string, error) {
 	if limit <= 0 || limit > 200 {
 		limit = 50
 	}
-	return s.repo.List(ctx, limit, cursor)
+	return s.repo.List(ctx, orgID, limit, cursor)
+}
+
+// Stream calls fn for every entry matching filter; exports use it.
+func (s *Service) Stream(ctx context.Context, filter ExportFilter, fn func(Entry) error) error {
+	return s.repo.Stream(ctx, filter, fn)
 }
 
 func (s *Service) Log(ctx context.Context, action, description string, actorID, entityType, entityID *string) error {
@@ -34,3 +39,17 @@ func (s *Service) Log(ctx context.Context, action, description string, actorID,
 	}
 	return s.repo.Insert(ctx, entry)
 }
+
+// LogSystem records something the system did on its own, such as a
+// background job, in orgID. Log can't place such entries: it takes the
+// organization from the actor.
+func (s *Service) LogSystem(ctx context.Context, orgID, action, description string, entityType, entityID *string) error {
+	entry := Entry{
+		ID:          uuid.NewString(),
+		Action:      action,
+		Description: description,
+		EntityType:  entityType,
+		EntityID:    entityID,
+	}
+	return s.repo.InsertSystem(ctx, orgID, entry)
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"backend-go-ticketing-gamify/internal/email"
+)
+
+var (
+	ErrEmailTaken    = errors.New("email already registered")
+	ErrUsernameTaken = errors.New("username already exists")
+	ErrUserNotFound  = errors.New("user not found")
+	// ErrPasswordResetRequired is returned at login after an admin forced a password reset.
+	ErrPasswordResetRequired = errors.New("password reset required, check your email for a reset link")
+)
+
+// ValidationError reports input an admin or invitee has to correct.
+type ValidationError struct {
+	msg string
+}
+
+func (e *ValidationError) Error() string { return e.msg }
+
+func invalid(format string, args ...any) error {
+	return &ValidationError{msg: fmt.Sprintf(format, args...)}
+}
+
+// CreateUserInput is an account created directly by an admin.
+type CreateUserInput struct {
+	Name     string              `json:"name"`
+	Email    string              `json:"email"`
+	Username string              `json:"username"`
+	Role     string              `json:"role"`
+	Projects []ProjectAssignment `json:"projects"`
+}
+
+// CreatedUser is returned to the admin. SetupURL is the set-password link
+// that was emailed to the user.
+type CreatedUser struct {
+	User     UserPublic `json:"user"`
+	SetupURL string     `json:"setupUrl"`
+}
+
+// CreateUser provisions an account in orgID without a usable password and
+// emails the user a link to choose one. The link lives as long as an invitation.
+func (s *Service) CreateUser(ctx context.Context, orgID, actorID string, input CreateUserInput) (*CreatedUser, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Email = strings.TrimSpace(input.Email)
+	input.Username = strings.TrimSpace(input.Username)
+	if err := validateName(input.Name); err != nil {
+		return nil, err
+	}
+	if !isValidEmail(input.Email) {
+		return nil, invalid("invalid email format")
+	}
+	if err := validateUsername(input.Username); err != nil {
+		return nil, err
+	}
+	role, err := accountRole(input.Role)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := s.checkAssignments(ctx, orgID, input.Projects)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := s.repo.UsernameExists(ctx, input.Username)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrUsernameTaken
+	}
+	if exists, err = s.repo.EmailExists(ctx, input.Email); err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailTaken
+	}
+
+	token := newSecret()
+	expires := time.Now().Add(s.inviteTTL)
+	created, err := s.repo.CreateAccounts(ctx, orgID, []NewAccount{{
+		Name:           input.Name,
+		Username:       input.Username,
+		Email:          input.Email,
+		Role:           role,
+		Projects:       projects,
+		ResetTokenHash: hashToken(token),
+		ResetExpires:   expires,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	user := &created[0]
+	s.afterAccountCreated(ctx, actorID, user, "created")
+	s.sendInvite(ctx, actorID, input.Email, user.Name, user.Role, token, expires, true)
+	return &CreatedUser{User: toPublic(user), SetupURL: s.frontendLink("/reset-password", token)}, nil
+}
+
+// ForcePasswordReset makes a user pick a new password: the current one stops
+// working, every session is signed out and a reset link is emailed.
+func (s *Service) ForcePasswordReset(ctx context.Context, orgID, actorID, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrUserNotFound
+	}
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.OrgID != orgID || user.DeactivatedAt != nil {
+		return ErrUserNotFound
+	}
+	if user.Email == nil || *user.Email == "" {
+		return invalid("user has no email address to send a reset link to")
+	}
+
+	token := newSecret()
+	if err := s.repo.ForcePasswordReset(ctx, user.ID, hashToken(token), time.Now().Add(s.inviteTTL)); err != nil {
+		return err
+	}
+	if s.denylist != nil {
+		if err := s.denylist.RevokeUser(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("forced a password reset for %s", user.Username)
+		entityType := "user"
+		_ = s.audit.Log(ctx, "password_reset_forced", desc, &actorID, &entityType, &user.ID)
+	}
+	if s.email != nil {
+		return s.email.SendPasswordReset(email.Recipient{Email: *user.Email, Name: user.Name}, token, s.inviteTTL)
+	}
+	return nil
+}
+
+// afterAccountCreated runs the bookkeeping shared by every admin-created account.
+func (s *Service) afterAccountCreated(ctx context.Context, actorID string, user *User, how string) {
+	if s.gamification != nil {
+		_ = s.gamification.EnsureUser(ctx, user.ID)
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("%s %s as %s", how, user.Username, user.Role)
+		entityType := "user"
+		_ = s.audit.Log(ctx, "user_created", desc, &actorID, &entityType, &user.ID)
+	}
+}
+
+// accountRole defaults and validates the workspace role of an admin-created account.
+func accountRole(role string) (string, error) {
+	if role == "" {
+		return "developer", nil
+	}
+	if !IsAllowedRole(role) {
+		return "", invalid("invalid role %q, must be developer, project_manager or admin", role)
+	}
+	return role, nil
+}
+
+func validateName(name string) error {
+	if len(name) < 2 {
+		return invalid("name must be at least 2 characters")
+	}
+	if len(name) > 50 {
+		return invalid("name must be less than 50 characters")
+	}
+	return nil
+}
+
+func validateUsername(username string) error {
+	if len(username) < 3 {
+		return invalid("username must be at least 3 characters")
+	}
+	if len(username) > 30 {
+		return invalid("username must be less than 30 characters")
+	}
+	if !isValidUsername(username) {
+		return invalid("username must start with a letter and contain only letters, numbers, underscores, and dots")
+	}
+	return nil
+}
@@ -1,7 +1,12 @@
 package auth
 
 import (
+	"errors"
+	"io"
+	"math"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -22,14 +27,53 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/login", h.login)
 	router.POST("/register", h.register)
 	router.POST("/refresh", h.refresh)
+	router.POST("/logout", h.logout)
 	router.POST("/verify-email", h.verifyEmail)
 	router.POST("/resend-verification", h.resendVerification)
 	router.POST("/update-unverified-email", h.updateUnverifiedEmail)
+	router.POST("/password-reset/request", h.requestPasswordReset)
+	router.POST("/password-reset/confirm", h.confirmPasswordReset)
+	router.POST("/mfa/verify", h.verifyMFA)
+	router.POST("/mfa/setup", h.setupMFAChallenge)
+	router.POST("/mfa/setup/activate", h.activateMFAChallenge)
+	router.GET("/methods", h.loginMethods)
+	router.GET("/oidc/authorize", h.startSSO)
+	router.POST("/oidc/callback", h.completeSSO)
+	router.GET("/invites/:token", h.getInvite)
+	router.POST("/invites/accept", h.acceptInvite)
+}
+
+// RegisterAdminRoutes mounts admin user provisioning under the users group.
+func (h *Handler) RegisterAdminRoutes(router *gin.RouterGroup) {
+	admin := middleware.RequireRoles("admin")
+	router.POST("", admin, h.createUser)
+	router.POST("/import", admin, h.importUsers)
+	router.GET("/invites", admin, h.listInvites)
+	router.POST("/invites", admin, h.createInvite)
+	router.DELETE("/invites/:id", admin, h.revokeInvite)
+	router.POST("/invites/:id/resend", admin, h.resendInvite)
+	router.POST("/:id/password-reset", admin, h.forcePasswordReset)
 }
 
 // RegisterProtected mounts routes that need authentication.
 func (h *Handler) RegisterProtected(router *gin.RouterGroup) {
 	router.POST("/change-password", h.changePassword)
+	router.GET("/sessions", h.listSessions)
+	router.DELETE("/sessions/:id", h.revokeSession)
+	router.POST("/logout-all", h.logoutAll)
+	router.GET("/mfa", h.mfaStatus)
+	router.POST("/mfa/enroll", h.enrollMFA)
+	router.POST("/mfa/activate", h.activateMFA)
+	router.POST("/mfa/disable", h.disableMFA)
+	router.POST("/mfa/recovery-codes", h.regenerateRecoveryCodes)
+	router.GET("/mfa/policy", middleware.RequireRoles("admin"), h.getMFAPolicy)
+	router.PUT("/mfa/policy", middleware.RequireRoles("admin"), h.setMFAPolicy)
+	router.GET("/lockouts", middleware.RequireRoles("admin"), h.listLockouts)
+	router.POST("/lockouts/unlock", middleware.RequireRoles("admin"), h.unlock)
+}
+
+func clientInfo(c *gin.Context) ClientInfo {
+	return ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
 }
 
 type loginRequest struct {
@@ -43,18 +87,41 @@ func (h *Handler) login(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
-	result, err := h.service.Login(c.Request.Context(), payload.Username, payload.Password)
+	result, err := h.service.Login(c.Request.Context(), payload.Username, payload.Password, clientInfo(c))
 	if err != nil {
+		var blocked *LoginBlockedError
+		if errors.As(err, &blocked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+			code := "login_throttled"
+			if errors.Is(err, ErrAccountLocked) {
+				code = "account_locked"
+			}
+			response.ErrorCode(c, http.StatusTooManyRequests, code, err.Error())
+			return
+		}
 		status := http.StatusInternalServerError
 		code := "internal_error"
 		if err == ErrInvalidCredentials {
 			status = http.StatusUnauthorized
 			code = "invalid_credentials"
+		} else if err == ErrPasswordLoginDisabled {
+			status = http.StatusForbidden
+			code = "password_login_disabled"
+		} else if err == ErrAccountDeactivated {
+			status = http.StatusForbidden
+			code = "account_deactivated"
+		} else if err == ErrPasswordResetRequired {
+			status = http.StatusForbidden
+			code = "password_reset_required"
 		}
 		response.ErrorCode(c, status, code, err.Error())
 		return
 	}
-	response.OK(c, result)
+	if result.Challenge != nil {
+		response.OK(c, result.Challenge)
+		return
+	}
+	response.OK(c, result.Session)
 }
 
 type registerRequest struct {
@@ -74,10 +141,6 @@ func (h *Handler) register(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
-	if payload.Role != "" && !isAllowedRole(payload.Role) {
-		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "invalid role")
-		return
-	}
 	result, err := h.service.Register(c.Request.Context(), RegisterInput{
 		Name:      payload.Name,
 		Email:     payload.Email,
@@ -89,6 +152,10 @@ func (h *Handler) register(c *gin.Context) {
 		Bio:       payload.Bio,
 	})
 	if err != nil {
+		if errors.Is(err, ErrPasswordLoginDisabled) {
+			response.ErrorCode(c, http.StatusForbidden, "password_login_disabled", err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
@@ -105,7 +172,7 @@ func (h *Handler) refresh(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
-	result, err := h.service.Refresh(c.Request.Context(), payload.RefreshToken)
+	result, err := h.service.Refresh(c.Request.Context(), payload.RefreshToken, clientInfo(c))
 	if err != nil {
 		if err == ErrInvalidCredentials {
 			response.ErrorCode(c, http.StatusUnauthorized, "invalid_refresh_token", "invalid refresh token")
@@ -117,6 +184,66 @@ func (h *Handler) refresh(c *gin.Context) {
 	response.OK(c, result)
 }
 
+func (h *Handler) logout(c *gin.Context) {
+	var payload refreshRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err := h.service.Logout(c.Request.Context(), payload.RefreshToken); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Session handlers
+
+func (h *Handler) listSessions(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	sessions, err := h.service.ListSessions(c.Request.Context(), user.ID, user.SessionID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, sessions)
+}
+
+func (h *Handler) revokeSession(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	if err := h.service.RevokeSession(c.Request.Context(), user.ID, c.Param("id")); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) logoutAll(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	revoked, err := h.service.LogoutAll(c.Request.Context(), user.ID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, gin.H{"revoked": revoked})
+}
+
 type changePasswordRequest struct {
 	OldPassword string `json:"oldPassword" binding:"required"`
 	NewPassword string `json:"newPassword" binding:"required"`
@@ -137,7 +264,7 @@ func (h *Handler) changePassword(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "new password must differ from old password")
 		return
 	}
-	if err := h.service.ChangePassword(c.Request.Context(), user.ID, payload.OldPassword, payload.NewPassword); err != nil {
+	if err := h.service.ChangePassword(c.Request.Context(), user.ID, user.SessionID, payload.OldPassword, payload.NewPassword); err != nil {
 		if err == ErrInvalidCredentials {
 			response.ErrorCode(c, http.StatusUnauthorized, "invalid_credentials", "invalid old password")
 			return
@@ -216,9 +343,507 @@ func (h *Handler) updateUnverifiedEmail(c *gin.Context) {
 	response.OK(c, nil)
 }
 
-func isAllowedRole(role string) bool {
+// Password reset handlers
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+func (h *Handler) requestPasswordReset(c *gin.Context) {
+	var payload requestPasswordResetRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err := h.service.RequestPasswordReset(c.Request.Context(), payload.Email); err != nil {
+		if errors.Is(err, ErrTooManyRequests) {
+			response.ErrorCode(c, http.StatusTooManyRequests, "rate_limited", "too many reset requests, try again later")
+			return
+		}
+		if errors.Is(err, ErrPasswordLoginDisabled) {
+			response.ErrorCode(c, http.StatusForbidden, "password_login_disabled", err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": gin.H{"message": "If an account exists for that email, a reset link has been sent"}})
+}
+
+type confirmPasswordResetRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+func (h *Handler) confirmPasswordReset(c *gin.Context) {
+	var payload confirmPasswordResetRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err := h.service.ConfirmPasswordReset(c.Request.Context(), payload.Token, payload.NewPassword); err != nil {
+		if errors.Is(err, ErrInvalidResetToken) {
+			response.ErrorCode(c, http.StatusBadRequest, "invalid_reset_token", err.Error())
+			return
+		}
+		if errors.Is(err, ErrPasswordLoginDisabled) {
+			response.ErrorCode(c, http.StatusForbidden, "password_login_disabled", err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MFA handlers
+
+// writeMFAError maps MFA errors to responses.
+func writeMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidMFACode):
+		response.ErrorCode(c, http.StatusUnauthorized, "invalid_mfa_code", err.Error())
+	case errors.Is(err, ErrInvalidChallenge):
+		response.ErrorCode(c, http.StatusUnauthorized, "invalid_mfa_challenge", err.Error())
+	case errors.Is(err, ErrInvalidCredentials):
+		response.ErrorCode(c, http.StatusUnauthorized, "invalid_credentials", err.Error())
+	case errors.Is(err, ErrMFAAlreadyEnabled), errors.Is(err, ErrMFANotEnabled), errors.Is(err, ErrMFANotEnrolled):
+		response.ErrorCode(c, http.StatusConflict, "mfa_state_conflict", err.Error())
+	case errors.Is(err, ErrMFARequired):
+		response.ErrorCode(c, http.StatusForbidden, "mfa_required", err.Error())
+	case errors.Is(err, ErrAccountDeactivated):
+		response.ErrorCode(c, http.StatusForbidden, "account_deactivated", err.Error())
+	case errors.Is(err, errMFANotConfigured):
+		response.ErrorCode(c, http.StatusServiceUnavailable, "mfa_unavailable", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type mfaChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code"`
+}
+
+func (h *Handler) mfaStatus(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	status, err := h.service.MFAStatus(c.Request.Context(), user.ID)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	response.OK(c, status)
+}
+
+func (h *Handler) enrollMFA(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	enrollment, err := h.service.BeginMFAEnrollment(c.Request.Context(), user.ID)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	response.OK(c, enrollment)
+}
+
+func (h *Handler) activateMFA(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload mfaCodeRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	codes, err := h.service.ActivateMFA(c.Request.Context(), user.ID, payload.Code)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	response.OK(c, gin.H{"recoveryCodes": codes})
+}
+
+type disableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+func (h *Handler) disableMFA(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload disableMFARequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err := h.service.DisableMFA(c.Request.Context(), user.ID, payload.Password, payload.Code); err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) regenerateRecoveryCodes(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload mfaCodeRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), user.ID, payload.Code)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	response.OK(c, gin.H{"recoveryCodes": codes})
+}
+
+func (h *Handler) verifyMFA(c *gin.Context) {
+	var payload mfaChallengeRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	result, err := h.service.VerifyMFAChallenge(c.Request.Context(), payload.ChallengeToken, payload.Code, clientInfo(c))
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	response.OK(c, result)
+}
+
+func (h *Handler) setupMFAChallenge(c *gin.Context) {
+	var payload mfaChallengeRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	enrollment, err := h.service.BeginChallengeEnrollment(c.Request.Context(), payload.ChallengeToken)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	response.OK(c, enrollment)
+}
+
+func (h *Handler) activateMFAChallenge(c *gin.Context) {
+	var payload mfaChallengeRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	result, err := h.service.ActivateChallengeEnrollment(c.Request.Context(), payload.ChallengeToken, payload.Code, clientInfo(c))
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	response.OK(c, result)
+}
+
+func (h *Handler) getMFAPolicy(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	roles, err := h.service.MFAPolicy(c.Request.Context(), user.OrgID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, gin.H{"enforcedRoles": roles})
+}
+
+type mfaPolicyRequest struct {
+	EnforcedRoles []string `json:"enforcedRoles"`
+}
+
+func (h *Handler) setMFAPolicy(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	var payload mfaPolicyRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	roles, err := h.service.SetMFAPolicy(c.Request.Context(), user.OrgID, user.ID, payload.EnforcedRoles)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	response.OK(c, gin.H{"enforcedRoles": roles})
+}
+
+// Lockout handlers
+
+func (h *Handler) listLockouts(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	lockouts, err := h.service.ListLockouts(c.Request.Context(), user.OrgID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, lockouts)
+}
+
+type unlockRequest struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+}
+
+func (h *Handler) unlock(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	var payload unlockRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err := h.service.Unlock(c.Request.Context(), user.OrgID, user.ID, payload.Username, payload.IP); err != nil {
+		if errors.Is(err, ErrLockoutNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SSO handlers
+
+func (h *Handler) loginMethods(c *gin.Context) {
+	response.OK(c, h.service.LoginMethods())
+}
+
+func (h *Handler) startSSO(c *gin.Context) {
+	url, err := h.service.StartSSO(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, ErrSSONotConfigured) {
+			response.ErrorCode(c, http.StatusNotFound, "sso_not_configured", err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusBadGateway, "sso_unavailable", err.Error())
+		return
+	}
+	response.OK(c, gin.H{"authorizationUrl": url})
+}
+
+type ssoCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+func (h *Handler) completeSSO(c *gin.Context) {
+	var payload ssoCallbackRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	result, err := h.service.CompleteSSO(c.Request.Context(), payload.Code, payload.State, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSSONotConfigured):
+			response.ErrorCode(c, http.StatusNotFound, "sso_not_configured", err.Error())
+		case errors.Is(err, ErrInvalidSSOState):
+			response.ErrorCode(c, http.StatusBadRequest, "invalid_sso_state", err.Error())
+		case errors.Is(err, ErrSSOEmailUnverified), errors.Is(err, ErrSSONoAccount):
+			response.ErrorCode(c, http.StatusForbidden, "sso_account_unavailable", err.Error())
+		case errors.Is(err, ErrAccountDeactivated):
+			response.ErrorCode(c, http.StatusForbidden, "account_deactivated", err.Error())
+		default:
+			response.ErrorCode(c, http.StatusUnauthorized, "sso_failed", err.Error())
+		}
+		return
+	}
+	if result.Challenge != nil {
+		response.OK(c, result.Challenge)
+		return
+	}
+	response.OK(c, result.Session)
+}
+
+// Admin user management handlers
+
+// writeAdminError maps user provisioning errors to responses.
+func writeAdminError(c *gin.Context, err error) {
+	var validation *ValidationError
+	switch {
+	case errors.As(err, &validation):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrInviteNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrInvalidInvite):
+		response.ErrorCode(c, http.StatusNotFound, "invalid_invite", err.Error())
+	case errors.Is(err, ErrEmailTaken):
+		response.ErrorCode(c, http.StatusConflict, "email_taken", err.Error())
+	case errors.Is(err, ErrUsernameTaken):
+		response.ErrorCode(c, http.StatusConflict, "username_taken", err.Error())
+	case errors.Is(err, ErrPasswordLoginDisabled):
+		response.ErrorCode(c, http.StatusForbidden, "password_login_disabled", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+func (h *Handler) createUser(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	var payload CreateUserInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	created, err := h.service.CreateUser(c.Request.Context(), user.OrgID, user.ID, payload)
+	if err != nil {
+		writeAdminError(c, err)
+		return
+	}
+	response.Created(c, created)
+}
+
+// maxImportBytes bounds the CSV upload.
+const maxImportBytes = 1 << 20
+
+// importUsers accepts the CSV either as a multipart "file" field or as the raw body.
+func (h *Handler) importUsers(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	var body io.Reader = c.Request.Body
+	if mediaType, _, _ := mime.ParseMediaType(c.ContentType()); mediaType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", "a CSV file up to 1MB is required in the \"file\" field")
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	report, err := h.service.ImportUsers(c.Request.Context(), user.OrgID, user.ID, body, dryRun)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, ErrImportRejected):
+			response.ErrorCodeDetails(c, http.StatusUnprocessableEntity, "import_rejected", err.Error(), report)
+		case errors.As(err, &tooLarge):
+			response.ErrorCode(c, http.StatusRequestEntityTooLarge, "file_too_large", "the CSV may be at most 1MB")
+		default:
+			writeAdminError(c, err)
+		}
+		return
+	}
+	if dryRun {
+		response.OK(c, report)
+		return
+	}
+	response.Created(c, report)
+}
+
+func (h *Handler) listInvites(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	invites, err := h.service.ListInvites(c.Request.Context(), user.OrgID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, invites)
+}
+
+func (h *Handler) createInvite(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	var payload InviteInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	invite, err := h.service.CreateInvite(c.Request.Context(), user.OrgID, user.ID, payload)
+	if err != nil {
+		writeAdminError(c, err)
+		return
+	}
+	response.Created(c, invite)
+}
+
+func (h *Handler) revokeInvite(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if err := h.service.RevokeInvite(c.Request.Context(), user.OrgID, user.ID, c.Param("id")); err != nil {
+		writeAdminError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) resendInvite(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	invite, err := h.service.ResendInvite(c.Request.Context(), user.OrgID, user.ID, c.Param("id"))
+	if err != nil {
+		writeAdminError(c, err)
+		return
+	}
+	response.OK(c, invite)
+}
+
+func (h *Handler) forcePasswordReset(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if err := h.service.ForcePasswordReset(c.Request.Context(), user.OrgID, user.ID, c.Param("id")); err != nil {
+		writeAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": gin.H{"message": "The user was signed out and emailed a reset link"}})
+}
+
+func (h *Handler) getInvite(c *gin.Context) {
+	invite, err := h.service.GetInvite(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		writeAdminError(c, err)
+		return
+	}
+	response.OK(c, invite)
+}
+
+func (h *Handler) acceptInvite(c *gin.Context) {
+	var payload AcceptInviteInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	result, err := h.service.AcceptInvite(c.Request.Context(), payload, clientInfo(c))
+	if err != nil {
+		writeAdminError(c, err)
+		return
+	}
+	if result.Challenge != nil {
+		response.OK(c, result.Challenge)
+		return
+	}
+	response.Created(c, result.Session)
+}
+
+// IsAllowedRole reports whether role is a value of the user_role enum.
+func IsAllowedRole(role string) bool {
 	switch role {
-	case "admin", "project_manager", "developer", "viewer":
+	case "admin", "project_manager", "developer":
 		return true
 	default:
 		return false
@@ -0,0 +1,347 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"backend-go-ticketing-gamify/internal/email"
+	"backend-go-ticketing-gamify/internal/organizations"
+)
+
+var (
+	// ErrInvalidInvite is returned for unknown, accepted, revoked or expired invitation tokens.
+	ErrInvalidInvite  = errors.New("invalid or expired invitation")
+	ErrInviteNotFound = errors.New("invitation not found")
+)
+
+// inviteRetention is how long expired and revoked invitations are kept.
+const inviteRetention = 30 * 24 * time.Hour
+
+// projectMemberRoles are the roles a user can be given on a project.
+var projectMemberRoles = map[string]bool{"member": true, "lead": true, "viewer": true}
+
+// ProjectAssignment is a project membership granted when an account is created.
+type ProjectAssignment struct {
+	ProjectID string `json:"projectId"`
+	Role      string `json:"role"`
+}
+
+// Invite is a pending or past invitation to join the workspace.
+type Invite struct {
+	ID         string              `json:"id"`
+	OrgID      string              `json:"-"`
+	Email      string              `json:"email"`
+	Name       string              `json:"name,omitempty"`
+	Role       string              `json:"role"`
+	Projects   []ProjectAssignment `json:"projects"`
+	InvitedBy  *string             `json:"invitedBy"`
+	ExpiresAt  time.Time           `json:"expiresAt"`
+	AcceptedAt *time.Time          `json:"acceptedAt,omitempty"`
+	RevokedAt  *time.Time          `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time           `json:"createdAt"`
+}
+
+// InviteInput is what an admin fills in to invite someone.
+type InviteInput struct {
+	Email    string              `json:"email"`
+	Name     string              `json:"name"`
+	Role     string              `json:"role"`
+	Projects []ProjectAssignment `json:"projects"`
+}
+
+// SentInvite is returned to the admin. InviteURL lets them share the link
+// by hand when email delivery is not configured.
+type SentInvite struct {
+	Invite
+	InviteURL string `json:"inviteUrl"`
+}
+
+// InvitePreview is the public view of an invitation shown on the sign-up page.
+type InvitePreview struct {
+	Email     string    `json:"email"`
+	Name      string    `json:"name,omitempty"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// AcceptInviteInput completes an invitation.
+type AcceptInviteInput struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// CreateInvite emails a single-use sign-up link to orgID that pre-assigns a
+// role and project memberships.
+func (s *Service) CreateInvite(ctx context.Context, orgID, actorID string, input InviteInput) (*SentInvite, error) {
+	input.Email = strings.TrimSpace(input.Email)
+	input.Name = strings.TrimSpace(input.Name)
+	if !isValidEmail(input.Email) {
+		return nil, invalid("invalid email format")
+	}
+	if input.Name != "" {
+		if err := validateName(input.Name); err != nil {
+			return nil, err
+		}
+	}
+	role, err := accountRole(input.Role)
+	if err != nil {
+		return nil, err
+	}
+	input.Role = role
+	if input.Projects, err = s.checkAssignments(ctx, orgID, input.Projects); err != nil {
+		return nil, err
+	}
+
+	taken, err := s.repo.EmailExists(ctx, input.Email)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrEmailTaken
+	}
+	pending, err := s.repo.PendingInviteExists(ctx, input.Email)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, invalid("%s already has a pending invitation", input.Email)
+	}
+
+	token := newSecret()
+	invite, err := s.repo.CreateInvite(ctx, orgID, input, actorID, hashToken(token), time.Now().Add(s.inviteTTL))
+	if err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("invited %s as %s", invite.Email, invite.Role)
+		entityType := "user_invite"
+		_ = s.audit.Log(ctx, "user_invited", desc, &actorID, &entityType, &invite.ID)
+	}
+	s.sendInvite(ctx, actorID, invite.Email, invite.Name, invite.Role, token, invite.ExpiresAt, false)
+	return &SentInvite{Invite: *invite, InviteURL: s.frontendLink("/accept-invite", token)}, nil
+}
+
+// ListInvites returns orgID's invitations that are still open.
+func (s *Service) ListInvites(ctx context.Context, orgID string) ([]Invite, error) {
+	return s.repo.ListPendingInvites(ctx, orgID)
+}
+
+// InviteOrgAdmin invites the first admin of a new organization. It implements
+// organizations.AdminInviter.
+func (s *Service) InviteOrgAdmin(ctx context.Context, orgID, actorID, email, name string) (string, error) {
+	sent, err := s.CreateInvite(ctx, orgID, actorID, InviteInput{Email: email, Name: name, Role: "admin"})
+	if err != nil {
+		var validation *ValidationError
+		if errors.As(err, &validation) || errors.Is(err, ErrEmailTaken) {
+			return "", &organizations.ValidationError{Message: err.Error()}
+		}
+		return "", err
+	}
+	return sent.InviteURL, nil
+}
+
+// RevokeInvite cancels a pending invitation so its link stops working.
+func (s *Service) RevokeInvite(ctx context.Context, orgID, actorID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInviteNotFound
+	}
+	invite, err := s.repo.RevokeInvite(ctx, orgID, id)
+	if err != nil {
+		return err
+	}
+	if invite == nil {
+		return ErrInviteNotFound
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("revoked the invitation for %s", invite.Email)
+		entityType := "user_invite"
+		_ = s.audit.Log(ctx, "invite_revoked", desc, &actorID, &entityType, &invite.ID)
+	}
+	return nil
+}
+
+// PurgeStaleInvites deletes invitations that expired or were revoked more
+// than inviteRetention ago without being accepted.
+func (s *Service) PurgeStaleInvites(ctx context.Context) (int64, error) {
+	return s.repo.PurgeInvites(ctx, time.Now().Add(-inviteRetention))
+}
+
+// ResendInvite issues a fresh link for a pending invitation and restarts its expiry.
+// The previous link stops working.
+func (s *Service) ResendInvite(ctx context.Context, orgID, actorID, id string) (*SentInvite, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInviteNotFound
+	}
+	token := newSecret()
+	invite, err := s.repo.RenewInvite(ctx, orgID, id, hashToken(token), time.Now().Add(s.inviteTTL))
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, ErrInviteNotFound
+	}
+	s.sendInvite(ctx, actorID, invite.Email, invite.Name, invite.Role, token, invite.ExpiresAt, false)
+	return &SentInvite{Invite: *invite, InviteURL: s.frontendLink("/accept-invite", token)}, nil
+}
+
+// GetInvite returns what the invitee needs to fill in the sign-up form.
+func (s *Service) GetInvite(ctx context.Context, token string) (*InvitePreview, error) {
+	if token == "" {
+		return nil, ErrInvalidInvite
+	}
+	invite, err := s.repo.FindInviteByToken(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, ErrInvalidInvite
+	}
+	return &InvitePreview{Email: invite.Email, Name: invite.Name, Role: invite.Role, ExpiresAt: invite.ExpiresAt}, nil
+}
+
+// AcceptInvite creates the invited account and signs it in, or returns an MFA
+// enrollment challenge when the invited role enforces MFA. The email is
+// treated as verified because the token was delivered to it.
+func (s *Service) AcceptInvite(ctx context.Context, input AcceptInviteInput, client ClientInfo) (*LoginResult, error) {
+	if s.passwordLoginDisabled {
+		return nil, ErrPasswordLoginDisabled
+	}
+	if input.Token == "" {
+		return nil, ErrInvalidInvite
+	}
+	tokenHash := hashToken(input.Token)
+	invite, err := s.repo.FindInviteByToken(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, ErrInvalidInvite
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = invite.Name
+	}
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	if err := validateUsername(input.Username); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(input.Password); err != nil {
+		return nil, invalid("%s", err.Error())
+	}
+	exists, err := s.repo.UsernameExists(ctx, input.Username)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrUsernameTaken
+	}
+	// the address may have registered on its own since the invitation was sent
+	if exists, err = s.repo.EmailExists(ctx, invite.Email); err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailTaken
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.AcceptInvite(ctx, invite, tokenHash, CreateUserParams{
+		ID:           uuid.NewString(),
+		Name:         name,
+		Username:     input.Username,
+		Email:        invite.Email,
+		PasswordHash: string(hashed),
+		Role:         invite.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.gamification != nil {
+		_ = s.gamification.EnsureUser(ctx, user.ID)
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("%s accepted an invitation and joined as %s", user.Name, user.Role)
+		entityType := "user"
+		_ = s.audit.Log(ctx, "invite_accepted", desc, &user.ID, &entityType, &user.ID)
+	}
+
+	challenge, err := s.mfaChallengeFor(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return &LoginResult{Challenge: challenge}, nil
+	}
+	session, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Session: session}, nil
+}
+
+// checkAssignments defaults and validates project memberships, rejecting
+// duplicates and projects that do not exist in orgID.
+func (s *Service) checkAssignments(ctx context.Context, orgID string, projects []ProjectAssignment) ([]ProjectAssignment, error) {
+	if len(projects) == 0 {
+		return []ProjectAssignment{}, nil
+	}
+	ids := make([]string, 0, len(projects))
+	seen := make(map[string]bool, len(projects))
+	for i := range projects {
+		p := &projects[i]
+		p.ProjectID = strings.TrimSpace(p.ProjectID)
+		if p.Role == "" {
+			p.Role = "member"
+		}
+		if !projectMemberRoles[p.Role] {
+			return nil, invalid("invalid project role %q", p.Role)
+		}
+		if seen[p.ProjectID] {
+			return nil, invalid("project %s is listed twice", p.ProjectID)
+		}
+		seen[p.ProjectID] = true
+		ids = append(ids, p.ProjectID)
+	}
+	found, err := s.repo.ExistingProjectIDs(ctx, orgID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, invalid("project %s not found", id)
+		}
+	}
+	return projects, nil
+}
+
+// sendInvite mails an invitation. Delivery problems are not fatal: the admin
+// still gets the link in the response.
+func (s *Service) sendInvite(ctx context.Context, actorID, to, name, role, token string, expiresAt time.Time, accountExists bool) {
+	if s.email == nil {
+		return
+	}
+	inviter := "An administrator"
+	if actor, err := s.repo.FindByID(ctx, actorID); err == nil && actor != nil {
+		inviter = actor.Name
+	}
+	_ = s.email.SendUserInvite(email.Recipient{Email: to, Name: name}, inviter, role, token, expiresAt, accountExists)
+}
+
+func (s *Service) frontendLink(path, token string) string {
+	return strings.TrimRight(s.frontendURL, "/") + path + "?" + url.Values{"token": {token}}.Encode()
+}
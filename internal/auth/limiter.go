@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// windowLimiter is a fixed-window counter keyed by an arbitrary string
+// (e.g. an email address). It mirrors middleware.RateLimit but works at the
+// service layer where the key is only known after parsing the body.
+type windowLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	buckets map[string]*windowCounter
+}
+
+type windowCounter struct {
+	count     int
+	windowEnd time.Time
+}
+
+func newWindowLimiter(limit int, window time.Duration) *windowLimiter {
+	return &windowLimiter{
+		limit:   limit,
+		window:  window,
+		buckets: make(map[string]*windowCounter),
+	}
+}
+
+// Allow records a hit for key and reports whether it is within the limit.
+func (l *windowLimiter) Allow(key string) bool {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok || now.After(b.windowEnd) {
+		l.buckets[key] = &windowCounter{count: 1, windowEnd: now.Add(l.window)}
+		l.sweep(now)
+		return true
+	}
+	if b.count >= l.limit {
+		return false
+	}
+	b.count++
+	return true
+}
+
+// sweep drops expired buckets so the map does not grow without bound.
+func (l *windowLimiter) sweep(now time.Time) {
+	if len(l.buckets) < 1024 {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.After(b.windowEnd) {
+			delete(l.buckets, key)
+		}
+	}
+}
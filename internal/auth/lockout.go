@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/organizations"
+)
+
+var (
+	// ErrLoginThrottled means the caller must wait before trying again.
+	ErrLoginThrottled = errors.New("too many failed login attempts, slow down")
+	// ErrAccountLocked means the username or IP is temporarily locked out.
+	ErrAccountLocked = errors.New("too many failed login attempts, temporarily locked")
+	// ErrLockoutNotFound is returned when unlocking a key with no recorded failures.
+	ErrLockoutNotFound = errors.New("no failed attempts recorded")
+)
+
+// LoginBlockedError wraps ErrLoginThrottled or ErrAccountLocked with how long to wait.
+type LoginBlockedError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string { return e.Err.Error() }
+func (e *LoginBlockedError) Unwrap() error { return e.Err }
+
+// LockoutPolicy tunes brute-force protection. Zero values fall back to defaults.
+type LockoutPolicy struct {
+	// MaxFailures locks a username after this many failures within Window.
+	MaxFailures int
+	// IPMaxFailures locks a client IP; higher because offices share an address.
+	IPMaxFailures int
+	Duration      time.Duration
+	Window        time.Duration
+	// DelayAfter is the failure count from which every further attempt must
+	// wait, starting at one second and doubling up to MaxDelay.
+	DelayAfter int
+	MaxDelay   time.Duration
+}
+
+func (p LockoutPolicy) withDefaults() LockoutPolicy {
+	if p.MaxFailures <= 0 {
+		p.MaxFailures = 10
+	}
+	if p.IPMaxFailures <= 0 {
+		p.IPMaxFailures = 50
+	}
+	if p.Duration <= 0 {
+		p.Duration = 15 * time.Minute
+	}
+	if p.Window <= 0 {
+		p.Window = 15 * time.Minute
+	}
+	if p.DelayAfter <= 0 {
+		p.DelayAfter = 3
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 30 * time.Second
+	}
+	return p
+}
+
+func userLockKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+func ipLockKey(ip string) string { return "ip:" + ip }
+
+// delayFor returns the wait required after the given number of failures.
+func (p LockoutPolicy) delayFor(failures int) time.Duration {
+	if failures < p.DelayAfter {
+		return 0
+	}
+	delay := time.Second << min(failures-p.DelayAfter, 16)
+	return min(delay, p.MaxDelay)
+}
+
+// checkLoginAllowed rejects attempts for locked keys or within the progressive delay.
+func (s *Service) checkLoginAllowed(ctx context.Context, username, ip string) error {
+	keys := []string{userLockKey(username)}
+	if ip != "" {
+		keys = append(keys, ipLockKey(ip))
+	}
+	rows, err := s.repo.GetLoginFailures(ctx, keys)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var blocked *LoginBlockedError
+	for _, f := range rows {
+		if f.LockedUntil != nil && f.LockedUntil.After(now) {
+			wait := f.LockedUntil.Sub(now)
+			if blocked == nil || blocked.Err != ErrAccountLocked || wait > blocked.RetryAfter {
+				blocked = &LoginBlockedError{Err: ErrAccountLocked, RetryAfter: wait}
+			}
+			continue
+		}
+		if now.Sub(f.LastFailedAt) > s.lockout.Window {
+			continue
+		}
+		// per-IP failures only lock; delays would punish everyone behind a NAT
+		if strings.HasPrefix(f.Key, "ip:") {
+			continue
+		}
+		if wait := f.LastFailedAt.Add(s.lockout.delayFor(f.Failures)).Sub(now); wait > 0 && blocked == nil {
+			blocked = &LoginBlockedError{Err: ErrLoginThrottled, RetryAfter: wait}
+		}
+	}
+	if blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+type lockTarget struct {
+	key   string
+	limit int
+}
+
+// recordLoginFailure counts a failed password for the username and IP and
+// locks either once its threshold is reached. user is nil for unknown usernames.
+func (s *Service) recordLoginFailure(ctx context.Context, username, ip string, user *User) {
+	targets := []lockTarget{{userLockKey(username), s.lockout.MaxFailures}}
+	if ip != "" {
+		targets = append(targets, lockTarget{ipLockKey(ip), s.lockout.IPMaxFailures})
+	}
+
+	for _, t := range targets {
+		f, err := s.repo.RecordLoginFailure(ctx, t.key, s.lockout.Window)
+		if err != nil || f == nil || f.Failures < t.limit || f.LockedUntil != nil {
+			continue
+		}
+		until := time.Now().Add(s.lockout.Duration)
+		if err := s.repo.LockLoginKey(ctx, t.key, until); err != nil || s.audit == nil {
+			continue
+		}
+		desc := fmt.Sprintf("%s locked until %s after %d failed login attempts", t.key, until.Format(time.RFC3339), f.Failures)
+		entityType := "login"
+		var entityID, actorID *string
+		if user != nil && strings.HasPrefix(t.key, "user:") {
+			entityType = "user"
+			entityID = &user.ID
+			actorID = &user.ID
+		}
+		_ = s.audit.Log(ctx, "account_locked", desc, actorID, &entityType, entityID)
+	}
+}
+
+// startSession issues tokens after every login step passed and tells the
+// user about failed attempts since their previous successful login.
+func (s *Service) startSession(ctx context.Context, user *User, client ClientInfo) (*LoginResponse, error) {
+	if user.DeactivatedAt != nil {
+		return nil, ErrAccountDeactivated
+	}
+	session, err := s.buildLoginResponse(ctx, user, nil, client)
+	if err != nil {
+		return nil, err
+	}
+	cleared, err := s.repo.ClearLoginFailures(ctx, userLockKey(user.Username))
+	if err == nil && cleared != nil {
+		session.FailedLoginAttempts = cleared.Failures
+		session.LastFailedLoginAt = &cleared.LastFailedAt
+	}
+	return session, nil
+}
+
+// ListLockouts returns orgID's usernames that are currently locked. Locked IPs
+// are shared by every organization and only listed for the default one.
+func (s *Service) ListLockouts(ctx context.Context, orgID string) ([]LoginFailure, error) {
+	return s.repo.ListLockedLoginKeys(ctx, orgID, orgID == organizations.DefaultID)
+}
+
+// Unlock clears the failure counter for a username of orgID or an IP.
+func (s *Service) Unlock(ctx context.Context, orgID, actorID, username, ip string) error {
+	var key string
+	switch {
+	case username != "" && ip == "":
+		user, err := s.repo.FindByUsername(ctx, strings.TrimSpace(username))
+		if err != nil {
+			return err
+		}
+		if user == nil || user.OrgID != orgID {
+			return ErrLockoutNotFound
+		}
+		key = userLockKey(username)
+	case ip != "" && username == "":
+		key = ipLockKey(ip)
+	default:
+		return fmt.Errorf("provide either username or ip")
+	}
+	cleared, err := s.repo.ClearLoginFailures(ctx, key)
+	if err != nil {
+		return err
+	}
+	if cleared == nil {
+		return ErrLockoutNotFound
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("%s unlocked by admin", key)
+		entityType := "login"
+		var entityID *string
+		if username != "" {
+			if user, err := s.repo.FindByUsername(ctx, username); err == nil && user != nil {
+				entityType = "user"
+				entityID = &user.ID
+			}
+		}
+		_ = s.audit.Log(ctx, "account_unlocked", desc, &actorID, &entityType, entityID)
+	}
+	return nil
+}
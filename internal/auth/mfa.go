@@ -0,0 +1,432 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolled    = errors.New("start enrollment before activating two-factor authentication")
+	ErrInvalidMFACode    = errors.New("invalid authentication code")
+	ErrInvalidChallenge  = errors.New("invalid or expired MFA challenge")
+	// ErrMFARequired is returned when disabling MFA for a role that must use it.
+	ErrMFARequired = errors.New("two-factor authentication is required for your role")
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+	recoveryCodeCount       = 10
+
+	challengeVerify = "verify"
+	challengeEnroll = "enroll"
+)
+
+// mfaEnforceableRoles are the roles an admin may require MFA for.
+var mfaEnforceableRoles = map[string]bool{"admin": true, "project_manager": true}
+
+// LoginResult is either a full session or, when a second factor is needed, a challenge.
+type LoginResult struct {
+	Session   *LoginResponse
+	Challenge *MFAChallenge
+}
+
+// MFAChallenge is returned by Login instead of tokens when a second step is needed.
+type MFAChallenge struct {
+	MFARequired bool `json:"mfaRequired"`
+	// EnrollmentRequired means the user's role enforces MFA but it is not set up yet.
+	EnrollmentRequired bool     `json:"enrollmentRequired"`
+	ChallengeToken     string   `json:"challengeToken"`
+	ExpiresIn          int64    `json:"expiresIn"`
+	Methods            []string `json:"methods"`
+}
+
+// MFAEnrollment carries the secret to load into an authenticator app.
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+}
+
+// MFAStatus describes a user's MFA setup.
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	Enforced               bool `json:"enforced"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+// MFAStatus returns whether MFA is on and required for the user.
+func (s *Service) MFAStatus(ctx context.Context, userID string) (*MFAStatus, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	enforced, err := s.repo.IsMFAEnforced(ctx, user.OrgID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	status := &MFAStatus{Enabled: user.MFAEnabled, Enforced: enforced}
+	if user.MFAEnabled {
+		if status.RecoveryCodesRemaining, err = s.repo.CountRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// BeginMFAEnrollment generates a new TOTP secret for the user. MFA stays off
+// until ActivateMFA confirms a code from the authenticator.
+func (s *Service) BeginMFAEnrollment(ctx context.Context, userID string) (*MFAEnrollment, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return s.beginEnrollment(ctx, user)
+}
+
+// ActivateMFA confirms enrollment with a code and returns fresh recovery codes.
+func (s *Service) ActivateMFA(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return s.activate(ctx, user, code)
+}
+
+// DisableMFA turns MFA off after re-checking the password and a current code.
+func (s *Service) DisableMFA(ctx context.Context, userID, password, code string) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidCredentials
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	enforced, err := s.repo.IsMFAEnforced(ctx, user.OrgID, user.Role)
+	if err != nil {
+		return err
+	}
+	if enforced {
+		return ErrMFARequired
+	}
+	if _, err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+	if err := s.repo.DisableMFA(ctx, user.ID); err != nil {
+		return err
+	}
+	s.logSessionEvent(ctx, user.ID, "mfa_disabled", fmt.Sprintf("%s disabled two-factor authentication", user.Username))
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a current code.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+	if _, err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	s.logSessionEvent(ctx, user.ID, "mfa_recovery_codes_regenerated", fmt.Sprintf("%s regenerated recovery codes", user.Username))
+	return codes, nil
+}
+
+// VerifyMFAChallenge completes a login with a TOTP or recovery code.
+func (s *Service) VerifyMFAChallenge(ctx context.Context, challengeToken, code string, client ClientInfo) (*LoginResponse, error) {
+	challenge, user, err := s.loadChallenge(ctx, challengeToken, challengeVerify)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrInvalidChallenge
+	}
+	if _, err := s.verifySecondFactor(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			_ = s.repo.RecordMFAChallengeFailure(ctx, challenge.ID, mfaChallengeMaxAttempts)
+		}
+		return nil, err
+	}
+	if ok, err := s.repo.ConsumeMFAChallenge(ctx, challenge.ID); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrInvalidChallenge
+	}
+	return s.startSession(ctx, user, client)
+}
+
+// BeginChallengeEnrollment starts enrollment for a user whose role enforces MFA
+// and who has only passed the password step.
+func (s *Service) BeginChallengeEnrollment(ctx context.Context, challengeToken string) (*MFAEnrollment, error) {
+	_, user, err := s.loadChallenge(ctx, challengeToken, challengeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	return s.beginEnrollment(ctx, user)
+}
+
+// ActivateChallengeEnrollment confirms enrollment and completes the login.
+// The response carries the recovery codes, which are shown only once.
+func (s *Service) ActivateChallengeEnrollment(ctx context.Context, challengeToken, code string, client ClientInfo) (*LoginResponse, error) {
+	challenge, user, err := s.loadChallenge(ctx, challengeToken, challengeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.activate(ctx, user, code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			_ = s.repo.RecordMFAChallengeFailure(ctx, challenge.ID, mfaChallengeMaxAttempts)
+		}
+		return nil, err
+	}
+	if ok, err := s.repo.ConsumeMFAChallenge(ctx, challenge.ID); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrInvalidChallenge
+	}
+	result, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = codes
+	return result, nil
+}
+
+// MFAPolicy returns the roles that must use MFA in orgID.
+func (s *Service) MFAPolicy(ctx context.Context, orgID string) ([]string, error) {
+	return s.repo.MFAEnforcedRoles(ctx, orgID)
+}
+
+// SetMFAPolicy replaces the roles that must use MFA in orgID.
+func (s *Service) SetMFAPolicy(ctx context.Context, orgID, actorID string, roles []string) ([]string, error) {
+	seen := make(map[string]bool, len(roles))
+	clean := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if !mfaEnforceableRoles[role] {
+			return nil, fmt.Errorf("MFA can only be enforced for admin and project_manager")
+		}
+		if !seen[role] {
+			seen[role] = true
+			clean = append(clean, role)
+		}
+	}
+	if err := s.repo.SetMFAEnforcedRoles(ctx, orgID, clean, actorID); err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("MFA enforced for roles: %v", clean)
+		actor := actorID
+		entityType := "mfa_policy"
+		_ = s.audit.Log(ctx, "mfa_policy_updated", desc, &actor, &entityType, nil)
+	}
+	return s.repo.MFAEnforcedRoles(ctx, orgID)
+}
+
+// mfaChallengeFor decides whether a user who passed the password step still
+// needs a second factor, and issues the challenge if so.
+func (s *Service) mfaChallengeFor(ctx context.Context, user *User) (*MFAChallenge, error) {
+	purpose := ""
+	if user.MFAEnabled {
+		purpose = challengeVerify
+	} else {
+		enforced, err := s.repo.IsMFAEnforced(ctx, user.OrgID, user.Role)
+		if err != nil {
+			return nil, err
+		}
+		if !enforced {
+			return nil, nil
+		}
+		purpose = challengeEnroll
+	}
+
+	secret := newSecret()
+	id := uuid.NewString()
+	if err := s.repo.CreateMFAChallenge(ctx, MFAChallengeRecord{
+		ID:        id,
+		UserID:    user.ID,
+		TokenHash: hashToken(secret),
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	}); err != nil {
+		return nil, err
+	}
+	challenge := &MFAChallenge{
+		MFARequired:    true,
+		ChallengeToken: id + "." + secret,
+		ExpiresIn:      int64(mfaChallengeTTL.Seconds()),
+		Methods:        []string{"totp", "recovery_code"},
+	}
+	if purpose == challengeEnroll {
+		challenge.EnrollmentRequired = true
+		challenge.Methods = []string{"totp_enrollment"}
+	}
+	return challenge, nil
+}
+
+func (s *Service) loadChallenge(ctx context.Context, token, purpose string) (*MFAChallengeRecord, *User, error) {
+	id, secret, err := splitRefresh(token)
+	if err != nil {
+		return nil, nil, ErrInvalidChallenge
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil, ErrInvalidChallenge
+	}
+	challenge, err := s.repo.GetMFAChallenge(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge == nil || challenge.Purpose != purpose || challenge.TokenHash != hashToken(secret) ||
+		time.Now().After(challenge.ExpiresAt) || challenge.Attempts >= mfaChallengeMaxAttempts {
+		return nil, nil, ErrInvalidChallenge
+	}
+	user, err := s.repo.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, ErrInvalidChallenge
+	}
+	return challenge, user, nil
+}
+
+func (s *Service) beginEnrollment(ctx context.Context, user *User) (*MFAEnrollment, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if s.mfaBox == nil {
+		return nil, errMFANotConfigured
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.mfaBox.seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPendingMFASecret(ctx, user.ID, sealed); err != nil {
+		return nil, err
+	}
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURL: totpURI(s.mfaIssuer, user.Username, secret),
+	}, nil
+}
+
+func (s *Service) activate(ctx context.Context, user *User, code string) ([]string, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	secret, err := s.openSecret(user)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := verifyTOTP(secret, code, time.Now(), user.MFALastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.EnableMFA(ctx, user.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	s.logSessionEvent(ctx, user.ID, "mfa_enabled", fmt.Sprintf("%s enabled two-factor authentication", user.Username))
+	return codes, nil
+}
+
+// verifySecondFactor accepts a TOTP code or an unused recovery code and
+// returns which method matched.
+func (s *Service) verifySecondFactor(ctx context.Context, user *User, code string) (string, error) {
+	if user.MFASecret == nil {
+		return "", ErrMFANotEnabled
+	}
+	secret, err := s.openSecret(user)
+	if err != nil {
+		return "", err
+	}
+	if step, ok := verifyTOTP(secret, code, time.Now(), user.MFALastStep); ok {
+		used, err := s.repo.UseMFAStep(ctx, user.ID, step)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return "", ErrInvalidMFACode
+		}
+		return "totp", nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != 10 {
+		return "", ErrInvalidMFACode
+	}
+	used, err := s.repo.UseRecoveryCode(ctx, user.ID, hashToken(normalized))
+	if err != nil {
+		return "", err
+	}
+	if !used {
+		return "", ErrInvalidMFACode
+	}
+	s.logSessionEvent(ctx, user.ID, "mfa_recovery_code_used", fmt.Sprintf("%s signed in with a recovery code", user.Username))
+	return "recovery_code", nil
+}
+
+func (s *Service) openSecret(user *User) (string, error) {
+	if s.mfaBox == nil {
+		return "", errMFANotConfigured
+	}
+	return s.mfaBox.open(*user.MFASecret)
+}
+
+// newRecoveryCodes returns display codes plus the hashes to store.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"backend-go-ticketing-gamify/internal/email"
+)
+
+// RequestPasswordReset emails a single-use reset link if the address belongs to an account.
+// It returns nil for unknown addresses so callers cannot probe which emails exist.
+func (s *Service) RequestPasswordReset(ctx context.Context, emailAddr string) error {
+	if s.passwordLoginDisabled {
+		return ErrPasswordLoginDisabled
+	}
+	emailAddr = strings.TrimSpace(emailAddr)
+	if !isValidEmail(emailAddr) {
+		return fmt.Errorf("invalid email format")
+	}
+	if !s.resetLimiter.Allow(strings.ToLower(emailAddr)) {
+		return ErrTooManyRequests
+	}
+
+	user, err := s.repo.FindByEmail(ctx, emailAddr)
+	if err != nil {
+		return err
+	}
+	// deactivated accounts get the same silent 202 as unknown emails
+	if user == nil || user.DeactivatedAt != nil {
+		return nil
+	}
+
+	token := newSecret()
+	expires := time.Now().Add(s.resetTTL)
+	if err := s.repo.SetPasswordResetToken(ctx, user.ID, hashToken(token), expires); err != nil {
+		return err
+	}
+
+	if s.audit != nil {
+		desc := fmt.Sprintf("%s requested a password reset", user.Username)
+		actorID := user.ID
+		entityType := "user"
+		entityID := user.ID
+		_ = s.audit.Log(ctx, "password_reset_requested", desc, &actorID, &entityType, &entityID)
+	}
+
+	if s.email != nil {
+		return s.email.SendPasswordReset(email.Recipient{Email: emailAddr, Name: user.Name}, token, s.resetTTL)
+	}
+	return nil
+}
+
+// ConfirmPasswordReset consumes a reset token, sets the new password and
+// revokes every refresh and access token so existing sessions are signed out.
+func (s *Service) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	if s.passwordLoginDisabled {
+		return ErrPasswordLoginDisabled
+	}
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	user, err := s.repo.FindByPasswordResetToken(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	if user == nil || user.PasswordResetExpires == nil || user.PasswordResetExpires.Before(time.Now()) {
+		return ErrInvalidResetToken
+	}
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.ResetPassword(ctx, user.ID, hashToken(token), string(newHash)); err != nil {
+		return err
+	}
+	if s.denylist != nil {
+		if err := s.denylist.RevokeUser(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	if s.audit != nil {
+		desc := fmt.Sprintf("%s reset their password", user.Username)
+		actorID := user.ID
+		entityType := "user"
+		entityID := user.ID
+		_ = s.audit.Log(ctx, "password_reset_completed", desc, &actorID, &entityType, &entityID)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -20,6 +21,7 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 // User represents user row.
 type User struct {
 	ID                       string
+	OrgID                    string
 	Name                     string
 	Username                 string
 	Email                    *string
@@ -31,10 +33,54 @@ type User struct {
 	AvatarURL                string
 	Badges                   []string
 	Bio                      *string
+	PasswordResetExpires     *time.Time
+	MFAEnabled               bool
+	MFASecret                *string // encrypted TOTP secret, set at enrollment before MFAEnabled
+	MFALastStep              int64
+	DeactivatedAt            *time.Time
+	PasswordResetRequired    bool
+}
+
+// userColumns is the column list scanned by scanUser.
+const userColumns = `id, name, username, email, email_verified, verification_token, verification_token_expires,
+       password_hash, role, COALESCE(avatar_url, ''), COALESCE(badges, ARRAY[]::text[]), bio,
+       password_reset_expires, mfa_enabled, mfa_secret, mfa_last_step, deactivated_at,
+       password_reset_required, org_id`
+
+func scanUser(row pgx.Row) (*User, error) {
+	var u User
+	if err := row.Scan(
+		&u.ID,
+		&u.Name,
+		&u.Username,
+		&u.Email,
+		&u.EmailVerified,
+		&u.VerificationToken,
+		&u.VerificationTokenExpires,
+		&u.PasswordHash,
+		&u.Role,
+		&u.AvatarURL,
+		&u.Badges,
+		&u.Bio,
+		&u.PasswordResetExpires,
+		&u.MFAEnabled,
+		&u.MFASecret,
+		&u.MFALastStep,
+		&u.DeactivatedAt,
+		&u.PasswordResetRequired,
+		&u.OrgID,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &u, nil
 }
 
 type CreateUserParams struct {
 	ID                       string
+	OrgID                    string
 	Name                     string
 	Username                 string
 	Email                    string
@@ -47,71 +93,34 @@ type CreateUserParams struct {
 	Bio                      *string
 }
 
+// RefreshToken is one link in a rotation chain. All tokens issued from the
+// same login share a FamilyID, which is what the API exposes as a session.
 type RefreshToken struct {
 	ID        string
 	UserID    string
+	FamilyID  string
+	ParentID  *string
 	TokenHash string
+	Device    string
+	IP        string
+	UserAgent string
 	ExpiresAt time.Time
 	RevokedAt *time.Time
 	CreatedAt time.Time
 }
 
 func (r *Repository) FindByUsername(ctx context.Context, username string) (*User, error) {
-	const query = `
-SELECT id, name, username, email, email_verified, verification_token, verification_token_expires,
-       password_hash, role, COALESCE(avatar_url, ''), COALESCE(badges, ARRAY[]::text[]), bio
+	query := `SELECT ` + userColumns + `
 FROM users
 WHERE username = $1`
-	var u User
-	if err := r.db.QueryRow(ctx, query, username).Scan(
-		&u.ID,
-		&u.Name,
-		&u.Username,
-		&u.Email,
-		&u.EmailVerified,
-		&u.VerificationToken,
-		&u.VerificationTokenExpires,
-		&u.PasswordHash,
-		&u.Role,
-		&u.AvatarURL,
-		&u.Badges,
-		&u.Bio,
-	); err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &u, nil
+	return scanUser(r.db.QueryRow(ctx, query, username))
 }
 
 func (r *Repository) FindByID(ctx context.Context, id string) (*User, error) {
-	const query = `
-SELECT id, name, username, email, email_verified, verification_token, verification_token_expires,
-       password_hash, role, COALESCE(avatar_url, ''), COALESCE(badges, ARRAY[]::text[]), bio
+	query := `SELECT ` + userColumns + `
 FROM users
 WHERE id = $1`
-	var u User
-	if err := r.db.QueryRow(ctx, query, id).Scan(
-		&u.ID,
-		&u.Name,
-		&u.Username,
-		&u.Email,
-		&u.EmailVerified,
-		&u.VerificationToken,
-		&u.VerificationTokenExpires,
-		&u.PasswordHash,
-		&u.Role,
-		&u.AvatarURL,
-		&u.Badges,
-		&u.Bio,
-	); err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &u, nil
+	return scanUser(r.db.QueryRow(ctx, query, id))
 }
 
 func (r *Repository) UsernameExists(ctx context.Context, username string) (bool, error) {
@@ -126,12 +135,11 @@ func (r *Repository) UsernameExists(ctx context.Context, username string) (bool,
 }
 
 func (r *Repository) CreateUser(ctx context.Context, params CreateUserParams) (*User, error) {
-	const query = `
-INSERT INTO users (id, name, username, email, email_verified, verification_token, verification_token_expires, password_hash, role, avatar_url, badges, bio, created_at, updated_at)
-VALUES ($1, $2, $3, $4, false, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW())
-RETURNING id, name, username, email, email_verified, verification_token, verification_token_expires, password_hash, role, COALESCE(avatar_url, ''), COALESCE(badges, ARRAY[]::text[]), bio`
-	var u User
-	if err := r.db.QueryRow(
+	query := `
+INSERT INTO users (id, name, username, email, email_verified, verification_token, verification_token_expires, password_hash, role, avatar_url, badges, bio, org_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, false, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW())
+RETURNING ` + userColumns
+	return scanUser(r.db.QueryRow(
 		ctx,
 		query,
 		params.ID,
@@ -145,23 +153,8 @@ RETURNING id, name, username, email, email_verified, verification_token, verific
 		params.AvatarURL,
 		params.Badges,
 		params.Bio,
-	).Scan(
-		&u.ID,
-		&u.Name,
-		&u.Username,
-		&u.Email,
-		&u.EmailVerified,
-		&u.VerificationToken,
-		&u.VerificationTokenExpires,
-		&u.PasswordHash,
-		&u.Role,
-		&u.AvatarURL,
-		&u.Badges,
-		&u.Bio,
-	); err != nil {
-		return nil, err
-	}
-	return &u, nil
+		params.OrgID,
+	))
 }
 
 func (r *Repository) UpdatePassword(ctx context.Context, userID, newHash string) error {
@@ -181,20 +174,23 @@ WHERE id = $1`
 
 func (r *Repository) CreateRefreshToken(ctx context.Context, token RefreshToken) error {
 	const query = `
-INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, revoked_at, created_at)
-VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.db.Exec(ctx, query, token.ID, token.UserID, token.TokenHash, token.ExpiresAt, token.RevokedAt, token.CreatedAt)
+INSERT INTO refresh_tokens (id, user_id, family_id, parent_id, token_hash, device, ip, user_agent, expires_at, revoked_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := r.db.Exec(ctx, query, token.ID, token.UserID, token.FamilyID, token.ParentID, token.TokenHash,
+		token.Device, token.IP, token.UserAgent, token.ExpiresAt, token.RevokedAt, token.CreatedAt)
 	return err
 }
 
 func (r *Repository) GetRefreshToken(ctx context.Context, id string) (*RefreshToken, error) {
 	const query = `
-SELECT id, user_id, token_hash, expires_at, revoked_at, created_at
+SELECT id, user_id, family_id, parent_id, token_hash, COALESCE(device, ''), COALESCE(ip, ''), COALESCE(user_agent, ''),
+       expires_at, revoked_at, created_at
 FROM refresh_tokens
 WHERE id = $1`
 	var t RefreshToken
 	if err := r.db.QueryRow(ctx, query, id).Scan(
-		&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.RevokedAt, &t.CreatedAt,
+		&t.ID, &t.UserID, &t.FamilyID, &t.ParentID, &t.TokenHash, &t.Device, &t.IP, &t.UserAgent,
+		&t.ExpiresAt, &t.RevokedAt, &t.CreatedAt,
 	); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -204,10 +200,85 @@ WHERE id = $1`
 	return &t, nil
 }
 
-func (r *Repository) RevokeRefreshToken(ctx context.Context, id string) error {
-	const query = `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
-	return err
+// RevokeRefreshToken revokes a single token. It reports false when the token
+// was already revoked, which callers treat as a reuse signal.
+func (r *Repository) RevokeRefreshToken(ctx context.Context, id string) (bool, error) {
+	const query = `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RevokeRefreshFamily revokes every live token of one session and returns how many were revoked.
+func (r *Repository) RevokeRefreshFamily(ctx context.Context, userID, familyID string) (int64, error) {
+	const query = `
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, userID, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RevokeAllRefreshTokens revokes every live token of the user, optionally keeping one session.
+func (r *Repository) RevokeAllRefreshTokens(ctx context.Context, userID, exceptFamilyID string) (int64, error) {
+	const query = `
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL AND ($2 = '' OR family_id::text <> $2)`
+	tag, err := r.db.Exec(ctx, query, userID, exceptFamilyID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// PurgeRefreshTokens deletes refresh tokens that expired before the cutoff.
+func (r *Repository) PurgeRefreshTokens(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ListSessions returns one row per live token family, newest activity first.
+func (r *Repository) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	const query = `
+SELECT family_id, device, ip, user_agent, started_at, last_used_at, expires_at
+FROM (
+  SELECT DISTINCT ON (t.family_id)
+         t.family_id,
+         COALESCE(t.device, '') AS device,
+         COALESCE(t.ip, '') AS ip,
+         COALESCE(t.user_agent, '') AS user_agent,
+         (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id) AS started_at,
+         t.created_at AS last_used_at,
+         t.expires_at
+  FROM refresh_tokens t
+  WHERE t.user_id = $1 AND t.revoked_at IS NULL AND t.expires_at > NOW()
+  ORDER BY t.family_id, t.created_at DESC
+) s
+ORDER BY last_used_at DESC`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var sess Session
+		if err := rows.Scan(&sess.ID, &sess.Device, &sess.IP, &sess.UserAgent, &sess.CreatedAt, &sess.LastUsedAt, &sess.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
 }
 
 // Email verification methods
@@ -224,32 +295,10 @@ func (r *Repository) EmailExists(ctx context.Context, email string) (bool, error
 }
 
 func (r *Repository) FindByVerificationToken(ctx context.Context, token string) (*User, error) {
-	const query = `
-SELECT id, name, username, email, email_verified, verification_token, verification_token_expires,
-       password_hash, role, COALESCE(avatar_url, ''), COALESCE(badges, ARRAY[]::text[]), bio
+	query := `SELECT ` + userColumns + `
 FROM users
 WHERE verification_token = $1`
-	var u User
-	if err := r.db.QueryRow(ctx, query, token).Scan(
-		&u.ID,
-		&u.Name,
-		&u.Username,
-		&u.Email,
-		&u.EmailVerified,
-		&u.VerificationToken,
-		&u.VerificationTokenExpires,
-		&u.PasswordHash,
-		&u.Role,
-		&u.AvatarURL,
-		&u.Badges,
-		&u.Bio,
-	); err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &u, nil
+	return scanUser(r.db.QueryRow(ctx, query, token))
 }
 
 func (r *Repository) SetEmailVerified(ctx context.Context, userID string) error {
@@ -295,3 +344,721 @@ WHERE id = $1`
 	}
 	return nil
 }
+
+// Password reset methods
+
+func (r *Repository) FindByEmail(ctx context.Context, email string) (*User, error) {
+	query := `SELECT ` + userColumns + `
+FROM users
+WHERE LOWER(email) = LOWER($1)`
+	return scanUser(r.db.QueryRow(ctx, query, email))
+}
+
+func (r *Repository) FindByPasswordResetToken(ctx context.Context, tokenHash string) (*User, error) {
+	query := `SELECT ` + userColumns + `
+FROM users
+WHERE password_reset_token_hash = $1`
+	return scanUser(r.db.QueryRow(ctx, query, tokenHash))
+}
+
+// SetPasswordResetToken stores the hash of a new reset token, replacing any previous one.
+func (r *Repository) SetPasswordResetToken(ctx context.Context, userID, tokenHash string, expires time.Time) error {
+	const query = `
+UPDATE users
+SET password_reset_token_hash = $2, password_reset_expires = $3, updated_at = NOW()
+WHERE id = $1`
+	tag, err := r.db.Exec(ctx, query, userID, tokenHash, expires)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ResetPassword sets a new password, burns the reset token and revokes all refresh tokens atomically.
+// It returns ErrInvalidResetToken if the token was consumed concurrently.
+func (r *Repository) ResetPassword(ctx context.Context, userID, tokenHash, newHash string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	const updateUser = `
+UPDATE users
+SET password_hash = $3, password_reset_token_hash = NULL, password_reset_expires = NULL,
+    password_reset_required = false, email_verified = true, updated_at = NOW()
+WHERE id = $1 AND password_reset_token_hash = $2`
+	tag, err := tx.Exec(ctx, updateUser, userID, tokenHash, newHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidResetToken
+	}
+
+	const revokeTokens = `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.Exec(ctx, revokeTokens, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// MFA methods
+
+// SetPendingMFASecret stores a freshly generated (encrypted) secret for an
+// enrollment that still has to be confirmed with a code.
+func (r *Repository) SetPendingMFASecret(ctx context.Context, userID, sealedSecret string) error {
+	const query = `
+UPDATE users
+SET mfa_secret = $2, mfa_last_step = 0, updated_at = NOW()
+WHERE id = $1 AND mfa_enabled = false`
+	tag, err := r.db.Exec(ctx, query, userID, sealedSecret)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableMFA turns on MFA and replaces the recovery codes in one transaction.
+func (r *Repository) EnableMFA(ctx context.Context, userID string, step int64, codeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	const enable = `
+UPDATE users
+SET mfa_enabled = true, mfa_last_step = $2, updated_at = NOW()
+WHERE id = $1 AND mfa_enabled = false AND mfa_secret IS NOT NULL`
+	tag, err := tx.Exec(ctx, enable, userID, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DisableMFA clears the secret and every recovery code.
+func (r *Repository) DisableMFA(ctx context.Context, userID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	const disable = `
+UPDATE users
+SET mfa_enabled = false, mfa_secret = NULL, mfa_last_step = 0, updated_at = NOW()
+WHERE id = $1`
+	if _, err := tx.Exec(ctx, disable, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UseMFAStep records the TOTP step just accepted. It returns false when an
+// equal or later step was already used, i.e. the code is being replayed.
+func (r *Repository) UseMFAStep(ctx context.Context, userID string, step int64) (bool, error) {
+	const query = `UPDATE users SET mfa_last_step = $2 WHERE id = $1 AND mfa_last_step < $2`
+	tag, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReplaceRecoveryCodes discards unused recovery codes and stores new ones.
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	const insert = `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(ctx, insert, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode burns a recovery code and reports whether it was valid and unused.
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	const query = `
+UPDATE mfa_recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has left.
+func (r *Repository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	const query = `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+	var n int
+	err := r.db.QueryRow(ctx, query, userID).Scan(&n)
+	return n, err
+}
+
+// MFAChallengeRecord is a pending second login step.
+type MFAChallengeRecord struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	Purpose   string
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+func (r *Repository) CreateMFAChallenge(ctx context.Context, c MFAChallengeRecord) error {
+	const query = `
+INSERT INTO mfa_challenges (id, user_id, token_hash, purpose, expires_at)
+VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.Exec(ctx, query, c.ID, c.UserID, c.TokenHash, c.Purpose, c.ExpiresAt)
+	return err
+}
+
+// GetMFAChallenge returns an unconsumed challenge, or nil.
+func (r *Repository) GetMFAChallenge(ctx context.Context, id string) (*MFAChallengeRecord, error) {
+	const query = `
+SELECT id, user_id, token_hash, purpose, attempts, expires_at
+FROM mfa_challenges
+WHERE id = $1 AND consumed_at IS NULL`
+	var c MFAChallengeRecord
+	if err := r.db.QueryRow(ctx, query, id).Scan(&c.ID, &c.UserID, &c.TokenHash, &c.Purpose, &c.Attempts, &c.ExpiresAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// RecordMFAChallengeFailure bumps the attempt counter and burns the challenge
+// once maxAttempts is reached.
+func (r *Repository) RecordMFAChallengeFailure(ctx context.Context, id string, maxAttempts int) error {
+	const query = `
+UPDATE mfa_challenges
+SET attempts = attempts + 1,
+    consumed_at = CASE WHEN attempts + 1 >= $2 THEN NOW() ELSE consumed_at END
+WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id, maxAttempts)
+	return err
+}
+
+// ConsumeMFAChallenge marks a challenge used; false means it was already consumed.
+func (r *Repository) ConsumeMFAChallenge(ctx context.Context, id string) (bool, error) {
+	const query = `UPDATE mfa_challenges SET consumed_at = NOW() WHERE id = $1 AND consumed_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// MFAEnforcedRoles lists roles that must use MFA in orgID.
+func (r *Repository) MFAEnforcedRoles(ctx context.Context, orgID string) ([]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT role::text FROM mfa_enforced_roles WHERE org_id = $1 ORDER BY role`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// IsMFAEnforced reports whether role must use MFA in orgID.
+func (r *Repository) IsMFAEnforced(ctx context.Context, orgID, role string) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM mfa_enforced_roles WHERE org_id = $1 AND role::text = $2)`
+	var enforced bool
+	err := r.db.QueryRow(ctx, query, orgID, role).Scan(&enforced)
+	return enforced, err
+}
+
+// SetMFAEnforcedRoles replaces the set of roles that must use MFA in orgID.
+func (r *Repository) SetMFAEnforcedRoles(ctx context.Context, orgID string, roles []string, actorID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_enforced_roles WHERE org_id = $1 AND NOT (role::text = ANY($2::text[]))`, orgID, roles); err != nil {
+		return err
+	}
+	const insert = `
+INSERT INTO mfa_enforced_roles (org_id, role, enforced_by)
+VALUES ($1, $2::user_role, $3)
+ON CONFLICT (org_id, role) DO NOTHING`
+	for _, role := range roles {
+		if _, err := tx.Exec(ctx, insert, orgID, role, actorID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Login failure tracking
+
+// LoginFailure counts recent failed logins for one key ("user:<name>" or "ip:<addr>").
+type LoginFailure struct {
+	Key           string     `json:"key"`
+	Failures      int        `json:"failures"`
+	FirstFailedAt time.Time  `json:"firstFailedAt"`
+	LastFailedAt  time.Time  `json:"lastFailedAt"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty"`
+}
+
+const loginFailureColumns = `key, failures, first_failed_at, last_failed_at, locked_until`
+
+func scanLoginFailure(row pgx.Row) (*LoginFailure, error) {
+	var f LoginFailure
+	if err := row.Scan(&f.Key, &f.Failures, &f.FirstFailedAt, &f.LastFailedAt, &f.LockedUntil); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+// GetLoginFailures returns the tracked rows for the given keys.
+func (r *Repository) GetLoginFailures(ctx context.Context, keys []string) ([]LoginFailure, error) {
+	query := `SELECT ` + loginFailureColumns + ` FROM login_failures WHERE key = ANY($1)`
+	rows, err := r.db.Query(ctx, query, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LoginFailure
+	for rows.Next() {
+		f, err := scanLoginFailure(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *f)
+	}
+	return out, rows.Err()
+}
+
+// RecordLoginFailure increments the counter for key. The count restarts when
+// the previous failure is older than window or a lockout has run out.
+func (r *Repository) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (*LoginFailure, error) {
+	const query = `
+INSERT INTO login_failures AS f (key, failures, first_failed_at, last_failed_at)
+VALUES ($1, 1, NOW(), NOW())
+ON CONFLICT (key) DO UPDATE SET
+  failures        = CASE WHEN f.last_failed_at < NOW() - make_interval(secs => $2) OR f.locked_until <= NOW()
+                         THEN 1 ELSE f.failures + 1 END,
+  first_failed_at = CASE WHEN f.last_failed_at < NOW() - make_interval(secs => $2) OR f.locked_until <= NOW()
+                         THEN NOW() ELSE f.first_failed_at END,
+  locked_until    = CASE WHEN f.locked_until <= NOW() THEN NULL ELSE f.locked_until END,
+  last_failed_at  = NOW()
+RETURNING key, failures, first_failed_at, last_failed_at, locked_until`
+	return scanLoginFailure(r.db.QueryRow(ctx, query, key, window.Seconds()))
+}
+
+// LockLoginKey blocks key until the given time.
+func (r *Repository) LockLoginKey(ctx context.Context, key string, until time.Time) error {
+	const query = `UPDATE login_failures SET locked_until = $2 WHERE key = $1`
+	_, err := r.db.Exec(ctx, query, key, until)
+	return err
+}
+
+// ClearLoginFailures removes the row for key and returns what it held.
+func (r *Repository) ClearLoginFailures(ctx context.Context, key string) (*LoginFailure, error) {
+	query := `DELETE FROM login_failures WHERE key = $1 RETURNING ` + loginFailureColumns
+	return scanLoginFailure(r.db.QueryRow(ctx, query, key))
+}
+
+// ListLockedLoginKeys returns the locked keys of orgID's usernames. IP keys
+// are not tied to an organization and are only included when withIPs is set.
+func (r *Repository) ListLockedLoginKeys(ctx context.Context, orgID string, withIPs bool) ([]LoginFailure, error) {
+	query := `SELECT ` + loginFailureColumns + `
+FROM login_failures
+WHERE locked_until > NOW()
+  AND (key IN (SELECT 'user:' || lower(username) FROM users WHERE org_id = $1)
+       OR ($2 AND key LIKE 'ip:%'))
+ORDER BY locked_until DESC`
+	rows, err := r.db.Query(ctx, query, orgID, withIPs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []LoginFailure{}
+	for rows.Next() {
+		f, err := scanLoginFailure(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *f)
+	}
+	return out, rows.Err()
+}
+
+// OIDC methods
+
+// OIDCState is a pending authorization-code login.
+type OIDCState struct {
+	StateHash    string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
+
+func (r *Repository) CreateOIDCState(ctx context.Context, st OIDCState) error {
+	const query = `
+INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, expires_at)
+VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(ctx, query, st.StateHash, st.CodeVerifier, st.Nonce, st.ExpiresAt)
+	return err
+}
+
+// ConsumeOIDCState deletes and returns an unexpired state, or nil. Each state
+// works once, which also protects against replayed callbacks.
+func (r *Repository) ConsumeOIDCState(ctx context.Context, stateHash string) (*OIDCState, error) {
+	const query = `
+DELETE FROM oidc_login_states
+WHERE state_hash = $1
+RETURNING state_hash, code_verifier, nonce, expires_at`
+	var st OIDCState
+	if err := r.db.QueryRow(ctx, query, stateHash).Scan(&st.StateHash, &st.CodeVerifier, &st.Nonce, &st.ExpiresAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if time.Now().After(st.ExpiresAt) {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+// FindByIdentity returns the user linked to an external identity.
+func (r *Repository) FindByIdentity(ctx context.Context, issuer, subject string) (*User, error) {
+	query := `SELECT ` + userColumns + `
+FROM users
+WHERE id = (SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2)`
+	return scanUser(r.db.QueryRow(ctx, query, issuer, subject))
+}
+
+// LinkIdentity attaches an external identity to a user, or refreshes its login time.
+func (r *Repository) LinkIdentity(ctx context.Context, userID, issuer, subject, email string) error {
+	const query = `
+INSERT INTO user_identities (user_id, issuer, subject, email, last_login_at)
+VALUES ($1, $2, $3, NULLIF($4, ''), NOW())
+ON CONFLICT (issuer, subject) DO UPDATE SET email = EXCLUDED.email, last_login_at = NOW()`
+	_, err := r.db.Exec(ctx, query, userID, issuer, subject, email)
+	return err
+}
+
+// CreateSSOUser inserts a user whose email was verified by the identity provider.
+func (r *Repository) CreateSSOUser(ctx context.Context, params CreateUserParams) (*User, error) {
+	query := `
+INSERT INTO users (id, name, username, email, email_verified, password_hash, role, avatar_url, badges, org_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, true, $5, $6, $7, $8, $9, NOW(), NOW())
+RETURNING ` + userColumns
+	return scanUser(r.db.QueryRow(ctx, query,
+		params.ID, params.Name, params.Username, params.Email, params.PasswordHash,
+		params.Role, params.AvatarURL, params.Badges, params.OrgID,
+	))
+}
+
+// UpdateRole sets the user's role.
+func (r *Repository) UpdateRole(ctx context.Context, userID, role string) error {
+	const query = `UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, userID, role)
+	return err
+}
+
+// Admin user management
+
+// NewAccount is a user created by an admin, who sets their own password
+// through the emailed reset token.
+type NewAccount struct {
+	Name           string
+	Username       string
+	Email          string
+	Role           string
+	Projects       []ProjectAssignment
+	ResetTokenHash string
+	ResetExpires   time.Time
+}
+
+// CreateAccounts inserts admin-created users of orgID and their project
+// memberships in one transaction, so a bulk import either fully succeeds or
+// changes nothing.
+func (r *Repository) CreateAccounts(ctx context.Context, orgID string, accounts []NewAccount) ([]User, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// "!" is never a valid bcrypt hash, so the account is unusable until a password is set.
+	query := `
+INSERT INTO users (name, username, email, email_verified, password_hash, role, password_reset_token_hash, password_reset_expires, org_id)
+VALUES ($1, $2, $3, false, '!', $4, $5, $6, $7)
+RETURNING ` + userColumns
+	created := make([]User, 0, len(accounts))
+	for _, a := range accounts {
+		user, err := scanUser(tx.QueryRow(ctx, query, a.Name, a.Username, a.Email, a.Role, a.ResetTokenHash, a.ResetExpires, orgID))
+		if err != nil {
+			return nil, err
+		}
+		if err := addMemberships(ctx, tx, user.ID, a.Projects); err != nil {
+			return nil, err
+		}
+		created = append(created, *user)
+	}
+	return created, tx.Commit(ctx)
+}
+
+func addMemberships(ctx context.Context, tx pgx.Tx, userID string, projects []ProjectAssignment) error {
+	const query = `
+INSERT INTO project_members (project_id, user_id, member_role)
+VALUES ($1, $2, $3)
+ON CONFLICT (project_id, user_id) DO NOTHING`
+	for _, p := range projects {
+		if _, err := tx.Exec(ctx, query, p.ProjectID, userID, p.Role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExistingProjectIDs returns which of ids are projects of orgID.
+func (r *Repository) ExistingProjectIDs(ctx context.Context, orgID string, ids []string) (map[string]bool, error) {
+	return r.existing(ctx, `SELECT id::text FROM projects WHERE id::text = ANY($1) AND org_id = $2`, ids, orgID)
+}
+
+// ExistingUsernames returns which of usernames are taken.
+func (r *Repository) ExistingUsernames(ctx context.Context, usernames []string) (map[string]bool, error) {
+	return r.existing(ctx, `SELECT username FROM users WHERE username = ANY($1)`, usernames)
+}
+
+// ExistingEmails returns which of emails are registered, lower-cased.
+func (r *Repository) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	return r.existing(ctx, `SELECT lower(email) FROM users WHERE lower(email) = ANY($1)`, emails)
+}
+
+func (r *Repository) existing(ctx context.Context, query string, values []string, args ...any) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(values) == 0 {
+		return found, nil
+	}
+	rows, err := r.db.Query(ctx, query, append([]any{values}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		found[v] = true
+	}
+	return found, rows.Err()
+}
+
+// ForcePasswordReset invalidates the current password for login, stores a
+// fresh reset token and revokes every refresh token.
+func (r *Repository) ForcePasswordReset(ctx context.Context, userID, tokenHash string, expires time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+UPDATE users
+SET password_reset_required = true, password_reset_token_hash = $2, password_reset_expires = $3, updated_at = NOW()
+WHERE id = $1`
+	tag, err := tx.Exec(ctx, query, userID, tokenHash, expires)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Invitations
+
+const inviteColumns = `id, org_id, email, COALESCE(name, ''), role, projects, invited_by, expires_at, accepted_at, revoked_at, created_at`
+
+func scanInvite(row pgx.Row) (*Invite, error) {
+	var inv Invite
+	var projects []byte
+	if err := row.Scan(&inv.ID, &inv.OrgID, &inv.Email, &inv.Name, &inv.Role, &projects, &inv.InvitedBy, &inv.ExpiresAt, &inv.AcceptedAt, &inv.RevokedAt, &inv.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(projects, &inv.Projects); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *Repository) CreateInvite(ctx context.Context, orgID string, input InviteInput, invitedBy, tokenHash string, expires time.Time) (*Invite, error) {
+	projects, err := json.Marshal(input.Projects)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+INSERT INTO user_invites (org_id, email, name, role, projects, token_hash, invited_by, expires_at)
+VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8)
+RETURNING ` + inviteColumns
+	return scanInvite(r.db.QueryRow(ctx, query, orgID, input.Email, input.Name, input.Role, projects, tokenHash, invitedBy, expires))
+}
+
+// ListPendingInvites returns orgID's invitations that were neither accepted
+// nor revoked, newest first.
+func (r *Repository) ListPendingInvites(ctx context.Context, orgID string) ([]Invite, error) {
+	query := `SELECT ` + inviteColumns + `
+FROM user_invites
+WHERE org_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+ORDER BY created_at DESC`
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []Invite{}
+	for rows.Next() {
+		inv, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *inv)
+	}
+	return invites, rows.Err()
+}
+
+// PendingInviteExists reports whether email already has an open invitation.
+func (r *Repository) PendingInviteExists(ctx context.Context, email string) (bool, error) {
+	const query = `
+SELECT EXISTS (
+  SELECT 1 FROM user_invites
+  WHERE lower(email) = lower($1) AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+)`
+	var exists bool
+	err := r.db.QueryRow(ctx, query, email).Scan(&exists)
+	return exists, err
+}
+
+// FindInviteByToken returns an open invitation by token hash.
+// PurgeInvites deletes invitations that were never accepted and expired or
+// were revoked before the cutoff.
+func (r *Repository) PurgeInvites(ctx context.Context, before time.Time) (int64, error) {
+	const query = `
+DELETE FROM user_invites
+WHERE accepted_at IS NULL AND (expires_at < $1 OR revoked_at < $1)`
+	tag, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *Repository) FindInviteByToken(ctx context.Context, tokenHash string) (*Invite, error) {
+	query := `SELECT ` + inviteColumns + `
+FROM user_invites
+WHERE token_hash = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`
+	return scanInvite(r.db.QueryRow(ctx, query, tokenHash))
+}
+
+// RevokeInvite cancels a pending invitation of orgID; nil means none matched.
+func (r *Repository) RevokeInvite(ctx context.Context, orgID, id string) (*Invite, error) {
+	query := `
+UPDATE user_invites SET revoked_at = NOW()
+WHERE id = $1 AND org_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+RETURNING ` + inviteColumns
+	return scanInvite(r.db.QueryRow(ctx, query, id, orgID))
+}
+
+// RenewInvite replaces the token of a pending invitation of orgID and extends it.
+func (r *Repository) RenewInvite(ctx context.Context, orgID, id, tokenHash string, expires time.Time) (*Invite, error) {
+	query := `
+UPDATE user_invites SET token_hash = $3, expires_at = $4
+WHERE id = $1 AND org_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+RETURNING ` + inviteColumns
+	return scanInvite(r.db.QueryRow(ctx, query, id, orgID, tokenHash, expires))
+}
+
+// AcceptInvite consumes the invitation and creates the user in its
+// organization with its role and project memberships. It returns ErrInvalidInvite if the invitation was used concurrently.
+func (r *Repository) AcceptInvite(ctx context.Context, invite *Invite, tokenHash string, params CreateUserParams) (*User, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const consume = `
+UPDATE user_invites SET accepted_at = NOW(), accepted_user_id = $3
+WHERE id = $1 AND token_hash = $2 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`
+	tag, err := tx.Exec(ctx, consume, invite.ID, tokenHash, params.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrInvalidInvite
+	}
+
+	query := `
+INSERT INTO users (id, name, username, email, email_verified, password_hash, role, org_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, true, $5, $6, $7, NOW(), NOW())
+RETURNING ` + userColumns
+	user, err := scanUser(tx.QueryRow(ctx, query, params.ID, params.Name, params.Username, params.Email, params.PasswordHash, params.Role, invite.OrgID))
+	if err != nil {
+		return nil, err
+	}
+	if err := addMemberships(ctx, tx, user.ID, invite.Projects); err != nil {
+		return nil, err
+	}
+	return user, tx.Commit(ctx)
+}
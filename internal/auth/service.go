@@ -15,7 +15,10 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/email"
 	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/jwtauth"
+	"backend-go-ticketing-gamify/internal/organizations"
 )
 
 // ErrInvalidCredentials indicates login failure.
@@ -23,58 +26,172 @@ var ErrInvalidCredentials = errors.New("invalid credentials")
 var ErrForbidden = errors.New("forbidden")
 var ErrEmailNotVerified = errors.New("email not verified")
 
+// ErrAccountDeactivated is returned when a deactivated user tries to sign in.
+var ErrAccountDeactivated = errors.New("account is deactivated")
+
+// ErrInvalidResetToken is returned for unknown, used, or expired reset tokens.
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// ErrTooManyRequests is returned when a per-email rate limit is exceeded.
+var ErrTooManyRequests = errors.New("too many requests")
+
 // EmailSender is the interface for sending emails
 type EmailSender interface {
 	SendVerificationEmail(to, name, token, frontendURL string) error
+	SendPasswordReset(to email.Recipient, token string, ttl time.Duration) error
+	SendUserInvite(to email.Recipient, inviterName, role, token string, expiresAt time.Time, accountExists bool) error
 	IsConfigured() bool
 }
 
+// Options tunes token lifetimes and limits. Zero values fall back to defaults.
+type Options struct {
+	// Keys signs access tokens; Denylist (optional) records revoked ones.
+	Keys        *jwtauth.KeySet
+	Denylist    *jwtauth.Denylist
+	FrontendURL string
+	AccessTTL   time.Duration
+	RefreshTTL  time.Duration
+	// PasswordResetTTL is how long a reset link stays valid.
+	PasswordResetTTL time.Duration
+	// PasswordResetLimit is how many reset emails one address may request per PasswordResetWindow.
+	PasswordResetLimit  int
+	PasswordResetWindow time.Duration
+	// InviteTTL is how long invitation and account setup links stay valid.
+	InviteTTL time.Duration
+	// MFAEncryptionKey encrypts TOTP secrets at rest; MFA enrollment is unavailable without it.
+	MFAEncryptionKey string
+	// MFAIssuer is the account issuer shown in authenticator apps.
+	MFAIssuer string
+	Lockout   LockoutPolicy
+	SSO       SSOOptions
+	// DisablePasswordLogin turns off password login, registration and reset (SSO-only deployments).
+	DisablePasswordLogin bool
+}
+
 // Service coordinates authentication flows.
 type Service struct {
 	repo         *Repository
 	gamification *gamification.Service
 	audit        *audit.Service
 	email        EmailSender
-	jwtSecret    string
+	keys         *jwtauth.KeySet
+	denylist     *jwtauth.Denylist
 	frontendURL  string
+	accessTTL    time.Duration
 	refreshTTL   time.Duration
+	resetTTL     time.Duration
+	inviteTTL    time.Duration
+	resetLimiter *windowLimiter
+	mfaBox       *secretBox
+	mfaIssuer    string
+	lockout      LockoutPolicy
+	sso          SSOOptions
+
+	passwordLoginDisabled bool
 }
 
-func NewService(repo *Repository, gamificationSvc *gamification.Service, auditSvc *audit.Service, emailSvc EmailSender, jwtSecret, frontendURL string) *Service {
+func NewService(repo *Repository, gamificationSvc *gamification.Service, auditSvc *audit.Service, emailSvc EmailSender, opts Options) *Service {
+	if opts.AccessTTL <= 0 {
+		opts.AccessTTL = 15 * time.Minute
+	}
+	if opts.RefreshTTL <= 0 {
+		opts.RefreshTTL = 7 * 24 * time.Hour
+	}
+	if opts.PasswordResetTTL <= 0 {
+		opts.PasswordResetTTL = 30 * time.Minute
+	}
+	if opts.PasswordResetLimit <= 0 {
+		opts.PasswordResetLimit = 3
+	}
+	if opts.PasswordResetWindow <= 0 {
+		opts.PasswordResetWindow = time.Hour
+	}
+	if opts.InviteTTL <= 0 {
+		opts.InviteTTL = 7 * 24 * time.Hour
+	}
+	if opts.SSO.DefaultRole == "" {
+		opts.SSO.DefaultRole = "developer"
+	}
+	if opts.MFAIssuer == "" {
+		opts.MFAIssuer = "Ticketing Gamified"
+	}
+	// a missing key only disables enrollment; logins keep working
+	mfaBox, _ := newSecretBox(opts.MFAEncryptionKey)
 	return &Service{
 		repo:         repo,
 		gamification: gamificationSvc,
 		audit:        auditSvc,
 		email:        emailSvc,
-		jwtSecret:    jwtSecret,
-		frontendURL:  frontendURL,
-		refreshTTL:   7 * 24 * time.Hour,
+		keys:         opts.Keys,
+		denylist:     opts.Denylist,
+		frontendURL:  opts.FrontendURL,
+		accessTTL:    opts.AccessTTL,
+		refreshTTL:   opts.RefreshTTL,
+		resetTTL:     opts.PasswordResetTTL,
+		inviteTTL:    opts.InviteTTL,
+		resetLimiter: newWindowLimiter(opts.PasswordResetLimit, opts.PasswordResetWindow),
+		mfaBox:       mfaBox,
+		mfaIssuer:    opts.MFAIssuer,
+		lockout:      opts.Lockout.withDefaults(),
+		sso:          opts.SSO,
+
+		passwordLoginDisabled: opts.DisablePasswordLogin,
 	}
 }
 
-// Login validates credentials and returns token.
-func (s *Service) Login(ctx context.Context, username, password string) (*LoginResponse, error) {
+// Login validates credentials and returns tokens, or an MFA challenge when
+// the user has MFA enabled or their role requires it.
+func (s *Service) Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResult, error) {
+	if s.passwordLoginDisabled {
+		return nil, ErrPasswordLoginDisabled
+	}
 	if username == "" || password == "" {
 		return nil, ErrInvalidCredentials
 	}
 
+	if err := s.checkLoginAllowed(ctx, username, client.IP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.FindByUsername(ctx, username)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
+		s.recordLoginFailure(ctx, username, client.IP, nil)
 		return nil, ErrInvalidCredentials
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		s.recordLoginFailure(ctx, username, client.IP, user)
 		return nil, ErrInvalidCredentials
 	}
+	if user.DeactivatedAt != nil {
+		return nil, ErrAccountDeactivated
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
 
-	return s.buildLoginResponse(ctx, user, "")
+	challenge, err := s.mfaChallengeFor(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return &LoginResult{Challenge: challenge}, nil
+	}
+	session, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Session: session}, nil
 }
 
 // Register creates a new user and sends verification email.
 func (s *Service) Register(ctx context.Context, input RegisterInput) (*RegisterResponse, error) {
+	if s.passwordLoginDisabled {
+		return nil, ErrPasswordLoginDisabled
+	}
 	// Basic required field validation
 	if input.Name == "" || input.Username == "" || input.Password == "" || input.Email == "" {
 		return nil, fmt.Errorf("name, email, username, and password are required")
@@ -85,40 +202,20 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*RegisterR
 		return nil, fmt.Errorf("invalid email format")
 	}
 
-	// Name validation
-	if len(input.Name) < 2 {
-		return nil, fmt.Errorf("name must be at least 2 characters")
-	}
-	if len(input.Name) > 50 {
-		return nil, fmt.Errorf("name must be less than 50 characters")
-	}
-
-	// Username validation
-	if len(input.Username) < 3 {
-		return nil, fmt.Errorf("username must be at least 3 characters")
+	if err := validateName(input.Name); err != nil {
+		return nil, err
 	}
-	if len(input.Username) > 30 {
-		return nil, fmt.Errorf("username must be less than 30 characters")
+	if err := validateUsername(input.Username); err != nil {
+		return nil, err
 	}
-	if !isValidUsername(input.Username) {
-		return nil, fmt.Errorf("username must start with a letter and contain only letters, numbers, underscores, and dots")
+	// roles other than the default are assigned by an admin
+	if input.Role != "" && input.Role != "developer" {
+		return nil, invalid("role is assigned by an admin")
 	}
 
 	// Password validation
-	if len(input.Password) < 8 {
-		return nil, fmt.Errorf("password must be at least 8 characters")
-	}
-	if !hasUppercase(input.Password) {
-		return nil, fmt.Errorf("password must contain at least one uppercase letter")
-	}
-	if !hasLowercase(input.Password) {
-		return nil, fmt.Errorf("password must contain at least one lowercase letter")
-	}
-	if !hasNumber(input.Password) {
-		return nil, fmt.Errorf("password must contain at least one number")
-	}
-	if !hasSpecialChar(input.Password) {
-		return nil, fmt.Errorf("password must contain at least one special character")
+	if err := validatePassword(input.Password); err != nil {
+		return nil, err
 	}
 
 	// Check username uniqueness
@@ -127,7 +224,7 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*RegisterR
 		return nil, err
 	}
 	if exists {
-		return nil, fmt.Errorf("username already exists")
+		return nil, ErrUsernameTaken
 	}
 
 	// Check email uniqueness
@@ -136,7 +233,7 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*RegisterR
 		return nil, err
 	}
 	if emailExists {
-		return nil, fmt.Errorf("email already registered")
+		return nil, ErrEmailTaken
 	}
 
 	hashed, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
@@ -150,6 +247,7 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*RegisterR
 
 	params := CreateUserParams{
 		ID:                       uuid.NewString(),
+		OrgID:                    organizations.DefaultID,
 		Name:                     input.Name,
 		Username:                 input.Username,
 		Email:                    input.Email,
@@ -197,8 +295,9 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*RegisterR
 	}, nil
 }
 
-// ChangePassword lets an authenticated user rotate password.
-func (s *Service) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error {
+// ChangePassword lets an authenticated user rotate password. Every other
+// session is signed out; the session identified by currentSessionID is kept.
+func (s *Service) ChangePassword(ctx context.Context, userID, currentSessionID, oldPassword, newPassword string) error {
 	if userID == "" || oldPassword == "" || newPassword == "" {
 		return fmt.Errorf("old and new password are required")
 	}
@@ -219,6 +318,9 @@ func (s *Service) ChangePassword(ctx context.Context, userID, oldPassword, newPa
 	if err := s.repo.UpdatePassword(ctx, userID, string(newHash)); err != nil {
 		return err
 	}
+	if err := s.revokeOtherSessions(ctx, userID, currentSessionID); err != nil {
+		return err
+	}
 	if s.audit != nil {
 		action := "password_changed"
 		desc := fmt.Sprintf("%s changed password", u.Username)
@@ -230,15 +332,27 @@ func (s *Service) ChangePassword(ctx context.Context, userID, oldPassword, newPa
 	return nil
 }
 
-func (s *Service) buildLoginResponse(ctx context.Context, user *User, existingRefreshID string) (*LoginResponse, error) {
-	token, err := s.createToken(user)
+// buildLoginResponse issues an access token plus a refresh token. A nil parent
+// starts a new session (token family); otherwise the new token joins the parent's family.
+func (s *Service) buildLoginResponse(ctx context.Context, user *User, parent *RefreshToken, client ClientInfo) (*LoginResponse, error) {
+	refreshToken, familyID, err := s.issueRefreshToken(ctx, user.ID, parent, client)
 	if err != nil {
 		return nil, err
 	}
-	refreshToken, err := s.issueRefreshToken(ctx, user.ID, existingRefreshID)
+	token, err := s.createToken(user, familyID)
 	if err != nil {
 		return nil, err
 	}
+	return &LoginResponse{
+		Token:         token,
+		RefreshToken:  refreshToken,
+		ExpiresIn:     int64(s.accessTTL.Seconds()),
+		EmailVerified: user.EmailVerified,
+		User:          toPublic(user),
+	}, nil
+}
+
+func toPublic(user *User) UserPublic {
 	var bio string
 	if user.Bio != nil {
 		bio = *user.Bio
@@ -247,42 +361,45 @@ func (s *Service) buildLoginResponse(ctx context.Context, user *User, existingRe
 	if user.Email != nil {
 		email = *user.Email
 	}
-	return &LoginResponse{
-		Token:         token,
-		RefreshToken:  refreshToken,
+	return UserPublic{
+		ID:            user.ID,
+		Name:          user.Name,
+		Username:      user.Username,
+		Email:         email,
 		EmailVerified: user.EmailVerified,
-		User: UserPublic{
-			ID:            user.ID,
-			Name:          user.Name,
-			Username:      user.Username,
-			Email:         email,
-			EmailVerified: user.EmailVerified,
-			Role:          user.Role,
-			AvatarURL:     user.AvatarURL,
-			Badges:        user.Badges,
-			Bio:           bio,
-		},
-	}, nil
+		Role:          user.Role,
+		AvatarURL:     user.AvatarURL,
+		Badges:        user.Badges,
+		Bio:           bio,
+	}
 }
 
-func (s *Service) createToken(user *User) (string, error) {
+func (s *Service) createToken(user *User, sessionID string) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"sub":  user.ID,
+		"sid":  sessionID,
+		"jti":  uuid.NewString(),
 		"name": user.Name,
 		"role": user.Role,
-		"exp":  time.Now().Add(24 * time.Hour).Unix(),
-		"iat":  time.Now().Unix(),
+		"org":  user.OrgID,
+		"exp":  now.Add(s.accessTTL).Unix(),
+		"iat":  now.Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	return s.keys.Sign(claims)
 }
 
 // LoginResponse returned to clients.
 type LoginResponse struct {
 	Token         string     `json:"token"`
 	RefreshToken  string     `json:"refreshToken"`
+	ExpiresIn     int64      `json:"expiresIn"` // access token lifetime in seconds
 	User          UserPublic `json:"user"`
 	EmailVerified bool       `json:"emailVerified"`
+	RecoveryCodes []string   `json:"recoveryCodes,omitempty"`
+	// FailedLoginAttempts counts failed passwords since the previous successful login.
+	FailedLoginAttempts int        `json:"failedLoginAttempts,omitempty"`
+	LastFailedLoginAt   *time.Time `json:"lastFailedLoginAt,omitempty"`
 }
 
 // RegisterResponse returned after registration.
@@ -318,48 +435,101 @@ type RegisterInput struct {
 }
 
 // Refresh validates a refresh token, rotates it, and issues new tokens.
-func (s *Service) Refresh(ctx context.Context, refreshPlain string) (*LoginResponse, error) {
-	refreshID, secret, err := splitRefresh(refreshPlain)
+// Presenting a token that was already rotated or revoked is treated as theft:
+// the whole token family is revoked so neither party can keep the session.
+func (s *Service) Refresh(ctx context.Context, refreshPlain string, client ClientInfo) (*LoginResponse, error) {
+	stored, err := s.lookupRefresh(ctx, refreshPlain)
 	if err != nil {
+		return nil, err
+	}
+	if stored.RevokedAt != nil {
+		s.handleRefreshReuse(ctx, stored)
 		return nil, ErrInvalidCredentials
 	}
-	stored, err := s.repo.GetRefreshToken(ctx, refreshID)
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidCredentials
+	}
+	user, err := s.repo.FindByID(ctx, stored.UserID)
+	if err != nil || user == nil || user.DeactivatedAt != nil {
+		return nil, ErrInvalidCredentials
+	}
+	// rotate: revoke old and issue new
+	revoked, err := s.repo.RevokeRefreshToken(ctx, stored.ID)
 	if err != nil {
 		return nil, err
 	}
-	if stored == nil || stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+	if !revoked {
+		// lost a race with another refresh using the same token
+		s.handleRefreshReuse(ctx, stored)
 		return nil, ErrInvalidCredentials
 	}
-	secretHash := hashRefresh(secret)
-	if secretHash != stored.TokenHash {
+	return s.buildLoginResponse(ctx, user, stored, client)
+}
+
+// lookupRefresh parses a raw refresh token and verifies its secret.
+func (s *Service) lookupRefresh(ctx context.Context, refreshPlain string) (*RefreshToken, error) {
+	refreshID, secret, err := splitRefresh(refreshPlain)
+	if err != nil {
 		return nil, ErrInvalidCredentials
 	}
-	user, err := s.repo.FindByID(ctx, stored.UserID)
-	if err != nil || user == nil {
+	if _, err := uuid.Parse(refreshID); err != nil {
 		return nil, ErrInvalidCredentials
 	}
-	// rotate: revoke old and issue new
-	_ = s.repo.RevokeRefreshToken(ctx, stored.ID)
-	return s.buildLoginResponse(ctx, user, stored.ID)
+	stored, err := s.repo.GetRefreshToken(ctx, refreshID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || hashRefresh(secret) != stored.TokenHash {
+		return nil, ErrInvalidCredentials
+	}
+	return stored, nil
 }
 
-func (s *Service) issueRefreshToken(ctx context.Context, userID string, previousID string) (string, error) {
-	secret := uuid.NewString() + uuid.NewString()
-	secret = strings.ReplaceAll(secret, "-", "")
+func (s *Service) handleRefreshReuse(ctx context.Context, stored *RefreshToken) {
+	n, err := s.repo.RevokeRefreshFamily(ctx, stored.UserID, stored.FamilyID)
+	if err != nil || n == 0 {
+		return
+	}
+	_ = s.denySession(ctx, stored.UserID, stored.FamilyID)
+	if s.audit == nil {
+		return
+	}
+	desc := fmt.Sprintf("refresh token reuse detected; revoked %d token(s) of session %s", n, stored.FamilyID)
+	actorID := stored.UserID
+	entityType := "user"
+	entityID := stored.UserID
+	_ = s.audit.Log(ctx, "refresh_token_reuse", desc, &actorID, &entityType, &entityID)
+}
+
+// issueRefreshToken stores a new refresh token and returns the raw token plus its family (session) ID.
+func (s *Service) issueRefreshToken(ctx context.Context, userID string, parent *RefreshToken, client ClientInfo) (string, string, error) {
+	secret := newSecret()
 	refreshID := uuid.NewString()
 	now := time.Now()
 	token := RefreshToken{
 		ID:        refreshID,
 		UserID:    userID,
+		FamilyID:  refreshID,
 		TokenHash: hashRefresh(secret),
+		Device:    describeDevice(client.UserAgent),
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
 		ExpiresAt: now.Add(s.refreshTTL),
 		RevokedAt: nil,
 		CreatedAt: now,
 	}
+	if parent != nil {
+		// keep the device captured at login for the lifetime of the session
+		token.FamilyID = parent.FamilyID
+		token.ParentID = &parent.ID
+		token.Device = parent.Device
+		token.IP = parent.IP
+		token.UserAgent = parent.UserAgent
+	}
 	if err := s.repo.CreateRefreshToken(ctx, token); err != nil {
-		return "", err
+		return "", "", err
 	}
-	return refreshID + "." + secret, nil
+	return refreshID + "." + secret, token.FamilyID, nil
 }
 
 func splitRefresh(raw string) (string, string, error) {
@@ -371,12 +541,41 @@ func splitRefresh(raw string) (string, string, error) {
 }
 
 func hashRefresh(secret string) string {
+	return hashToken(secret)
+}
+
+// newSecret returns a random 64-char hex string suitable for bearer secrets.
+func newSecret() string {
+	return strings.ReplaceAll(uuid.NewString()+uuid.NewString(), "-", "")
+}
+
+// hashToken is used for every secret we persist so a DB leak does not expose usable tokens.
+func hashToken(secret string) string {
 	sum := sha256.Sum256([]byte(secret))
 	return hex.EncodeToString(sum[:])
 }
 
 // Validation helper functions
 
+func validatePassword(password string) error {
+	if len(password) < 8 {
+		return fmt.Errorf("password must be at least 8 characters")
+	}
+	if !hasUppercase(password) {
+		return fmt.Errorf("password must contain at least one uppercase letter")
+	}
+	if !hasLowercase(password) {
+		return fmt.Errorf("password must contain at least one lowercase letter")
+	}
+	if !hasNumber(password) {
+		return fmt.Errorf("password must contain at least one number")
+	}
+	if !hasSpecialChar(password) {
+		return fmt.Errorf("password must contain at least one special character")
+	}
+	return nil
+}
+
 func isValidUsername(username string) bool {
 	if len(username) == 0 {
 		return false
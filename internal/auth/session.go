@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientInfo describes the client that performs a login or refresh.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Session is one signed-in device: a refresh token family.
+type Session struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
+// ErrSessionNotFound is returned when revoking a session that does not exist or is already gone.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ListSessions returns the user's active sessions, flagging the one making the request.
+func (s *Service) ListSessions(ctx context.Context, userID, currentSessionID string) ([]Session, error) {
+	sessions, err := s.repo.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs out a single session of the user.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ErrSessionNotFound
+	}
+	n, err := s.repo.RevokeRefreshFamily(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	if err := s.denySession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.logSessionEvent(ctx, userID, "session_revoked", fmt.Sprintf("session %s revoked", sessionID))
+	return nil
+}
+
+// PurgeExpiredSessions deletes refresh tokens past their expiry. They can no
+// longer be used, so nothing is lost.
+func (s *Service) PurgeExpiredSessions(ctx context.Context) (int64, error) {
+	return s.repo.PurgeRefreshTokens(ctx, time.Now())
+}
+
+// Logout revokes the session the given refresh token belongs to. Unknown or
+// already revoked tokens are ignored so logout is idempotent.
+func (s *Service) Logout(ctx context.Context, refreshPlain string) error {
+	stored, err := s.lookupRefresh(ctx, refreshPlain)
+	if err != nil {
+		if err == ErrInvalidCredentials {
+			return nil
+		}
+		return err
+	}
+	n, err := s.repo.RevokeRefreshFamily(ctx, stored.UserID, stored.FamilyID)
+	if err != nil {
+		return err
+	}
+	if err := s.denySession(ctx, stored.UserID, stored.FamilyID); err != nil {
+		return err
+	}
+	if n > 0 {
+		s.logSessionEvent(ctx, stored.UserID, "logout", fmt.Sprintf("session %s signed out", stored.FamilyID))
+	}
+	return nil
+}
+
+// LogoutAll revokes every session of the user and returns how many tokens were revoked.
+func (s *Service) LogoutAll(ctx context.Context, userID string) (int64, error) {
+	n, err := s.repo.RevokeAllRefreshTokens(ctx, userID, "")
+	if err != nil {
+		return 0, err
+	}
+	if s.denylist != nil {
+		if err := s.denylist.RevokeUser(ctx, userID); err != nil {
+			return 0, err
+		}
+	}
+	s.logSessionEvent(ctx, userID, "logout_all", "signed out of all sessions")
+	return n, nil
+}
+
+// revokeOtherSessions signs out every session except keepSessionID, cutting
+// off their access tokens as well as their refresh tokens.
+func (s *Service) revokeOtherSessions(ctx context.Context, userID, keepSessionID string) error {
+	sessions, err := s.repo.ListSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.repo.RevokeAllRefreshTokens(ctx, userID, keepSessionID); err != nil {
+		return err
+	}
+	for _, sess := range sessions {
+		if sess.ID == keepSessionID {
+			continue
+		}
+		if err := s.denySession(ctx, userID, sess.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// denySession rejects access tokens already issued for the session. They live
+// at most accessTTL, so the entry can expire after that.
+func (s *Service) denySession(ctx context.Context, userID, sessionID string) error {
+	if s.denylist == nil {
+		return nil
+	}
+	return s.denylist.Revoke(ctx, sessionID, userID, time.Now().Add(s.accessTTL))
+}
+
+func (s *Service) logSessionEvent(ctx context.Context, userID, action, desc string) {
+	if s.audit == nil {
+		return
+	}
+	actorID := userID
+	entityType := "user"
+	entityID := userID
+	_ = s.audit.Log(ctx, action, desc, &actorID, &entityType, &entityID)
+}
+
+// describeDevice turns a user agent into a short label such as "Chrome on Windows".
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "curl/"):
+		browser = "curl"
+	case strings.Contains(ua, "postman"):
+		browser = "Postman"
+	case strings.Contains(ua, "okhttp") || strings.Contains(ua, "dart:io"):
+		browser = "Mobile app"
+	}
+
+	platform := ""
+	switch {
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os x") || strings.Contains(ua, "macintosh"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+	if platform == "" {
+		return browser
+	}
+	return browser + " on " + platform
+}
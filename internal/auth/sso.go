@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"backend-go-ticketing-gamify/internal/oidc"
+	"backend-go-ticketing-gamify/internal/organizations"
+)
+
+var (
+	ErrSSONotConfigured      = errors.New("single sign-on is not configured")
+	ErrPasswordLoginDisabled = errors.New("password login is disabled, use single sign-on")
+	ErrInvalidSSOState       = errors.New("invalid or expired sign-in attempt, start again")
+	ErrSSOEmailUnverified    = errors.New("identity provider did not return a verified email")
+	ErrSSONoAccount          = errors.New("no account exists for this identity")
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+// rolePriority picks the strongest role when several IdP groups map to roles.
+var rolePriority = map[string]int{"admin": 3, "project_manager": 2, "developer": 1}
+
+// SSOOptions configures OpenID Connect login.
+type SSOOptions struct {
+	// Provider is nil when SSO is not configured.
+	Provider *oidc.Provider
+	// AutoProvision creates accounts for unknown identities with a verified email.
+	AutoProvision bool
+	// RoleMapping maps IdP group names to user roles.
+	RoleMapping map[string]string
+	// DefaultRole is given to provisioned users no group maps.
+	DefaultRole string
+}
+
+// ParseRoleMapping parses "group=role,group2=role2". Roles must be values of
+// the user_role enum, so a bad mapping fails at startup rather than at the
+// first login it applies to.
+func ParseRoleMapping(spec string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected group=role", pair)
+		}
+		if !IsAllowedRole(role) {
+			return nil, fmt.Errorf("invalid role mapping %q, role must be developer, project_manager or admin", pair)
+		}
+		mapping[group] = role
+	}
+	return mapping, nil
+}
+
+// LoginMethods reports which login methods this deployment offers.
+func (s *Service) LoginMethods() map[string]bool {
+	return map[string]bool{
+		"password": !s.passwordLoginDisabled,
+		"oidc":     s.sso.Provider != nil,
+	}
+}
+
+// StartSSO creates the state, nonce and PKCE verifier for a login and returns
+// the URL to send the browser to.
+func (s *Service) StartSSO(ctx context.Context) (string, error) {
+	if s.sso.Provider == nil {
+		return "", ErrSSONotConfigured
+	}
+	state := oidc.NewVerifier()
+	st := OIDCState{
+		StateHash:    hashToken(state),
+		CodeVerifier: oidc.NewVerifier(),
+		Nonce:        oidc.NewVerifier(),
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}
+	if err := s.repo.CreateOIDCState(ctx, st); err != nil {
+		return "", err
+	}
+	return s.sso.Provider.AuthCodeURL(ctx, state, st.Nonce, st.CodeVerifier)
+}
+
+// CompleteSSO handles the authorization-code callback: it exchanges the
+// code, verifies the ID token, links or provisions the user, syncs the role
+// from IdP groups and then continues like a password login.
+func (s *Service) CompleteSSO(ctx context.Context, code, state string, client ClientInfo) (*LoginResult, error) {
+	if s.sso.Provider == nil {
+		return nil, ErrSSONotConfigured
+	}
+	st, err := s.repo.ConsumeOIDCState(ctx, hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, ErrInvalidSSOState
+	}
+	tokens, err := s.sso.Provider.Exchange(ctx, code, st.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.sso.Provider.VerifyIDToken(ctx, tokens.IDToken, st.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Email == "" || len(claims.Groups) == 0 {
+		if err := s.sso.Provider.UserInfo(ctx, tokens.AccessToken, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.resolveSSOUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeactivatedAt != nil {
+		return nil, ErrAccountDeactivated
+	}
+	if err := s.repo.LinkIdentity(ctx, user.ID, claims.Issuer, claims.Subject, claims.Email); err != nil {
+		return nil, err
+	}
+	if role := s.mappedRole(claims.Groups); role != "" && role != user.Role {
+		if err := s.repo.UpdateRole(ctx, user.ID, role); err != nil {
+			return nil, err
+		}
+		if s.audit != nil {
+			desc := fmt.Sprintf("%s role synced from identity provider: %s -> %s", user.Username, user.Role, role)
+			actorID := user.ID
+			entityType := "user"
+			entityID := user.ID
+			_ = s.audit.Log(ctx, "user_role_synced", desc, &actorID, &entityType, &entityID)
+		}
+		user.Role = role
+	}
+
+	challenge, err := s.mfaChallengeFor(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return &LoginResult{Challenge: challenge}, nil
+	}
+	session, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Session: session}, nil
+}
+
+// resolveSSOUser finds the account for an identity: an existing link first,
+// then a user with the same verified email, then (optionally) a new account.
+func (s *Service) resolveSSOUser(ctx context.Context, claims *oidc.Claims) (*User, error) {
+	user, err := s.repo.FindByIdentity(ctx, claims.Issuer, claims.Subject)
+	if err != nil || user != nil {
+		return user, err
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrSSOEmailUnverified
+	}
+	user, err = s.repo.FindByEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		if s.audit != nil {
+			desc := fmt.Sprintf("%s linked an identity from %s", user.Username, claims.Issuer)
+			actorID := user.ID
+			entityType := "user"
+			entityID := user.ID
+			_ = s.audit.Log(ctx, "identity_linked", desc, &actorID, &entityType, &entityID)
+		}
+		return user, nil
+	}
+	if !s.sso.AutoProvision {
+		return nil, ErrSSONoAccount
+	}
+	return s.provisionSSOUser(ctx, claims)
+}
+
+func (s *Service) provisionSSOUser(ctx context.Context, claims *oidc.Claims) (*User, error) {
+	username, err := s.availableUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = username
+	}
+	role := s.mappedRole(claims.Groups)
+	if role == "" {
+		role = s.sso.DefaultRole
+	}
+	// SSO accounts get an unusable random password; they can set one via password reset
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newSecret()), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.CreateSSOUser(ctx, CreateUserParams{
+		ID:           uuid.NewString(),
+		OrgID:        organizations.DefaultID,
+		Name:         name,
+		Username:     username,
+		Email:        claims.Email,
+		PasswordHash: string(hashed),
+		Role:         role,
+		Badges:       []string{"Initiate"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.gamification != nil {
+		_ = s.gamification.EnsureUser(ctx, user.ID)
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("%s joined the workspace via single sign-on", user.Name)
+		actorID := user.ID
+		entityType := "user"
+		entityID := user.ID
+		_ = s.audit.Log(ctx, "user_registered", desc, &actorID, &entityType, &entityID)
+	}
+	return user, nil
+}
+
+var usernameCleaner = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// availableUsername derives a unique username from preferred_username or the email local part.
+func (s *Service) availableUsername(ctx context.Context, claims *oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" || strings.Contains(base, "@") {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = strings.Trim(usernameCleaner.ReplaceAllString(strings.ToLower(base), ""), "._-")
+	if len(base) < 3 {
+		base = "user" + base
+	}
+	if len(base) > 24 {
+		base = base[:24]
+	}
+	candidate := base
+	for i := 2; i < 100; i++ {
+		exists, err := s.repo.UsernameExists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+	return base + "-" + uuid.NewString()[:8], nil
+}
+
+// mappedRole returns the highest-priority role any of the groups maps to.
+func (s *Service) mappedRole(groups []string) string {
+	best := ""
+	for _, g := range groups {
+		if role, ok := s.sso.RoleMapping[g]; ok && rolePriority[role] > rolePriority[best] {
+			best = role
+		}
+	}
+	return best
+}
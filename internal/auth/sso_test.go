@@ -0,0 +1,254 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/auth"
+	"backend-go-ticketing-gamify/internal/database/dbtest"
+	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/jwtauth"
+	"backend-go-ticketing-gamify/internal/oidc"
+	"backend-go-ticketing-gamify/internal/oidc/oidctest"
+	"backend-go-ticketing-gamify/internal/organizations"
+)
+
+type ssoEnv struct {
+	db  *pgxpool.Pool
+	idp *oidctest.Provider
+	svc *auth.Service
+}
+
+func newSSOEnv(t *testing.T, autoProvision bool) *ssoEnv {
+	t.Helper()
+	db := dbtest.Open(t)
+	idp, srv, err := oidctest.Start("ticketing", "")
+	if err != nil {
+		t.Fatalf("start provider: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	keys, err := jwtauth.LoadKeySet(jwtauth.Config{Secret: "test-secret"})
+	if err != nil {
+		t.Fatalf("keys: %v", err)
+	}
+	auditSvc := audit.NewService(audit.NewRepository(db))
+	svc := auth.NewService(auth.NewRepository(db), gamification.NewService(gamification.NewRepository(db), auditSvc), auditSvc, nil, auth.Options{
+		Keys: keys,
+		SSO: auth.SSOOptions{
+			Provider: oidc.NewProvider(oidc.Config{
+				Issuer:      srv.URL,
+				ClientID:    "ticketing",
+				RedirectURL: "http://localhost:5173/auth/callback",
+				Scopes:      []string{"openid", "email", "profile", "groups"},
+			}),
+			AutoProvision: autoProvision,
+			RoleMapping:   map[string]string{"eng-leads": "project_manager", "it-admins": "admin", "eng": "developer"},
+		},
+	})
+	return &ssoEnv{db: db, idp: idp, svc: svc}
+}
+
+// login signs user in at the provider and completes the callback.
+func (e *ssoEnv) login(t *testing.T, user oidctest.User) (*auth.LoginResult, error) {
+	t.Helper()
+	ctx := context.Background()
+	authURL, err := e.svc.StartSSO(ctx)
+	if err != nil {
+		t.Fatalf("StartSSO: %v", err)
+	}
+	code, state, err := e.idp.Login(authURL, user)
+	if err != nil {
+		t.Fatalf("provider login: %v", err)
+	}
+	return e.svc.CompleteSSO(ctx, code, state, auth.ClientInfo{IP: "127.0.0.1", UserAgent: "test"})
+}
+
+func (e *ssoEnv) identities(t *testing.T, userID string) int {
+	t.Helper()
+	var n int
+	if err := e.db.QueryRow(context.Background(), `SELECT COUNT(*) FROM user_identities WHERE user_id = $1`, userID).Scan(&n); err != nil {
+		t.Fatalf("count identities: %v", err)
+	}
+	return n
+}
+
+func (e *ssoEnv) existingUser(t *testing.T, role string) (id, email string) {
+	t.Helper()
+	id = dbtest.User(t, e.db, organizations.DefaultID, role)
+	if err := e.db.QueryRow(context.Background(), `SELECT email FROM users WHERE id = $1`, id).Scan(&email); err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	return id, email
+}
+
+func TestSSOLinksByVerifiedEmail(t *testing.T) {
+	env := newSSOEnv(t, false)
+	userID, email := env.existingUser(t, "developer")
+
+	result, err := env.login(t, oidctest.User{Email: email, Name: "Existing", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("CompleteSSO: %v", err)
+	}
+	if result.Session == nil || result.Session.User.ID != userID || result.Session.Token == "" {
+		t.Fatalf("result = %+v, want a session for %s", result, userID)
+	}
+	if n := env.identities(t, userID); n != 1 {
+		t.Fatalf("identities = %d, want 1", n)
+	}
+
+	// the link now resolves the user without relying on the email
+	again, err := env.login(t, oidctest.User{Email: email, EmailVerified: false})
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.Session.User.ID != userID {
+		t.Fatalf("second login signed in %s, want %s", again.Session.User.ID, userID)
+	}
+}
+
+func TestSSORefusesUnverifiedEmail(t *testing.T) {
+	env := newSSOEnv(t, true)
+	userID, email := env.existingUser(t, "admin")
+
+	_, err := env.login(t, oidctest.User{Email: email, EmailVerified: false})
+	if !errors.Is(err, auth.ErrSSOEmailUnverified) {
+		t.Fatalf("err = %v, want ErrSSOEmailUnverified", err)
+	}
+	if n := env.identities(t, userID); n != 0 {
+		t.Fatalf("identities = %d, want the account left unlinked", n)
+	}
+	var users int
+	if err := env.db.QueryRow(context.Background(), `SELECT COUNT(*) FROM users WHERE LOWER(email) = LOWER($1)`, email).Scan(&users); err != nil {
+		t.Fatalf("count users: %v", err)
+	}
+	if users != 1 {
+		t.Fatalf("users with the email = %d, want no account provisioned", users)
+	}
+}
+
+func TestSSOWithoutAutoProvision(t *testing.T) {
+	env := newSSOEnv(t, false)
+	_, err := env.login(t, oidctest.User{Email: "new@example.com", EmailVerified: true})
+	if !errors.Is(err, auth.ErrSSONoAccount) {
+		t.Fatalf("err = %v, want ErrSSONoAccount", err)
+	}
+}
+
+func TestSSOAutoProvisionMapsGroups(t *testing.T) {
+	cases := []struct {
+		name   string
+		groups []string
+		role   string
+	}{
+		{"no mapped group gets the default role", []string{"marketing"}, "developer"},
+		{"mapped group", []string{"eng-leads"}, "project_manager"},
+		{"strongest of several groups", []string{"eng", "it-admins", "eng-leads"}, "admin"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := newSSOEnv(t, true)
+			result, err := env.login(t, oidctest.User{Email: "Nia.Putri@example.com", Name: "Nia Putri", Groups: tc.groups, EmailVerified: true})
+			if err != nil {
+				t.Fatalf("CompleteSSO: %v", err)
+			}
+			user := result.Session.User
+			if user.Role != tc.role {
+				t.Errorf("role = %q, want %q", user.Role, tc.role)
+			}
+			if user.Name != "Nia Putri" || user.Username != "nia.putri" || user.Email != "Nia.Putri@example.com" {
+				t.Errorf("user = %+v", user)
+			}
+			var orgID string
+			if err := env.db.QueryRow(context.Background(), `SELECT org_id::text FROM users WHERE id = $1`, user.ID).Scan(&orgID); err != nil {
+				t.Fatalf("load org: %v", err)
+			}
+			if orgID != organizations.DefaultID {
+				t.Errorf("org = %s, want the default organization", orgID)
+			}
+			if n := env.identities(t, user.ID); n != 1 {
+				t.Errorf("identities = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestSSOSyncsRoleFromGroups(t *testing.T) {
+	env := newSSOEnv(t, false)
+	userID, email := env.existingUser(t, "developer")
+
+	result, err := env.login(t, oidctest.User{Email: email, Groups: []string{"eng-leads"}, EmailVerified: true})
+	if err != nil {
+		t.Fatalf("CompleteSSO: %v", err)
+	}
+	if result.Session.User.Role != "project_manager" {
+		t.Fatalf("role = %q, want project_manager", result.Session.User.Role)
+	}
+	var role string
+	if err := env.db.QueryRow(context.Background(), `SELECT role::text FROM users WHERE id = $1`, userID).Scan(&role); err != nil {
+		t.Fatalf("load role: %v", err)
+	}
+	if role != "project_manager" {
+		t.Fatalf("stored role = %q, want project_manager", role)
+	}
+
+	// unmapped groups leave the role alone
+	result, err = env.login(t, oidctest.User{Email: email, Groups: []string{"marketing"}, EmailVerified: true})
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if result.Session.User.Role != "project_manager" {
+		t.Fatalf("role = %q after an unmapped group, want project_manager", result.Session.User.Role)
+	}
+}
+
+func TestSSOStateIsSingleUse(t *testing.T) {
+	env := newSSOEnv(t, true)
+	ctx := context.Background()
+	authURL, err := env.svc.StartSSO(ctx)
+	if err != nil {
+		t.Fatalf("StartSSO: %v", err)
+	}
+	code, state, err := env.idp.Login(authURL, oidctest.User{Email: "ana@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("provider login: %v", err)
+	}
+	if _, err := env.svc.CompleteSSO(ctx, code, state, auth.ClientInfo{}); err != nil {
+		t.Fatalf("CompleteSSO: %v", err)
+	}
+	if _, err := env.svc.CompleteSSO(ctx, code, state, auth.ClientInfo{}); !errors.Is(err, auth.ErrInvalidSSOState) {
+		t.Fatalf("replayed callback: err = %v, want ErrInvalidSSOState", err)
+	}
+	if _, err := env.svc.CompleteSSO(ctx, code, "forged", auth.ClientInfo{}); !errors.Is(err, auth.ErrInvalidSSOState) {
+		t.Fatalf("unknown state: err = %v, want ErrInvalidSSOState", err)
+	}
+}
+
+func TestParseRoleMapping(t *testing.T) {
+	cases := []struct {
+		spec string
+		want map[string]string
+		ok   bool
+	}{
+		{"", map[string]string{}, true},
+		{"it-admins=admin, leads = project_manager,devs=developer", map[string]string{"it-admins": "admin", "leads": "project_manager", "devs": "developer"}, true},
+		{"guests=viewer", nil, false},
+		{"devs=Developer", nil, false},
+		{"devs", nil, false},
+		{"=admin", nil, false},
+	}
+	for _, tc := range cases {
+		got, err := auth.ParseRoleMapping(tc.spec)
+		if (err == nil) != tc.ok {
+			t.Errorf("ParseRoleMapping(%q) err = %v, want ok %v", tc.spec, err, tc.ok)
+			continue
+		}
+		if tc.ok && !maps.Equal(got, tc.want) {
+			t.Errorf("ParseRoleMapping(%q) = %v, want %v", tc.spec, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// MaxImportRows caps how many users one CSV import may create.
+const MaxImportRows = 1000
+
+// ErrImportRejected is returned with the report when a non-dry-run import has
+// invalid rows; nothing is created in that case.
+var ErrImportRejected = errors.New("import has invalid rows, nothing was created")
+
+// ImportReport describes every row of a CSV import.
+type ImportReport struct {
+	DryRun  bool        `json:"dryRun"`
+	Total   int         `json:"total"`
+	Valid   int         `json:"valid"`
+	Invalid int         `json:"invalid"`
+	Created int         `json:"created"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+// ImportRow is one CSV record with its validation result. Line is the line
+// number in the file, counting the header as line 1.
+type ImportRow struct {
+	Line     int                 `json:"line"`
+	Name     string              `json:"name"`
+	Email    string              `json:"email"`
+	Username string              `json:"username"`
+	Role     string              `json:"role"`
+	Projects []ProjectAssignment `json:"projects"`
+	Errors   []string            `json:"errors,omitempty"`
+	UserID   string              `json:"userId,omitempty"`
+}
+
+var importColumns = map[string]bool{"name": true, "email": true, "username": true, "role": true, "projects": true}
+
+// ImportUsers validates a CSV of users and, unless dryRun is set, creates all
+// of them in one transaction. Columns: name, email, username and optionally
+// role and projects ("projectId:role;projectId"). Users join orgID and every
+// one of them gets an email with a link to set their password.
+func (s *Service) ImportUsers(ctx context.Context, orgID, actorID string, r io.Reader, dryRun bool) (*ImportReport, error) {
+	rows, err := parseImport(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkImport(ctx, orgID, rows); err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: dryRun, Total: len(rows), Rows: rows}
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			report.Invalid++
+		} else {
+			report.Valid++
+		}
+	}
+	if dryRun {
+		return report, nil
+	}
+	if report.Invalid > 0 {
+		return report, ErrImportRejected
+	}
+
+	expires := time.Now().Add(s.inviteTTL)
+	tokens := make([]string, len(rows))
+	accounts := make([]NewAccount, len(rows))
+	for i, row := range rows {
+		tokens[i] = newSecret()
+		accounts[i] = NewAccount{
+			Name:           row.Name,
+			Username:       row.Username,
+			Email:          row.Email,
+			Role:           row.Role,
+			Projects:       row.Projects,
+			ResetTokenHash: hashToken(tokens[i]),
+			ResetExpires:   expires,
+		}
+	}
+	created, err := s.repo.CreateAccounts(ctx, orgID, accounts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range created {
+		user := &created[i]
+		report.Rows[i].UserID = user.ID
+		s.afterAccountCreated(ctx, actorID, user, "imported")
+		s.sendInvite(ctx, actorID, rows[i].Email, user.Name, user.Role, tokens[i], expires, true)
+	}
+	report.Created = len(created)
+	return report, nil
+}
+
+// parseImport reads the CSV and checks each row on its own.
+func parseImport(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, invalid("the file is empty")
+	}
+	if err != nil {
+		return nil, invalid("invalid CSV: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))
+		if !importColumns[col] {
+			return nil, invalid("unknown column %q", col)
+		}
+		if _, dup := columns[col]; dup {
+			return nil, invalid("column %q appears twice", col)
+		}
+		columns[col] = i
+	}
+	for _, col := range []string{"name", "email", "username"} {
+		if _, ok := columns[col]; !ok {
+			return nil, invalid("missing required column %q", col)
+		}
+	}
+
+	rows := []ImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, invalid("invalid CSV: %v", err)
+		}
+		if len(rows) == MaxImportRows {
+			return nil, invalid("an import may contain at most %d users", MaxImportRows)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(col string) string {
+			i, ok := columns[col]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := ImportRow{
+			Line:     line,
+			Name:     field("name"),
+			Email:    field("email"),
+			Username: field("username"),
+		}
+		if err := validateName(row.Name); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+		if !isValidEmail(row.Email) {
+			row.Errors = append(row.Errors, "invalid email format")
+		}
+		if err := validateUsername(row.Username); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+		if row.Role, err = accountRole(field("role")); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+		row.Projects = parseImportProjects(field("projects"), &row)
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, invalid("the file has no users")
+	}
+	return rows, nil
+}
+
+// parseImportProjects reads "projectId:role;projectId", recording problems on row.
+func parseImportProjects(value string, row *ImportRow) []ProjectAssignment {
+	projects := []ProjectAssignment{}
+	seen := map[string]bool{}
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, role, _ := strings.Cut(item, ":")
+		p := ProjectAssignment{ProjectID: strings.TrimSpace(id), Role: strings.TrimSpace(role)}
+		if p.Role == "" {
+			p.Role = "member"
+		}
+		if !projectMemberRoles[p.Role] {
+			row.Errors = append(row.Errors, fmt.Sprintf("invalid project role %q", p.Role))
+			continue
+		}
+		if seen[p.ProjectID] {
+			row.Errors = append(row.Errors, fmt.Sprintf("project %s is listed twice", p.ProjectID))
+			continue
+		}
+		seen[p.ProjectID] = true
+		projects = append(projects, p)
+	}
+	return projects
+}
+
+// checkImport flags duplicates within the file and conflicts with existing
+// users and with projects outside orgID.
+func (s *Service) checkImport(ctx context.Context, orgID string, rows []ImportRow) error {
+	var emails, usernames, projectIDs []string
+	for _, row := range rows {
+		emails = append(emails, strings.ToLower(row.Email))
+		usernames = append(usernames, row.Username)
+		for _, p := range row.Projects {
+			projectIDs = append(projectIDs, p.ProjectID)
+		}
+	}
+	takenEmails, err := s.repo.ExistingEmails(ctx, emails)
+	if err != nil {
+		return err
+	}
+	takenUsernames, err := s.repo.ExistingUsernames(ctx, usernames)
+	if err != nil {
+		return err
+	}
+	projects, err := s.repo.ExistingProjectIDs(ctx, orgID, projectIDs)
+	if err != nil {
+		return err
+	}
+
+	emailLine := map[string]int{}
+	usernameLine := map[string]int{}
+	for i := range rows {
+		row := &rows[i]
+		emailKey := strings.ToLower(row.Email)
+		if row.Email != "" {
+			if first, ok := emailLine[emailKey]; ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("email duplicates line %d", first))
+			} else {
+				emailLine[emailKey] = row.Line
+			}
+		}
+		if takenEmails[emailKey] {
+			row.Errors = append(row.Errors, ErrEmailTaken.Error())
+		}
+		if row.Username != "" {
+			if first, ok := usernameLine[row.Username]; ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("username duplicates line %d", first))
+			} else {
+				usernameLine[row.Username] = row.Line
+			}
+		}
+		if takenUsernames[row.Username] {
+			row.Errors = append(row.Errors, ErrUsernameTaken.Error())
+		}
+		for _, p := range row.Projects {
+			if !projects[p.ProjectID] {
+				row.Errors = append(row.Errors, fmt.Sprintf("project %s not found", p.ProjectID))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/auth"
+	"backend-go-ticketing-gamify/internal/database/dbtest"
+	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/jwtauth"
+)
+
+func TestImportUsersRejectsUnknownRole(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	orgID := dbtest.Org(t, db)
+	adminID := dbtest.User(t, db, orgID, "admin")
+	keys, err := jwtauth.LoadKeySet(jwtauth.Config{Secret: "test-secret"})
+	if err != nil {
+		t.Fatalf("keys: %v", err)
+	}
+	auditSvc := audit.NewService(audit.NewRepository(db))
+	svc := auth.NewService(auth.NewRepository(db), gamification.NewService(gamification.NewRepository(db), auditSvc), auditSvc, nil, auth.Options{Keys: keys})
+
+	const csv = "name,email,username,role\n" +
+		"Ana Lee,ana@example.com,ana,developer\n" +
+		"Bo Chan,bo@example.com,bo,viewer\n"
+
+	report, err := svc.ImportUsers(ctx, orgID, adminID, strings.NewReader(csv), true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if report.Valid != 1 || report.Invalid != 1 || len(report.Rows[1].Errors) == 0 {
+		t.Fatalf("dry run report = %+v, want the viewer row invalid", report)
+	}
+
+	if _, err := svc.ImportUsers(ctx, orgID, adminID, strings.NewReader(csv), false); !errors.Is(err, auth.ErrImportRejected) {
+		t.Fatalf("import err = %v, want ErrImportRejected", err)
+	}
+}
@@ -0,0 +1,258 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	feedPrefix = "tgc_"
+	// MaxFeeds caps how many feeds one user may keep.
+	MaxFeeds = 20
+	// feedPast and feedAhead bound the events a feed carries around today.
+	feedPast  = 90 * 24 * time.Hour
+	feedAhead = 365 * 24 * time.Hour
+	// touchInterval limits last_used_at writes to one per feed and interval.
+	touchInterval   = 5 * time.Minute
+	defaultFeedName = "Ticketing Gamified"
+)
+
+var (
+	ErrFeedNotFound = errors.New("calendar feed not found")
+	// ErrInvalidFeed wraps what is wrong with a feed request.
+	ErrInvalidFeed = errors.New("invalid calendar feed")
+)
+
+// ListFeeds returns the caller's calendar feeds.
+func (s *Service) ListFeeds(ctx context.Context, userID string) ([]Feed, error) {
+	return s.repo.ListFeeds(ctx, userID)
+}
+
+// CreateFeed issues a new feed URL for userID. baseURL is used when no
+// public URL is configured.
+func (s *Service) CreateFeed(ctx context.Context, userID, orgID string, input FeedInput, baseURL string) (*CreatedFeed, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = defaultFeedName
+	}
+	if len(name) > 100 {
+		return nil, fmt.Errorf("%w: name may be at most 100 characters", ErrInvalidFeed)
+	}
+	projectIDs, err := s.checkProjects(ctx, orgID, input.ProjectIDs)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.repo.CountFeeds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= MaxFeeds {
+		return nil, fmt.Errorf("%w: at most %d feeds; delete one first", ErrInvalidFeed, MaxFeeds)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	feed, err := s.repo.CreateFeed(ctx, userID, name, secret[:len(feedPrefix)+8], hashToken(secret), projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	s.logFeed(ctx, userID, "calendar_feed_created", fmt.Sprintf("Calendar feed %q created", feed.Name))
+	return &CreatedFeed{Feed: *feed, URL: s.feedURL(baseURL, secret)}, nil
+}
+
+// RotateFeed gives one of the caller's feeds a new URL; the old one stops
+// working.
+func (s *Service) RotateFeed(ctx context.Context, userID, feedID, baseURL string) (*CreatedFeed, error) {
+	if uuid.Validate(feedID) != nil {
+		return nil, ErrFeedNotFound
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	feed, err := s.repo.RotateFeed(ctx, userID, feedID, secret[:len(feedPrefix)+8], hashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+	if feed == nil {
+		return nil, ErrFeedNotFound
+	}
+	s.logFeed(ctx, userID, "calendar_feed_rotated", fmt.Sprintf("Calendar feed %q rotated", feed.Name))
+	return &CreatedFeed{Feed: *feed, URL: s.feedURL(baseURL, secret)}, nil
+}
+
+// DeleteFeed deletes one of the caller's feeds.
+func (s *Service) DeleteFeed(ctx context.Context, userID, feedID string) error {
+	if uuid.Validate(feedID) != nil {
+		return ErrFeedNotFound
+	}
+	feed, err := s.repo.DeleteFeed(ctx, userID, feedID)
+	if err != nil {
+		return err
+	}
+	if feed == nil {
+		return ErrFeedNotFound
+	}
+	s.logFeed(ctx, userID, "calendar_feed_deleted", fmt.Sprintf("Calendar feed %q deleted", feed.Name))
+	return nil
+}
+
+// checkProjects de-duplicates ids and makes sure each is a project of the
+// organization.
+func (s *Service) checkProjects(ctx context.Context, orgID string, ids []string) ([]string, error) {
+	out := []string{}
+	for _, id := range ids {
+		if uuid.Validate(id) != nil {
+			return nil, fmt.Errorf("%w: %q is not a project id", ErrInvalidFeed, id)
+		}
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+	n, err := s.repo.CountProjects(ctx, orgID, out)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(out) {
+		return nil, fmt.Errorf("%w: unknown project", ErrInvalidFeed)
+	}
+	return out, nil
+}
+
+func (s *Service) logFeed(ctx context.Context, userID, action, desc string) {
+	if s.audit == nil {
+		return
+	}
+	entityType := "user"
+	_ = s.audit.Log(ctx, action, desc, &userID, &entityType, &userID)
+}
+
+// feedURL is the subscription URL of a feed token.
+func (s *Service) feedURL(baseURL, secret string) string {
+	base := s.publicURL
+	if base == "" {
+		base = strings.TrimRight(baseURL, "/")
+	}
+	return base + "/calendar/feeds/" + secret + ".ics"
+}
+
+// findFeed returns the feed of a token, or nil when it matches none.
+func (s *Service) findFeed(ctx context.Context, token string) (*feedOwner, error) {
+	if !strings.HasPrefix(token, feedPrefix) {
+		return nil, nil
+	}
+	owner, err := s.repo.FindFeed(ctx, hashToken(token))
+	if err != nil || owner == nil {
+		return nil, err
+	}
+	if owner.LastUsedAt == nil || time.Since(*owner.LastUsedAt) > touchInterval {
+		_ = s.repo.TouchFeed(ctx, owner.FeedID)
+	}
+	return owner, nil
+}
+
+// writeFeed writes the events of a feed as an iCalendar document: ticket
+// deadlines and epic start and due dates of the owner's organization, from
+// 90 days back to a year ahead.
+func (s *Service) writeFeed(ctx context.Context, owner *feedOwner, w io.Writer) error {
+	now := time.Now().UTC()
+	start, end := now.Add(-feedPast), now.Add(feedAhead)
+	events, err := s.repo.GetEvents(ctx, Filter{
+		OrgID:      owner.OrgID,
+		StartDate:  &start,
+		EndDate:    &end,
+		ProjectIDs: owner.ProjectIDs,
+		Type:       "all",
+	})
+	if err != nil {
+		return err
+	}
+
+	iw := newICSWriter(w)
+	iw.begin(owner.Name)
+	for _, e := range events {
+		iw.event(s.icsEvent(e))
+	}
+	return iw.end()
+}
+
+// icsEvent turns a calendar event into a VEVENT. The UID only depends on the
+// event's kind and record, so calendar apps update events in place.
+func (s *Service) icsEvent(e CalendarEvent) icsEvent {
+	ev := icsEvent{
+		UID:        fmt.Sprintf("%s-%s@ticketing-gamify", strings.ReplaceAll(e.Type, "_", "-"), e.ID),
+		Date:       e.Date,
+		EndDate:    e.EndDate,
+		Categories: e.ProjectName,
+		URL:        s.frontendURL + "/projects/" + url.PathEscape(e.ProjectID) + "?" + url.Values{"epic": {e.ID}}.Encode(),
+	}
+	switch e.Type {
+	case TypeTicketDeadline:
+		ev.Summary = "Due: " + e.Title
+		ev.URL = s.frontendURL + "/tickets/" + url.PathEscape(e.ID)
+	case TypeTicketStart:
+		ev.Summary = "Starts: " + e.Title
+		ev.URL = s.frontendURL + "/tickets/" + url.PathEscape(e.ID)
+	case TypeEpicStart:
+		ev.Summary = "Epic starts: " + e.Title
+	case TypeMilestone:
+		ev.Summary = "Milestone: " + e.Title
+		ev.URL = s.frontendURL + "/projects/" + url.PathEscape(e.ProjectID) + "?" + url.Values{"milestone": {e.ID}}.Encode()
+	case TypeTimeOff:
+		ev.URL = s.frontendURL + "/calendar"
+		ev.Summary = fmt.Sprintf("%s away (%s)", e.UserName, e.Kind)
+		ev.Description = "Time off: " + label(e.Kind) + "\n\n" + ev.URL
+		return ev
+	default:
+		ev.Summary = "Epic due: " + e.Title
+	}
+
+	lines := []string{"Project: " + e.ProjectName, "Status: " + label(e.Status)}
+	if e.Priority != "" {
+		lines = append(lines, "Priority: "+label(e.Priority))
+	}
+	if e.UserName != "" {
+		lines = append(lines, "Assignee: "+e.UserName)
+	}
+	lines = append(lines, "", ev.URL)
+	ev.Description = strings.Join(lines, "\n")
+	return ev
+}
+
+// label turns an enum value such as "in_progress" into "In progress".
+func label(value string) string {
+	if value == "" {
+		return value
+	}
+	value = strings.ReplaceAll(value, "_", " ")
+	return strings.ToUpper(value[:1]) + value[1:]
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return feedPrefix + hex.EncodeToString(buf), nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
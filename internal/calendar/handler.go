@@ -1,11 +1,16 @@
 package calendar
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"backend-go-ticketing-gamify/internal/middleware"
 	"backend-go-ticketing-gamify/internal/response"
 )
 
@@ -22,10 +27,47 @@ func NewHandler(service *Service) *Handler {
 // RegisterRoutes attaches calendar endpoints.
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/events", h.getEvents)
+	router.GET("/week", h.week)
+	router.GET("/agenda", h.agenda)
+	router.GET("/overdue", h.overdue)
+	router.GET("/time-off", h.listTimeOff)
+	router.POST("/time-off", h.createTimeOff)
+	router.DELETE("/time-off/:id", h.deleteTimeOff)
+	router.GET("/feeds", h.listFeeds)
+	router.POST("/feeds", h.createFeed)
+	router.POST("/feeds/:id/rotate", h.rotateFeed)
+	router.DELETE("/feeds/:id", h.deleteFeed)
+}
+
+// RegisterFeedRoutes attaches the .ics feeds. They authenticate with the
+// token in the URL, so mount them outside the API key and session guards:
+// calendar apps can send neither.
+func (h *Handler) RegisterFeedRoutes(router *gin.RouterGroup) {
+	router.GET("/feeds/:file", h.feed)
+}
+
+// eventFilter reads the filters every event listing takes: projectId,
+// assigneeId ("me" for the caller) and type.
+func eventFilter(c *gin.Context, user *middleware.UserContext) Filter {
+	filter := Filter{
+		OrgID:      user.OrgID,
+		ProjectID:  c.Query("projectId"),
+		AssigneeID: c.Query("assigneeId"),
+		Type:       c.DefaultQuery("type", "all"),
+	}
+	if filter.AssigneeID == "me" {
+		filter.AssigneeID = user.ID
+	}
+	return filter
 }
 
 func (h *Handler) getEvents(c *gin.Context) {
-	var filter Filter
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	filter := eventFilter(c, user)
 
 	if startStr := c.Query("start"); startStr != "" {
 		if t, err := time.Parse("2006-01-02", startStr); err == nil {
@@ -37,8 +79,6 @@ func (h *Handler) getEvents(c *gin.Context) {
 			filter.EndDate = &t
 		}
 	}
-	filter.ProjectID = c.Query("projectId")
-	filter.Type = c.DefaultQuery("type", "all")
 
 	events, err := h.service.GetEvents(c.Request.Context(), filter)
 	if err != nil {
@@ -50,3 +90,281 @@ func (h *Handler) getEvents(c *gin.Context) {
 	}
 	response.OK(c, events)
 }
+
+// week serves the week containing ?date= (default today), starting on
+// ?weekStart=monday or sunday.
+func (h *Handler) week(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	date, ok := dateQuery(c, "date")
+	if !ok {
+		return
+	}
+	weekStart := time.Monday
+	switch c.DefaultQuery("weekStart", "monday") {
+	case "monday":
+	case "sunday":
+		weekStart = time.Sunday
+	default:
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "weekStart must be monday or sunday")
+		return
+	}
+	view, err := h.service.Week(c.Request.Context(), eventFilter(c, user), date, weekStart)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, view)
+}
+
+// agenda serves the days with events from ?start= (default today) for
+// ?days= (default 14).
+func (h *Handler) agenda(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	start, ok := dateQuery(c, "start")
+	if !ok {
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "14"))
+	if err != nil || days < 1 || days > MaxAgendaDays {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", fmt.Sprintf("days must be between 1 and %d", MaxAgendaDays))
+		return
+	}
+	view, err := h.service.Agenda(c.Request.Context(), eventFilter(c, user), start, days)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, view)
+}
+
+func (h *Handler) overdue(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	events, err := h.service.GetOverdue(c.Request.Context(), eventFilter(c, user))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, events)
+}
+
+// listTimeOff serves the time off overlapping ?start= to ?end= (default the
+// current month), optionally of one ?userId=.
+func (h *Handler) listTimeOff(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	now := today()
+	filter := TimeOffFilter{
+		OrgID:  user.OrgID,
+		UserID: c.Query("userId"),
+		Start:  time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+	}
+	filter.End = filter.Start.AddDate(0, 1, -1)
+	if filter.UserID == "me" {
+		filter.UserID = user.ID
+	}
+	if c.Query("start") != "" {
+		start, ok := dateQuery(c, "start")
+		if !ok {
+			return
+		}
+		filter.Start = start
+		filter.End = start.AddDate(0, 1, -1)
+	}
+	if c.Query("end") != "" {
+		end, ok := dateQuery(c, "end")
+		if !ok {
+			return
+		}
+		filter.End = end
+	}
+	entries, err := h.service.ListTimeOff(c.Request.Context(), filter)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, entries)
+}
+
+func (h *Handler) createTimeOff(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload TimeOffInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	entry, err := h.service.CreateTimeOff(c.Request.Context(), user, payload)
+	if err != nil {
+		writeTimeOffError(c, err)
+		return
+	}
+	response.Created(c, entry)
+}
+
+func (h *Handler) deleteTimeOff(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	if err := h.service.DeleteTimeOff(c.Request.Context(), user, c.Param("id")); err != nil {
+		writeTimeOffError(c, err)
+		return
+	}
+	response.NoContent(c)
+}
+
+// dateQuery parses a YYYY-MM-DD query parameter, today when absent. It
+// answers 400 itself when the value is malformed.
+func dateQuery(c *gin.Context, name string) (time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return today(), true
+	}
+	t, err := time.Parse(dateLayout, raw)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", name+" must be a date like 2006-01-02")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func writeTimeOffError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrTimeOffNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrForbidden):
+		response.ErrorCode(c, http.StatusForbidden, "forbidden", "only admins and project managers manage time off of others")
+	case errors.Is(err, ErrInvalidTimeOff):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+func (h *Handler) listFeeds(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	feeds, err := h.service.ListFeeds(c.Request.Context(), user.ID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, feeds)
+}
+
+func (h *Handler) createFeed(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload FeedInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	feed, err := h.service.CreateFeed(c.Request.Context(), user.ID, user.OrgID, payload, requestBaseURL(c))
+	if err != nil {
+		writeFeedError(c, err)
+		return
+	}
+	response.Created(c, feed)
+}
+
+func (h *Handler) rotateFeed(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	feed, err := h.service.RotateFeed(c.Request.Context(), user.ID, c.Param("id"), requestBaseURL(c))
+	if err != nil {
+		writeFeedError(c, err)
+		return
+	}
+	response.OK(c, feed)
+}
+
+func (h *Handler) deleteFeed(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	if err := h.service.DeleteFeed(c.Request.Context(), user.ID, c.Param("id")); err != nil {
+		writeFeedError(c, err)
+		return
+	}
+	response.NoContent(c)
+}
+
+// feed serves /calendar/feeds/<token>.ics.
+func (h *Handler) feed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("file"), ".ics")
+	if !ok {
+		response.ErrorCode(c, http.StatusNotFound, "not_found", ErrFeedNotFound.Error())
+		return
+	}
+	owner, err := h.service.findFeed(c.Request.Context(), token)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	if owner == nil {
+		response.ErrorCode(c, http.StatusNotFound, "not_found", ErrFeedNotFound.Error())
+		return
+	}
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="calendar.ics"`)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+	if err := h.service.writeFeed(c.Request.Context(), owner, c.Writer); err != nil {
+		// the status is out already; a broken body makes the client retry
+		_ = c.Error(err)
+	}
+}
+
+// requestBaseURL is the scheme and host the request reached the API on.
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func writeFeedError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFeedNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrInvalidFeed):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -0,0 +1,103 @@
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icsLineLimit is the longest content line RFC 5545 allows, in octets.
+const icsLineLimit = 75
+
+// icsEvent is one all-day VEVENT of a feed.
+type icsEvent struct {
+	UID         string
+	Date        time.Time
+	EndDate     *time.Time // last day of an event spanning several
+	Summary     string
+	Description string
+	URL         string
+	Categories  string
+}
+
+// icsWriter writes an iCalendar (RFC 5545) document.
+type icsWriter struct {
+	w     *bufio.Writer
+	stamp string
+}
+
+func newICSWriter(w io.Writer) *icsWriter {
+	return &icsWriter{w: bufio.NewWriter(w), stamp: time.Now().UTC().Format("20060102T150405Z")}
+}
+
+// begin opens the calendar. name is what calendar apps show for it.
+func (iw *icsWriter) begin(name string) {
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:-//Ticketing Gamified//Calendar Feed//EN")
+	iw.line("CALSCALE:GREGORIAN")
+	iw.line("METHOD:PUBLISH")
+	iw.line("X-WR-CALNAME:" + escapeText(name))
+	// ask subscribers to poll hourly; Google Calendar ignores this and picks its own interval
+	iw.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	iw.line("X-PUBLISHED-TTL:PT1H")
+}
+
+func (iw *icsWriter) event(e icsEvent) {
+	first, last := day(e.Date), day(e.Date)
+	if e.EndDate != nil {
+		last = day(*e.EndDate)
+	}
+	iw.line("BEGIN:VEVENT")
+	iw.line("UID:" + e.UID)
+	iw.line("DTSTAMP:" + iw.stamp)
+	iw.line("DTSTART;VALUE=DATE:" + first.Format("20060102"))
+	// DTEND of an all-day event is the day after the last one
+	iw.line("DTEND;VALUE=DATE:" + last.AddDate(0, 0, 1).Format("20060102"))
+	iw.line("SUMMARY:" + escapeText(e.Summary))
+	if e.Description != "" {
+		iw.line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	if e.Categories != "" {
+		iw.line("CATEGORIES:" + escapeText(e.Categories))
+	}
+	if e.URL != "" {
+		iw.line("URL:" + e.URL)
+	}
+	iw.line("TRANSP:TRANSPARENT")
+	iw.line("END:VEVENT")
+}
+
+// end closes the calendar and flushes what is buffered.
+func (iw *icsWriter) end() error {
+	iw.line("END:VCALENDAR")
+	return iw.w.Flush()
+}
+
+// line writes a content line, folded so no line is longer than 75 octets.
+// Folds never split a UTF-8 sequence.
+func (iw *icsWriter) line(s string) {
+	limit := icsLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		iw.w.WriteString(s[:cut])
+		iw.w.WriteString("\r\n ")
+		s = s[cut:]
+		// continuation lines start with the space
+		limit = icsLineLimit - 1
+	}
+	iw.w.WriteString(s)
+	iw.w.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escapeText escapes a TEXT property value.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -2,22 +2,133 @@ package calendar
 
 import "time"
 
+// Event types.
+const (
+	TypeTicketDeadline = "ticket_deadline"
+	TypeTicketStart    = "ticket_start"
+	TypeEpicStart      = "epic_start"
+	TypeEpicEnd        = "epic_end"
+	TypeMilestone      = "milestone"
+	TypeTimeOff        = "time_off"
+)
+
 // CalendarEvent represents a deadline or scheduled event.
 type CalendarEvent struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Type        string    `json:"type"` // "ticket_deadline", "epic_start", "epic_end"
-	Date        time.Time `json:"date"`
-	ProjectID   string    `json:"projectId,omitempty"`
-	ProjectName string    `json:"projectName,omitempty"`
-	Status      string    `json:"status,omitempty"`
-	Priority    string    `json:"priority,omitempty"`
+	ID    string    `json:"id"`
+	Title string    `json:"title"`
+	Type  string    `json:"type"`
+	Date  time.Time `json:"date"`
+	// EndDate is the last day of an event spanning several, such as time off.
+	EndDate     *time.Time `json:"endDate,omitempty"`
+	ProjectID   string     `json:"projectId,omitempty"`
+	ProjectName string     `json:"projectName,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	// UserID is the assignee of a ticket, the owner of an epic or who is
+	// away for time off.
+	UserID   string `json:"userId,omitempty"`
+	UserName string `json:"userName,omitempty"`
+	// Kind is the kind of time off: vacation, sick, holiday or other.
+	Kind string `json:"kind,omitempty"`
+	// Overdue marks a deadline that has passed while the work isn't done.
+	Overdue bool `json:"overdue,omitempty"`
 }
 
 // Filter for calendar events.
 type Filter struct {
+	OrgID     string
 	StartDate *time.Time
 	EndDate   *time.Time
 	ProjectID string
-	Type      string // "ticket", "epic", "all"
+	// ProjectIDs, when set, keeps only events of these projects.
+	ProjectIDs []string
+	// AssigneeID keeps the tickets assigned to, epics owned by and time off
+	// of one user; milestones belong to no one and are left out.
+	AssigneeID string
+	// Type is "all" or a comma separated list of ticket, epic, milestone
+	// and time_off.
+	Type string
+}
+
+// Day is one day of a week or agenda view.
+type Day struct {
+	Date   string          `json:"date"`
+	Events []CalendarEvent `json:"events"`
+}
+
+// View is a week or agenda: the events of each day of a range, and the
+// overdue items, whatever their date.
+type View struct {
+	Start   string          `json:"start"`
+	End     string          `json:"end"`
+	Days    []Day           `json:"days"`
+	Overdue []CalendarEvent `json:"overdue"`
+}
+
+// Time off kinds.
+var TimeOffKinds = []string{"vacation", "sick", "holiday", "other"}
+
+// TimeOff is a range of days, inclusive, a team member is away.
+type TimeOff struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	UserName  string    `json:"userName"`
+	Kind      string    `json:"kind"`
+	StartDate time.Time `json:"startDate"`
+	EndDate   time.Time `json:"endDate"`
+	Note      string    `json:"note"`
+	CreatedBy *string   `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TimeOffInput describes new time off. Without userId it is the caller's
+// own; admins and project managers may enter it for anyone.
+type TimeOffInput struct {
+	UserID    string     `json:"userId"`
+	Kind      string     `json:"kind"`
+	StartDate *time.Time `json:"startDate"`
+	EndDate   *time.Time `json:"endDate"`
+	Note      string     `json:"note"`
+}
+
+// TimeOffFilter for listing time off overlapping a range.
+type TimeOffFilter struct {
+	OrgID  string
+	UserID string
+	Start  time.Time
+	End    time.Time
+}
+
+// Feed is a secret .ics URL a calendar app subscribes to. The token is only
+// returned once, when the feed is created or rotated.
+type Feed struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	ProjectIDs []string   `json:"projectIds"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedFeed carries the subscription URL of a new or rotated feed.
+type CreatedFeed struct {
+	Feed
+	URL string `json:"url"`
+}
+
+// FeedInput describes a new feed. Without projectIds it covers every
+// project of the organization.
+type FeedInput struct {
+	Name       string   `json:"name"`
+	ProjectIDs []string `json:"projectIds"`
+}
+
+// feedOwner is a feed looked up by its token, with the owner it acts for.
+type feedOwner struct {
+	FeedID     string
+	UserID     string
+	OrgID      string
+	Name       string
+	ProjectIDs []string
+	LastUsedAt *time.Time
 }
@@ -2,8 +2,13 @@ package calendar
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,10 +22,129 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-// GetEvents returns calendar events (deadlines, epic dates).
-func (r *Repository) GetEvents(ctx context.Context, filter Filter) ([]CalendarEvent, error) {
-	var events []CalendarEvent
+// eventSource is one kind of calendar event and the query that finds it.
+// Every query selects the same columns, filters on the organization as $1
+// and leaves the date condition to dates or overdue.
+type eventSource struct {
+	typ   string
+	group string
+	query string
+	// dates keeps events in the range $2 to $3.
+	dates string
+	// overdue keeps deadlines before $2 whose work isn't done; empty for
+	// events that can't be overdue.
+	overdue string
+	// project is the project condition, with %s standing for the comparison.
+	project string
+	// person is the column AssigneeID filters on; empty leaves the source
+	// out of per-person views.
+	person string
+}
+
+var eventSources = []eventSource{
+	{
+		typ:   TypeTicketDeadline,
+		group: "ticket",
+		query: `
+SELECT t.id, t.title, 'ticket_deadline', t.due_date::timestamptz, NULL::timestamptz, t.project_id, p.name, t.status::text, t.priority::text,
+       COALESCE(t.assignee_id::text, ''), COALESCE(u.name, ''), ''
+FROM tickets t
+JOIN projects p ON p.id = t.project_id
+LEFT JOIN users u ON u.id = t.assignee_id
+WHERE p.org_id = $1 AND t.due_date IS NOT NULL`,
+		dates:   `t.due_date >= $2 AND t.due_date <= $3`,
+		overdue: `t.due_date < $2 AND t.status <> 'done'`,
+		project: `t.project_id %s`,
+		person:  `t.assignee_id`,
+	},
+	{
+		typ:   TypeTicketStart,
+		group: "ticket",
+		query: `
+SELECT t.id, t.title, 'ticket_start', t.start_date, NULL::timestamptz, t.project_id, p.name, t.status::text, t.priority::text,
+       COALESCE(t.assignee_id::text, ''), COALESCE(u.name, ''), ''
+FROM tickets t
+JOIN projects p ON p.id = t.project_id
+LEFT JOIN users u ON u.id = t.assignee_id
+WHERE p.org_id = $1 AND t.start_date IS NOT NULL`,
+		dates:   `t.start_date >= $2 AND t.start_date <= $3`,
+		project: `t.project_id %s`,
+		person:  `t.assignee_id`,
+	},
+	{
+		typ:   TypeEpicStart,
+		group: "epic",
+		query: `
+SELECT e.id, e.title, 'epic_start', e.start_date, NULL::timestamptz, e.project_id, p.name, e.status::text, '',
+       COALESCE(e.owner_id::text, ''), COALESCE(u.name, ''), ''
+FROM epics e
+JOIN projects p ON p.id = e.project_id
+LEFT JOIN users u ON u.id = e.owner_id
+WHERE p.org_id = $1 AND e.start_date IS NOT NULL`,
+		dates:   `e.start_date >= $2 AND e.start_date <= $3`,
+		project: `e.project_id %s`,
+		person:  `e.owner_id`,
+	},
+	{
+		typ:   TypeEpicEnd,
+		group: "epic",
+		query: `
+SELECT e.id, e.title, 'epic_end', e.due_date, NULL::timestamptz, e.project_id, p.name, e.status::text, '',
+       COALESCE(e.owner_id::text, ''), COALESCE(u.name, ''), ''
+FROM epics e
+JOIN projects p ON p.id = e.project_id
+LEFT JOIN users u ON u.id = e.owner_id
+WHERE p.org_id = $1 AND e.due_date IS NOT NULL`,
+		dates:   `e.due_date >= $2 AND e.due_date <= $3`,
+		overdue: `e.due_date < $2 AND e.status <> 'done'`,
+		project: `e.project_id %s`,
+		person:  `e.owner_id`,
+	},
+	{
+		typ:   TypeMilestone,
+		group: "milestone",
+		query: `
+SELECT m.id, m.title, 'milestone', m.due_date::timestamptz, NULL::timestamptz, m.project_id, p.name,
+       CASE WHEN m.completed_at IS NULL THEN 'open' ELSE 'done' END, '', '', '', ''
+FROM milestones m
+JOIN projects p ON p.id = m.project_id
+WHERE p.org_id = $1`,
+		dates:   `m.due_date >= $2 AND m.due_date <= $3`,
+		overdue: `m.due_date < $2 AND m.completed_at IS NULL`,
+		project: `m.project_id %s`,
+	},
+	{
+		typ:   TypeTimeOff,
+		group: "time_off",
+		query: `
+SELECT o.id, u.name, 'time_off', o.start_date::timestamptz, o.end_date::timestamptz, '', '', '', '',
+       o.user_id::text, u.name, o.kind
+FROM time_off o
+JOIN users u ON u.id = o.user_id
+WHERE u.org_id = $1`,
+		// time off shows on every day it overlaps the range
+		dates:   `o.start_date <= $3 AND o.end_date >= $2`,
+		project: `EXISTS (SELECT 1 FROM project_members pm WHERE pm.user_id = o.user_id AND pm.project_id %s)`,
+		person:  `o.user_id`,
+	},
+}
+
+// wants reports whether a Type filter asks for a group of events.
+func wants(typ, group string) bool {
+	if typ == "" || typ == "all" {
+		return true
+	}
+	for _, t := range strings.Split(typ, ",") {
+		if strings.TrimSpace(t) == group {
+			return true
+		}
+	}
+	return false
+}
 
+// GetEvents returns calendar events (deadlines, start dates, epic dates,
+// milestones and time off) of an organization, by date.
+func (r *Repository) GetEvents(ctx context.Context, filter Filter) ([]CalendarEvent, error) {
 	// Default date range: current month
 	now := time.Now()
 	startDate := filter.StartDate
@@ -33,107 +157,243 @@ func (r *Repository) GetEvents(ctx context.Context, filter Filter) ([]CalendarEv
 		lastOfMonth := startDate.AddDate(0, 1, -1)
 		endDate = &lastOfMonth
 	}
+	return r.events(ctx, filter, false, *startDate, *endDate)
+}
 
-	// Get ticket deadlines
-	if filter.Type == "" || filter.Type == "all" || filter.Type == "ticket" {
-		ticketQuery := `
-			SELECT t.id, t.title, 'ticket_deadline', t.due_date, t.project_id, p.name, t.status, t.priority
-			FROM tickets t
-			JOIN projects p ON p.id = t.project_id
-			WHERE t.due_date IS NOT NULL
-			  AND t.due_date >= $1
-			  AND t.due_date <= $2`
-		args := []any{startDate, endDate}
+// GetOverdue returns the deadlines before today whose work isn't done:
+// tickets, epics and milestones, oldest first.
+func (r *Repository) GetOverdue(ctx context.Context, filter Filter, today time.Time) ([]CalendarEvent, error) {
+	return r.events(ctx, filter, true, today)
+}
 
+func (r *Repository) events(ctx context.Context, filter Filter, overdue bool, dates ...time.Time) ([]CalendarEvent, error) {
+	events := []CalendarEvent{}
+	for _, src := range eventSources {
+		if !wants(filter.Type, src.group) || (overdue && src.overdue == "") || (filter.AssigneeID != "" && src.person == "") {
+			continue
+		}
+		query := src.query
+		args := []any{filter.OrgID}
+		for _, d := range dates {
+			args = append(args, d)
+		}
+		if overdue {
+			query += ` AND ` + src.overdue
+		} else {
+			query += ` AND ` + src.dates
+		}
 		if filter.ProjectID != "" {
-			ticketQuery += ` AND t.project_id = $3`
 			args = append(args, filter.ProjectID)
+			query += ` AND ` + fmt.Sprintf(src.project, fmt.Sprintf("= $%d", len(args)))
+		}
+		if len(filter.ProjectIDs) > 0 {
+			args = append(args, filter.ProjectIDs)
+			query += ` AND ` + fmt.Sprintf(src.project, fmt.Sprintf("= ANY($%d::uuid[])", len(args)))
+		}
+		if filter.AssigneeID != "" {
+			args = append(args, filter.AssigneeID)
+			query += fmt.Sprintf(` AND %s::text = $%d`, src.person, len(args))
 		}
-		ticketQuery += ` ORDER BY t.due_date ASC`
 
-		rows, err := r.db.Query(ctx, ticketQuery, args...)
+		rows, err := r.db.Query(ctx, query, args...)
 		if err != nil {
 			return nil, err
 		}
-		defer rows.Close()
-
 		for rows.Next() {
 			var e CalendarEvent
-			if err := rows.Scan(&e.ID, &e.Title, &e.Type, &e.Date, &e.ProjectID, &e.ProjectName, &e.Status, &e.Priority); err != nil {
+			if err := rows.Scan(&e.ID, &e.Title, &e.Type, &e.Date, &e.EndDate, &e.ProjectID, &e.ProjectName, &e.Status, &e.Priority, &e.UserID, &e.UserName, &e.Kind); err != nil {
+				rows.Close()
 				return nil, err
 			}
 			events = append(events, e)
 		}
+		rows.Close()
 		if err := rows.Err(); err != nil {
 			return nil, err
 		}
 	}
+	slices.SortStableFunc(events, func(a, b CalendarEvent) int {
+		return a.Date.Compare(b.Date)
+	})
+	return events, nil
+}
 
-	// Get epic start dates
-	if filter.Type == "" || filter.Type == "all" || filter.Type == "epic" {
-		epicStartQuery := `
-			SELECT e.id, e.title, 'epic_start', e.start_date, e.project_id, p.name, e.status, ''
-			FROM epics e
-			JOIN projects p ON p.id = e.project_id
-			WHERE e.start_date IS NOT NULL
-			  AND e.start_date >= $1
-			  AND e.start_date <= $2`
-		args := []any{startDate, endDate}
+const feedColumns = `id, name, prefix, project_ids, last_used_at, created_at`
 
-		if filter.ProjectID != "" {
-			epicStartQuery += ` AND e.project_id = $3`
-			args = append(args, filter.ProjectID)
-		}
+func scanFeed(row pgx.Row) (*Feed, error) {
+	var f Feed
+	if err := row.Scan(&f.ID, &f.Name, &f.Prefix, &f.ProjectIDs, &f.LastUsedAt, &f.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
 
-		rows, err := r.db.Query(ctx, epicStartQuery, args...)
+// ListFeeds returns a user's calendar feeds, newest first.
+func (r *Repository) ListFeeds(ctx context.Context, userID string) ([]Feed, error) {
+	query := `SELECT ` + feedColumns + ` FROM calendar_feeds WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := []Feed{}
+	for rows.Next() {
+		f, err := scanFeed(rows)
 		if err != nil {
 			return nil, err
 		}
-		defer rows.Close()
+		feeds = append(feeds, *f)
+	}
+	return feeds, rows.Err()
+}
 
-		for rows.Next() {
-			var e CalendarEvent
-			if err := rows.Scan(&e.ID, &e.Title, &e.Type, &e.Date, &e.ProjectID, &e.ProjectName, &e.Status, &e.Priority); err != nil {
-				return nil, err
-			}
-			events = append(events, e)
-		}
-		if err := rows.Err(); err != nil {
-			return nil, err
-		}
+// CountFeeds returns how many feeds a user has.
+func (r *Repository) CountFeeds(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `SELECT count(*) FROM calendar_feeds WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
 
-		// Get epic end dates
-		epicEndQuery := `
-			SELECT e.id, e.title, 'epic_end', e.due_date, e.project_id, p.name, e.status, ''
-			FROM epics e
-			JOIN projects p ON p.id = e.project_id
-			WHERE e.due_date IS NOT NULL
-			  AND e.due_date >= $1
-			  AND e.due_date <= $2`
-		args = []any{startDate, endDate}
+func (r *Repository) CreateFeed(ctx context.Context, userID, name, prefix, tokenHash string, projectIDs []string) (*Feed, error) {
+	query := `
+INSERT INTO calendar_feeds (user_id, name, prefix, token_hash, project_ids)
+VALUES ($1, $2, $3, $4, $5::uuid[])
+RETURNING ` + feedColumns
+	return scanFeed(r.db.QueryRow(ctx, query, userID, name, prefix, tokenHash, projectIDs))
+}
 
-		if filter.ProjectID != "" {
-			epicEndQuery += ` AND e.project_id = $3`
-			args = append(args, filter.ProjectID)
+// RotateFeed replaces the token of one of userID's feeds, so the old URL stops
+// working, and returns the feed, or nil if none matched.
+func (r *Repository) RotateFeed(ctx context.Context, userID, feedID, prefix, tokenHash string) (*Feed, error) {
+	query := `
+UPDATE calendar_feeds SET prefix = $3, token_hash = $4, last_used_at = NULL
+WHERE id = $1 AND user_id = $2
+RETURNING ` + feedColumns
+	f, err := scanFeed(r.db.QueryRow(ctx, query, feedID, userID, prefix, tokenHash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// DeleteFeed deletes one of userID's feeds and returns it, or nil if none matched.
+func (r *Repository) DeleteFeed(ctx context.Context, userID, feedID string) (*Feed, error) {
+	query := `DELETE FROM calendar_feeds WHERE id = $1 AND user_id = $2 RETURNING ` + feedColumns
+	f, err := scanFeed(r.db.QueryRow(ctx, query, feedID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// FindFeed looks up a feed by its token hash. Feeds of deactivated users
+// are not found.
+func (r *Repository) FindFeed(ctx context.Context, tokenHash string) (*feedOwner, error) {
+	const query = `
+SELECT f.id, f.user_id, u.org_id, f.name, f.project_ids, f.last_used_at
+FROM calendar_feeds f
+JOIN users u ON u.id = f.user_id
+WHERE f.token_hash = $1
+  AND u.deactivated_at IS NULL`
+	var o feedOwner
+	if err := r.db.QueryRow(ctx, query, tokenHash).Scan(&o.FeedID, &o.UserID, &o.OrgID, &o.Name, &o.ProjectIDs, &o.LastUsedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
 		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *Repository) TouchFeed(ctx context.Context, feedID string) error {
+	_, err := r.db.Exec(ctx, `UPDATE calendar_feeds SET last_used_at = now() WHERE id = $1`, feedID)
+	return err
+}
+
+// CountProjects returns how many of ids are projects of the organization.
+func (r *Repository) CountProjects(ctx context.Context, orgID string, ids []string) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `SELECT count(*) FROM projects WHERE org_id = $1 AND id = ANY($2::uuid[])`, orgID, ids).Scan(&n)
+	return n, err
+}
 
-		rows2, err := r.db.Query(ctx, epicEndQuery, args...)
+const timeOffColumns = `o.id, o.user_id, u.name, o.kind, o.start_date, o.end_date, o.note, o.created_by, o.created_at`
+
+func scanTimeOff(row pgx.Row) (*TimeOff, error) {
+	var t TimeOff
+	if err := row.Scan(&t.ID, &t.UserID, &t.UserName, &t.Kind, &t.StartDate, &t.EndDate, &t.Note, &t.CreatedBy, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTimeOff returns time off of an organization overlapping a range.
+func (r *Repository) ListTimeOff(ctx context.Context, filter TimeOffFilter) ([]TimeOff, error) {
+	query := `
+SELECT ` + timeOffColumns + `
+FROM time_off o
+JOIN users u ON u.id = o.user_id
+WHERE u.org_id = $1 AND o.start_date <= $3 AND o.end_date >= $2`
+	args := []any{filter.OrgID, filter.Start, filter.End}
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		query += fmt.Sprintf(` AND o.user_id::text = $%d`, len(args))
+	}
+	query += ` ORDER BY o.start_date ASC, u.name ASC`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []TimeOff{}
+	for rows.Next() {
+		t, err := scanTimeOff(rows)
 		if err != nil {
 			return nil, err
 		}
-		defer rows2.Close()
+		entries = append(entries, *t)
+	}
+	return entries, rows.Err()
+}
 
-		for rows2.Next() {
-			var e CalendarEvent
-			if err := rows2.Scan(&e.ID, &e.Title, &e.Type, &e.Date, &e.ProjectID, &e.ProjectName, &e.Status, &e.Priority); err != nil {
-				return nil, err
-			}
-			events = append(events, e)
-		}
-		if err := rows2.Err(); err != nil {
-			return nil, err
-		}
+// GetTimeOff returns an entry of orgID, or nil.
+func (r *Repository) GetTimeOff(ctx context.Context, orgID, id string) (*TimeOff, error) {
+	query := `
+SELECT ` + timeOffColumns + `
+FROM time_off o
+JOIN users u ON u.id = o.user_id
+WHERE o.id::text = $1 AND u.org_id = $2`
+	t, err := scanTimeOff(r.db.QueryRow(ctx, query, id, orgID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
 	}
+	return t, err
+}
 
-	return events, nil
+func (r *Repository) CreateTimeOff(ctx context.Context, input TimeOffInput, createdBy string) (*TimeOff, error) {
+	var id string
+	const query = `
+INSERT INTO time_off (user_id, kind, start_date, end_date, note, created_by)
+VALUES ($1, $2, $3::date, $4::date, $5, $6)
+RETURNING id`
+	if err := r.db.QueryRow(ctx, query, input.UserID, input.Kind, input.StartDate, input.EndDate, input.Note, createdBy).Scan(&id); err != nil {
+		return nil, err
+	}
+	const get = `SELECT ` + timeOffColumns + ` FROM time_off o JOIN users u ON u.id = o.user_id WHERE o.id = $1`
+	return scanTimeOff(r.db.QueryRow(ctx, get, id))
+}
+
+func (r *Repository) DeleteTimeOff(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM time_off WHERE id = $1`, id)
+	return err
+}
+
+// UserInOrg reports whether userID belongs to orgID.
+func (r *Repository) UserInOrg(ctx context.Context, orgID, userID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id::text = $1 AND org_id = $2)`, userID, orgID).Scan(&ok)
+	return ok, err
 }
@@ -1,18 +1,45 @@
 package calendar
 
-import "context"
+import (
+	"context"
+	"strings"
+
+	"backend-go-ticketing-gamify/internal/audit"
+)
+
+// Options holds the URLs feeds link to.
+type Options struct {
+	// FrontendURL is where event links point.
+	FrontendURL string
+	// PublicURL is the address calendar apps reach this API on. Empty means
+	// the host of the request that created the feed.
+	PublicURL string
+}
 
 // Service provides business logic for calendar.
 type Service struct {
-	repo *Repository
+	repo        *Repository
+	audit       *audit.Service
+	frontendURL string
+	publicURL   string
 }
 
 // NewService creates a new calendar service.
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *Repository, auditSvc *audit.Service, opts Options) *Service {
+	return &Service{
+		repo:        repo,
+		audit:       auditSvc,
+		frontendURL: strings.TrimRight(opts.FrontendURL, "/"),
+		publicURL:   strings.TrimRight(opts.PublicURL, "/"),
+	}
 }
 
 // GetEvents returns calendar events.
 func (s *Service) GetEvents(ctx context.Context, filter Filter) ([]CalendarEvent, error) {
-	return s.repo.GetEvents(ctx, filter)
+	events, err := s.repo.GetEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	markOverdue(events, today())
+	return events, nil
 }
@@ -0,0 +1,110 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"backend-go-ticketing-gamify/internal/middleware"
+)
+
+// maxTimeOffDays bounds one time off entry.
+const maxTimeOffDays = 366
+
+var (
+	ErrTimeOffNotFound = errors.New("time off not found")
+	ErrForbidden       = errors.New("forbidden")
+	// ErrInvalidTimeOff wraps what is wrong with a time off entry.
+	ErrInvalidTimeOff = errors.New("invalid time off")
+)
+
+// managesTimeOff reports whether actor may enter and remove time off of others.
+func managesTimeOff(actor *middleware.UserContext) bool {
+	return actor.Role == "admin" || actor.Role == "project_manager"
+}
+
+// ListTimeOff returns time off overlapping a range.
+func (s *Service) ListTimeOff(ctx context.Context, filter TimeOffFilter) ([]TimeOff, error) {
+	return s.repo.ListTimeOff(ctx, filter)
+}
+
+// CreateTimeOff records time off of the caller or, for admins and project
+// managers, of anyone in the organization.
+func (s *Service) CreateTimeOff(ctx context.Context, actor *middleware.UserContext, input TimeOffInput) (*TimeOff, error) {
+	if input.UserID == "" {
+		input.UserID = actor.ID
+	}
+	if input.UserID != actor.ID {
+		if !managesTimeOff(actor) {
+			return nil, ErrForbidden
+		}
+		ok, err := s.repo.UserInOrg(ctx, actor.OrgID, input.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: user not found in this organization", ErrInvalidTimeOff)
+		}
+	}
+	if input.Kind == "" {
+		input.Kind = "vacation"
+	}
+	if !slices.Contains(TimeOffKinds, input.Kind) {
+		return nil, fmt.Errorf("%w: kind must be one of %s", ErrInvalidTimeOff, strings.Join(TimeOffKinds, ", "))
+	}
+	if input.StartDate == nil {
+		return nil, fmt.Errorf("%w: startDate is required", ErrInvalidTimeOff)
+	}
+	if input.EndDate == nil {
+		input.EndDate = input.StartDate
+	}
+	start, end := day(*input.StartDate), day(*input.EndDate)
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: endDate is before startDate", ErrInvalidTimeOff)
+	}
+	if end.Sub(start).Hours()/24 >= maxTimeOffDays {
+		return nil, fmt.Errorf("%w: at most %d days at once", ErrInvalidTimeOff, maxTimeOffDays)
+	}
+	input.StartDate, input.EndDate = &start, &end
+	input.Note = strings.TrimSpace(input.Note)
+
+	entry, err := s.repo.CreateTimeOff(ctx, input, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.logTimeOff(ctx, actor, "time_off_created", fmt.Sprintf("%s entered %s time off for %s from %s to %s",
+		actor.Name, entry.Kind, entry.UserName, entry.StartDate.Format(dateLayout), entry.EndDate.Format(dateLayout)), entry.UserID)
+	return entry, nil
+}
+
+// DeleteTimeOff removes an entry of the caller or, for admins and project
+// managers, of anyone in the organization.
+func (s *Service) DeleteTimeOff(ctx context.Context, actor *middleware.UserContext, id string) error {
+	entry, err := s.repo.GetTimeOff(ctx, actor.OrgID, id)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return ErrTimeOffNotFound
+	}
+	if entry.UserID != actor.ID && !managesTimeOff(actor) {
+		return ErrForbidden
+	}
+	if err := s.repo.DeleteTimeOff(ctx, entry.ID); err != nil {
+		return err
+	}
+	s.logTimeOff(ctx, actor, "time_off_deleted", fmt.Sprintf("%s removed %s time off of %s from %s to %s",
+		actor.Name, entry.Kind, entry.UserName, entry.StartDate.Format(dateLayout), entry.EndDate.Format(dateLayout)), entry.UserID)
+	return nil
+}
+
+func (s *Service) logTimeOff(ctx context.Context, actor *middleware.UserContext, action, desc, userID string) {
+	if s.audit == nil {
+		return
+	}
+	actorID := actor.ID
+	entityType := "user"
+	_ = s.audit.Log(ctx, action, desc, &actorID, &entityType, &userID)
+}
@@ -0,0 +1,111 @@
+package calendar
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// MaxAgendaDays bounds how far ahead an agenda looks.
+	MaxAgendaDays = 90
+)
+
+// today is the current date, at midnight UTC.
+func today() time.Time {
+	return day(time.Now())
+}
+
+// day truncates t to its date, at midnight UTC.
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// markOverdue flags the deadlines among events that passed while the work
+// isn't done.
+func markOverdue(events []CalendarEvent, now time.Time) {
+	for i, e := range events {
+		switch e.Type {
+		case TypeTicketDeadline, TypeEpicEnd, TypeMilestone:
+			events[i].Overdue = e.Status != "done" && day(e.Date).Before(now)
+		}
+	}
+}
+
+// GetOverdue returns the deadlines that passed while the work isn't done.
+// StartDate and EndDate of the filter are ignored.
+func (s *Service) GetOverdue(ctx context.Context, filter Filter) ([]CalendarEvent, error) {
+	now := today()
+	events, err := s.repo.GetOverdue(ctx, filter, now)
+	if err != nil {
+		return nil, err
+	}
+	markOverdue(events, now)
+	return events, nil
+}
+
+// Week returns the seven days from the start of the week containing date,
+// weeks starting on weekStart.
+func (s *Service) Week(ctx context.Context, filter Filter, date time.Time, weekStart time.Weekday) (*View, error) {
+	start := day(date)
+	start = start.AddDate(0, 0, -((int(start.Weekday()) - int(weekStart) + 7) % 7))
+	return s.view(ctx, filter, start, 7, true)
+}
+
+// Agenda returns the days from start that have events, up to days ahead.
+func (s *Service) Agenda(ctx context.Context, filter Filter, start time.Time, days int) (*View, error) {
+	return s.view(ctx, filter, day(start), days, false)
+}
+
+// view lays the events of days days from start out by day; an event spanning
+// several days is listed on each. With all, days without events are kept.
+func (s *Service) view(ctx context.Context, filter Filter, start time.Time, days int, all bool) (*View, error) {
+	end := start.AddDate(0, 0, days-1)
+	filter.StartDate = &start
+	// the day's deadlines are stored as timestamps up to its last moment
+	last := end.Add(24*time.Hour - time.Nanosecond)
+	filter.EndDate = &last
+	events, err := s.repo.GetEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	overdue, err := s.GetOverdue(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	now := today()
+	markOverdue(events, now)
+
+	byDay := make([][]CalendarEvent, days)
+	for _, e := range events {
+		first, final := day(e.Date), day(e.Date)
+		if e.EndDate != nil {
+			final = day(*e.EndDate)
+		}
+		if first.Before(start) {
+			first = start
+		}
+		for d := first; !d.After(final) && !d.After(end); d = d.AddDate(0, 0, 1) {
+			i := int(d.Sub(start).Hours() / 24)
+			byDay[i] = append(byDay[i], e)
+		}
+	}
+
+	view := &View{
+		Start:   start.Format(dateLayout),
+		End:     end.Format(dateLayout),
+		Days:    []Day{},
+		Overdue: overdue,
+	}
+	for i, dayEvents := range byDay {
+		if len(dayEvents) == 0 && !all {
+			continue
+		}
+		if dayEvents == nil {
+			dayEvents = []CalendarEvent{}
+		}
+		view.Days = append(view.Days, Day{Date: start.AddDate(0, 0, i).Format(dateLayout), Events: dayEvents})
+	}
+	return view, nil
+}
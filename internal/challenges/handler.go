@@ -1,10 +1,12 @@
 package challenges
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
+	"backend-go-ticketing-gamify/internal/middleware"
 	"backend-go-ticketing-gamify/internal/response"
 )
 
@@ -22,6 +24,8 @@ func NewHandler(service *Service) *Handler {
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/active", h.getActiveChallenges)
 	router.GET("/user/:userId", h.getUserProgress)
+	router.GET("/team/active", h.getActiveTeamChallenges)
+	router.GET("/team/:teamId", h.getTeamProgress)
 }
 
 func (h *Handler) getActiveChallenges(c *gin.Context) {
@@ -30,13 +34,22 @@ func (h *Handler) getActiveChallenges(c *gin.Context) {
 }
 
 func (h *Handler) getUserProgress(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	userID := c.Param("userId")
 	if userID == "" {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "userId is required")
 		return
 	}
-	progress, err := h.service.GetUserProgress(c.Request.Context(), userID)
+	progress, err := h.service.GetUserProgress(c.Request.Context(), user.OrgID, userID)
 	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
@@ -45,3 +58,25 @@ func (h *Handler) getUserProgress(c *gin.Context) {
 	}
 	response.OK(c, progress)
 }
+
+func (h *Handler) getActiveTeamChallenges(c *gin.Context) {
+	response.OK(c, h.service.GetActiveTeamChallenges())
+}
+
+func (h *Handler) getTeamProgress(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	progress, err := h.service.GetTeamProgress(c.Request.Context(), user.OrgID, c.Param("teamId"))
+	if err != nil {
+		if errors.Is(err, ErrTeamNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, progress)
+}
@@ -26,10 +26,27 @@ type UserChallenge struct {
 	CompletedAt *time.Time `json:"completedAt,omitempty"`
 }
 
-// GetWeeklyChallenges returns the current week's challenges.
-func GetWeeklyChallenges() []Challenge {
-	now := time.Now()
-	// Calculate start of current week (Monday)
+// TeamChallenge represents a team's progress on a team challenge. Every
+// member's share is listed so the team can see who is contributing.
+type TeamChallenge struct {
+	ChallengeID  string         `json:"challengeId"`
+	Challenge    Challenge      `json:"challenge"`
+	TeamID       string         `json:"teamId"`
+	Current      int            `json:"current"`
+	Completed    bool           `json:"completed"`
+	Percentage   int            `json:"percentage"`
+	Contributors []Contribution `json:"contributors"`
+}
+
+// Contribution is one team member's share of a team challenge.
+type Contribution struct {
+	UserID  string `json:"userId"`
+	Name    string `json:"name"`
+	Current int    `json:"current"`
+}
+
+// weekBounds returns Monday 00:00 and Sunday 23:59:59 of the week containing now.
+func weekBounds(now time.Time) (time.Time, time.Time) {
 	weekday := int(now.Weekday())
 	if weekday == 0 {
 		weekday = 7
@@ -38,6 +55,12 @@ func GetWeeklyChallenges() []Challenge {
 	startOfWeek = time.Date(startOfWeek.Year(), startOfWeek.Month(), startOfWeek.Day(), 0, 0, 0, 0, now.Location())
 	endOfWeek := startOfWeek.AddDate(0, 0, 6)
 	endOfWeek = time.Date(endOfWeek.Year(), endOfWeek.Month(), endOfWeek.Day(), 23, 59, 59, 0, now.Location())
+	return startOfWeek, endOfWeek
+}
+
+// GetWeeklyChallenges returns the current week's challenges.
+func GetWeeklyChallenges() []Challenge {
+	startOfWeek, endOfWeek := weekBounds(time.Now())
 
 	return []Challenge{
 		{
@@ -86,3 +109,56 @@ func GetWeeklyChallenges() []Challenge {
 		},
 	}
 }
+
+// GetTeamWeeklyChallenges returns the current week's team challenges. They
+// count the work of all members together; "contributors" needs every member
+// to close at least one ticket, so its target is the team size.
+func GetTeamWeeklyChallenges() []Challenge {
+	startOfWeek, endOfWeek := weekBounds(time.Now())
+
+	return []Challenge{
+		{
+			ID:          "team_weekly_tickets_25",
+			Title:       "Squad Sprint",
+			Description: "Close 25 tickets as a team this week",
+			Type:        "tickets",
+			Target:      25,
+			XPReward:    150,
+			StartDate:   startOfWeek,
+			EndDate:     endOfWeek,
+			Active:      true,
+		},
+		{
+			ID:          "team_weekly_xp_1000",
+			Title:       "XP Together",
+			Description: "Earn 1000 XP as a team this week",
+			Type:        "xp",
+			Target:      1000,
+			XPReward:    100,
+			StartDate:   startOfWeek,
+			EndDate:     endOfWeek,
+			Active:      true,
+		},
+		{
+			ID:          "team_weekly_contributors",
+			Title:       "All Hands",
+			Description: "Every team member closes at least one ticket this week",
+			Type:        "contributors",
+			XPReward:    120,
+			StartDate:   startOfWeek,
+			EndDate:     endOfWeek,
+			Active:      true,
+		},
+		{
+			ID:          "team_weekly_comments_40",
+			Title:       "Review Circle",
+			Description: "Post 40 comments as a team this week",
+			Type:        "comments",
+			Target:      40,
+			XPReward:    80,
+			StartDate:   startOfWeek,
+			EndDate:     endOfWeek,
+			Active:      true,
+		},
+	}
+}
@@ -2,8 +2,10 @@ package challenges
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,20 +22,15 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 // GetUserChallengeProgress returns user's progress on current week's challenges.
 func (r *Repository) GetUserChallengeProgress(ctx context.Context, userID string) ([]UserChallenge, error) {
 	challenges := GetWeeklyChallenges()
-	now := time.Now()
-
-	// Calculate start of current week
-	weekday := int(now.Weekday())
-	if weekday == 0 {
-		weekday = 7
-	}
-	startOfWeek := now.AddDate(0, 0, -weekday+1)
-	startOfWeek = time.Date(startOfWeek.Year(), startOfWeek.Month(), startOfWeek.Day(), 0, 0, 0, 0, now.Location())
+	startOfWeek, _ := weekBounds(time.Now())
 
 	var result []UserChallenge
 
 	for _, c := range challenges {
-		var current int
+		var (
+			current int
+			err     error
+		)
 
 		switch c.Type {
 		case "tickets":
@@ -43,22 +40,25 @@ func (r *Repository) GetUserChallengeProgress(ctx context.Context, userID string
 				WHERE assignee_id = $1 
 				  AND status = 'done' 
 				  AND updated_at >= $2`
-			_ = r.db.QueryRow(ctx, query, userID, startOfWeek).Scan(&current)
+			err = r.db.QueryRow(ctx, query, userID, startOfWeek).Scan(&current)
 
 		case "xp":
 			// Count XP earned this week from xp_events
 			const query = `
-				SELECT COALESCE(SUM(xp), 0) FROM xp_events 
+				SELECT COALESCE(SUM(xp_value), 0) FROM xp_events 
 				WHERE user_id = $1 
 				  AND created_at >= $2`
-			_ = r.db.QueryRow(ctx, query, userID, startOfWeek).Scan(&current)
+			err = r.db.QueryRow(ctx, query, userID, startOfWeek).Scan(&current)
 
 		case "streak":
 			// Get current streak
 			const query = `
-				SELECT COALESCE(current_streak, 0) FROM gamification_stats 
+				SELECT streak_days FROM gamification_user_stats 
 				WHERE user_id = $1`
-			_ = r.db.QueryRow(ctx, query, userID).Scan(&current)
+			err = r.db.QueryRow(ctx, query, userID).Scan(&current)
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = nil
+			}
 
 		case "comments":
 			// Count comments posted this week
@@ -66,7 +66,10 @@ func (r *Repository) GetUserChallengeProgress(ctx context.Context, userID string
 				SELECT COUNT(*) FROM ticket_comments 
 				WHERE author_id = $1 
 				  AND created_at >= $2`
-			_ = r.db.QueryRow(ctx, query, userID, startOfWeek).Scan(&current)
+			err = r.db.QueryRow(ctx, query, userID, startOfWeek).Scan(&current)
+		}
+		if err != nil {
+			return nil, err
 		}
 
 		percentage := 0
@@ -89,3 +92,117 @@ func (r *Repository) GetUserChallengeProgress(ctx context.Context, userID string
 
 	return result, nil
 }
+
+// UserInOrg reports whether userID belongs to orgID.
+func (r *Repository) UserInOrg(ctx context.Context, orgID, userID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id::text = $1 AND org_id = $2)`, userID, orgID).Scan(&ok)
+	return ok, err
+}
+
+// teamContributions lists each member's share of a team challenge type since
+// the start of the week. XP only counts once the member joined the team.
+var teamContributions = map[string]string{
+	"tickets": `
+		SELECT u.id, u.name, COUNT(t.id)::int
+		FROM team_members tm
+		JOIN users u ON u.id = tm.user_id
+		LEFT JOIN tickets t ON t.assignee_id = u.id AND t.status = 'done' AND t.updated_at >= $2
+		WHERE tm.team_id = $1
+		GROUP BY u.id, u.name
+		ORDER BY 3 DESC, u.name`,
+	"xp": `
+		SELECT u.id, u.name, COALESCE(SUM(x.xp_value), 0)::int
+		FROM team_members tm
+		JOIN users u ON u.id = tm.user_id
+		LEFT JOIN xp_events x ON x.user_id = u.id AND x.created_at >= GREATEST($2, tm.joined_at)
+		WHERE tm.team_id = $1
+		GROUP BY u.id, u.name
+		ORDER BY 3 DESC, u.name`,
+	"comments": `
+		SELECT u.id, u.name, COUNT(c.id)::int
+		FROM team_members tm
+		JOIN users u ON u.id = tm.user_id
+		LEFT JOIN ticket_comments c ON c.author_id = u.id AND c.created_at >= $2
+		WHERE tm.team_id = $1
+		GROUP BY u.id, u.name
+		ORDER BY 3 DESC, u.name`,
+}
+
+// GetTeamChallengeProgress returns a team's progress on current week's team challenges.
+func (r *Repository) GetTeamChallengeProgress(ctx context.Context, teamID string) ([]TeamChallenge, error) {
+	startOfWeek, _ := weekBounds(time.Now())
+
+	result := []TeamChallenge{}
+	for _, c := range GetTeamWeeklyChallenges() {
+		kind := c.Type
+		if kind == "contributors" {
+			kind = "tickets"
+		}
+		contributors, err := r.contributions(ctx, teamContributions[kind], teamID, startOfWeek)
+		if err != nil {
+			return nil, err
+		}
+
+		current := 0
+		for _, m := range contributors {
+			if c.Type == "contributors" {
+				if m.Current > 0 {
+					current++
+				}
+			} else {
+				current += m.Current
+			}
+		}
+		if c.Type == "contributors" {
+			c.Target = len(contributors)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		percentage := 0
+		if c.Target > 0 {
+			percentage = (current * 100) / c.Target
+			if percentage > 100 {
+				percentage = 100
+			}
+		}
+
+		result = append(result, TeamChallenge{
+			ChallengeID:  c.ID,
+			Challenge:    c,
+			TeamID:       teamID,
+			Current:      current,
+			Completed:    c.Target > 0 && current >= c.Target,
+			Percentage:   percentage,
+			Contributors: contributors,
+		})
+	}
+	return result, nil
+}
+
+func (r *Repository) contributions(ctx context.Context, query, teamID string, since time.Time) ([]Contribution, error) {
+	rows, err := r.db.Query(ctx, query, teamID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contributors := []Contribution{}
+	for rows.Next() {
+		var m Contribution
+		if err := rows.Scan(&m.UserID, &m.Name, &m.Current); err != nil {
+			return nil, err
+		}
+		contributors = append(contributors, m)
+	}
+	return contributors, rows.Err()
+}
+
+// TeamInOrg reports whether teamID belongs to orgID.
+func (r *Repository) TeamInOrg(ctx context.Context, orgID, teamID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM teams WHERE id::text = $1 AND org_id = $2)`, teamID, orgID).Scan(&ok)
+	return ok, err
+}
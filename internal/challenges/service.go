@@ -1,6 +1,16 @@
 package challenges
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrUserNotFound is returned for users outside the caller's organization.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrTeamNotFound is returned for teams outside the caller's organization.
+	ErrTeamNotFound = errors.New("team not found")
+)
 
 // Service provides business logic for challenges.
 type Service struct {
@@ -17,7 +27,31 @@ func (s *Service) GetActiveChallenges() []Challenge {
 	return GetWeeklyChallenges()
 }
 
-// GetUserProgress returns user's progress on current challenges.
-func (s *Service) GetUserProgress(ctx context.Context, userID string) ([]UserChallenge, error) {
+// GetUserProgress returns the progress on current challenges of a user in orgID.
+func (s *Service) GetUserProgress(ctx context.Context, orgID, userID string) ([]UserChallenge, error) {
+	ok, err := s.repo.UserInOrg(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUserNotFound
+	}
 	return s.repo.GetUserChallengeProgress(ctx, userID)
 }
+
+// GetActiveTeamChallenges returns current week's team challenges.
+func (s *Service) GetActiveTeamChallenges() []Challenge {
+	return GetTeamWeeklyChallenges()
+}
+
+// GetTeamProgress returns the progress on current team challenges of a team in orgID.
+func (s *Service) GetTeamProgress(ctx context.Context, orgID, teamID string) ([]TeamChallenge, error) {
+	ok, err := s.repo.TeamInOrg(ctx, orgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTeamNotFound
+	}
+	return s.repo.GetTeamChallengeProgress(ctx, teamID)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -23,12 +24,70 @@ type Config struct {
 	DatabaseURL     string
 	JWTSecret       string
 	FrontendURL     string
+	PublicURL       string
 	RateLimitPerMin int
 	APIKeyRateLimit int
 	RateLimitWindow time.Duration
 	APIKey          string
 	APIKeyHeader    string
 	ShutdownTimeout time.Duration
+	// JobsEnabled runs the background job scheduler inside the API server.
+	// Turn it off when a separate cmd/worker runs the jobs.
+	JobsEnabled bool
+	// MigrateOnStart applies pending schema migrations before serving.
+	MigrateOnStart bool
+
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
+	JWTAlgorithm      string
+	JWTKeyID          string
+	JWTPrivateKeyFile string
+	JWTPreviousKeys   string
+	JWTKeyGrace       time.Duration
+
+	MFAEncryptionKey string
+	MFAIssuer        string
+
+	LoginMaxFailures   int
+	LoginIPMaxFailures int
+	LoginLockout       time.Duration
+	LoginFailureWindow time.Duration
+
+	PasswordLoginEnabled bool
+	OIDCIssuer           string
+	OIDCClientID         string
+	OIDCClientSecret     string
+	OIDCRedirectURL      string
+	OIDCScopes           string
+	OIDCGroupsClaim      string
+	OIDCAutoProvision    bool
+	OIDCRoleMapping      string
+	OIDCDefaultRole      string
+
+	PasswordResetTTL    time.Duration
+	PasswordResetLimit  int
+	PasswordResetWindow time.Duration
+	UserInviteTTL       time.Duration
+
+	EmailTransport     string
+	EmailFromAddress   string
+	EmailFromName      string
+	EmailDefaultLocale string
+	EmailOutboxDir     string
+	EmailQueueSize     int
+	EmailWorkers       int
+	EmailMaxAttempts   int
+	EmailRetryDelay    time.Duration
+	ResendAPIKey       string
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+
+	// SLAWebhookSecret signs SLA breach webhooks when set.
+	SLAWebhookSecret string
+	// SLAWebhookAllowPrivate lets SLA webhooks reach private and loopback addresses.
+	SLAWebhookAllowPrivate bool
 }
 
 var (
@@ -48,18 +107,71 @@ func Load() Config {
 			DatabaseURL:     os.Getenv("DATABASE_URL"),
 			JWTSecret:       os.Getenv("JWT_SECRET"),
 			FrontendURL:     getEnv("FRONTEND_URL", "http://localhost:5173"),
+			PublicURL:       os.Getenv("PUBLIC_URL"),
 			RateLimitPerMin: getInt("RATE_LIMIT_PER_MIN", 120),
 			APIKeyRateLimit: getInt("API_KEY_RATE_LIMIT_PER_MIN", 300),
 			RateLimitWindow: getDuration("RATE_LIMIT_WINDOW", time.Minute),
 			APIKey:          os.Getenv("API_KEY"),
 			APIKeyHeader:    getEnv("API_KEY_HEADER", "X-API-Key"),
 			ShutdownTimeout: getDuration("SHUTDOWN_TIMEOUT", defaultShutdown),
+			JobsEnabled:     getBool("JOBS_ENABLED", true),
+			MigrateOnStart:  getBool("MIGRATE_ON_START", false),
+
+			AccessTokenTTL:    getDuration("JWT_ACCESS_TTL", 15*time.Minute),
+			RefreshTokenTTL:   getDuration("JWT_REFRESH_TTL", 7*24*time.Hour),
+			JWTAlgorithm:      getEnv("JWT_ALGORITHM", "HS256"),
+			JWTKeyID:          getEnv("JWT_KEY_ID", "primary"),
+			JWTPrivateKeyFile: os.Getenv("JWT_PRIVATE_KEY_FILE"),
+			JWTPreviousKeys:   os.Getenv("JWT_PREVIOUS_KEYS"),
+			JWTKeyGrace:       getDuration("JWT_KEY_GRACE", 24*time.Hour),
+
+			MFAEncryptionKey: getEnv("MFA_ENCRYPTION_KEY", os.Getenv("JWT_SECRET")),
+			MFAIssuer:        getEnv("MFA_ISSUER", "Ticketing Gamified"),
+
+			LoginMaxFailures:   getInt("LOGIN_MAX_FAILURES", 10),
+			LoginIPMaxFailures: getInt("LOGIN_IP_MAX_FAILURES", 50),
+			LoginLockout:       getDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			LoginFailureWindow: getDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+
+			PasswordLoginEnabled: getBool("PASSWORD_LOGIN_ENABLED", true),
+			OIDCIssuer:           os.Getenv("OIDC_ISSUER"),
+			OIDCClientID:         os.Getenv("OIDC_CLIENT_ID"),
+			OIDCClientSecret:     os.Getenv("OIDC_CLIENT_SECRET"),
+			OIDCRedirectURL:      getEnv("OIDC_REDIRECT_URL", getEnv("FRONTEND_URL", "http://localhost:5173")+"/auth/callback"),
+			OIDCScopes:           getEnv("OIDC_SCOPES", "openid email profile"),
+			OIDCGroupsClaim:      getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			OIDCAutoProvision:    getBool("OIDC_AUTO_PROVISION", true),
+			OIDCRoleMapping:      os.Getenv("OIDC_ROLE_MAPPING"),
+			OIDCDefaultRole:      getEnv("OIDC_DEFAULT_ROLE", "developer"),
+
+			PasswordResetTTL:    getDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+			PasswordResetLimit:  getInt("PASSWORD_RESET_LIMIT", 3),
+			PasswordResetWindow: getDuration("PASSWORD_RESET_WINDOW", time.Hour),
+			UserInviteTTL:       getDuration("USER_INVITE_TTL", 7*24*time.Hour),
+
+			EmailTransport:     os.Getenv("EMAIL_TRANSPORT"),
+			EmailFromAddress:   getEnv("EMAIL_FROM", getEnv("RESEND_FROM_EMAIL", "noreply@resend.dev")),
+			EmailFromName:      getEnv("EMAIL_FROM_NAME", getEnv("RESEND_FROM_NAME", "Ticketing Gamified")),
+			EmailDefaultLocale: getEnv("EMAIL_DEFAULT_LOCALE", "en"),
+			EmailOutboxDir:     getEnv("EMAIL_OUTBOX_DIR", "tmp/outbox"),
+			EmailQueueSize:     getInt("EMAIL_QUEUE_SIZE", 256),
+			EmailWorkers:       getInt("EMAIL_WORKERS", 2),
+			EmailMaxAttempts:   getInt("EMAIL_MAX_ATTEMPTS", 5),
+			EmailRetryDelay:    getDuration("EMAIL_RETRY_DELAY", 2*time.Second),
+			ResendAPIKey:       os.Getenv("RESEND_API_KEY"),
+			SMTPHost:           os.Getenv("SMTP_HOST"),
+			SMTPPort:           getInt("SMTP_PORT", 587),
+			SMTPUsername:       os.Getenv("SMTP_USERNAME"),
+			SMTPPassword:       os.Getenv("SMTP_PASSWORD"),
+
+			SLAWebhookSecret:       os.Getenv("SLA_WEBHOOK_SECRET"),
+			SLAWebhookAllowPrivate: getBool("SLA_WEBHOOK_ALLOW_PRIVATE", false),
 		}
 
 		if cfg.DatabaseURL == "" {
 			log.Println("config: DATABASE_URL not set; DB calls will fail")
 		}
-		if cfg.JWTSecret == "" {
+		if cfg.JWTSecret == "" && cfg.JWTAlgorithm == "HS256" {
 			log.Println("config: JWT_SECRET not set; auth will fail")
 		}
 	})
@@ -89,6 +201,17 @@ func getDuration(key string, fallback time.Duration) time.Duration {
 	return fallback
 }
 
+func getBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err == nil {
+			return parsed
+		}
+		log.Printf("config: invalid bool for %s, using fallback %t", key, fallback)
+	}
+	return fallback
+}
+
 func getInt(key string, fallback int) int {
 	if value := os.Getenv(key); value != "" {
 		var parsed int
@@ -0,0 +1,130 @@
+// Package dbtest gives tests their own PostgreSQL database. Tests using it
+// are skipped unless TEST_DATABASE_URL points at a server where that role
+// may create databases.
+package dbtest
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"backend-go-ticketing-gamify/internal/database"
+)
+
+// Empty creates a database with nothing in it, dropped when the test ends.
+func Empty(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	admin, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect to TEST_DATABASE_URL: %v", err)
+	}
+	name := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+name); err != nil {
+		admin.Close(ctx)
+		t.Fatalf("create database: %v", err)
+	}
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	cfg.ConnConfig.Database = name
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect to %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		pool.Close()
+		if _, err := admin.Exec(context.Background(), "DROP DATABASE IF EXISTS "+name+" WITH (FORCE)"); err != nil {
+			t.Logf("drop database %s: %v", name, err)
+		}
+		admin.Close(context.Background())
+	})
+	return pool
+}
+
+// Open creates a database with every migration applied, dropped when the
+// test ends.
+func Open(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	pool := Empty(t)
+	migrator, err := database.NewMigrator(pool)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return pool
+}
+
+// Org creates an organization and returns its ID.
+func Org(t testing.TB, db *pgxpool.Pool) string {
+	t.Helper()
+	slug := "org-" + uuid.NewString()[:8]
+	return insert(t, db, `INSERT INTO organizations (name, slug) VALUES ($1, $1) RETURNING id::text`, slug)
+}
+
+// User creates a verified user with role in org and returns its ID.
+func User(t testing.TB, db *pgxpool.Pool, orgID, role string) string {
+	t.Helper()
+	username := "user-" + uuid.NewString()[:8]
+	return insert(t, db, `
+INSERT INTO users (name, username, email, email_verified, password_hash, role, org_id)
+VALUES ($1, $1, $1 || '@example.com', true, '!', $2::user_role, $3)
+RETURNING id::text`, username, role, orgID)
+}
+
+// Project creates a project in org, led by its creator, and returns its ID.
+func Project(t testing.TB, db *pgxpool.Pool, orgID, creatorID string) string {
+	t.Helper()
+	id := insert(t, db, `
+INSERT INTO projects (name, created_by, org_id) VALUES ($1, $2, $3) RETURNING id::text`,
+		"project-"+uuid.NewString()[:8], creatorID, orgID)
+	Exec(t, db, `INSERT INTO project_members (project_id, user_id, member_role) VALUES ($1, $2, 'lead')`, id, creatorID)
+	return id
+}
+
+// Ticket creates a ticket in project, reported by and assigned to userID,
+// and returns its ID.
+func Ticket(t testing.TB, db *pgxpool.Pool, projectID, userID string) string {
+	t.Helper()
+	return insert(t, db, `
+INSERT INTO tickets (project_id, title, reporter_id, assignee_id) VALUES ($1, $2, $3, $3) RETURNING id::text`,
+		projectID, "ticket-"+uuid.NewString()[:8], userID)
+}
+
+// Exec runs a statement, failing the test on error.
+func Exec(t testing.TB, db *pgxpool.Pool, sql string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(context.Background(), sql, args...); err != nil {
+		t.Fatalf("exec %s: %v", firstLine(sql), err)
+	}
+}
+
+func insert(t testing.TB, db *pgxpool.Pool, sql string, args ...any) string {
+	t.Helper()
+	var id string
+	if err := db.QueryRow(context.Background(), sql, args...).Scan(&id); err != nil {
+		t.Fatalf("insert %s: %v", firstLine(sql), err)
+	}
+	return id
+}
+
+func firstLine(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if i := strings.IndexByte(sql, '\n'); i > 0 {
+		return sql[:i]
+	}
+	return sql
+}
@@ -0,0 +1,108 @@
+package database_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"backend-go-ticketing-gamify/internal/database"
+	"backend-go-ticketing-gamify/internal/database/dbtest"
+	"backend-go-ticketing-gamify/internal/database/sqlscan"
+)
+
+// TestMigrateUpDownUp applies every migration, reverts them all and applies
+// them again, then prepares every SQL statement of the repositories against
+// the resulting schema.
+func TestMigrateUpDownUp(t *testing.T) {
+	db := dbtest.Empty(t)
+	ctx := context.Background()
+	migrations, err := database.Migrations()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	ran, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("first up: %v", err)
+	}
+	if len(ran) != len(migrations) {
+		t.Fatalf("first up applied %v, want all %d migrations", ran, len(migrations))
+	}
+
+	reverted, err := migrator.Down(ctx, len(migrations))
+	if err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if len(reverted) != len(migrations) {
+		t.Fatalf("down reverted %v, want all %d migrations", reverted, len(migrations))
+	}
+	if left := tables(t, db); len(left) != 0 {
+		t.Fatalf("tables left after reverting every migration: %v", left)
+	}
+
+	ran, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	if len(ran) != len(migrations) {
+		t.Fatalf("second up applied %v, want all %d migrations", ran, len(migrations))
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, st := range statuses {
+		if st.AppliedAt == nil || st.Modified || st.Unknown {
+			t.Errorf("migration %04d_%s: %+v", st.Version, st.Name, st)
+		}
+	}
+
+	statements, err := sqlscan.Collect("..")
+	if err != nil {
+		t.Fatalf("collect statements: %v", err)
+	}
+	if len(statements) == 0 {
+		t.Fatal("found no SQL statements under internal/")
+	}
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer conn.Release()
+	for i, st := range statements {
+		name := "stmt_" + strconv.Itoa(i)
+		if _, err := conn.Conn().Prepare(ctx, name, st.SQL); err != nil {
+			t.Errorf("%s: %v", st.Pos, err)
+			continue
+		}
+		_ = conn.Conn().Deallocate(ctx, name)
+	}
+}
+
+// tables lists the tables in the public schema other than schema_migrations.
+func tables(t *testing.T, db *pgxpool.Pool) []string {
+	t.Helper()
+	rows, err := db.Query(context.Background(), `
+SELECT tablename FROM pg_tables
+WHERE schemaname = 'public' AND tablename <> 'schema_migrations'
+ORDER BY tablename`)
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan table: %v", err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS public.audit_log;
+DROP TABLE IF EXISTS public.refresh_tokens;
+DROP TABLE IF EXISTS public.xp_events;
+DROP TABLE IF EXISTS public.gamification_user_stats;
+DROP TABLE IF EXISTS public.ticket_comments;
+DROP TABLE IF EXISTS public.ticket_history;
+DROP TABLE IF EXISTS public.tickets;
+DROP TABLE IF EXISTS public.epics;
+DROP TABLE IF EXISTS public.project_activity;
+DROP TABLE IF EXISTS public.project_invites;
+DROP TABLE IF EXISTS public.project_members;
+DROP TABLE IF EXISTS public.projects;
+DROP TABLE IF EXISTS public.users;
+
+DROP TYPE IF EXISTS project_member_role;
+DROP TYPE IF EXISTS ticket_type;
+DROP TYPE IF EXISTS ticket_priority;
+DROP TYPE IF EXISTS ticket_status;
+DROP TYPE IF EXISTS project_status;
+DROP TYPE IF EXISTS user_role;
@@ -1,4 +1,5 @@
--- enums & extension
+-- The original schema, as first deployed. Every statement is idempotent so
+-- databases created before migrations existed can run it safely.
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
 
 DO $$
@@ -23,16 +24,10 @@ BEGIN
   END IF;
 END$$;
 
--- Tables in dependency order
-
 CREATE TABLE IF NOT EXISTS public.users (
   id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
   name character varying NOT NULL,
   username character varying NOT NULL UNIQUE,
-  email character varying UNIQUE,
-  email_verified boolean NOT NULL DEFAULT false,
-  verification_token character varying,
-  verification_token_expires timestamptz,
   password_hash character varying NOT NULL,
   role user_role NOT NULL DEFAULT 'developer',
   avatar_url character varying,
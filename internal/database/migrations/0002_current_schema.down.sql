@@ -0,0 +1,59 @@
+DROP INDEX IF EXISTS public.idx_xp_events_user_created;
+
+DROP TABLE IF EXISTS public.job_runs;
+DROP TABLE IF EXISTS public.job_schedules;
+
+ALTER TABLE public.audit_log DROP COLUMN IF EXISTS org_id;
+
+DROP TABLE IF EXISTS public.access_token_denylist;
+DROP TABLE IF EXISTS public.user_invites;
+DROP TABLE IF EXISTS public.api_tokens;
+DROP TABLE IF EXISTS public.oidc_login_states;
+DROP TABLE IF EXISTS public.user_identities;
+DROP TABLE IF EXISTS public.login_failures;
+DROP TABLE IF EXISTS public.mfa_enforced_roles;
+DROP TABLE IF EXISTS public.mfa_challenges;
+DROP TABLE IF EXISTS public.mfa_recovery_codes;
+
+ALTER TABLE public.refresh_tokens
+  DROP COLUMN IF EXISTS family_id,
+  DROP COLUMN IF EXISTS parent_id,
+  DROP COLUMN IF EXISTS device,
+  DROP COLUMN IF EXISTS ip,
+  DROP COLUMN IF EXISTS user_agent;
+
+DROP TABLE IF EXISTS public.season_results;
+DROP TABLE IF EXISTS public.seasons;
+
+ALTER TABLE public.gamification_user_stats
+  DROP COLUMN IF EXISTS streak_through,
+  DROP COLUMN IF EXISTS streak_freezes,
+  DROP COLUMN IF EXISTS streak_grace_days,
+  DROP COLUMN IF EXISTS streak_skip_weekends;
+
+DROP TABLE IF EXISTS public.project_teams;
+DROP TABLE IF EXISTS public.team_members;
+DROP TABLE IF EXISTS public.teams;
+
+ALTER TABLE public.projects DROP COLUMN IF EXISTS org_id;
+
+ALTER TABLE public.users
+  DROP COLUMN IF EXISTS org_id,
+  DROP COLUMN IF EXISTS email,
+  DROP COLUMN IF EXISTS email_verified,
+  DROP COLUMN IF EXISTS verification_token,
+  DROP COLUMN IF EXISTS verification_token_expires,
+  DROP COLUMN IF EXISTS password_reset_token_hash,
+  DROP COLUMN IF EXISTS password_reset_expires,
+  DROP COLUMN IF EXISTS tokens_revoked_at,
+  DROP COLUMN IF EXISTS mfa_enabled,
+  DROP COLUMN IF EXISTS mfa_secret,
+  DROP COLUMN IF EXISTS mfa_last_step,
+  DROP COLUMN IF EXISTS is_service_account,
+  DROP COLUMN IF EXISTS deactivated_at,
+  DROP COLUMN IF EXISTS deactivated_by,
+  DROP COLUMN IF EXISTS anonymized_at,
+  DROP COLUMN IF EXISTS password_reset_required,
+  DROP COLUMN IF EXISTS timezone;
+
+DROP TABLE IF EXISTS public.organizations;
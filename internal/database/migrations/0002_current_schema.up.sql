@@ -0,0 +1,235 @@
+-- Brings the initial schema up to date with everything added before
+-- migrations existed: organizations, email and MFA sign-in, sessions, API
+-- tokens, invitations, teams, seasons, streaks and background jobs. Like
+-- 0001 it is idempotent, so databases created from any earlier schema file
+-- can run it.
+
+CREATE TABLE IF NOT EXISTS public.organizations (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  name character varying NOT NULL,
+  slug character varying NOT NULL UNIQUE,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- self-registration, SSO provisioning and the seeders land in the default organization
+INSERT INTO public.organizations (id, name, slug)
+VALUES ('00000000-0000-0000-0000-000000000001', 'Default', 'default')
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE public.users
+  ADD COLUMN IF NOT EXISTS org_id uuid NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES public.organizations(id),
+  ADD COLUMN IF NOT EXISTS email character varying UNIQUE,
+  ADD COLUMN IF NOT EXISTS email_verified boolean NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS verification_token character varying,
+  ADD COLUMN IF NOT EXISTS verification_token_expires timestamptz,
+  ADD COLUMN IF NOT EXISTS password_reset_token_hash character varying,
+  ADD COLUMN IF NOT EXISTS password_reset_expires timestamptz,
+  ADD COLUMN IF NOT EXISTS tokens_revoked_at timestamptz,
+  ADD COLUMN IF NOT EXISTS mfa_enabled boolean NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS mfa_secret text,
+  ADD COLUMN IF NOT EXISTS mfa_last_step bigint NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS is_service_account boolean NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS deactivated_at timestamptz,
+  ADD COLUMN IF NOT EXISTS deactivated_by uuid,
+  ADD COLUMN IF NOT EXISTS anonymized_at timestamptz,
+  ADD COLUMN IF NOT EXISTS password_reset_required boolean NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS timezone character varying NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE public.projects
+  ADD COLUMN IF NOT EXISTS org_id uuid NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES public.organizations(id);
+
+CREATE TABLE IF NOT EXISTS public.teams (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES public.organizations(id),
+  name character varying NOT NULL,
+  description text,
+  lead_id uuid REFERENCES public.users(id),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (org_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS public.team_members (
+  team_id uuid NOT NULL REFERENCES public.teams(id) ON DELETE CASCADE,
+  user_id uuid NOT NULL REFERENCES public.users(id),
+  joined_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (team_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS public.project_teams (
+  project_id uuid NOT NULL REFERENCES public.projects(id),
+  team_id uuid NOT NULL REFERENCES public.teams(id) ON DELETE CASCADE,
+  assigned_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (project_id, team_id)
+);
+
+ALTER TABLE public.gamification_user_stats
+  ADD COLUMN IF NOT EXISTS streak_through date,
+  ADD COLUMN IF NOT EXISTS streak_freezes integer NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS streak_grace_days integer NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS streak_skip_weekends boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS public.seasons (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES public.organizations(id),
+  name character varying NOT NULL,
+  starts_at timestamptz NOT NULL DEFAULT now(),
+  ends_at timestamptz,
+  ended_at timestamptz,
+  created_by uuid REFERENCES public.users(id),
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.season_results (
+  season_id uuid NOT NULL REFERENCES public.seasons(id),
+  user_id uuid NOT NULL REFERENCES public.users(id),
+  rank integer NOT NULL,
+  position integer NOT NULL,
+  xp integer NOT NULL,
+  level integer NOT NULL,
+  badge character varying,
+  PRIMARY KEY (season_id, user_id)
+);
+
+-- each refresh token belongs to a session (family); older tokens start their own
+ALTER TABLE public.refresh_tokens
+  ADD COLUMN IF NOT EXISTS family_id uuid,
+  ADD COLUMN IF NOT EXISTS parent_id uuid,
+  ADD COLUMN IF NOT EXISTS device character varying,
+  ADD COLUMN IF NOT EXISTS ip character varying,
+  ADD COLUMN IF NOT EXISTS user_agent text;
+UPDATE public.refresh_tokens SET family_id = id WHERE family_id IS NULL;
+ALTER TABLE public.refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS public.mfa_recovery_codes (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+  code_hash text NOT NULL,
+  used_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.mfa_challenges (
+  id uuid PRIMARY KEY,
+  user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+  token_hash text NOT NULL,
+  purpose character varying NOT NULL,
+  attempts integer NOT NULL DEFAULT 0,
+  expires_at timestamptz NOT NULL,
+  consumed_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.mfa_enforced_roles (
+  org_id uuid NOT NULL REFERENCES public.organizations(id),
+  role user_role NOT NULL,
+  enforced_by uuid REFERENCES public.users(id),
+  enforced_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (org_id, role)
+);
+
+CREATE TABLE IF NOT EXISTS public.login_failures (
+  key character varying PRIMARY KEY,
+  failures integer NOT NULL DEFAULT 0,
+  first_failed_at timestamptz NOT NULL DEFAULT now(),
+  last_failed_at timestamptz NOT NULL DEFAULT now(),
+  locked_until timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS public.user_identities (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+  issuer character varying NOT NULL,
+  subject character varying NOT NULL,
+  email character varying,
+  last_login_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (issuer, subject)
+);
+
+CREATE TABLE IF NOT EXISTS public.oidc_login_states (
+  state_hash character varying PRIMARY KEY,
+  code_verifier character varying NOT NULL,
+  nonce character varying NOT NULL,
+  expires_at timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.api_tokens (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+  name character varying NOT NULL,
+  prefix character varying NOT NULL,
+  token_hash text NOT NULL UNIQUE,
+  scopes text[] NOT NULL DEFAULT ARRAY[]::text[],
+  expires_at timestamptz,
+  last_used_at timestamptz,
+  last_used_ip character varying,
+  revoked_at timestamptz,
+  created_by uuid REFERENCES public.users(id),
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.user_invites (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES public.organizations(id),
+  email character varying NOT NULL,
+  name character varying,
+  role user_role NOT NULL DEFAULT 'developer',
+  projects jsonb NOT NULL DEFAULT '[]'::jsonb,
+  token_hash text NOT NULL UNIQUE,
+  invited_by uuid REFERENCES public.users(id),
+  expires_at timestamptz NOT NULL,
+  accepted_at timestamptz,
+  accepted_user_id uuid REFERENCES public.users(id),
+  revoked_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.access_token_denylist (
+  token_id text PRIMARY KEY,
+  user_id uuid REFERENCES public.users(id),
+  expires_at timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+ALTER TABLE public.audit_log
+  ADD COLUMN IF NOT EXISTS org_id uuid REFERENCES public.organizations(id);
+
+-- job_schedules holds the next due run of each background job; replicas
+-- claim a run by moving next_run_at forward.
+CREATE TABLE IF NOT EXISTS public.job_schedules (
+  name character varying PRIMARY KEY,
+  schedule character varying NOT NULL,
+  next_run_at timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS public.job_runs (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  job_name character varying NOT NULL,
+  trigger character varying NOT NULL,
+  triggered_by uuid REFERENCES public.users(id),
+  status character varying NOT NULL DEFAULT 'running',
+  result text,
+  error text,
+  started_at timestamptz NOT NULL DEFAULT now(),
+  finished_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_password_reset_token ON public.users (password_reset_token_hash) WHERE password_reset_token_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user ON public.mfa_recovery_codes (user_id, code_hash) WHERE used_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_mfa_challenges_expires ON public.mfa_challenges (expires_at);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON public.user_identities (user_id);
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON public.api_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_invites_email ON public.user_invites (lower(email)) WHERE accepted_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_access_token_denylist_expires ON public.access_token_denylist (expires_at);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_family ON public.refresh_tokens (user_id, family_id);
+CREATE INDEX IF NOT EXISTS idx_users_org ON public.users (org_id);
+CREATE INDEX IF NOT EXISTS idx_projects_org ON public.projects (org_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_org_created ON public.audit_log (org_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_team_members_user ON public.team_members (user_id);
+CREATE INDEX IF NOT EXISTS idx_xp_events_user_created ON public.xp_events (user_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seasons_one_active ON public.seasons (org_id) WHERE ended_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_season_results_user ON public.season_results (user_id);
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_started ON public.job_runs (job_name, started_at DESC);
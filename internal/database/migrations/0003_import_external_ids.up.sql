@@ -0,0 +1,12 @@
+-- Maps ids from other trackers to the records an import created, so running
+-- the same import again skips what is already there. scope_id is the project
+-- for epics, tickets and comments and the organization for users.
+CREATE TABLE IF NOT EXISTS public.import_external_ids (
+  scope_id uuid NOT NULL,
+  source character varying NOT NULL,
+  kind character varying NOT NULL,
+  external_id character varying NOT NULL,
+  entity_id uuid NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (scope_id, source, kind, external_id)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS public.idx_tickets_labels;
+
+ALTER TABLE public.tickets DROP COLUMN IF EXISTS labels;
@@ -0,0 +1,5 @@
+-- Free-form labels on tickets, set on create and update or in bulk.
+ALTER TABLE public.tickets
+  ADD COLUMN IF NOT EXISTS labels text[] NOT NULL DEFAULT ARRAY[]::text[];
+
+CREATE INDEX IF NOT EXISTS idx_tickets_labels ON public.tickets USING gin (labels);
@@ -0,0 +1,14 @@
+-- Secret .ics feed URLs calendar apps subscribe to. Only a hash of the token
+-- is kept; project_ids narrows a feed to some projects, empty means all.
+CREATE TABLE IF NOT EXISTS public.calendar_feeds (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+  name character varying NOT NULL,
+  prefix character varying NOT NULL,
+  token_hash text NOT NULL UNIQUE,
+  project_ids uuid[] NOT NULL DEFAULT ARRAY[]::uuid[],
+  last_used_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_feeds_user ON public.calendar_feeds (user_id);
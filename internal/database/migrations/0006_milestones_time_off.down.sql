@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.time_off;
+DROP TABLE IF EXISTS public.milestones;
@@ -0,0 +1,29 @@
+-- Project milestones: dated checkpoints shown on the calendar next to epics.
+CREATE TABLE IF NOT EXISTS public.milestones (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  project_id uuid NOT NULL REFERENCES public.projects(id) ON DELETE CASCADE,
+  title character varying NOT NULL,
+  description text NOT NULL DEFAULT '',
+  due_date date NOT NULL,
+  completed_at timestamptz,
+  created_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_milestones_project_due ON public.milestones (project_id, due_date);
+
+-- Days team members are away, inclusive of both ends.
+CREATE TABLE IF NOT EXISTS public.time_off (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+  kind character varying NOT NULL DEFAULT 'vacation',
+  start_date date NOT NULL,
+  end_date date NOT NULL,
+  note text NOT NULL DEFAULT '',
+  created_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_time_off_user_dates ON public.time_off (user_id, start_date, end_date);
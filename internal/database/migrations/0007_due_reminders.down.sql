@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.ticket_on_time_bonus;
+DROP TABLE IF EXISTS public.ticket_due_notices;
+DROP TABLE IF EXISTS public.due_policies;
+DROP TABLE IF EXISTS public.ticket_watchers;
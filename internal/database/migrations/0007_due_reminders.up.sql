@@ -0,0 +1,42 @@
+-- People who get a ticket's due date reminders besides its assignee.
+CREATE TABLE IF NOT EXISTS public.ticket_watchers (
+  ticket_id uuid NOT NULL REFERENCES public.tickets(id) ON DELETE CASCADE,
+  user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (ticket_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_watchers_user ON public.ticket_watchers (user_id);
+
+-- Per-project due date rules. Projects without a row use the defaults.
+-- reminder_days are days relative to the due date: -1 the day before, 0 on
+-- the day, 1 a day late. auto_raise_after_days NULL leaves priority alone.
+CREATE TABLE IF NOT EXISTS public.due_policies (
+  project_id uuid PRIMARY KEY REFERENCES public.projects(id) ON DELETE CASCADE,
+  reminder_days integer[] NOT NULL DEFAULT ARRAY[-1, 0, 1],
+  escalate_urgent boolean NOT NULL DEFAULT true,
+  auto_raise_after_days integer,
+  on_time_bonus_xp integer NOT NULL DEFAULT 10,
+  updated_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- What the due date job already did for a ticket's due date, so each
+-- reminder, escalation and priority raise happens once. Changing the due
+-- date arms them again.
+CREATE TABLE IF NOT EXISTS public.ticket_due_notices (
+  ticket_id uuid NOT NULL REFERENCES public.tickets(id) ON DELETE CASCADE,
+  due_date date NOT NULL,
+  kind character varying NOT NULL,
+  offset_days integer NOT NULL DEFAULT 0,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (ticket_id, due_date, kind, offset_days)
+);
+
+-- The on-time bonus a ticket's completion earned, taken back if it reopens.
+CREATE TABLE IF NOT EXISTS public.ticket_on_time_bonus (
+  ticket_id uuid PRIMARY KEY REFERENCES public.tickets(id) ON DELETE CASCADE,
+  user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+  xp integer NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.sla_breaches;
+DROP TABLE IF EXISTS public.sla_targets;
+DROP TABLE IF EXISTS public.sla_policies;
+DROP TABLE IF EXISTS public.sla_calendars;
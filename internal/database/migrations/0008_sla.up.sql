@@ -0,0 +1,49 @@
+-- Business hours SLA timers count in. work_days are 0 (Sunday) to 6;
+-- start_minute and end_minute are minutes after midnight in timezone.
+CREATE TABLE IF NOT EXISTS public.sla_calendars (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES public.organizations(id) ON DELETE CASCADE,
+  name character varying NOT NULL,
+  timezone character varying NOT NULL DEFAULT 'UTC',
+  work_days integer[] NOT NULL DEFAULT ARRAY[1, 2, 3, 4, 5],
+  start_minute integer NOT NULL DEFAULT 540,
+  end_minute integer NOT NULL DEFAULT 1020,
+  holidays date[] NOT NULL DEFAULT '{}',
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  CHECK (start_minute >= 0 AND start_minute < end_minute AND end_minute <= 1440)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sla_calendars_org ON public.sla_calendars (org_id);
+
+-- A project's SLA policy. Without a calendar timers run around the clock;
+-- they stop while a ticket sits in one of paused_statuses.
+CREATE TABLE IF NOT EXISTS public.sla_policies (
+  project_id uuid PRIMARY KEY REFERENCES public.projects(id) ON DELETE CASCADE,
+  enabled boolean NOT NULL DEFAULT true,
+  calendar_id uuid REFERENCES public.sla_calendars(id) ON DELETE RESTRICT,
+  paused_statuses ticket_status[] NOT NULL DEFAULT '{}',
+  updated_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Targets of a policy by priority and, optionally, ticket type. A target
+-- with a type wins over the one without for tickets of that type.
+CREATE TABLE IF NOT EXISTS public.sla_targets (
+  project_id uuid NOT NULL REFERENCES public.sla_policies(project_id) ON DELETE CASCADE,
+  priority ticket_priority NOT NULL,
+  type ticket_type,
+  response_minutes integer CHECK (response_minutes > 0),
+  resolution_minutes integer CHECK (resolution_minutes > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sla_targets_key ON public.sla_targets (project_id, priority, COALESCE(type::text, ''));
+
+-- Breaches the SLA job found, one per ticket and timer.
+CREATE TABLE IF NOT EXISTS public.sla_breaches (
+  ticket_id uuid NOT NULL REFERENCES public.tickets(id) ON DELETE CASCADE,
+  kind character varying NOT NULL,
+  breached_at timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (ticket_id, kind)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.ticket_status_transitions;
@@ -0,0 +1,51 @@
+-- Every status change of a ticket, for flow metrics. from_status is NULL on
+-- the row a ticket is created with.
+CREATE TABLE IF NOT EXISTS public.ticket_status_transitions (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  ticket_id uuid NOT NULL REFERENCES public.tickets(id) ON DELETE CASCADE,
+  from_status ticket_status,
+  to_status ticket_status NOT NULL,
+  actor_id uuid REFERENCES public.users(id) ON DELETE SET NULL,
+  changed_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_status_transitions_ticket ON public.ticket_status_transitions (ticket_id, changed_at);
+CREATE INDEX IF NOT EXISTS idx_ticket_status_transitions_to ON public.ticket_status_transitions (to_status, changed_at);
+
+-- Backfill existing tickets: created as todo, then the status lines of
+-- their history.
+WITH history AS (
+  SELECT h.ticket_id, h.actor_id, h.timestamp AS changed_at,
+         substring(h.text FROM '^Status (?:changed to|diubah ke) (backlog|todo|in_progress|review|done)')::ticket_status AS status
+  FROM public.ticket_history h
+  WHERE h.text ~ '^Status (changed to|diubah ke) (backlog|todo|in_progress|review|done)'
+), steps AS (
+  SELECT t.id AS ticket_id, NULL::uuid AS actor_id, t.created_at AS changed_at, 'todo'::ticket_status AS status, 0 AS seq
+  FROM public.tickets t
+  UNION ALL
+  SELECT h.ticket_id, h.actor_id, GREATEST(h.changed_at, t.created_at), h.status, 1
+  FROM history h
+  JOIN public.tickets t ON t.id = h.ticket_id
+), ordered AS (
+  SELECT ticket_id, actor_id, changed_at, status,
+         LAG(status) OVER (PARTITION BY ticket_id ORDER BY changed_at, seq) AS previous
+  FROM steps
+)
+INSERT INTO public.ticket_status_transitions (ticket_id, from_status, to_status, actor_id, changed_at)
+SELECT ticket_id, previous, status, actor_id, changed_at
+FROM ordered
+WHERE previous IS DISTINCT FROM status;
+
+-- Tickets whose history misses the change to their current status, such as
+-- imported ones, are taken to have changed at their last update.
+INSERT INTO public.ticket_status_transitions (ticket_id, from_status, to_status, changed_at)
+SELECT t.id, last.to_status, t.status, GREATEST(t.updated_at, last.changed_at)
+FROM public.tickets t
+JOIN LATERAL (
+  SELECT x.to_status, x.changed_at
+  FROM public.ticket_status_transitions x
+  WHERE x.ticket_id = t.id
+  ORDER BY x.changed_at DESC
+  LIMIT 1
+) last ON true
+WHERE last.to_status <> t.status;
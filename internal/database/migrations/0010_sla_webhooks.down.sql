@@ -0,0 +1 @@
+ALTER TABLE public.sla_policies DROP COLUMN IF EXISTS webhook_url;
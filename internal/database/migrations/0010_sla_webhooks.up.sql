@@ -0,0 +1,2 @@
+-- Where the SLA job posts the breaches of a project's tickets.
+ALTER TABLE public.sla_policies ADD COLUMN IF NOT EXISTS webhook_url text;
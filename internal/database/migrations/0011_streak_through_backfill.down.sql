@@ -0,0 +1,2 @@
+-- The backfilled days are valid streak state; there is nothing to undo.
+SELECT 1;
@@ -0,0 +1,14 @@
+-- Streaks that ran before streak_through existed: they cover up to the local
+-- day of the last closed ticket. Without one they cannot be placed and start
+-- over.
+UPDATE public.gamification_user_stats g
+SET streak_through = (g.last_ticket_closed_at AT TIME ZONE u.timezone)::date
+FROM public.users u
+WHERE u.id = g.user_id
+  AND g.streak_days > 0
+  AND g.streak_through IS NULL
+  AND g.last_ticket_closed_at IS NOT NULL;
+
+UPDATE public.gamification_user_stats
+SET streak_days = 0
+WHERE streak_days > 0 AND streak_through IS NULL;
@@ -0,0 +1,163 @@
+// Package sqlscan finds the SQL statements in Go sources, so they can be
+// checked against a real schema (cmd/sqlcheck and the migration tests).
+package sqlscan
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Statement is a complete SQL statement and where it is in the sources.
+type Statement struct {
+	Pos string // file:line:column
+	SQL string
+}
+
+var sqlStart = regexp.MustCompile(`(?i)^\s*(SELECT|INSERT|UPDATE|DELETE|WITH)\s`)
+
+// Collect returns the complete SQL statements in string constants of the
+// non-test Go files under dir. Statements built with fmt verbs are left out.
+func Collect(dir string) ([]Statement, error) {
+	fset := token.NewFileSet()
+	byPkg := map[string][]*ast.File{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		pkgDir := filepath.Dir(path)
+		byPkg[pkgDir] = append(byPkg[pkgDir], file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var statements []Statement
+	for _, files := range byPkg {
+		consts := packageConsts(files)
+		for _, file := range files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				expr, ok := n.(ast.Expr)
+				if !ok {
+					return true
+				}
+				switch expr.(type) {
+				case *ast.BasicLit, *ast.BinaryExpr:
+				default:
+					return true
+				}
+				sql, ok := fold(expr, consts)
+				if !ok {
+					// built at run time; its literal parts are fragments, not
+					// statements, so don't look inside
+					_, dynamic := expr.(*ast.BinaryExpr)
+					return !dynamic
+				}
+				if sqlStart.MatchString(sql) && !strings.Contains(sql, "%") {
+					pos := fset.Position(expr.Pos())
+					key := pos.String()
+					if !seen[key] {
+						seen[key] = true
+						statements = append(statements, Statement{Pos: key, SQL: sql})
+					}
+				}
+				// the folded string covers its operands
+				return false
+			})
+		}
+	}
+	sort.Slice(statements, func(i, j int) bool { return statements[i].Pos < statements[j].Pos })
+	return statements, nil
+}
+
+// packageConsts maps the names of string constants and variables declared
+// with a constant value anywhere in a package to their values. Local
+// declarations are included; a name declared more than once, like the
+// ubiquitous query, is left out because it can't be told apart by name.
+func packageConsts(files []*ast.File) map[string]string {
+	specs := map[string]ast.Expr{}
+	declared := map[string]int{}
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch d := n.(type) {
+			case *ast.ValueSpec:
+				for i, name := range d.Names {
+					declared[name.Name]++
+					if len(d.Names) == len(d.Values) {
+						specs[name.Name] = d.Values[i]
+					}
+				}
+			case *ast.AssignStmt:
+				if d.Tok == token.DEFINE {
+					for _, lhs := range d.Lhs {
+						if name, ok := lhs.(*ast.Ident); ok {
+							declared[name.Name]++
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	for name, n := range declared {
+		if n > 1 {
+			delete(specs, name)
+		}
+	}
+	consts := map[string]string{}
+	// resolve constants defined in terms of others, in any declaration order
+	for changed := true; changed; {
+		changed = false
+		for name, expr := range specs {
+			if _, done := consts[name]; done {
+				continue
+			}
+			if value, ok := fold(expr, consts); ok {
+				consts[name] = value
+				changed = true
+			}
+		}
+	}
+	return consts
+}
+
+// fold evaluates a constant string expression: literals, known constant
+// names and + between them.
+func fold(expr ast.Expr, consts map[string]string) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.Ident:
+		s, ok := consts[e.Name]
+		return s, ok
+	case *ast.ParenExpr:
+		return fold(e.X, consts)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := fold(e.X, consts)
+		if !ok {
+			return "", false
+		}
+		y, ok := fold(e.Y, consts)
+		return x + y, ok
+	}
+	return "", false
+}
@@ -0,0 +1,78 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileMailer writes each message as an .eml file into a directory.
+// Useful for local development and for inspecting output in CI.
+type FileMailer struct {
+	dir string
+}
+
+// NewFileMailer creates a transport that stores messages under dir.
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{dir: dir}
+}
+
+func (m *FileMailer) Name() string { return "file" }
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("create outbox dir: %w", err)
+	}
+	body, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+	recipient := "unknown"
+	if len(msg.To) > 0 {
+		recipient = sanitizeFilename(msg.To[0])
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), recipient)
+	return os.WriteFile(filepath.Join(m.dir, name), body, 0o644)
+}
+
+// ConsoleMailer prints a summary and the plain-text body instead of sending.
+type ConsoleMailer struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewConsoleMailer logs to out, or stdout when out is nil.
+func NewConsoleMailer(out io.Writer) *ConsoleMailer {
+	if out == nil {
+		out = os.Stdout
+	}
+	return &ConsoleMailer{out: out}
+}
+
+func (m *ConsoleMailer) Name() string { return "console" }
+
+func (m *ConsoleMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := fmt.Fprintf(m.out, "[EMAIL] to=%s subject=%q\n%s\n", strings.Join(msg.To, ","), msg.Subject, msg.Text)
+	return err
+}
+
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
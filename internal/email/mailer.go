@@ -0,0 +1,87 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message is a fully rendered email ready to hand to a transport.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer delivers rendered messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+	Name() string
+}
+
+// Config controls transport selection and delivery behaviour.
+type Config struct {
+	// Transport is one of "resend", "smtp", "file" or "console".
+	// When empty, resend is used if ResendAPIKey is set, console otherwise.
+	Transport     string
+	FromEmail     string
+	FromName      string
+	FrontendURL   string
+	DefaultLocale string
+
+	ResendAPIKey string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	OutboxDir string
+
+	QueueSize   int
+	Workers     int
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+func (c Config) from() string {
+	if c.FromName == "" {
+		return c.FromEmail
+	}
+	return fmt.Sprintf("%s <%s>", c.FromName, c.FromEmail)
+}
+
+// NewMailer builds the transport described by cfg.
+func NewMailer(cfg Config) (Mailer, error) {
+	transport := strings.ToLower(strings.TrimSpace(cfg.Transport))
+	if transport == "" {
+		transport = "console"
+		if cfg.ResendAPIKey != "" {
+			transport = "resend"
+		}
+	}
+	switch transport {
+	case "resend":
+		if cfg.ResendAPIKey == "" {
+			return nil, fmt.Errorf("email: resend transport requires RESEND_API_KEY")
+		}
+		return NewResendMailer(cfg.ResendAPIKey), nil
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("email: smtp transport requires SMTP_HOST")
+		}
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword), nil
+	case "file":
+		if cfg.OutboxDir == "" {
+			return nil, fmt.Errorf("email: file transport requires EMAIL_OUTBOX_DIR")
+		}
+		return NewFileMailer(cfg.OutboxDir), nil
+	case "console":
+		return NewConsoleMailer(nil), nil
+	default:
+		return nil, fmt.Errorf("email: unknown transport %q", cfg.Transport)
+	}
+}
@@ -0,0 +1,150 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned when the outbound queue cannot accept more messages.
+var ErrQueueFull = errors.New("email queue is full")
+
+// ErrQueueClosed is returned when enqueueing after Close.
+var ErrQueueClosed = errors.New("email queue is closed")
+
+const sendTimeout = 30 * time.Second
+
+type job struct {
+	msg     Message
+	attempt int
+}
+
+// Queue delivers messages in the background with exponential backoff retries,
+// so HTTP handlers never wait on the provider.
+type Queue struct {
+	mailer      Mailer
+	jobs        chan job
+	maxAttempts int
+	retryDelay  time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	pending sync.WaitGroup
+	workers sync.WaitGroup
+	stop    chan struct{}
+}
+
+// NewQueue starts workers goroutines that send through mailer.
+func NewQueue(mailer Mailer, size, workers, maxAttempts int, retryDelay time.Duration) *Queue {
+	if size <= 0 {
+		size = 256
+	}
+	if workers <= 0 {
+		workers = 2
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	if retryDelay <= 0 {
+		retryDelay = 2 * time.Second
+	}
+	q := &Queue{
+		mailer:      mailer,
+		jobs:        make(chan job, size),
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+		stop:        make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Enqueue schedules msg for delivery without blocking.
+func (q *Queue) Enqueue(msg Message) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	q.pending.Add(1)
+	select {
+	case q.jobs <- job{msg: msg, attempt: 1}:
+		return nil
+	default:
+		q.pending.Done()
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting messages and waits for in-flight deliveries (including
+// scheduled retries) until ctx expires.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		q.pending.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	close(q.stop)
+	q.workers.Wait()
+	return err
+}
+
+func (q *Queue) work() {
+	defer q.workers.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case j := <-q.jobs:
+			q.deliver(j)
+		}
+	}
+}
+
+func (q *Queue) deliver(j job) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	err := q.mailer.Send(ctx, j.msg)
+	cancel()
+	to := strings.Join(j.msg.To, ",")
+	if err == nil {
+		log.Printf("[EMAIL] sent %q to %s via %s", j.msg.Subject, to, q.mailer.Name())
+		q.pending.Done()
+		return
+	}
+	if j.attempt >= q.maxAttempts {
+		log.Printf("[EMAIL] giving up on %q to %s after %d attempts: %v", j.msg.Subject, to, j.attempt, err)
+		q.pending.Done()
+		return
+	}
+	delay := q.retryDelay << (j.attempt - 1)
+	log.Printf("[EMAIL] attempt %d for %q to %s failed, retrying in %s: %v", j.attempt, j.msg.Subject, to, delay, err)
+	j.attempt++
+	time.AfterFunc(delay, func() {
+		select {
+		case q.jobs <- j:
+		case <-q.stop:
+			q.pending.Done()
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const resendEndpoint = "https://api.resend.com/emails"
+
+// ResendRequest represents the Resend API request body
+type ResendRequest struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	HTML    string   `json:"html"`
+	Text    string   `json:"text,omitempty"`
+}
+
+// ResendMailer sends messages through the Resend HTTP API.
+type ResendMailer struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewResendMailer creates a Resend transport.
+func NewResendMailer(apiKey string) *ResendMailer {
+	return &ResendMailer{
+		apiKey:   apiKey,
+		endpoint: resendEndpoint,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (m *ResendMailer) Name() string { return "resend" }
+
+func (m *ResendMailer) Send(ctx context.Context, msg Message) error {
+	jsonData, err := json.Marshal(ResendRequest{
+		From:    msg.From,
+		To:      msg.To,
+		Subject: msg.Subject,
+		HTML:    msg.HTML,
+		Text:    msg.Text,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("resend API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
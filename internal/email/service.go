@@ -1,140 +1,203 @@
 package email
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"log"
+	"net/url"
+	"strings"
+	"time"
 )
 
-// Service handles email sending via Resend
+// Recipient identifies who a message is addressed to and in which locale.
+type Recipient struct {
+	Email  string
+	Name   string
+	Locale string
+}
+
+// DigestItem is a single ticket line in the daily digest.
+type DigestItem struct {
+	Title    string
+	Status   string
+	Priority string
+	DueDate  string
+	URL      string
+}
+
+// Service renders templated emails and hands them to a background queue.
 type Service struct {
-	apiKey      string
-	fromEmail   string
-	fromName    string
-	frontendURL string
+	cfg      Config
+	mailer   Mailer
+	renderer *Renderer
+	queue    *Queue
 }
 
-// NewService creates a new email service from environment variables
-func NewService() *Service {
-	return &Service{
-		apiKey:      os.Getenv("RESEND_API_KEY"),
-		fromEmail:   getEnv("RESEND_FROM_EMAIL", "noreply@resend.dev"),
-		fromName:    getEnv("RESEND_FROM_NAME", "Ticketing Gamified"),
-		frontendURL: getEnv("FRONTEND_URL", "http://localhost:5173"),
+// NewService builds the configured transport, parses templates and starts the send queue.
+func NewService(cfg Config) (*Service, error) {
+	if cfg.FromEmail == "" {
+		cfg.FromEmail = "noreply@resend.dev"
 	}
-}
+	if cfg.FromName == "" {
+		cfg.FromName = appName
+	}
+	if cfg.FrontendURL == "" {
+		cfg.FrontendURL = "http://localhost:5173"
+	}
+	cfg.FrontendURL = strings.TrimRight(cfg.FrontendURL, "/")
 
-func getEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+	mailer, err := NewMailer(cfg)
+	if err != nil {
+		return nil, err
 	}
-	return fallback
+	renderer, err := NewRenderer(cfg.DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[EMAIL] using %s transport", mailer.Name())
+	return &Service{
+		cfg:      cfg,
+		mailer:   mailer,
+		renderer: renderer,
+		queue:    NewQueue(mailer, cfg.QueueSize, cfg.Workers, cfg.MaxAttempts, cfg.RetryDelay),
+	}, nil
 }
 
-// IsConfigured checks if Resend is properly configured
+// IsConfigured reports whether messages actually leave the process.
 func (s *Service) IsConfigured() bool {
-	return s.apiKey != ""
+	return s.mailer.Name() != "console"
 }
 
-// ResendRequest represents the Resend API request body
-type ResendRequest struct {
-	From    string   `json:"from"`
-	To      []string `json:"to"`
-	Subject string   `json:"subject"`
-	HTML    string   `json:"html"`
+// Close drains the send queue.
+func (s *Service) Close(ctx context.Context) error {
+	return s.queue.Close(ctx)
 }
 
-// SendVerificationEmail sends email verification link via Resend
-func (s *Service) SendVerificationEmail(to, name, token, frontendURL string) error {
-	if !s.IsConfigured() {
-		// Log but don't fail - useful for development
-		fmt.Printf("[EMAIL] Would send verification email to %s with token %s\n", to, token)
-		fmt.Printf("[EMAIL] Verification URL: %s/verify-email?token=%s\n", frontendURL, token)
-		return nil
+// Send renders kind for the recipient and enqueues it for delivery.
+func (s *Service) Send(kind Kind, to Recipient, data map[string]any) error {
+	if to.Email == "" {
+		return fmt.Errorf("recipient email is required")
 	}
-
-	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", frontendURL, token)
-
-	htmlBody := fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-  <style>
-    body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; margin: 0; padding: 0; background: #f4f4f5; }
-    .container { max-width: 600px; margin: 0 auto; padding: 40px 20px; }
-    .card { background: white; border-radius: 16px; padding: 40px; box-shadow: 0 4px 6px rgba(0,0,0,0.05); }
-    .header { text-align: center; margin-bottom: 30px; }
-    .header h1 { color: #4F46E5; margin: 0; font-size: 28px; }
-    .content { margin-bottom: 30px; }
-    .button { display: inline-block; background: linear-gradient(135deg, #4F46E5 0%%, #7C3AED 100%%); color: white !important; text-decoration: none; padding: 16px 40px; border-radius: 12px; font-weight: 600; font-size: 16px; }
-    .button-container { text-align: center; margin: 30px 0; }
-    .link { color: #4F46E5; word-break: break-all; font-size: 14px; }
-    .footer { text-align: center; color: #71717a; font-size: 13px; margin-top: 30px; padding-top: 20px; border-top: 1px solid #e4e4e7; }
-    .badge { display: inline-block; background: #fef3c7; color: #92400e; padding: 4px 12px; border-radius: 20px; font-size: 12px; margin-top: 10px; }
-  </style>
-</head>
-<body>
-  <div class="container">
-    <div class="card">
-      <div class="header">
-        <h1>🎮 Ticketing Gamified</h1>
-      </div>
-      <div class="content">
-        <h2 style="color: #18181b; margin-top: 0;">Hi %s! 👋</h2>
-        <p>Welcome to <strong>Ticketing Gamified</strong>! You're just one step away from starting your journey.</p>
-        <p>Please verify your email address to complete your registration and unlock all features.</p>
-        <div class="button-container">
-          <a href="%s" class="button">✨ Verify Email Address</a>
-        </div>
-        <p style="font-size: 14px; color: #71717a;">Or copy and paste this link in your browser:</p>
-        <p class="link">%s</p>
-        <span class="badge">⏰ This link expires in 24 hours</span>
-      </div>
-      <div class="footer">
-        <p>If you didn't create an account, you can safely ignore this email.</p>
-        <p>© 2026 Ticketing Gamified. All rights reserved.</p>
-      </div>
-    </div>
-  </div>
-</body>
-</html>
-`, name, verifyURL, verifyURL)
-
-	reqBody := ResendRequest{
-		From:    fmt.Sprintf("%s <%s>", s.fromName, s.fromEmail),
-		To:      []string{to},
-		Subject: "🔐 Verify Your Email - Ticketing Gamified",
-		HTML:    htmlBody,
+	if data == nil {
+		data = map[string]any{}
 	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+	if _, ok := data["Name"]; !ok {
+		data["Name"] = to.Name
 	}
-
-	req, err := http.NewRequest("POST", "https://api.resend.com/emails", bytes.NewBuffer(jsonData))
+	subject, html, text, err := s.renderer.Render(kind, to.Locale, data)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
+	return s.queue.Enqueue(Message{
+		From:    s.cfg.from(),
+		To:      []string{to.Email},
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	})
+}
+
+// SendVerificationEmail sends the email verification link.
+func (s *Service) SendVerificationEmail(to, name, token, frontendURL string) error {
+	return s.Send(KindVerification, Recipient{Email: to, Name: name}, map[string]any{
+		"URL":          s.link(frontendURL, "/verify-email", url.Values{"token": {token}}),
+		"ExpiresHours": 24,
+	})
+}
 
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+// SendPasswordReset sends a single-use password reset link.
+func (s *Service) SendPasswordReset(to Recipient, token string, ttl time.Duration) error {
+	return s.Send(KindPasswordReset, to, map[string]any{
+		"URL":            s.link("", "/reset-password", url.Values{"token": {token}}),
+		"ExpiresMinutes": int(ttl.Minutes()),
+	})
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+// SendProjectInvite invites someone to a project, optionally with a join code.
+func (s *Service) SendProjectInvite(to Recipient, inviterName, projectName, code string, expiresAt *time.Time) error {
+	data := map[string]any{
+		"InviterName": inviterName,
+		"ProjectName": projectName,
+		"Code":        code,
+		"URL":         s.link("", "/join", url.Values{"code": {code}}),
+	}
+	if expiresAt != nil {
+		data["ExpiresAt"] = expiresAt.Format("2006-01-02")
 	}
-	defer resp.Body.Close()
+	return s.Send(KindProjectInvite, to, data)
+}
 
-	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("resend API error (status %d): %s", resp.StatusCode, string(body))
+// SendUserInvite invites someone to the workspace. accountExists selects the
+// set-password page (account created by an admin) over the sign-up page.
+func (s *Service) SendUserInvite(to Recipient, inviterName, role, token string, expiresAt time.Time, accountExists bool) error {
+	path := "/accept-invite"
+	if accountExists {
+		path = "/reset-password"
 	}
+	return s.Send(KindUserInvite, to, map[string]any{
+		"InviterName":   inviterName,
+		"Role":          role,
+		"AccountExists": accountExists,
+		"URL":           s.link("", path, url.Values{"token": {token}}),
+		"ExpiresAt":     expiresAt.Format("2006-01-02"),
+	})
+}
 
-	fmt.Printf("[EMAIL] Verification email sent to %s via Resend\n", to)
-	return nil
+// SendDailyDigest summarises a user's open work.
+func (s *Service) SendDailyDigest(to Recipient, date time.Time, items []DigestItem, xpEarned int) error {
+	return s.Send(KindDailyDigest, to, map[string]any{
+		"Date":     date.Format("2006-01-02"),
+		"Items":    items,
+		"XPEarned": xpEarned,
+		"URL":      s.link("", "/dashboard", nil),
+	})
+}
+
+// SendDueReminder notifies about an upcoming or missed ticket due date.
+func (s *Service) SendDueReminder(to Recipient, ticketID, ticketTitle, projectName string, dueDate time.Time, overdue bool) error {
+	return s.Send(KindDueReminder, to, map[string]any{
+		"TicketTitle": ticketTitle,
+		"ProjectName": projectName,
+		"DueDate":     dueDate.Format("2006-01-02"),
+		"Overdue":     overdue,
+		"URL":         s.TicketURL(ticketID),
+	})
+}
+
+// SendDueEscalation tells a project lead that an urgent ticket is overdue.
+func (s *Service) SendDueEscalation(to Recipient, ticketID, ticketTitle, projectName, assigneeName string, dueDate time.Time) error {
+	return s.Send(KindDueEscalation, to, map[string]any{
+		"TicketTitle":  ticketTitle,
+		"ProjectName":  projectName,
+		"AssigneeName": assigneeName,
+		"DueDate":      dueDate.Format("2006-01-02"),
+		"URL":          s.TicketURL(ticketID),
+	})
+}
+
+// SendAchievementUnlocked celebrates a newly unlocked achievement.
+func (s *Service) SendAchievementUnlocked(to Recipient, name, description, icon string, xpReward int) error {
+	return s.Send(KindAchievementUnlocked, to, map[string]any{
+		"AchievementName": name,
+		"Description":     description,
+		"Icon":            icon,
+		"XPReward":        xpReward,
+		"URL":             s.link("", "/achievements", nil),
+	})
+}
+
+// TicketURL returns the frontend link for a ticket.
+func (s *Service) TicketURL(ticketID string) string {
+	return s.link("", "/tickets/"+url.PathEscape(ticketID), nil)
+}
+
+func (s *Service) link(base, path string, query url.Values) string {
+	if base == "" {
+		base = s.cfg.FrontendURL
+	}
+	link := strings.TrimRight(base, "/") + path
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
 }
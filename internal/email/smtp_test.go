@@ -0,0 +1,320 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTP is a minimal SMTP relay on 127.0.0.1. It rejects MAIL FROM with
+// a temporary error for the first failFirst sessions and records the
+// messages it accepts. With stall set it accepts connections but never
+// greets.
+type fakeSMTP struct {
+	ln        net.Listener
+	failFirst int
+	stall     bool
+
+	mu       sync.Mutex
+	sessions []time.Time
+	messages []string
+	received chan struct{}
+}
+
+// startFakeSMTP starts a relay that fails the first failFirst sessions, or
+// stalls every one.
+func startFakeSMTP(t *testing.T, failFirst int, stall bool) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTP{ln: ln, failFirst: failFirst, stall: stall, received: make(chan struct{}, 16)}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTP) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTP) mailer() *SMTPMailer {
+	return NewSMTPMailer("127.0.0.1", s.port(), "", "")
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(conn)
+	}
+}
+
+func (s *fakeSMTP) session(conn net.Conn) {
+	defer conn.Close()
+	s.mu.Lock()
+	s.sessions = append(s.sessions, time.Now())
+	attempt := len(s.sessions)
+	s.mu.Unlock()
+	if s.stall {
+		io.Copy(io.Discard, conn)
+		return
+	}
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			if attempt <= s.failFirst {
+				reply("451 try again later")
+				continue
+			}
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var body strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				body.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, body.String())
+			s.mu.Unlock()
+			s.received <- struct{}{}
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTP) wait(t *testing.T) string {
+	t.Helper()
+	select {
+	case <-s.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages[len(s.messages)-1]
+}
+
+// parsed is a received message split into its headers and parts.
+type parsed struct {
+	header  mail.Header
+	subject string
+	parts   map[string]string
+}
+
+func parseMessage(t *testing.T, raw string) parsed {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type %q: %v", msg.Header.Get("Content-Type"), err)
+	}
+	parts := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		body, _ := io.ReadAll(p)
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	return parsed{header: msg.Header, subject: subject, parts: parts}
+}
+
+func TestSMTPMailerSendsMIME(t *testing.T) {
+	server := startFakeSMTP(t, 0, false)
+	err := server.mailer().Send(context.Background(), Message{
+		From:    "Ticketing <noreply@example.com>",
+		To:      []string{"dev@example.com"},
+		Subject: "Tiket baru ✅",
+		HTML:    "<p>Hello</p>",
+		Text:    "Hello\nthere",
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	msg := parseMessage(t, server.wait(t))
+
+	if got := msg.header.Get("To"); got != "dev@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if msg.header.Get("MIME-Version") != "1.0" || msg.header.Get("Message-ID") == "" || msg.header.Get("Date") == "" {
+		t.Errorf("missing headers: %v", msg.header)
+	}
+	if msg.subject != "Tiket baru ✅" {
+		t.Errorf("subject = %q", msg.subject)
+	}
+	if got := msg.parts["text/plain"]; got != "Hello\r\nthere" {
+		t.Errorf("text part = %q", got)
+	}
+	if got := msg.parts["text/html"]; got != "<p>Hello</p>" {
+		t.Errorf("html part = %q", got)
+	}
+}
+
+func TestSMTPMailerHonorsContext(t *testing.T) {
+	server := startFakeSMTP(t, 0, true)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err := server.mailer().Send(ctx, Message{From: "noreply@example.com", To: []string{"dev@example.com"}, Text: "hi"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Fatalf("send returned after %s", elapsed)
+	}
+}
+
+func smtpService(t *testing.T, server *fakeSMTP, maxAttempts int, retryDelay time.Duration) *Service {
+	t.Helper()
+	svc, err := NewService(Config{
+		Transport:     "smtp",
+		FromEmail:     "noreply@example.com",
+		DefaultLocale: "en",
+		SMTPHost:      "127.0.0.1",
+		SMTPPort:      server.port(),
+		MaxAttempts:   maxAttempts,
+		RetryDelay:    retryDelay,
+	})
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		svc.Close(ctx)
+	})
+	return svc
+}
+
+func TestServiceLocaleFallback(t *testing.T) {
+	server := startFakeSMTP(t, 0, false)
+	svc := smtpService(t, server, 1, time.Millisecond)
+
+	cases := []struct {
+		locale  string
+		subject string
+		greet   string
+	}{
+		{"id-ID", "🔐 Verifikasi Email - " + appName, "Halo Budi!"},
+		{"fr", "🔐 Verify Your Email - " + appName, "Hi Budi!"},
+		{"", "🔐 Verify Your Email - " + appName, "Hi Budi!"},
+	}
+	for _, tc := range cases {
+		t.Run(strconv.Quote(tc.locale), func(t *testing.T) {
+			err := svc.Send(KindVerification, Recipient{Email: "budi@example.com", Name: "Budi", Locale: tc.locale}, map[string]any{
+				"URL":          "http://localhost/verify",
+				"ExpiresHours": 24,
+			})
+			if err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			msg := parseMessage(t, server.wait(t))
+			if msg.subject != tc.subject {
+				t.Errorf("subject = %q, want %q", msg.subject, tc.subject)
+			}
+			if !strings.HasPrefix(msg.parts["text/plain"], tc.greet) {
+				t.Errorf("text part = %q, want it to start with %q", msg.parts["text/plain"], tc.greet)
+			}
+			if !strings.Contains(msg.parts["text/html"], "http://localhost/verify") {
+				t.Errorf("html part lacks the link: %q", msg.parts["text/html"])
+			}
+		})
+	}
+}
+
+func TestQueueRetriesWithBackoff(t *testing.T) {
+	server := startFakeSMTP(t, 2, false)
+	const delay = 50 * time.Millisecond
+	svc := smtpService(t, server, 3, delay)
+
+	if err := svc.Send(KindVerification, Recipient{Email: "dev@example.com"}, map[string]any{"URL": "x"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	server.wait(t)
+
+	server.mu.Lock()
+	sessions := append([]time.Time(nil), server.sessions...)
+	server.mu.Unlock()
+	if len(sessions) != 3 {
+		t.Fatalf("sessions = %d, want 3", len(sessions))
+	}
+	if gap := sessions[1].Sub(sessions[0]); gap < delay {
+		t.Errorf("first retry after %s, want at least %s", gap, delay)
+	}
+	if gap := sessions[2].Sub(sessions[1]); gap < 2*delay {
+		t.Errorf("second retry after %s, want at least %s", gap, 2*delay)
+	}
+}
+
+func TestQueueGivesUpAfterMaxAttempts(t *testing.T) {
+	server := startFakeSMTP(t, 10, false)
+	svc := smtpService(t, server, 2, 10*time.Millisecond)
+
+	if err := svc.Send(KindVerification, Recipient{Email: "dev@example.com"}, map[string]any{"URL": "x"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := svc.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.sessions) != 2 || len(server.messages) != 0 {
+		t.Fatalf("sessions = %d, messages = %d; want 2 and 0", len(server.sessions), len(server.messages))
+	}
+}
@@ -0,0 +1,134 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Kind identifies a message template.
+type Kind string
+
+const (
+	KindVerification        Kind = "verification"
+	KindPasswordReset       Kind = "password_reset"
+	KindProjectInvite       Kind = "project_invite"
+	KindUserInvite          Kind = "user_invite"
+	KindDailyDigest         Kind = "daily_digest"
+	KindDueReminder         Kind = "due_reminder"
+	KindDueEscalation       Kind = "due_escalation"
+	KindAchievementUnlocked Kind = "achievement_unlocked"
+)
+
+const appName = "Ticketing Gamified"
+
+// supportedLocales lists the locales shipped under templates/.
+var supportedLocales = []string{"en", "id"}
+
+// Renderer turns a Kind plus data into subject, HTML and plain-text bodies.
+type Renderer struct {
+	html          map[string]*htmltemplate.Template
+	text          map[string]*texttemplate.Template
+	defaultLocale string
+}
+
+// NewRenderer parses the embedded templates for every supported locale.
+func NewRenderer(defaultLocale string) (*Renderer, error) {
+	r := &Renderer{
+		html:          make(map[string]*htmltemplate.Template, len(supportedLocales)),
+		text:          make(map[string]*texttemplate.Template, len(supportedLocales)),
+		defaultLocale: "en",
+	}
+	funcs := map[string]any{
+		"dict":   dict,
+		"footer": footer,
+	}
+	for _, locale := range supportedLocales {
+		h, err := htmltemplate.New(locale).Funcs(funcs).ParseFS(templateFS, "templates/layout.html.tmpl", "templates/"+locale+".html.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("parse %s html templates: %w", locale, err)
+		}
+		t, err := texttemplate.New(locale).Funcs(funcs).ParseFS(templateFS, "templates/"+locale+".txt.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("parse %s text templates: %w", locale, err)
+		}
+		r.html[locale] = h
+		r.text[locale] = t
+	}
+	if loc := normalizeLocale(defaultLocale); r.html[loc] != nil {
+		r.defaultLocale = loc
+	}
+	return r, nil
+}
+
+// Render executes the templates for kind in locale, falling back to the default locale.
+func (r *Renderer) Render(kind Kind, locale string, data map[string]any) (subject, html, text string, err error) {
+	loc := normalizeLocale(locale)
+	if r.html[loc] == nil {
+		loc = r.defaultLocale
+	}
+	payload := make(map[string]any, len(data)+2)
+	for k, v := range data {
+		payload[k] = v
+	}
+	payload["AppName"] = appName
+	payload["Year"] = time.Now().Year()
+
+	var buf bytes.Buffer
+	if err := r.text[loc].ExecuteTemplate(&buf, string(kind)+".subject", payload); err != nil {
+		return "", "", "", fmt.Errorf("render %s subject: %w", kind, err)
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := r.html[loc].ExecuteTemplate(&buf, string(kind), payload); err != nil {
+		return "", "", "", fmt.Errorf("render %s html: %w", kind, err)
+	}
+	html = buf.String()
+
+	buf.Reset()
+	if err := r.text[loc].ExecuteTemplate(&buf, string(kind), payload); err != nil {
+		return "", "", "", fmt.Errorf("render %s text: %w", kind, err)
+	}
+	text = strings.TrimSpace(buf.String()) + "\n"
+	return subject, html, text, nil
+}
+
+// normalizeLocale maps values like "id-ID" or "EN_us" to the base language tag.
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict requires key/value pairs")
+	}
+	out := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict key must be a string")
+		}
+		out[key] = pairs[i+1]
+	}
+	return out, nil
+}
+
+func footer(data map[string]any, text string) map[string]any {
+	return map[string]any{
+		"AppName": data["AppName"],
+		"Year":    data["Year"],
+		"Footer":  text,
+	}
+}
@@ -1,6 +1,7 @@
 package epics
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -46,6 +47,7 @@ func (h *Handler) listByProject(c *gin.Context) {
 		}
 	}
 	filter := Filter{
+		OrgID:     user.OrgID,
 		ProjectID: projectID,
 		Status:    c.Query("status"),
 		Search:    c.Query("q"),
@@ -80,8 +82,16 @@ func (h *Handler) create(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "title is required")
 		return
 	}
-	epic, err := h.service.Create(c.Request.Context(), payload)
+	epic, err := h.service.Create(c.Request.Context(), user.OrgID, payload)
 	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		if errors.Is(err, ErrUnknownOwner) {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
@@ -89,11 +99,12 @@ func (h *Handler) create(c *gin.Context) {
 }
 
 func (h *Handler) get(c *gin.Context) {
-	if middleware.CurrentUser(c) == nil {
+	user := middleware.CurrentUser(c)
+	if user == nil {
 		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
 		return
 	}
-	epic, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	epic, err := h.service.Get(c.Request.Context(), user.OrgID, c.Param("id"))
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -106,7 +117,8 @@ func (h *Handler) get(c *gin.Context) {
 }
 
 func (h *Handler) update(c *gin.Context) {
-	if middleware.CurrentUser(c) == nil {
+	user := middleware.CurrentUser(c)
+	if user == nil {
 		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
 		return
 	}
@@ -115,8 +127,12 @@ func (h *Handler) update(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
-	epic, err := h.service.Update(c.Request.Context(), c.Param("id"), payload)
+	epic, err := h.service.Update(c.Request.Context(), user.OrgID, c.Param("id"), payload)
 	if err != nil {
+		if errors.Is(err, ErrUnknownOwner) {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
@@ -128,11 +144,12 @@ func (h *Handler) update(c *gin.Context) {
 }
 
 func (h *Handler) delete(c *gin.Context) {
-	if middleware.CurrentUser(c) == nil {
+	user := middleware.CurrentUser(c)
+	if user == nil {
 		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
 		return
 	}
-	deleted, err := h.service.Delete(c.Request.Context(), c.Param("id"))
+	deleted, err := h.service.Delete(c.Request.Context(), user.OrgID, c.Param("id"))
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
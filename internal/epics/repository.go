@@ -38,9 +38,10 @@ LEFT JOIN LATERAL (
 LEFT JOIN LATERAL (
     SELECT COUNT(*)::int AS count FROM tickets t WHERE t.epic_id = e.id
 ) total ON true
-WHERE e.project_id = $1`)
-	args = append(args, filter.ProjectID)
-	idx++
+JOIN projects p ON p.id = e.project_id
+WHERE e.project_id = $1 AND p.org_id = $2`)
+	args = append(args, filter.ProjectID, filter.OrgID)
+	idx += 2
 	if filter.Status != "" {
 		sb.WriteString(fmt.Sprintf(" AND e.status = $%d", idx))
 		args = append(args, filter.Status)
@@ -77,7 +78,8 @@ WHERE e.project_id = $1`)
 	return epics, rows.Err()
 }
 
-func (r *Repository) Get(ctx context.Context, id string) (*Epic, error) {
+// Get returns an epic of a project in orgID.
+func (r *Repository) Get(ctx context.Context, orgID, id string) (*Epic, error) {
 	const query = `
 SELECT e.id, e.project_id, e.title, e.description, e.status, e.start_date, e.due_date, e.owner_id, e.created_at, e.updated_at,
        COALESCE(done.count, 0) AS done_count,
@@ -89,9 +91,10 @@ LEFT JOIN LATERAL (
 LEFT JOIN LATERAL (
     SELECT COUNT(*)::int AS count FROM tickets t WHERE t.epic_id = e.id
 ) total ON true
-WHERE e.id = $1`
+JOIN projects p ON p.id = e.project_id
+WHERE e.id = $1 AND p.org_id = $2`
 	var e Epic
-	if err := r.db.QueryRow(ctx, query, id).Scan(&e.ID, &e.ProjectID, &e.Title, &e.Description, &e.Status, &e.StartDate, &e.DueDate, &e.OwnerID, &e.CreatedAt, &e.UpdatedAt, &e.DoneCount, &e.TotalCount); err != nil {
+	if err := r.db.QueryRow(ctx, query, id, orgID).Scan(&e.ID, &e.ProjectID, &e.Title, &e.Description, &e.Status, &e.StartDate, &e.DueDate, &e.OwnerID, &e.CreatedAt, &e.UpdatedAt, &e.DoneCount, &e.TotalCount); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
@@ -115,7 +118,8 @@ RETURNING id, project_id, title, description, status, start_date, due_date, owne
 	return &e, nil
 }
 
-func (r *Repository) Update(ctx context.Context, id string, input UpdateInput) (*Epic, error) {
+// Update changes an epic of orgID; the caller checked that it exists there.
+func (r *Repository) Update(ctx context.Context, orgID, id string, input UpdateInput) (*Epic, error) {
 	setParts := []string{}
 	args := []any{}
 	idx := 1
@@ -156,7 +160,7 @@ func (r *Repository) Update(ctx context.Context, id string, input UpdateInput) (
 	}
 
 	if len(setParts) == 0 {
-		return r.Get(ctx, id)
+		return r.Get(ctx, orgID, id)
 	}
 	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", idx))
 	args = append(args, time.Now())
@@ -167,7 +171,21 @@ func (r *Repository) Update(ctx context.Context, id string, input UpdateInput) (
 	if _, err := r.db.Exec(ctx, query, args...); err != nil {
 		return nil, err
 	}
-	return r.Get(ctx, id)
+	return r.Get(ctx, orgID, id)
+}
+
+// ProjectInOrg reports whether projectID belongs to orgID.
+func (r *Repository) ProjectInOrg(ctx context.Context, orgID, projectID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id::text = $1 AND org_id = $2)`, projectID, orgID).Scan(&ok)
+	return ok, err
+}
+
+// UserInOrg reports whether userID belongs to orgID.
+func (r *Repository) UserInOrg(ctx context.Context, orgID, userID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id::text = $1 AND org_id = $2)`, userID, orgID).Scan(&ok)
+	return ok, err
 }
 
 func (r *Repository) Delete(ctx context.Context, id string) (bool, error) {
@@ -1,6 +1,14 @@
 package epics
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrUnknownOwner    = errors.New("owner not found in this organization")
+)
 
 type Service struct {
 	repo *Repository
@@ -14,18 +22,53 @@ func (s *Service) List(ctx context.Context, filter Filter) ([]Epic, error) {
 	return s.repo.ListByProject(ctx, filter)
 }
 
-func (s *Service) Get(ctx context.Context, id string) (*Epic, error) {
-	return s.repo.Get(ctx, id)
+func (s *Service) Get(ctx context.Context, orgID, id string) (*Epic, error) {
+	return s.repo.Get(ctx, orgID, id)
 }
 
-func (s *Service) Create(ctx context.Context, input CreateInput) (*Epic, error) {
+func (s *Service) Create(ctx context.Context, orgID string, input CreateInput) (*Epic, error) {
+	ok, err := s.repo.ProjectInOrg(ctx, orgID, input.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+	if err := s.checkOwner(ctx, orgID, input.OwnerID); err != nil {
+		return nil, err
+	}
 	return s.repo.Create(ctx, input)
 }
 
-func (s *Service) Update(ctx context.Context, id string, input UpdateInput) (*Epic, error) {
-	return s.repo.Update(ctx, id, input)
+func (s *Service) Update(ctx context.Context, orgID, id string, input UpdateInput) (*Epic, error) {
+	current, err := s.repo.Get(ctx, orgID, id)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	if err := s.checkOwner(ctx, orgID, input.OwnerID); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, orgID, id, input)
 }
 
-func (s *Service) Delete(ctx context.Context, id string) (bool, error) {
+func (s *Service) Delete(ctx context.Context, orgID, id string) (bool, error) {
+	current, err := s.repo.Get(ctx, orgID, id)
+	if err != nil || current == nil {
+		return false, err
+	}
 	return s.repo.Delete(ctx, id)
 }
+
+func (s *Service) checkOwner(ctx context.Context, orgID string, ownerID *string) error {
+	if ownerID == nil || *ownerID == "" {
+		return nil
+	}
+	ok, err := s.repo.UserInOrg(ctx, orgID, *ownerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUnknownOwner
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ type Epic struct {
 
 // Filter for listing epics by project.
 type Filter struct {
+	OrgID     string
 	ProjectID string
 	Status    string
 	Search    string
@@ -0,0 +1,342 @@
+// Package export streams tabular data to HTTP clients as CSV, XLSX or
+// NDJSON. Rows are written as they are produced, so an export never holds
+// the whole dataset in memory.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend-go-ticketing-gamify/internal/response"
+)
+
+// Formats an export can be written in.
+const (
+	CSV    = "csv"
+	XLSX   = "xlsx"
+	NDJSON = "ndjson"
+)
+
+var ErrUnknownFormat = errors.New("format must be csv, xlsx or ndjson")
+
+// flushEvery is how many rows are buffered before they are pushed to the client.
+const flushEvery = 500
+
+// writeTimeout replaces the server's write timeout for a download, which is
+// sized for ordinary responses and would cut off exports of large projects.
+const writeTimeout = 30 * time.Minute
+
+// Column names a field in the header row and the NDJSON objects.
+type Column struct {
+	Key   string
+	Title string
+}
+
+// Writer writes rows whose values line up with the columns it was created with.
+// Values may be strings, numbers, bools, times, pointers to those, or nil.
+type Writer interface {
+	Row(values ...any) error
+	// Close finishes the document. It must be called even when no rows were written.
+	Close() error
+}
+
+// ParseFormat validates a format query parameter; empty means CSV.
+func ParseFormat(format string) (string, error) {
+	switch f := strings.ToLower(format); f {
+	case "":
+		return CSV, nil
+	case CSV, XLSX, NDJSON:
+		return f, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// Start sends the download headers for name (without extension) and returns a
+// writer streaming the response body.
+func Start(c *gin.Context, format, name string, columns []Column) (Writer, error) {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102-150405"), format)
+	var contentType string
+	switch format {
+	case CSV:
+		contentType = "text/csv; charset=utf-8"
+	case XLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case NDJSON:
+		contentType = "application/x-ndjson"
+	default:
+		return nil, ErrUnknownFormat
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+	c.Status(http.StatusOK)
+	return NewWriter(c.Writer, format, columns)
+}
+
+// Fail reports an export error. Until the first row is written nothing of
+// the body has gone out and the client still gets an error response;
+// afterwards the download can only be cut short.
+func Fail(c *gin.Context, name string, err error) {
+	if !c.Writer.Written() {
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		c.Header("Cache-Control", "")
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	log.Printf("%s export failed: %v", name, err)
+}
+
+// NewWriter returns a writer of the given format on w. What the writer
+// produces before the first row, such as the header or the fixed parts of a
+// workbook, is held back until that row or Close. If w can be flushed, it is
+// flushed every few hundred rows.
+func NewWriter(w io.Writer, format string, columns []Column) (Writer, error) {
+	held := &holdback{w: w, buf: new(bytes.Buffer)}
+	f := flusher{w: held}
+	if fl, ok := w.(http.Flusher); ok {
+		f.flush = fl.Flush
+	}
+	var (
+		writer Writer
+		err    error
+	)
+	switch format {
+	case CSV:
+		writer, err = newCSVWriter(f, columns)
+	case XLSX:
+		writer, err = newXLSXWriter(f, columns)
+	case NDJSON:
+		writer = &ndjsonWriter{f: f, columns: columns}
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &heldWriter{Writer: writer, held: held}, nil
+}
+
+// holdback buffers writes until release, then passes them straight through.
+type holdback struct {
+	w   io.Writer
+	buf *bytes.Buffer
+}
+
+func (h *holdback) Write(p []byte) (int, error) {
+	if h.buf != nil {
+		return h.buf.Write(p)
+	}
+	return h.w.Write(p)
+}
+
+func (h *holdback) release() error {
+	if h.buf == nil {
+		return nil
+	}
+	buf := h.buf
+	h.buf = nil
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+// heldWriter releases what its writer held back on the first row or Close.
+type heldWriter struct {
+	Writer
+	held *holdback
+}
+
+func (w *heldWriter) Row(values ...any) error {
+	if err := w.held.release(); err != nil {
+		return err
+	}
+	return w.Writer.Row(values...)
+}
+
+func (w *heldWriter) Close() error {
+	if err := w.held.release(); err != nil {
+		return err
+	}
+	return w.Writer.Close()
+}
+
+type flusher struct {
+	w     io.Writer
+	flush func()
+	rows  int
+}
+
+// row counts a written row and flushes the client every flushEvery rows.
+func (f *flusher) row(buffered interface{ Flush() error }) error {
+	f.rows++
+	if f.rows%flushEvery != 0 {
+		return nil
+	}
+	return f.push(buffered)
+}
+
+func (f *flusher) push(buffered interface{ Flush() error }) error {
+	if buffered != nil {
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+	}
+	if f.flush != nil {
+		f.flush()
+	}
+	return nil
+}
+
+type csvWriter struct {
+	f   flusher
+	csv *csv.Writer
+}
+
+func newCSVWriter(f flusher, columns []Column) (*csvWriter, error) {
+	w := &csvWriter{f: f, csv: csv.NewWriter(f.w)}
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Title
+	}
+	return w, w.csv.Write(header)
+}
+
+func (w *csvWriter) Row(values ...any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		s, isText := cellText(v)
+		if isText {
+			s = escapeFormula(s)
+		}
+		record[i] = s
+	}
+	if err := w.csv.Write(record); err != nil {
+		return err
+	}
+	return w.f.row(csvFlusher{w.csv})
+}
+
+func (w *csvWriter) Close() error {
+	return w.f.push(csvFlusher{w.csv})
+}
+
+type csvFlusher struct{ w *csv.Writer }
+
+func (f csvFlusher) Flush() error {
+	f.w.Flush()
+	return f.w.Error()
+}
+
+// escapeFormula keeps spreadsheet apps from evaluating user text such as
+// "=HYPERLINK(...)" as a formula.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+type ndjsonWriter struct {
+	f       flusher
+	columns []Column
+}
+
+func (w *ndjsonWriter) Row(values ...any) error {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, col := range w.columns {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		key, _ := json.Marshal(col.Key)
+		sb.Write(key)
+		sb.WriteByte(':')
+		var v any
+		if i < len(values) {
+			v = jsonValue(values[i])
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		sb.Write(value)
+	}
+	sb.WriteString("}\n")
+	if _, err := io.WriteString(w.f.w, sb.String()); err != nil {
+		return err
+	}
+	return w.f.row(nil)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.f.push(nil)
+}
+
+// deref unwraps the pointer types repositories scan nullable columns into.
+func deref(v any) any {
+	switch x := v.(type) {
+	case *string:
+		if x == nil {
+			return nil
+		}
+		return *x
+	case *int:
+		if x == nil {
+			return nil
+		}
+		return *x
+	case *time.Time:
+		if x == nil {
+			return nil
+		}
+		return *x
+	}
+	return v
+}
+
+func jsonValue(v any) any {
+	v = deref(v)
+	if t, ok := v.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return v
+}
+
+// cellText renders a value as text and reports whether it is free text
+// rather than a number, bool or time.
+func cellText(v any) (string, bool) {
+	switch x := deref(v).(type) {
+	case nil:
+		return "", false
+	case string:
+		return x, true
+	case int:
+		return strconv.Itoa(x), false
+	case int64:
+		return strconv.FormatInt(x, 10), false
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), false
+	case bool:
+		return strconv.FormatBool(x), false
+	case time.Time:
+		return x.UTC().Format(time.RFC3339), false
+	default:
+		return fmt.Sprint(x), true
+	}
+}
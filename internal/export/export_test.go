@@ -0,0 +1,123 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testColumns = []Column{{Key: "id", Title: "ID"}, {Key: "title", Title: "Title"}}
+
+func TestNothingIsWrittenBeforeTheFirstRow(t *testing.T) {
+	for _, format := range []string{CSV, XLSX, NDJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format, testColumns)
+			if err != nil {
+				t.Fatalf("NewWriter: %v", err)
+			}
+			if buf.Len() != 0 {
+				t.Fatalf("%d bytes written before the first row", buf.Len())
+			}
+			if err := w.Row("1", "First"); err != nil {
+				t.Fatalf("Row: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if !bytes.Contains(body(t, format, buf.Bytes()), []byte("First")) {
+				t.Fatalf("the row is missing from the export")
+			}
+		})
+	}
+}
+
+func TestFailBeforeTheFirstRow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, format := range []string{CSV, XLSX, NDJSON} {
+		t.Run(format, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodGet, "/export", nil)
+			if _, err := Start(c, format, "tickets", testColumns); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			Fail(c, "tickets", errors.New("query failed"))
+			if rec.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want 500", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Disposition"); got != "" {
+				t.Errorf("Content-Disposition = %q, want none", got)
+			}
+			if !strings.Contains(rec.Body.String(), "query failed") {
+				t.Errorf("body = %q, want the error", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestExportOutlastsTheServerWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/export", func(c *gin.Context) {
+		w, err := Start(c, CSV, "tickets", testColumns)
+		if err != nil {
+			Fail(c, "tickets", err)
+			return
+		}
+		time.Sleep(300 * time.Millisecond)
+		if err := w.Row("1", "Slow"); err != nil {
+			t.Errorf("Row: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	})
+	srv := httptest.NewUnstartedServer(engine)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/export")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Contains(data, []byte("Slow")) {
+		t.Fatalf("body = %q, want the row written after the server's write timeout", data)
+	}
+}
+
+// body returns the text of an export: the sheet of a workbook, else the bytes.
+func body(t *testing.T, format string, data []byte) []byte {
+	t.Helper()
+	if format != XLSX {
+		return data
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open workbook: %v", err)
+	}
+	f, err := zr.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatalf("open sheet: %v", err)
+	}
+	defer f.Close()
+	sheet, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read sheet: %v", err)
+	}
+	return sheet
+}
@@ -0,0 +1,147 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCellText is the longest text a spreadsheet cell may hold.
+const maxCellText = 32767
+
+// The parts of a single-sheet workbook other than the sheet itself.
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+	// style 1 is bold for the header row, style 2 a date-time format
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs></styleSheet>`},
+}
+
+// xlsxWriter streams a workbook with one sheet. The fixed parts go first;
+// the sheet is the last zip entry and is written row by row.
+type xlsxWriter struct {
+	f     flusher
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+func newXLSXWriter(f flusher, columns []Column) (*xlsxWriter, error) {
+	zw := zip.NewWriter(f.w)
+	for _, part := range xlsxParts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	w := &xlsxWriter{f: f, zip: zw, sheet: bufio.NewWriter(sheet)}
+	w.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	header := make([]any, len(columns))
+	for i, col := range columns {
+		header[i] = col.Title
+	}
+	return w, w.writeRow(header, 1)
+}
+
+func (w *xlsxWriter) Row(values ...any) error {
+	if err := w.writeRow(values, 0); err != nil {
+		return err
+	}
+	return w.f.row(xlsxFlusher{w})
+}
+
+func (w *xlsxWriter) writeRow(values []any, style int) error {
+	w.row++
+	w.sheet.WriteString(`<row r="` + strconv.Itoa(w.row) + `">`)
+	for i, v := range values {
+		ref := columnName(i) + strconv.Itoa(w.row)
+		switch x := deref(v).(type) {
+		case nil:
+			continue
+		case int, int64, float64:
+			text, _ := cellText(x)
+			w.sheet.WriteString(`<c r="` + ref + `"><v>` + text + `</v></c>`)
+		case bool:
+			b := "0"
+			if x {
+				b = "1"
+			}
+			w.sheet.WriteString(`<c r="` + ref + `" t="b"><v>` + b + `</v></c>`)
+		case time.Time:
+			w.sheet.WriteString(`<c r="` + ref + `" s="2"><v>` + strconv.FormatFloat(serialDate(x), 'f', -1, 64) + `</v></c>`)
+		default:
+			text, _ := cellText(x)
+			if len(text) > maxCellText {
+				text = text[:maxCellText]
+			}
+			s := ""
+			if style != 0 {
+				s = ` s="` + strconv.Itoa(style) + `"`
+			}
+			w.sheet.WriteString(`<c r="` + ref + `"` + s + ` t="inlineStr"><is><t xml:space="preserve">`)
+			// EscapeText also replaces characters XML can't carry
+			if err := xml.EscapeText(w.sheet, []byte(strings.ToValidUTF8(text, "�"))); err != nil {
+				return err
+			}
+			w.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := w.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (w *xlsxWriter) Close() error {
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	if err := w.zip.Close(); err != nil {
+		return err
+	}
+	return w.f.push(nil)
+}
+
+type xlsxFlusher struct{ w *xlsxWriter }
+
+func (f xlsxFlusher) Flush() error {
+	if err := f.w.sheet.Flush(); err != nil {
+		return err
+	}
+	return f.w.zip.Flush()
+}
+
+// columnName turns a zero-based index into A, B, ..., Z, AA, ...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// excelEpoch is day zero of spreadsheet serial dates.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// serialDate is t in UTC as a spreadsheet date: days since excelEpoch.
+func serialDate(t time.Time) float64 {
+	return t.UTC().Sub(excelEpoch).Hours() / 24
+}
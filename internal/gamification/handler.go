@@ -1,12 +1,14 @@
 package gamification
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"backend-go-ticketing-gamify/internal/middleware"
 	"backend-go-ticketing-gamify/internal/response"
 )
 
@@ -24,16 +26,58 @@ func NewHandler(service *Service) *Handler {
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/stats/:userID", h.getStats)
 	router.GET("/events", h.listEvents)
+	router.PUT("/streak/settings", h.updateStreakSettings)
 	router.GET("/leaderboard", h.leaderboard)
+	router.GET("/leaderboard/me", h.myRank)
+	router.GET("/leaderboard/teams", h.teamLeaderboard)
+
+	router.GET("/seasons", h.listSeasons)
+	router.GET("/seasons/current", h.currentSeason)
+	router.GET("/seasons/history", h.seasonHistory)
+	router.GET("/seasons/:seasonId/results", h.seasonResults)
+	router.POST("/seasons", middleware.RequireRoles("admin"), h.startSeason)
+	router.POST("/seasons/current/end", middleware.RequireRoles("admin"), h.endSeason)
 }
 
 func (h *Handler) getStats(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	userID := c.Param("userID")
 	if userID == "" {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "userID is required")
 		return
 	}
-	stats, err := h.service.GetStats(c.Request.Context(), userID)
+	stats, err := h.service.GetStats(c.Request.Context(), user.OrgID, userID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	if stats == nil {
+		response.ErrorCode(c, http.StatusNotFound, "not_found", "stats not found")
+		return
+	}
+	response.OK(c, stats)
+}
+
+func (h *Handler) updateStreakSettings(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload StreakSettings
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	stats, err := h.service.UpdateStreakSettings(c.Request.Context(), user.OrgID, user.ID, payload)
+	if errors.Is(err, ErrInvalidStreakSettings) {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -46,6 +90,11 @@ func (h *Handler) getStats(c *gin.Context) {
 }
 
 func (h *Handler) listEvents(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	userID := c.Query("userId")
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	if limit <= 0 || limit > 200 {
@@ -57,7 +106,7 @@ func (h *Handler) listEvents(c *gin.Context) {
 			cursorPtr = &ts
 		}
 	}
-	events, nextCursor, err := h.service.ListEvents(c.Request.Context(), userID, limit, cursorPtr)
+	events, nextCursor, err := h.service.ListEvents(c.Request.Context(), user.OrgID, userID, limit, cursorPtr)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -70,22 +119,235 @@ func (h *Handler) listEvents(c *gin.Context) {
 }
 
 func (h *Handler) leaderboard(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-	if limit <= 0 || limit > 200 {
-		limit = 50
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
 	}
-	cursor, _ := strconv.Atoi(c.DefaultQuery("cursor", "0"))
-	if cursor < 0 {
-		cursor = 0
+	filter, err := leaderboardFilter(c, user.OrgID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
 	}
-	rows, err := h.service.Leaderboard(c.Request.Context(), limit, cursor)
+	rows, err := h.service.Leaderboard(c.Request.Context(), filter)
 	if err != nil {
-		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		writeLeaderboardError(c, err)
 		return
 	}
-	meta := gin.H{"limit": limit}
-	if len(rows) == limit {
-		meta["nextCursor"] = cursor + limit
+	meta := gin.H{"limit": filter.Limit, "period": periodOf(filter)}
+	if len(rows) == filter.Limit {
+		meta["nextCursor"] = rows[len(rows)-1].Position
 	}
 	response.WithMeta(c, http.StatusOK, rows, meta)
 }
+
+func (h *Handler) myRank(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	filter, err := leaderboardFilter(c, user.OrgID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	neighbors, _ := strconv.Atoi(c.DefaultQuery("neighbors", "2"))
+	rows, err := h.service.LeaderboardAround(c.Request.Context(), filter, user.ID, neighbors)
+	if err != nil {
+		writeLeaderboardError(c, err)
+		return
+	}
+	meta := gin.H{"period": periodOf(filter)}
+	for _, row := range rows {
+		if row.ID == user.ID {
+			meta["rank"] = row.Rank
+			meta["position"] = row.Position
+		}
+	}
+	response.WithMeta(c, http.StatusOK, rows, meta)
+}
+
+func (h *Handler) teamLeaderboard(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	filter, err := leaderboardFilter(c, user.OrgID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	rows, err := h.service.TeamLeaderboard(c.Request.Context(), filter)
+	if err != nil {
+		writeLeaderboardError(c, err)
+		return
+	}
+	meta := gin.H{"limit": filter.Limit, "period": periodOf(filter)}
+	if len(rows) == filter.Limit {
+		meta["nextCursor"] = rows[len(rows)-1].Position
+	}
+	response.WithMeta(c, http.StatusOK, rows, meta)
+}
+
+// leaderboardFilter reads period, from, to, projectId, teamId, role, limit and
+// cursor. from/to take a date (to is inclusive) or an RFC 3339 timestamp
+// (to is exclusive); giving them without a period means "custom".
+func leaderboardFilter(c *gin.Context, orgID string) (LeaderboardFilter, error) {
+	filter := LeaderboardFilter{
+		OrgID:     orgID,
+		Period:    c.Query("period"),
+		ProjectID: c.Query("projectId"),
+		TeamID:    c.Query("teamId"),
+		Role:      c.Query("role"),
+	}
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+	filter.Cursor, _ = strconv.Atoi(c.DefaultQuery("cursor", "0"))
+	if filter.Cursor < 0 {
+		filter.Cursor = 0
+	}
+	var err error
+	if filter.From, err = parseBound(c.Query("from"), false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseBound(c.Query("to"), true); err != nil {
+		return filter, err
+	}
+	if filter.Period == "" && (filter.From != nil || filter.To != nil) {
+		filter.Period = "custom"
+	}
+	return filter, nil
+}
+
+// parseBound reads a window bound. A date as the end bound covers that whole day.
+func parseBound(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, ErrInvalidWindow
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return &day, nil
+}
+
+// periodOf describes the window for the response meta before the service resolves it.
+func periodOf(filter LeaderboardFilter) string {
+	if filter.Period == "" {
+		return "all"
+	}
+	return filter.Period
+}
+
+func writeLeaderboardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidWindow), errors.Is(err, ErrInvalidSeason):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	case errors.Is(err, ErrNoActiveSeason), errors.Is(err, ErrSeasonNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrSeasonActive):
+		response.ErrorCode(c, http.StatusConflict, "season_active", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+func (h *Handler) listSeasons(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	seasons, err := h.service.ListSeasons(c.Request.Context(), user.OrgID)
+	if err != nil {
+		writeLeaderboardError(c, err)
+		return
+	}
+	response.OK(c, seasons)
+}
+
+func (h *Handler) currentSeason(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	season, err := h.service.CurrentSeason(c.Request.Context(), user.OrgID)
+	if err != nil {
+		writeLeaderboardError(c, err)
+		return
+	}
+	response.OK(c, season)
+}
+
+func (h *Handler) seasonHistory(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	userID := c.DefaultQuery("userId", user.ID)
+	history, err := h.service.SeasonHistory(c.Request.Context(), user.OrgID, userID)
+	if err != nil {
+		writeLeaderboardError(c, err)
+		return
+	}
+	response.OK(c, history)
+}
+
+func (h *Handler) seasonResults(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	results, err := h.service.SeasonResults(c.Request.Context(), user.OrgID, c.Param("seasonId"))
+	if err != nil {
+		writeLeaderboardError(c, err)
+		return
+	}
+	response.OK(c, results)
+}
+
+func (h *Handler) startSeason(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload StartSeasonInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	season, err := h.service.StartSeason(c.Request.Context(), user.OrgID, user.ID, payload)
+	if err != nil {
+		writeLeaderboardError(c, err)
+		return
+	}
+	response.Created(c, season)
+}
+
+func (h *Handler) endSeason(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	season, err := h.service.EndSeason(c.Request.Context(), user.OrgID, user.ID)
+	if err != nil {
+		writeLeaderboardError(c, err)
+		return
+	}
+	response.OK(c, season)
+}
@@ -2,12 +2,14 @@ package gamification
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,13 +22,15 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) GetStats(ctx context.Context, userID string) (*UserStats, error) {
+func (r *Repository) GetStats(ctx context.Context, orgID, userID string) (*UserStats, error) {
 	const query = `
-SELECT user_id, xp_total, level, next_level_threshold, tickets_closed_count, streak_days, COALESCE(last_ticket_closed_at, NOW()) 
-FROM gamification_user_stats
-WHERE user_id = $1`
+SELECT g.user_id, g.xp_total, g.level, g.next_level_threshold, g.tickets_closed_count, g.streak_days, COALESCE(g.last_ticket_closed_at, NOW()),
+       g.streak_through, g.streak_freezes, g.streak_grace_days, g.streak_skip_weekends
+FROM gamification_user_stats g
+JOIN users u ON u.id = g.user_id
+WHERE g.user_id::text = $1 AND u.org_id = $2`
 	var stats UserStats
-	if err := r.db.QueryRow(ctx, query, userID).Scan(
+	if err := r.db.QueryRow(ctx, query, userID, orgID).Scan(
 		&stats.UserID,
 		&stats.XPTotal,
 		&stats.Level,
@@ -34,6 +38,10 @@ WHERE user_id = $1`
 		&stats.TicketsClosed,
 		&stats.StreakDays,
 		&stats.LastTicketClosedAt,
+		&stats.StreakThrough,
+		&stats.StreakFreezes,
+		&stats.StreakSettings.GraceDays,
+		&stats.StreakSettings.SkipWeekends,
 	); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -43,7 +51,7 @@ WHERE user_id = $1`
 	return &stats, nil
 }
 
-func (r *Repository) ListEvents(ctx context.Context, userID string, limit int, cursor *time.Time) ([]XPEvent, *string, error) {
+func (r *Repository) ListEvents(ctx context.Context, orgID, userID string, limit int, cursor *time.Time) ([]XPEvent, *string, error) {
 	if limit <= 0 || limit > 200 {
 		limit = 50
 	}
@@ -54,7 +62,9 @@ func (r *Repository) ListEvents(ctx context.Context, userID string, limit int, c
 	)
 	sb.WriteString(`SELECT id, user_id, ticket_id, priority, xp_value, note, created_at
 FROM xp_events
-WHERE 1=1`)
+WHERE user_id IN (SELECT id FROM users WHERE org_id = $1)`)
+	args = append(args, orgID)
+	idx++
 	if userID != "" {
 		sb.WriteString(fmt.Sprintf(" AND user_id = $%d::uuid", idx))
 		args = append(args, userID)
@@ -115,36 +125,158 @@ func (r *Repository) Adjust(ctx context.Context, input AdjustInput) error {
 		return err
 	}
 	defer tx.Rollback(ctx)
+	if err := adjustTx(ctx, tx, input); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
 
+// adjustTx is Adjust within the caller's transaction. The event is dated
+// input.EarnedAt when set; such a backdated close doesn't extend the streak,
+// which only counts days as they happen.
+func adjustTx(ctx context.Context, tx pgx.Tx, input AdjustInput) error {
+	if input.UserID == "" || input.XP == 0 {
+		return nil
+	}
+	at := time.Now()
+	if input.EarnedAt != nil {
+		at = *input.EarnedAt
+	}
 	const insertEvent = `
-INSERT INTO xp_events (id, user_id, ticket_id, priority, xp_value, note)
-VALUES ($1, $2, $3, $4, $5, $6)`
-	if _, err := tx.Exec(ctx, insertEvent, uuid.NewString(), input.UserID, input.TicketID, input.Priority, input.XP, input.Note); err != nil {
+INSERT INTO xp_events (id, user_id, ticket_id, priority, xp_value, note, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	if _, err := tx.Exec(ctx, insertEvent, uuid.NewString(), input.UserID, input.TicketID, input.Priority, input.XP, input.Note, at); err != nil {
 		return err
 	}
 
 	const upsertStats = `
 INSERT INTO gamification_user_stats (user_id, xp_total, level, next_level_threshold, tickets_closed_count, streak_days, last_ticket_closed_at)
-VALUES ($1, $2, 1, 100, $3, 1, NOW())
+VALUES ($1, $2, 1, 100, $3, 0, $4)
 ON CONFLICT (user_id) DO UPDATE
 SET xp_total = GREATEST(gamification_user_stats.xp_total + EXCLUDED.xp_total, 0),
     tickets_closed_count = GREATEST(gamification_user_stats.tickets_closed_count + EXCLUDED.tickets_closed_count, 0),
     level = FLOOR(GREATEST(gamification_user_stats.xp_total + EXCLUDED.xp_total, 0)/100)::int + 1,
     next_level_threshold = (FLOOR(GREATEST(gamification_user_stats.xp_total + EXCLUDED.xp_total, 0)/100)::int + 1) * 100,
-    streak_days = CASE
-        WHEN EXCLUDED.tickets_closed_count > 0 THEN
-            CASE
-                WHEN gamification_user_stats.last_ticket_closed_at >= CURRENT_DATE THEN gamification_user_stats.streak_days
-                WHEN gamification_user_stats.last_ticket_closed_at = CURRENT_DATE - INTERVAL '1 day' THEN gamification_user_stats.streak_days + 1
-                ELSE 1
-            END
-        ELSE gamification_user_stats.streak_days
-    END,
-    last_ticket_closed_at = CASE WHEN EXCLUDED.tickets_closed_count > 0 THEN NOW() ELSE gamification_user_stats.last_ticket_closed_at END`
-	if _, err := tx.Exec(ctx, upsertStats, input.UserID, input.XP, input.ClosedDelta); err != nil {
+    last_ticket_closed_at = CASE WHEN EXCLUDED.tickets_closed_count > 0
+      THEN GREATEST(gamification_user_stats.last_ticket_closed_at, EXCLUDED.last_ticket_closed_at)
+      ELSE gamification_user_stats.last_ticket_closed_at END`
+	if _, err := tx.Exec(ctx, upsertStats, input.UserID, input.XP, input.ClosedDelta, at); err != nil {
 		return err
 	}
-	return tx.Commit(ctx)
+	if input.ClosedDelta > 0 && input.EarnedAt == nil {
+		return recordStreak(ctx, tx, input.UserID, at)
+	}
+	return nil
+}
+
+const streakColumns = `g.streak_days, g.streak_through, g.streak_freezes, g.streak_grace_days, g.streak_skip_weekends, u.timezone`
+
+func scanStreak(row pgx.Row, dest ...any) (*streak, string, error) {
+	var (
+		st streak
+		tz string
+	)
+	dest = append(dest, &st.Days, &st.Through, &st.Freezes, &st.Settings.GraceDays, &st.Settings.SkipWeekends, &tz)
+	if err := row.Scan(dest...); err != nil {
+		return nil, "", err
+	}
+	return &st, tz, nil
+}
+
+// recordStreak extends userID's streak with the local day now falls on in
+// their timezone.
+func recordStreak(ctx context.Context, tx pgx.Tx, userID string, now time.Time) error {
+	query := `
+SELECT ` + streakColumns + `
+FROM gamification_user_stats g
+JOIN users u ON u.id = g.user_id
+WHERE g.user_id = $1
+FOR UPDATE OF g`
+	st, tz, err := scanStreak(tx.QueryRow(ctx, query, userID))
+	if err != nil {
+		return err
+	}
+	st.record(localDay(now, tz))
+	return saveStreak(ctx, tx, userID, st)
+}
+
+func saveStreak(ctx context.Context, tx pgx.Tx, userID string, st *streak) error {
+	_, err := tx.Exec(ctx, `
+UPDATE gamification_user_stats
+SET streak_days = $2, streak_through = $3, streak_freezes = $4
+WHERE user_id = $1`, userID, st.Days, st.Through, st.Freezes)
+	return err
+}
+
+// ExpireStreaks settles every streak whose owner has missed at least one
+// local day as of now. Streaks locked by a concurrent close are skipped; the
+// close settles them itself.
+func (r *Repository) ExpireStreaks(ctx context.Context, now time.Time) (frozen, broken int, err error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+SELECT g.user_id, ` + streakColumns + `
+FROM gamification_user_stats g
+JOIN users u ON u.id = g.user_id
+WHERE g.streak_days > 0
+  AND g.streak_through < ($1::timestamptz AT TIME ZONE u.timezone)::date - 1
+FOR UPDATE OF g SKIP LOCKED`
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	type candidate struct {
+		userID string
+		state  *streak
+		tz     string
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if c.state, c.tz, err = scanStreak(rows, &c.userID); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	for _, c := range candidates {
+		if !c.state.settle(localDay(now, c.tz)) {
+			continue
+		}
+		if err := saveStreak(ctx, tx, c.userID, c.state); err != nil {
+			return 0, 0, err
+		}
+		if c.state.Days == 0 {
+			broken++
+		} else {
+			frozen++
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, err
+	}
+	return frozen, broken, nil
+}
+
+// UpdateStreakSettings stores userID's streak settings.
+func (r *Repository) UpdateStreakSettings(ctx context.Context, orgID, userID string, settings StreakSettings) error {
+	const query = `
+INSERT INTO gamification_user_stats (user_id, streak_grace_days, streak_skip_weekends)
+SELECT id, $3, $4 FROM users WHERE id::text = $1 AND org_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET streak_grace_days = EXCLUDED.streak_grace_days,
+    streak_skip_weekends = EXCLUDED.streak_skip_weekends`
+	_, err := r.db.Exec(ctx, query, userID, orgID, settings.GraceDays, settings.SkipWeekends)
+	return err
 }
 
 func (r *Repository) EnsureUser(ctx context.Context, userID string) error {
@@ -156,52 +288,172 @@ ON CONFLICT (user_id) DO NOTHING`
 	return err
 }
 
-func (r *Repository) Leaderboard(ctx context.Context, limit int, cursor int) ([]LeaderboardRow, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 50
+// rankedUsers builds a CTE named ranked with every eligible user of the
+// filter, their XP from xp_events inside the window, a dense rank (ties share
+// a rank) and a unique position that orders ties by name.
+func rankedUsers(filter LeaderboardFilter) (string, []any) {
+	var (
+		args = []any{filter.OrgID}
+		idx  = 2
+		xp   strings.Builder
+		who  strings.Builder
+	)
+	xp.WriteString(`
+  SELECT x.user_id, SUM(x.xp_value)::int AS xp
+  FROM xp_events x`)
+	if filter.ProjectID != "" {
+		xp.WriteString(`
+  JOIN tickets t ON t.id = x.ticket_id`)
 	}
-	if cursor < 0 {
-		cursor = 0
+	xp.WriteString(`
+  WHERE true`)
+	if filter.From != nil {
+		xp.WriteString(fmt.Sprintf(" AND x.created_at >= $%d", idx))
+		args = append(args, *filter.From)
+		idx++
 	}
-	const query = `
-SELECT u.id,
-       u.name,
-       u.username,
-       u.role,
-       COALESCE(g.xp_total, 0)   AS xp,
-       COALESCE(g.level, 1)      AS level,
-       COALESCE(g.tickets_closed_count, 0) AS tickets_closed_count
-FROM users u
-LEFT JOIN gamification_user_stats g ON g.user_id = u.id
-ORDER BY COALESCE(g.xp_total, 0) DESC, COALESCE(g.level, 1) DESC
-LIMIT $1 OFFSET $2`
-	rows, err := r.db.Query(ctx, query, limit, cursor)
+	if filter.To != nil {
+		xp.WriteString(fmt.Sprintf(" AND x.created_at < $%d", idx))
+		args = append(args, *filter.To)
+		idx++
+	}
+	if filter.ProjectID != "" {
+		xp.WriteString(fmt.Sprintf(" AND t.project_id::text = $%d", idx))
+		who.WriteString(fmt.Sprintf(" AND EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id::text = $%d AND pm.user_id = u.id)", idx))
+		args = append(args, filter.ProjectID)
+		idx++
+	}
+	xp.WriteString(`
+  GROUP BY x.user_id`)
+	if filter.TeamID != "" {
+		who.WriteString(fmt.Sprintf(" AND EXISTS (SELECT 1 FROM team_members tm WHERE tm.team_id::text = $%d AND tm.user_id = u.id)", idx))
+		args = append(args, filter.TeamID)
+		idx++
+	}
+	if filter.Role != "" {
+		who.WriteString(fmt.Sprintf(" AND u.role::text = $%d", idx))
+		args = append(args, filter.Role)
+	}
+
+	query := `
+WITH xp AS (` + xp.String() + `
+), ranked AS (
+  SELECT u.id,
+         u.name,
+         u.username,
+         u.role,
+         COALESCE(xp.xp, 0) AS xp,
+         COALESCE(g.level, 1) AS level,
+         COALESCE(g.tickets_closed_count, 0) AS tickets_closed_count,
+         DENSE_RANK() OVER (ORDER BY COALESCE(xp.xp, 0) DESC)::int AS rank,
+         ROW_NUMBER() OVER (ORDER BY COALESCE(xp.xp, 0) DESC, u.name, u.id)::int AS position,
+         MAX(COALESCE(xp.xp, 0)) OVER ()::int AS leader_xp
+  FROM users u
+  LEFT JOIN xp ON xp.user_id = u.id
+  LEFT JOIN gamification_user_stats g ON g.user_id = u.id
+  WHERE u.deactivated_at IS NULL AND u.org_id = $1` + who.String() + `
+)`
+	return query, args
+}
+
+// Leaderboard returns the ranked users after the position in filter.Cursor.
+func (r *Repository) Leaderboard(ctx context.Context, filter LeaderboardFilter) ([]LeaderboardRow, error) {
+	query, args := rankedUsers(filter)
+	query += fmt.Sprintf(`
+SELECT id, name, username, role, xp, level, tickets_closed_count, rank, position, leader_xp - xp
+FROM ranked
+WHERE position > $%d
+ORDER BY position
+LIMIT $%d`, len(args)+1, len(args)+2)
+	return r.leaderboardRows(ctx, query, append(args, filter.Cursor, filter.Limit)...)
+}
+
+// LeaderboardAround returns userID's row with up to neighbors rows above and
+// below it, or nothing when the filter leaves the user out.
+func (r *Repository) LeaderboardAround(ctx context.Context, filter LeaderboardFilter, userID string, neighbors int) ([]LeaderboardRow, error) {
+	query, args := rankedUsers(filter)
+	query += fmt.Sprintf(`, me AS (
+  SELECT position FROM ranked WHERE id::text = $%d
+)
+SELECT r.id, r.name, r.username, r.role, r.xp, r.level, r.tickets_closed_count, r.rank, r.position, r.leader_xp - r.xp
+FROM ranked r, me
+WHERE r.position BETWEEN me.position - $%d AND me.position + $%d
+ORDER BY r.position`, len(args)+1, len(args)+2, len(args)+2)
+	return r.leaderboardRows(ctx, query, append(args, userID, neighbors)...)
+}
+
+func (r *Repository) leaderboardRows(ctx context.Context, query string, args ...any) ([]LeaderboardRow, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var rowsOut []LeaderboardRow
-	position := 1
-	var leaderXP int
-
+	rowsOut := []LeaderboardRow{}
 	for rows.Next() {
 		var row LeaderboardRow
-		if err := rows.Scan(&row.ID, &row.Name, &row.Username, &row.Role, &row.XP, &row.Level, &row.TicketsClosedCount); err != nil {
+		if err := rows.Scan(&row.ID, &row.Name, &row.Username, &row.Role, &row.XP, &row.Level, &row.TicketsClosedCount, &row.Rank, &row.Position, &row.XPGap); err != nil {
 			return nil, err
 		}
-		row.Rank = position
-		if position == 1 {
-			leaderXP = row.XP
-			row.XPGap = 0
-		} else {
-			row.XPGap = leaderXP - row.XP
-			if row.XPGap < 0 {
-				row.XPGap = 0
-			}
+		rowsOut = append(rowsOut, row)
+	}
+	return rowsOut, rows.Err()
+}
+
+// TeamLeaderboard ranks the teams of an organization by the XP their members
+// earned inside the window while on the team.
+func (r *Repository) TeamLeaderboard(ctx context.Context, filter LeaderboardFilter) ([]TeamLeaderboardRow, error) {
+	var (
+		args = []any{filter.OrgID}
+		idx  = 2
+		join strings.Builder
+	)
+	if filter.From != nil {
+		join.WriteString(fmt.Sprintf(" AND x.created_at >= $%d", idx))
+		args = append(args, *filter.From)
+		idx++
+	}
+	if filter.To != nil {
+		join.WriteString(fmt.Sprintf(" AND x.created_at < $%d", idx))
+		args = append(args, *filter.To)
+		idx++
+	}
+	query := `
+WITH ranked AS (
+  SELECT t.id,
+         t.name,
+         (SELECT COUNT(*) FROM team_members m WHERE m.team_id = t.id)::int AS member_count,
+         COALESCE(SUM(x.xp_value), 0)::int AS xp
+  FROM teams t
+  LEFT JOIN team_members tm ON tm.team_id = t.id
+  LEFT JOIN xp_events x ON x.user_id = tm.user_id AND x.created_at >= tm.joined_at` + join.String() + `
+  WHERE t.org_id = $1
+  GROUP BY t.id, t.name
+), positioned AS (
+  SELECT *,
+         DENSE_RANK() OVER (ORDER BY xp DESC)::int AS rank,
+         ROW_NUMBER() OVER (ORDER BY xp DESC, name, id)::int AS position,
+         MAX(xp) OVER ()::int AS leader_xp
+  FROM ranked
+)
+SELECT id, name, member_count, xp, rank, position, leader_xp - xp
+FROM positioned
+WHERE position > $` + fmt.Sprint(idx) + `
+ORDER BY position
+LIMIT $` + fmt.Sprint(idx+1)
+	rows, err := r.db.Query(ctx, query, append(args, filter.Cursor, filter.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rowsOut := []TeamLeaderboardRow{}
+	for rows.Next() {
+		var row TeamLeaderboardRow
+		if err := rows.Scan(&row.ID, &row.Name, &row.MemberCount, &row.XP, &row.Rank, &row.Position, &row.XPGap); err != nil {
+			return nil, err
 		}
 		rowsOut = append(rowsOut, row)
-		position++
 	}
 	return rowsOut, rows.Err()
 }
@@ -248,3 +500,181 @@ SET tickets_closed_count = EXCLUDED.tickets_closed_count,
 	_, err := r.db.Exec(ctx, query)
 	return err
 }
+
+const seasonQuery = `
+SELECT s.id, s.name, s.starts_at, s.ends_at, s.ended_at, s.ended_at IS NULL,
+       (SELECT COUNT(*) FROM season_results r WHERE r.season_id = s.id)::int,
+       s.created_at
+FROM seasons s
+WHERE s.org_id = $1`
+
+func scanSeason(row pgx.Row) (*Season, error) {
+	var s Season
+	if err := row.Scan(&s.ID, &s.Name, &s.StartsAt, &s.EndsAt, &s.EndedAt, &s.Active, &s.Participants, &s.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ActiveSeason returns the running season of an organization, if any.
+func (r *Repository) ActiveSeason(ctx context.Context, orgID string) (*Season, error) {
+	return scanSeason(r.db.QueryRow(ctx, seasonQuery+` AND s.ended_at IS NULL`, orgID))
+}
+
+func (r *Repository) GetSeason(ctx context.Context, orgID, id string) (*Season, error) {
+	return scanSeason(r.db.QueryRow(ctx, seasonQuery+` AND s.id::text = $2`, orgID, id))
+}
+
+// ListSeasons returns an organization's seasons, newest first.
+func (r *Repository) ListSeasons(ctx context.Context, orgID string) ([]Season, error) {
+	rows, err := r.db.Query(ctx, seasonQuery+` ORDER BY s.starts_at DESC`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seasons := []Season{}
+	for rows.Next() {
+		s, err := scanSeason(rows)
+		if err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, *s)
+	}
+	return seasons, rows.Err()
+}
+
+var errSeasonActive = errors.New("a season is already running")
+
+// StartSeason opens a season starting now. Only one season per organization
+// can run at a time.
+func (r *Repository) StartSeason(ctx context.Context, orgID, actorID string, input StartSeasonInput) (*Season, error) {
+	const query = `
+INSERT INTO seasons (org_id, name, ends_at, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, starts_at, ends_at, ended_at, true, 0, created_at`
+	s, err := scanSeason(r.db.QueryRow(ctx, query, orgID, input.Name, input.EndsAt, actorID))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, errSeasonActive
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// DueSeasons returns the running seasons whose planned end has passed at now.
+func (r *Repository) DueSeasons(ctx context.Context, now time.Time) ([]DueSeason, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT org_id::text, id::text FROM seasons
+WHERE ended_at IS NULL AND ends_at <= $1
+ORDER BY ends_at`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	due := []DueSeason{}
+	for rows.Next() {
+		var d DueSeason
+		if err := rows.Scan(&d.OrgID, &d.ID); err != nil {
+			return nil, err
+		}
+		due = append(due, d)
+	}
+	return due, rows.Err()
+}
+
+// EndSeason closes a running season and archives its final standings: every
+// active user with seasonal XP gets a season_results row, and the top three
+// ranks get a badge that is also added to users.badges. A season past its
+// planned end is closed at ends_at, so XP earned after it does not count.
+func (r *Repository) EndSeason(ctx context.Context, orgID, id string) (*Season, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var startsAt, endedAt time.Time
+	err = tx.QueryRow(ctx, `
+UPDATE seasons SET ended_at = LEAST(NOW(), COALESCE(ends_at, NOW()))
+WHERE id = $1 AND org_id = $2 AND ended_at IS NULL
+RETURNING starts_at, ended_at`, id, orgID).Scan(&startsAt, &endedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ranked, args := rankedUsers(LeaderboardFilter{OrgID: orgID, From: &startsAt, To: &endedAt})
+	archive := ranked + fmt.Sprintf(`
+INSERT INTO season_results (season_id, user_id, rank, position, xp, level, badge)
+SELECT $%d, id, rank, position, xp, FLOOR(xp / 100)::int + 1,
+       CASE rank WHEN 1 THEN 'gold' WHEN 2 THEN 'silver' WHEN 3 THEN 'bronze' END
+FROM ranked
+WHERE xp > 0`, len(args)+1)
+	if _, err := tx.Exec(ctx, archive, append(args, id)...); err != nil {
+		return nil, err
+	}
+	const badges = `
+UPDATE users u
+SET badges = array_append(u.badges, s.name || ' ' || r.badge)
+FROM season_results r
+JOIN seasons s ON s.id = r.season_id
+WHERE r.season_id = $1 AND r.user_id = u.id AND r.badge IS NOT NULL`
+	if _, err := tx.Exec(ctx, badges, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.GetSeason(ctx, orgID, id)
+}
+
+const seasonResultQuery = `
+SELECT r.season_id, s.name, r.user_id, u.name, u.username, r.rank, r.position, r.xp, r.level, r.badge, s.ended_at
+FROM season_results r
+JOIN seasons s ON s.id = r.season_id
+JOIN users u ON u.id = r.user_id
+WHERE s.org_id = $1`
+
+// SeasonResults returns the archived standings of a season by position.
+func (r *Repository) SeasonResults(ctx context.Context, orgID, seasonID string) ([]SeasonResult, error) {
+	return r.seasonResults(ctx, seasonResultQuery+` AND r.season_id = $2 ORDER BY r.position`, orgID, seasonID)
+}
+
+// UserSeasonHistory returns a user's placements across ended seasons, newest first.
+func (r *Repository) UserSeasonHistory(ctx context.Context, orgID, userID string) ([]SeasonResult, error) {
+	return r.seasonResults(ctx, seasonResultQuery+` AND r.user_id::text = $2 ORDER BY s.ended_at DESC`, orgID, userID)
+}
+
+func (r *Repository) seasonResults(ctx context.Context, query string, args ...any) ([]SeasonResult, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SeasonResult{}
+	for rows.Next() {
+		var res SeasonResult
+		if err := rows.Scan(&res.SeasonID, &res.SeasonName, &res.UserID, &res.Name, &res.Username, &res.Rank, &res.Position, &res.XP, &res.Level, &res.Badge, &res.EndedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// SeasonXP sums a user's XP events since a season started.
+func (r *Repository) SeasonXP(ctx context.Context, userID string, since time.Time) (int, error) {
+	var xp int
+	err := r.db.QueryRow(ctx, `SELECT COALESCE(SUM(xp_value), 0)::int FROM xp_events WHERE user_id::text = $1 AND created_at >= $2`, userID, since).Scan(&xp)
+	return xp, err
+}
@@ -0,0 +1,154 @@
+package gamification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSeasonActive   = errSeasonActive
+	ErrNoActiveSeason = errors.New("no season is running")
+	ErrSeasonNotFound = errors.New("season not found")
+	ErrInvalidSeason  = errors.New("name must be between 2 and 80 characters and endsAt in the future")
+)
+
+// CurrentSeason returns the running season, or ErrNoActiveSeason.
+func (s *Service) CurrentSeason(ctx context.Context, orgID string) (*Season, error) {
+	season, err := s.repo.ActiveSeason(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, ErrNoActiveSeason
+	}
+	return season, nil
+}
+
+// ListSeasons returns every season of the organization, newest first.
+func (s *Service) ListSeasons(ctx context.Context, orgID string) ([]Season, error) {
+	return s.repo.ListSeasons(ctx, orgID)
+}
+
+// StartSeason begins a season now. Seasonal XP and level count from here on;
+// lifetime XP is untouched.
+func (s *Service) StartSeason(ctx context.Context, orgID, actorID string, input StartSeasonInput) (*Season, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if len(input.Name) < 2 || len(input.Name) > 80 {
+		return nil, ErrInvalidSeason
+	}
+	if input.EndsAt != nil && !input.EndsAt.After(time.Now()) {
+		return nil, ErrInvalidSeason
+	}
+	season, err := s.repo.StartSeason(ctx, orgID, actorID, input)
+	if err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("started season %s", season.Name)
+		entityType := "season"
+		_ = s.audit.Log(ctx, "season_started", desc, &actorID, &entityType, &season.ID)
+	}
+	return season, nil
+}
+
+// EndSeason ends the running season and archives its final standings.
+func (s *Service) EndSeason(ctx context.Context, orgID, actorID string) (*Season, error) {
+	current, err := s.CurrentSeason(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	season, err := s.repo.EndSeason(ctx, orgID, current.ID)
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, ErrNoActiveSeason
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("ended season %s with %d ranked users", season.Name, season.Participants)
+		entityType := "season"
+		_ = s.audit.Log(ctx, "season_ended", desc, &actorID, &entityType, &season.ID)
+	}
+	return season, nil
+}
+
+// EndDueSeasons ends every running season whose endsAt has passed and
+// archives its standings as of endsAt. It returns how many seasons ended.
+func (s *Service) EndDueSeasons(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.DueSeasons(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	ended := 0
+	for _, d := range due {
+		season, err := s.repo.EndSeason(ctx, d.OrgID, d.ID)
+		if err != nil {
+			return ended, err
+		}
+		if season == nil {
+			// an admin ended it in the meantime
+			continue
+		}
+		ended++
+		if s.audit != nil {
+			desc := fmt.Sprintf("season %s reached its end with %d ranked users", season.Name, season.Participants)
+			entityType := "season"
+			_ = s.audit.LogSystem(ctx, d.OrgID, "season_ended", desc, &entityType, &season.ID)
+		}
+	}
+	return ended, nil
+}
+
+// SeasonResults returns the archived standings of an ended season.
+func (s *Service) SeasonResults(ctx context.Context, orgID, seasonID string) ([]SeasonResult, error) {
+	season, err := s.repo.GetSeason(ctx, orgID, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, ErrSeasonNotFound
+	}
+	return s.repo.SeasonResults(ctx, orgID, season.ID)
+}
+
+// SeasonHistory returns a user's placements in past seasons.
+func (s *Service) SeasonHistory(ctx context.Context, orgID, userID string) ([]SeasonResult, error) {
+	return s.repo.UserSeasonHistory(ctx, orgID, userID)
+}
+
+// seasonWindow bounds a "season" leaderboard to the running season.
+func (s *Service) seasonWindow(ctx context.Context, filter *LeaderboardFilter) error {
+	if filter.Period != "season" {
+		return nil
+	}
+	season, err := s.CurrentSeason(ctx, filter.OrgID)
+	if err != nil {
+		return err
+	}
+	filter.From, filter.To = &season.StartsAt, nil
+	return nil
+}
+
+// seasonStats is the user's seasonal XP and level, or nil outside a season.
+func (s *Service) seasonStats(ctx context.Context, orgID, userID string) (*SeasonStats, error) {
+	season, err := s.repo.ActiveSeason(ctx, orgID)
+	if err != nil || season == nil {
+		return nil, err
+	}
+	xp, err := s.repo.SeasonXP(ctx, userID, season.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+	return &SeasonStats{SeasonID: season.ID, Name: season.Name, XP: xp, Level: levelFor(xp)}, nil
+}
+
+// levelFor mirrors the lifetime level formula: a level every 100 XP.
+func levelFor(xp int) int {
+	if xp < 0 {
+		xp = 0
+	}
+	return xp/100 + 1
+}
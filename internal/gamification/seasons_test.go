@@ -0,0 +1,90 @@
+package gamification_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/database/dbtest"
+	"backend-go-ticketing-gamify/internal/gamification"
+)
+
+func TestEndDueSeasons(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	auditSvc := audit.NewService(audit.NewRepository(db))
+	svc := gamification.NewService(gamification.NewRepository(db), auditSvc)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	orgID := dbtest.Org(t, db)
+	adminID := dbtest.User(t, db, orgID, "admin")
+	userID := dbtest.User(t, db, orgID, "developer")
+	endsAt := now.Add(time.Hour)
+	season, err := svc.StartSeason(ctx, orgID, adminID, gamification.StartSeasonInput{Name: "Q1", EndsAt: &endsAt})
+	if err != nil {
+		t.Fatalf("StartSeason: %v", err)
+	}
+	// move the season into the past: it ended an hour ago
+	endsAt = now.Add(-time.Hour)
+	dbtest.Exec(t, db, `UPDATE seasons SET starts_at = $2, ends_at = $3 WHERE id = $1`, season.ID, now.Add(-72*time.Hour), endsAt)
+	dbtest.Exec(t, db, `INSERT INTO xp_events (user_id, xp_value, created_at) VALUES ($1, 50, $2), ($1, 70, $3)`,
+		userID, now.Add(-48*time.Hour), now.Add(-30*time.Minute))
+
+	// a season without a planned end keeps running
+	otherOrg := dbtest.Org(t, db)
+	open, err := svc.StartSeason(ctx, otherOrg, dbtest.User(t, db, otherOrg, "admin"), gamification.StartSeasonInput{Name: "Open"})
+	if err != nil {
+		t.Fatalf("StartSeason without endsAt: %v", err)
+	}
+
+	n, err := svc.EndDueSeasons(ctx, now)
+	if err != nil {
+		t.Fatalf("EndDueSeasons: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("ended %d seasons, want 1", n)
+	}
+
+	if _, err := svc.CurrentSeason(ctx, orgID); !errors.Is(err, gamification.ErrNoActiveSeason) {
+		t.Fatalf("CurrentSeason err = %v, want ErrNoActiveSeason", err)
+	}
+	if cur, err := svc.CurrentSeason(ctx, otherOrg); err != nil || cur.ID != open.ID {
+		t.Fatalf("the season without endsAt: %+v, %v; want it running", cur, err)
+	}
+	seasons, err := svc.ListSeasons(ctx, orgID)
+	if err != nil || len(seasons) != 1 {
+		t.Fatalf("ListSeasons = %+v, %v", seasons, err)
+	}
+	if seasons[0].EndedAt == nil || !seasons[0].EndedAt.Equal(endsAt) {
+		t.Errorf("endedAt = %v, want endsAt %s", seasons[0].EndedAt, endsAt)
+	}
+
+	// XP earned after endsAt does not count
+	results, err := svc.SeasonResults(ctx, orgID, season.ID)
+	if err != nil {
+		t.Fatalf("SeasonResults: %v", err)
+	}
+	if len(results) != 1 || results[0].UserID != userID || results[0].XP != 50 {
+		t.Fatalf("results = %+v, want %s with 50 XP", results, userID)
+	}
+
+	entries, _, err := auditSvc.List(ctx, orgID, 50, nil)
+	if err != nil {
+		t.Fatalf("audit list: %v", err)
+	}
+	logged := false
+	for _, e := range entries {
+		if e.Action == "season_ended" && e.EntityID != nil && *e.EntityID == season.ID {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Errorf("season_ended is not in the organization's audit log")
+	}
+
+	if n, err := svc.EndDueSeasons(ctx, now); err != nil || n != 0 {
+		t.Fatalf("second run ended %d, %v; want none", n, err)
+	}
+}
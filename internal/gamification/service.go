@@ -2,9 +2,17 @@ package gamification
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"github.com/jackc/pgx/v5"
 )
 
+var ErrInvalidWindow = errors.New("period must be week, month, quarter, season, all or custom with from before to")
+
+// LeaderboardRow is a user's standing. Users with the same XP share a rank;
+// position breaks ties by name and is what cursors refer to.
 type LeaderboardRow struct {
 	ID                 string `json:"id"`
 	Name               string `json:"name"`
@@ -14,27 +22,48 @@ type LeaderboardRow struct {
 	Level              int    `json:"level"`
 	TicketsClosedCount int    `json:"ticketsClosedCount"`
 	Rank               int    `json:"rank"`
+	Position           int    `json:"position"`
 	XPGap              int    `json:"xpGap"`
 }
 
+// TeamLeaderboardRow is a team's position on the team leaderboard.
+type TeamLeaderboardRow struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	MemberCount int    `json:"memberCount"`
+	XP          int    `json:"xp"`
+	Rank        int    `json:"rank"`
+	Position    int    `json:"position"`
+	XPGap       int    `json:"xpGap"`
+}
+
 // Service exposes business logic for gamification.
 type Service struct {
-	repo *Repository
+	repo  *Repository
+	audit *audit.Service
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *Repository, auditSvc *audit.Service) *Service {
+	return &Service{repo: repo, audit: auditSvc}
 }
 
-func (s *Service) GetStats(ctx context.Context, userID string) (*UserStats, error) {
-	return s.repo.GetStats(ctx, userID)
+// GetStats returns lifetime stats plus the standing in the running season.
+func (s *Service) GetStats(ctx context.Context, orgID, userID string) (*UserStats, error) {
+	stats, err := s.repo.GetStats(ctx, orgID, userID)
+	if err != nil || stats == nil {
+		return stats, err
+	}
+	if stats.Season, err = s.seasonStats(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+	return stats, nil
 }
 
-func (s *Service) ListEvents(ctx context.Context, userID string, limit int, cursor *time.Time) ([]XPEvent, *string, error) {
+func (s *Service) ListEvents(ctx context.Context, orgID, userID string, limit int, cursor *time.Time) ([]XPEvent, *string, error) {
 	if limit <= 0 || limit > 200 {
 		limit = 50
 	}
-	return s.repo.ListEvents(ctx, userID, limit, cursor)
+	return s.repo.ListEvents(ctx, orgID, userID, limit, cursor)
 }
 
 func (s *Service) AwardXP(ctx context.Context, input AwardInput) error {
@@ -48,6 +77,7 @@ func (s *Service) AwardXP(ctx context.Context, input AwardInput) error {
 		XP:          input.XP,
 		Note:        input.Note,
 		ClosedDelta: 1,
+		EarnedAt:    input.EarnedAt,
 	})
 }
 
@@ -59,6 +89,12 @@ func (s *Service) AdjustXP(ctx context.Context, input AdjustInput) error {
 	return s.repo.Adjust(ctx, input)
 }
 
+// AdjustXPTx is AdjustXP within the caller's transaction, for writes that
+// must commit or roll back together with the XP they earn.
+func (s *Service) AdjustXPTx(ctx context.Context, tx pgx.Tx, input AdjustInput) error {
+	return adjustTx(ctx, tx, input)
+}
+
 func (s *Service) EnsureUser(ctx context.Context, userID string) error {
 	if userID == "" {
 		return nil
@@ -66,10 +102,89 @@ func (s *Service) EnsureUser(ctx context.Context, userID string) error {
 	return s.repo.EnsureUser(ctx, userID)
 }
 
-func (s *Service) Leaderboard(ctx context.Context, limit int, cursor int) ([]LeaderboardRow, error) {
+// Leaderboard ranks users by the XP they earned in the filter's window.
+func (s *Service) Leaderboard(ctx context.Context, filter LeaderboardFilter) ([]LeaderboardRow, error) {
+	if err := s.seasonWindow(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := resolveWindow(&filter, time.Now()); err != nil {
+		return nil, err
+	}
 	// ensure closed counts are in sync with latest tickets
 	_ = s.repo.RefreshAllClosedCounts(ctx)
-	return s.repo.Leaderboard(ctx, limit, cursor)
+	return s.repo.Leaderboard(ctx, normalizePage(filter))
+}
+
+// LeaderboardAround returns userID's standing with up to neighbors users
+// ranked directly above and below.
+func (s *Service) LeaderboardAround(ctx context.Context, filter LeaderboardFilter, userID string, neighbors int) ([]LeaderboardRow, error) {
+	if err := s.seasonWindow(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := resolveWindow(&filter, time.Now()); err != nil {
+		return nil, err
+	}
+	if neighbors < 0 || neighbors > 10 {
+		neighbors = 2
+	}
+	return s.repo.LeaderboardAround(ctx, filter, userID, neighbors)
+}
+
+// TeamLeaderboard ranks the organization's teams by the XP of their members.
+func (s *Service) TeamLeaderboard(ctx context.Context, filter LeaderboardFilter) ([]TeamLeaderboardRow, error) {
+	if err := s.seasonWindow(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := resolveWindow(&filter, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.repo.TeamLeaderboard(ctx, normalizePage(filter))
+}
+
+// resolveWindow turns filter.Period into From/To. Weeks start on Monday and
+// quarters on January, April, July and October; "custom" keeps the given
+// bounds, "season" the running season's start and "all" (the default) has none.
+func resolveWindow(filter *LeaderboardFilter, now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var from time.Time
+	switch filter.Period {
+	case "", "all":
+		filter.Period = "all"
+		filter.From, filter.To = nil, nil
+		return nil
+	case "season":
+		return nil
+	case "custom":
+		if filter.From == nil || filter.To == nil || !filter.From.Before(*filter.To) {
+			return ErrInvalidWindow
+		}
+		return nil
+	case "week":
+		weekday := int(today.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		from = today.AddDate(0, 0, 1-weekday)
+	case "month":
+		from = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	case "quarter":
+		month := time.Month((int(today.Month())-1)/3*3 + 1)
+		from = time.Date(today.Year(), month, 1, 0, 0, 0, 0, today.Location())
+	default:
+		return ErrInvalidWindow
+	}
+	filter.From, filter.To = &from, nil
+	return nil
+}
+
+func normalizePage(filter LeaderboardFilter) LeaderboardFilter {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+	if filter.Cursor < 0 {
+		filter.Cursor = 0
+	}
+	return filter
 }
 
 // RefreshClosedCount recomputes closed ticket count from tickets table for accuracy.
@@ -0,0 +1,117 @@
+package gamification
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// freezeEvery is how many streak days earn one streak freeze.
+	freezeEvery = 7
+	// maxFreezes caps how many unused freezes a user can hold.
+	maxFreezes = 2
+	// maxGraceDays caps how many missed days a streak may tolerate for free.
+	maxGraceDays = 2
+)
+
+var ErrInvalidStreakSettings = errors.New("graceDays must be between 0 and 2")
+
+// StreakSettings are a user's choices for how strict their streak is.
+// Grace days may be missed without breaking it; with SkipWeekends only
+// Monday to Friday have to be worked.
+type StreakSettings struct {
+	GraceDays    int  `json:"graceDays"`
+	SkipWeekends bool `json:"skipWeekends"`
+}
+
+// streak is the streak state kept in gamification_user_stats. Through is the
+// last local day the streak covers, as a date at midnight UTC.
+type streak struct {
+	Days     int
+	Through  *time.Time
+	Freezes  int
+	Settings StreakSettings
+}
+
+// localDay returns the calendar day now falls on in the IANA zone tz, as a
+// date at midnight UTC. Unknown zones count as UTC.
+func localDay(now time.Time, tz string) time.Time {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	y, m, d := now.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// settle applies the days missed before today: grace days are free, each
+// further missed day spends a freeze, and without enough freezes the streak
+// is broken. It reports whether anything changed.
+func (s *streak) settle(today time.Time) bool {
+	if s.Days == 0 || s.Through == nil {
+		return false
+	}
+	budget := s.Settings.GraceDays + s.Freezes
+	var missed []time.Time
+	for d := s.Through.AddDate(0, 0, 1); d.Before(today); d = d.AddDate(0, 0, 1) {
+		if s.Settings.SkipWeekends && (d.Weekday() == time.Saturday || d.Weekday() == time.Sunday) {
+			continue
+		}
+		missed = append(missed, d)
+		if len(missed) > budget {
+			break
+		}
+	}
+	if len(missed) <= s.Settings.GraceDays {
+		return false
+	}
+	if len(missed) > budget {
+		s.Days, s.Through = 0, nil
+		return true
+	}
+	// freezes cover the earliest missed days so the grace days stay ahead
+	spent := len(missed) - s.Settings.GraceDays
+	s.Freezes -= spent
+	through := missed[spent-1]
+	s.Through = &through
+	return true
+}
+
+// record counts a ticket closed on day, earning a freeze every freezeEvery
+// streak days. A streak without a Through day can't be placed and starts
+// over.
+func (s *streak) record(day time.Time) {
+	s.settle(day)
+	switch {
+	case s.Days == 0 || s.Through == nil:
+		s.Days = 1
+	case day.After(*s.Through):
+		s.Days++
+	default:
+		return
+	}
+	s.Through = &day
+	if s.Days%freezeEvery == 0 && s.Freezes < maxFreezes {
+		s.Freezes++
+	}
+}
+
+// UpdateStreakSettings changes how strict userID's streak is. The new
+// settings apply from the next check on.
+func (s *Service) UpdateStreakSettings(ctx context.Context, orgID, userID string, settings StreakSettings) (*UserStats, error) {
+	if settings.GraceDays < 0 || settings.GraceDays > maxGraceDays {
+		return nil, ErrInvalidStreakSettings
+	}
+	if err := s.repo.UpdateStreakSettings(ctx, orgID, userID, settings); err != nil {
+		return nil, err
+	}
+	return s.GetStats(ctx, orgID, userID)
+}
+
+// ExpireStreaks settles every running streak that missed a day in its
+// owner's timezone, spending freezes or breaking it. It is meant to run at
+// least hourly so each timezone's midnight is seen soon after it passes.
+func (s *Service) ExpireStreaks(ctx context.Context) (frozen, broken int, err error) {
+	return s.repo.ExpireStreaks(ctx, time.Now())
+}
@@ -0,0 +1,49 @@
+package gamification
+
+import (
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func dayPtr(s string) *time.Time {
+	d := day(s)
+	return &d
+}
+
+func TestStreakRecord(t *testing.T) {
+	cases := []struct {
+		name        string
+		state       streak
+		closed      string
+		wantDays    int
+		wantThrough string
+		wantFreezes int
+	}{
+		{"first close", streak{}, "2026-03-10", 1, "2026-03-10", 0},
+		{"next day", streak{Days: 3, Through: dayPtr("2026-03-09")}, "2026-03-10", 4, "2026-03-10", 0},
+		{"same day", streak{Days: 3, Through: dayPtr("2026-03-10")}, "2026-03-10", 3, "2026-03-10", 0},
+		{"missed a day", streak{Days: 3, Through: dayPtr("2026-03-08")}, "2026-03-10", 1, "2026-03-10", 0},
+		{"missed a day with a freeze", streak{Days: 3, Through: dayPtr("2026-03-08"), Freezes: 1}, "2026-03-10", 4, "2026-03-10", 0},
+		{"seventh day earns a freeze", streak{Days: 6, Through: dayPtr("2026-03-09")}, "2026-03-10", 7, "2026-03-10", 1},
+		// streaks from before streak_through existed
+		{"running streak without a day", streak{Days: 5}, "2026-03-10", 1, "2026-03-10", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := tc.state
+			st.record(day(tc.closed))
+			if st.Days != tc.wantDays || st.Through == nil || !st.Through.Equal(day(tc.wantThrough)) || st.Freezes != tc.wantFreezes {
+				t.Fatalf("streak = %d days through %v with %d freezes, want %d through %s with %d",
+					st.Days, st.Through, st.Freezes, tc.wantDays, tc.wantThrough, tc.wantFreezes)
+			}
+		})
+	}
+}
@@ -11,6 +11,13 @@ type UserStats struct {
 	TicketsClosed      int       `json:"ticketsClosed"`
 	StreakDays         int       `json:"streakDays"`
 	LastTicketClosedAt time.Time `json:"lastTicketClosedAt"`
+	// StreakThrough is the last day, in the user's timezone, the streak
+	// covers; StreakFreezes are earned freezes that cover a missed day each.
+	StreakThrough  *time.Time     `json:"streakThrough"`
+	StreakFreezes  int            `json:"streakFreezes"`
+	StreakSettings StreakSettings `json:"streakSettings"`
+	// Season is set while a season is running.
+	Season *SeasonStats `json:"season,omitempty"`
 }
 
 // XPEvent describes XP award events.
@@ -24,13 +31,16 @@ type XPEvent struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-// AwardInput parameters for awarding xp.
+// AwardInput parameters for awarding xp. EarnedAt backdates the award to
+// when the work was done, such as the closing time of an imported ticket;
+// nil means now.
 type AwardInput struct {
 	UserID   string
 	TicketID string
 	Priority string
 	XP       int
 	Note     string
+	EarnedAt *time.Time
 }
 
 // AdjustInput allows positive (award) or negative (rollback) XP.
@@ -41,4 +51,70 @@ type AdjustInput struct {
 	XP          int
 	Note        string
 	ClosedDelta int
+	EarnedAt    *time.Time
+}
+
+// LeaderboardFilter selects whose XP a leaderboard ranks. From and To bound
+// xp_events.created_at (To is exclusive); nil leaves that side open. Cursor is
+// the position of the last row already seen.
+type LeaderboardFilter struct {
+	OrgID     string
+	Period    string
+	From      *time.Time
+	To        *time.Time
+	ProjectID string
+	TeamID    string
+	Role      string
+	Limit     int
+	Cursor    int
+}
+
+// Season is a period with its own XP and level. EndsAt is the planned end;
+// EndedAt is set once an admin or the season-end job ends it and its
+// standings are archived.
+type Season struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	StartsAt     time.Time  `json:"startsAt"`
+	EndsAt       *time.Time `json:"endsAt,omitempty"`
+	EndedAt      *time.Time `json:"endedAt,omitempty"`
+	Active       bool       `json:"active"`
+	Participants int        `json:"participants"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// SeasonResult is a user's archived final standing in a season. Badge is
+// gold, silver or bronze for the top three ranks.
+type SeasonResult struct {
+	SeasonID   string    `json:"seasonId"`
+	SeasonName string    `json:"seasonName"`
+	UserID     string    `json:"userId"`
+	Name       string    `json:"name"`
+	Username   string    `json:"username"`
+	Rank       int       `json:"rank"`
+	Position   int       `json:"position"`
+	XP         int       `json:"xp"`
+	Level      int       `json:"level"`
+	Badge      *string   `json:"badge,omitempty"`
+	EndedAt    time.Time `json:"endedAt"`
+}
+
+// DueSeason is a running season whose planned end has passed.
+type DueSeason struct {
+	OrgID string
+	ID    string
+}
+
+// StartSeasonInput is the payload to start a season.
+type StartSeasonInput struct {
+	Name   string     `json:"name"`
+	EndsAt *time.Time `json:"endsAt"`
+}
+
+// SeasonStats is a user's standing in the running season.
+type SeasonStats struct {
+	SeasonID string `json:"seasonId"`
+	Name     string `json:"name"`
+	XP       int    `json:"xp"`
+	Level    int    `json:"level"`
 }
@@ -0,0 +1,218 @@
+package imports
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvFields are the ticket fields a CSV column can be mapped to, in the
+// order the preview lists them.
+var csvFields = []string{"externalId", "title", "description", "status", "priority", "type", "epic", "assignee", "reporter", "startDate", "dueDate", "createdAt", "closedAt"}
+
+// csvAliases are header names, lower-cased, that suggest a field.
+var csvAliases = map[string][]string{
+	"externalId":  {"id", "key", "issue key", "issue id", "external id", "number", "#"},
+	"title":       {"title", "summary", "subject", "name"},
+	"description": {"description", "body", "details"},
+	"status":      {"status", "state"},
+	"priority":    {"priority"},
+	"type":        {"type", "issue type", "kind"},
+	"epic":        {"epic", "epic name", "epic link", "milestone"},
+	"assignee":    {"assignee", "assigned to", "owner"},
+	"reporter":    {"reporter", "author", "created by", "creator"},
+	"startDate":   {"start date", "start", "started"},
+	"dueDate":     {"due date", "due", "deadline"},
+	"createdAt":   {"created", "created at", "created date"},
+	"closedAt":    {"closed", "closed at", "resolved", "resolution date", "resolved at"},
+}
+
+// previewRows is how many rows a preview shows.
+const previewRows = 5
+
+func newCSVReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+func readHeader(reader *csv.Reader) ([]string, error) {
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, invalid("the file is empty")
+	}
+	if err != nil {
+		return nil, invalid("invalid CSV: %v", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+	return header, nil
+}
+
+// PreviewCSV reads a CSV's header and first rows and suggests a mapping.
+func PreviewCSV(r io.Reader) (*Preview, error) {
+	reader := newCSVReader(r)
+	header, err := readHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	preview := &Preview{Headers: header, Sample: [][]string{}, Suggested: map[string]string{}, Fields: csvFields}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, invalid("invalid CSV: %v", err)
+		}
+		preview.Rows++
+		if len(preview.Sample) < previewRows {
+			preview.Sample = append(preview.Sample, record)
+		}
+	}
+	for _, field := range csvFields {
+		for _, col := range header {
+			if aliasOf(field, col) {
+				preview.Suggested[field] = col
+				break
+			}
+		}
+	}
+	return preview, nil
+}
+
+func aliasOf(field, header string) bool {
+	h := strings.ToLower(header)
+	if h == strings.ToLower(field) {
+		return true
+	}
+	for _, alias := range csvAliases[field] {
+		if h == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCSV reads tickets from a CSV using the mapping's columns. Epics are
+// referenced by title and created when the project has none of that name.
+func parseCSV(r io.Reader, mapping Mapping) (*Batch, error) {
+	reader := newCSVReader(r)
+	header, err := readHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, col := range header {
+		index[col] = i
+	}
+	columns := map[string]int{}
+	for field, col := range mapping.Columns {
+		if col == "" {
+			continue
+		}
+		if !contains(csvFields, field) {
+			return nil, invalid("unknown field %q in mapping", field)
+		}
+		i, ok := index[col]
+		if !ok {
+			return nil, invalid("mapped column %q is not in the file", col)
+		}
+		columns[field] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, invalid("the mapping must name the title column")
+	}
+
+	batch := &Batch{}
+	epics := map[string]bool{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, invalid("invalid CSV: %v", err)
+		}
+		if len(batch.Tickets) == MaxTickets {
+			return nil, invalid("an import may contain at most %d tickets", MaxTickets)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		t := Ticket{
+			ExternalID:  field("externalId"),
+			Title:       field("title"),
+			Description: field("description"),
+			Status:      field("status"),
+			Priority:    field("priority"),
+			Type:        field("type"),
+			Assignee:    personFrom(field("assignee")),
+			Reporter:    personFrom(field("reporter")),
+			Line:        line,
+		}
+		t.Errors = parseDates(mapping.DateFormat,
+			dateField{field("startDate"), &t.StartDate},
+			dateField{field("dueDate"), &t.DueDate},
+			dateField{field("createdAt"), &t.CreatedAt},
+			dateField{field("closedAt"), &t.ClosedAt})
+		if t.ExternalID == "" {
+			// without an id column, re-runs recognize a row by its content
+			sum := sha256.Sum256([]byte(t.Title + "\x00" + field("createdAt") + "\x00" + t.Description))
+			t.ExternalID = "row-" + hex.EncodeToString(sum[:8])
+		}
+		if epic := field("epic"); epic != "" {
+			t.EpicRef = strings.ToLower(epic)
+			if !epics[t.EpicRef] {
+				epics[t.EpicRef] = true
+				batch.Epics = append(batch.Epics, Epic{ExternalID: t.EpicRef, Title: epic, Line: line})
+			}
+		}
+		batch.Tickets = append(batch.Tickets, t)
+	}
+	if len(batch.Tickets) == 0 {
+		return nil, invalid("the file has no tickets")
+	}
+	return batch, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportError is a problem with the file as a whole, as opposed to one of
+// its records.
+type ImportError struct {
+	msg string
+	err error
+}
+
+func (e *ImportError) Error() string { return e.msg }
+
+// Unwrap exposes the error that caused it, such as the upload being too large.
+func (e *ImportError) Unwrap() error { return e.err }
+
+func invalid(format string, args ...any) error {
+	e := &ImportError{msg: fmt.Sprintf(format, args...)}
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			e.err = err
+		}
+	}
+	return e
+}
@@ -0,0 +1,158 @@
+package imports
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	const file = "\ufeffKey, Summary,State,Prio,Kind,Epic,Owner,Created,Resolved\n" +
+		"T-1,Login page,Done,High,Bug,Auth,ana@example.com,2026-01-05,2026-01-09T15:04:05Z\n" +
+		"T-2,Signup,In Progress,,Story,auth,bo.chan,2026-01-06 10:30,\n" +
+		"T-3,Billing,Open,P1,Task,Billing,Carla Diaz,05/01/2026,\n"
+	mapping := Mapping{Columns: map[string]string{
+		"externalId": "Key", "title": "Summary", "status": "State", "priority": "Prio", "type": "Kind",
+		"epic": "Epic", "assignee": "Owner", "createdAt": "Created", "closedAt": "Resolved",
+	}}
+
+	batch, err := parseCSV(strings.NewReader(file), mapping)
+	if err != nil {
+		t.Fatalf("parseCSV: %v", err)
+	}
+	if len(batch.Tickets) != 3 {
+		t.Fatalf("got %d tickets, want 3", len(batch.Tickets))
+	}
+
+	first := batch.Tickets[0]
+	if first.ExternalID != "T-1" || first.Title != "Login page" || first.Status != "Done" || first.Priority != "High" || first.Type != "Bug" {
+		t.Errorf("first ticket = %+v", first)
+	}
+	if first.Line != 2 {
+		t.Errorf("first ticket line = %d, want 2", first.Line)
+	}
+	if first.Assignee == nil || first.Assignee.Key != "email:ana@example.com" {
+		t.Errorf("first assignee = %+v, want ana by email", first.Assignee)
+	}
+	if want := time.Date(2026, 1, 9, 15, 4, 5, 0, time.UTC); first.ClosedAt == nil || !first.ClosedAt.Equal(want) {
+		t.Errorf("first closedAt = %v, want %s", first.ClosedAt, want)
+	}
+
+	second := batch.Tickets[1]
+	if second.Assignee == nil || second.Assignee.Key != "username:bo.chan" {
+		t.Errorf("second assignee = %+v, want bo.chan by username", second.Assignee)
+	}
+	if want := time.Date(2026, 1, 6, 10, 30, 0, 0, time.UTC); second.CreatedAt == nil || !second.CreatedAt.Equal(want) || second.ClosedAt != nil {
+		t.Errorf("second dates = %v, %v; want created %s and not closed", second.CreatedAt, second.ClosedAt, want)
+	}
+
+	// 05/01/2026 is not ISO 8601 and no date format is mapped
+	third := batch.Tickets[2]
+	if len(third.Errors) != 1 || !strings.Contains(third.Errors[0], "05/01/2026") {
+		t.Errorf("third errors = %v, want the unreadable date", third.Errors)
+	}
+
+	// epics are matched by title regardless of case and listed once
+	var titles []string
+	for _, e := range batch.Epics {
+		titles = append(titles, e.Title)
+	}
+	if !slices.Equal(titles, []string{"Auth", "Billing"}) {
+		t.Errorf("epics = %v, want Auth and Billing", titles)
+	}
+	if first.EpicRef != "auth" || second.EpicRef != "auth" {
+		t.Errorf("epic refs = %q, %q; want both auth", first.EpicRef, second.EpicRef)
+	}
+}
+
+func TestParseCSVDateFormat(t *testing.T) {
+	const file = "title,due\nShip it,31/12/2026\n"
+	batch, err := parseCSV(strings.NewReader(file), Mapping{
+		Columns:    map[string]string{"title": "title", "dueDate": "due"},
+		DateFormat: "02/01/2006",
+	})
+	if err != nil {
+		t.Fatalf("parseCSV: %v", err)
+	}
+	got := batch.Tickets[0]
+	if want := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC); len(got.Errors) != 0 || got.DueDate == nil || !got.DueDate.Equal(want) {
+		t.Fatalf("due = %v, errors %v; want %s", got.DueDate, got.Errors, want)
+	}
+}
+
+func TestParseCSVExternalIDWithoutColumn(t *testing.T) {
+	const file = "title,description,created\nA,first,2026-01-01\nA,second,2026-01-01\nA,first,2026-01-01\n"
+	mapping := Mapping{Columns: map[string]string{"title": "title", "description": "description", "createdAt": "created"}}
+	parse := func() []Ticket {
+		batch, err := parseCSV(strings.NewReader(file), mapping)
+		if err != nil {
+			t.Fatalf("parseCSV: %v", err)
+		}
+		return batch.Tickets
+	}
+	first, again := parse(), parse()
+	for i := range first {
+		if !strings.HasPrefix(first[i].ExternalID, "row-") || first[i].ExternalID != again[i].ExternalID {
+			t.Fatalf("row %d external ids %q and %q, want the same row- id on every run", i, first[i].ExternalID, again[i].ExternalID)
+		}
+	}
+	if first[0].ExternalID == first[1].ExternalID {
+		t.Errorf("rows with different content share external id %q", first[0].ExternalID)
+	}
+	if first[0].ExternalID != first[2].ExternalID {
+		t.Errorf("identical rows got external ids %q and %q", first[0].ExternalID, first[2].ExternalID)
+	}
+}
+
+func TestParseCSVRejectsFile(t *testing.T) {
+	cases := []struct {
+		name    string
+		file    string
+		columns map[string]string
+		want    string
+	}{
+		{"empty", "", map[string]string{"title": "title"}, "the file is empty"},
+		{"header only", "title\n", map[string]string{"title": "title"}, "no tickets"},
+		{"no title mapping", "title\nA\n", map[string]string{"status": "title"}, "title column"},
+		{"unknown field", "title\nA\n", map[string]string{"title": "title", "points": "title"}, `unknown field "points"`},
+		{"missing column", "title\nA\n", map[string]string{"title": "Summary"}, `"Summary" is not in the file`},
+		{"broken quotes", "title\n\"A\n", map[string]string{"title": "title"}, "invalid CSV"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseCSV(strings.NewReader(tc.file), Mapping{Columns: tc.columns})
+			var importErr *ImportError
+			if !errors.As(err, &importErr) || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err = %v, want an ImportError about %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestPreviewCSV(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("Issue key,Summary,Status,Assigned To,Resolution Date,Points\n")
+	for i := 0; i < 7; i++ {
+		sb.WriteString("K,S,Open,ana,2026-01-01,3\n")
+	}
+	preview, err := PreviewCSV(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("PreviewCSV: %v", err)
+	}
+	if preview.Rows != 7 || len(preview.Sample) != previewRows {
+		t.Errorf("rows = %d with %d sampled, want 7 with %d", preview.Rows, len(preview.Sample), previewRows)
+	}
+	want := map[string]string{
+		"externalId": "Issue key", "title": "Summary", "status": "Status", "assignee": "Assigned To", "closedAt": "Resolution Date",
+	}
+	if len(preview.Suggested) != len(want) {
+		t.Errorf("suggested = %v, want %v", preview.Suggested, want)
+	}
+	for field, col := range want {
+		if preview.Suggested[field] != col {
+			t.Errorf("suggested %s = %q, want %q", field, preview.Suggested[field], col)
+		}
+	}
+}
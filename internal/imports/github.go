@@ -0,0 +1,161 @@
+package imports
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubIssue struct {
+	Number      int              `json:"number"`
+	Title       string           `json:"title"`
+	Body        string           `json:"body"`
+	State       string           `json:"state"`
+	Labels      []githubLabel    `json:"labels"`
+	Assignee    *githubUser      `json:"assignee"`
+	User        *githubUser      `json:"user"`
+	CreatedAt   string           `json:"created_at"`
+	ClosedAt    string           `json:"closed_at"`
+	Milestone   *githubMilestone `json:"milestone"`
+	PullRequest json.RawMessage  `json:"pull_request"`
+}
+
+// githubLabel accepts both label objects and plain label names.
+type githubLabel string
+
+func (l *githubLabel) UnmarshalJSON(data []byte) error {
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		*l = githubLabel(name)
+		return nil
+	}
+	var obj struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*l = githubLabel(obj.Name)
+	return nil
+}
+
+type githubMilestone struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	DueOn       string `json:"due_on"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type githubComment struct {
+	ID          int64       `json:"id"`
+	IssueNumber int         `json:"issue_number"`
+	IssueURL    string      `json:"issue_url"`
+	User        *githubUser `json:"user"`
+	Body        string      `json:"body"`
+	CreatedAt   string      `json:"created_at"`
+}
+
+// parseGitHub reads GitHub issues as the REST API returns them: a bare array
+// of issues, or {"issues": [...], "comments": [...]} with the repository's
+// issue comments. Milestones become epics, pull requests are skipped, and
+// labels that name a status, priority or type set it.
+func parseGitHub(r io.Reader, mapping Mapping) (*Batch, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, invalid("invalid JSON: %v", err)
+	}
+	var export struct {
+		Issues   []githubIssue   `json:"issues"`
+		Comments []githubComment `json:"comments"`
+	}
+	if err := json.Unmarshal(raw, &export.Issues); err != nil {
+		if err := json.Unmarshal(raw, &export); err != nil || export.Issues == nil {
+			return nil, invalid("expected a JSON array of issues or an object with \"issues\"")
+		}
+	}
+	if len(export.Issues) > MaxTickets {
+		return nil, invalid("an import may contain at most %d tickets", MaxTickets)
+	}
+
+	comments := map[int][]githubComment{}
+	for _, c := range export.Comments {
+		number := c.IssueNumber
+		if number == 0 {
+			// issue_url ends in /issues/<number>
+			number, _ = strconv.Atoi(c.IssueURL[strings.LastIndex(c.IssueURL, "/")+1:])
+		}
+		comments[number] = append(comments[number], c)
+	}
+
+	batch := &Batch{}
+	milestones := map[int]bool{}
+	for _, issue := range export.Issues {
+		if len(issue.PullRequest) > 0 && string(issue.PullRequest) != "null" {
+			continue
+		}
+		t := Ticket{
+			ExternalID:  "#" + strconv.Itoa(issue.Number),
+			Title:       issue.Title,
+			Description: issue.Body,
+			Status:      "todo",
+			Assignee:    githubPerson(issue.Assignee),
+			Reporter:    githubPerson(issue.User),
+		}
+		if strings.EqualFold(issue.State, "closed") {
+			t.Status = "done"
+		}
+		for _, label := range issue.Labels {
+			name := string(label)
+			// "priority: high", "type/bug" and the like
+			if i := strings.IndexAny(name, ":/"); i >= 0 {
+				name = strings.TrimSpace(name[i+1:])
+			}
+			if v, ok := mapping.known("priority", name); ok {
+				t.Priority = v
+			} else if v, ok := mapping.known("type", name); ok {
+				t.Type = v
+			} else if v, ok := mapping.known("status", name); ok && t.Status != "done" {
+				t.Status = v
+			}
+		}
+		t.Errors = parseDates("", dateField{issue.CreatedAt, &t.CreatedAt}, dateField{issue.ClosedAt, &t.ClosedAt})
+		if m := issue.Milestone; m != nil {
+			t.EpicRef = milestoneID(m.Number)
+			if !milestones[m.Number] {
+				milestones[m.Number] = true
+				e := Epic{ExternalID: t.EpicRef, Title: m.Title, Description: m.Description, Status: "in_progress"}
+				if strings.EqualFold(m.State, "closed") {
+					e.Status = "done"
+				}
+				e.Errors = parseDates("", dateField{m.DueOn, &e.DueDate}, dateField{m.CreatedAt, &e.CreatedAt})
+				batch.Epics = append(batch.Epics, e)
+			}
+		}
+		for _, c := range comments[issue.Number] {
+			comment := Comment{ExternalID: fmt.Sprintf("comment-%d", c.ID), Author: githubPerson(c.User), Body: c.Body}
+			t.Errors = append(t.Errors, parseDates("", dateField{c.CreatedAt, &comment.CreatedAt})...)
+			t.Comments = append(t.Comments, comment)
+		}
+		batch.Tickets = append(batch.Tickets, t)
+	}
+	return batch, nil
+}
+
+func milestoneID(number int) string {
+	return "milestone-" + strconv.Itoa(number)
+}
+
+func githubPerson(u *githubUser) *Person {
+	if u == nil || u.Login == "" {
+		return nil
+	}
+	return &Person{Key: "github:" + strings.ToLower(u.Login), Username: u.Login}
+}
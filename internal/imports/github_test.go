@@ -0,0 +1,81 @@
+package imports
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const githubExport = `{"issues": [
+  {"number": 1, "title": "Crash on start", "body": "Stack trace", "state": "closed",
+   "labels": [{"name": "type: bug"}, {"name": "priority/high"}, {"name": "in progress"}],
+   "assignee": {"login": "Ana-Lee"}, "user": {"login": "bo"},
+   "created_at": "2026-02-01T08:00:00Z", "closed_at": "2026-02-03T17:00:00Z",
+   "milestone": {"number": 4, "title": "v1.0", "state": "open", "due_on": "2026-03-01T00:00:00Z"}},
+  {"number": 2, "title": "Add dark mode", "state": "open", "labels": ["enhancement", "review", "good first issue"],
+   "created_at": "2026-02-02T08:00:00Z", "milestone": {"number": 4, "title": "v1.0", "state": "open"}},
+  {"number": 3, "title": "Bump deps", "state": "open", "pull_request": {"url": "https://example.com/pulls/3"}}
+],
+"comments": [
+  {"id": 900, "issue_url": "https://api.github.com/repos/acme/app/issues/1", "user": {"login": "carla"}, "body": "Fixed in #3", "created_at": "2026-02-03T16:00:00Z"},
+  {"id": 901, "issue_number": 2, "user": {"login": "ana-lee"}, "body": "+1", "created_at": "not a date"}
+]}`
+
+func TestParseGitHub(t *testing.T) {
+	batch, err := parseGitHub(strings.NewReader(githubExport), Mapping{})
+	if err != nil {
+		t.Fatalf("parseGitHub: %v", err)
+	}
+	if len(batch.Tickets) != 2 {
+		t.Fatalf("got %d tickets, want 2 (the pull request skipped)", len(batch.Tickets))
+	}
+	if len(batch.Epics) != 1 || batch.Epics[0].ExternalID != "milestone-4" || batch.Epics[0].Title != "v1.0" || batch.Epics[0].Status != "in_progress" {
+		t.Fatalf("epics = %+v, want milestone v1.0 once", batch.Epics)
+	}
+
+	crash := batch.Tickets[0]
+	// a closed issue stays done whatever its labels say
+	if crash.ExternalID != "#1" || crash.Status != "done" || crash.Type != "bug" || crash.Priority != "high" || crash.EpicRef != "milestone-4" {
+		t.Errorf("crash = %+v", crash)
+	}
+	if crash.Assignee == nil || crash.Assignee.Key != "github:ana-lee" || crash.Assignee.Username != "Ana-Lee" {
+		t.Errorf("assignee = %+v", crash.Assignee)
+	}
+	if want := time.Date(2026, 2, 3, 17, 0, 0, 0, time.UTC); crash.ClosedAt == nil || !crash.ClosedAt.Equal(want) {
+		t.Errorf("closedAt = %v, want %s", crash.ClosedAt, want)
+	}
+	if len(crash.Comments) != 1 || crash.Comments[0].ExternalID != "comment-900" || crash.Comments[0].Author.Key != "github:carla" {
+		t.Errorf("comments = %+v, want comment-900 by carla", crash.Comments)
+	}
+
+	dark := batch.Tickets[1]
+	if dark.Status != "review" || dark.Type != "feature" || dark.Priority != "" {
+		t.Errorf("dark mode = %+v, want an in-review feature with the default priority", dark)
+	}
+	if len(dark.Comments) != 1 || len(dark.Errors) != 1 || !strings.Contains(dark.Errors[0], "not a date") {
+		t.Errorf("dark mode comments %+v, errors %v; want the comment and its bad date", dark.Comments, dark.Errors)
+	}
+}
+
+func TestParseGitHubMappedLabels(t *testing.T) {
+	const body = `[{"number": 7, "title": "Slow page", "state": "open", "labels": ["sev-1", "perf"]}]`
+	mapping := Mapping{Values: map[string]map[string]string{
+		"priority": {"sev-1": "urgent"},
+		"type":     {"perf": "chore"},
+	}}
+	batch, err := parseGitHub(strings.NewReader(body), mapping)
+	if err != nil {
+		t.Fatalf("parseGitHub: %v", err)
+	}
+	if got := batch.Tickets[0]; got.Status != "todo" || got.Priority != "urgent" || got.Type != "chore" {
+		t.Fatalf("ticket = %+v, want an urgent chore to do", got)
+	}
+}
+
+func TestParseGitHubRejectsFile(t *testing.T) {
+	for _, body := range []string{`{"comments": []}`, `"issues"`, `{`} {
+		if _, err := parseGitHub(strings.NewReader(body), Mapping{}); err == nil {
+			t.Errorf("parseGitHub(%s) succeeded, want an error", body)
+		}
+	}
+}
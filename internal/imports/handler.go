@@ -0,0 +1,143 @@
+package imports
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/response"
+)
+
+// maxImportBytes bounds an uploaded export.
+const maxImportBytes = 20 << 20
+
+// Handler provides HTTP endpoints.
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("", h.importTickets)
+	router.POST("/preview", h.preview)
+}
+
+// upload returns the export, sent either as a multipart "file" field or as
+// the raw body, and the mapping, from a multipart "mapping" field or the
+// query. The caller closes the file.
+func upload(c *gin.Context) (io.ReadCloser, Mapping, error) {
+	var mapping Mapping
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	body := c.Request.Body
+	raw := c.Query("mapping")
+	if mediaType, _, _ := mime.ParseMediaType(c.ContentType()); mediaType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, mapping, err
+			}
+			return nil, mapping, invalid("a file up to 20MB is required in the \"file\" field")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, mapping, invalid("%v", err)
+		}
+		body = file
+		if field := c.PostForm("mapping"); field != "" {
+			raw = field
+		}
+	}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			body.Close()
+			return nil, mapping, invalid("invalid mapping: %v", err)
+		}
+	}
+	return body, mapping, nil
+}
+
+// preview shows a CSV's columns and first rows with a suggested mapping.
+func (h *Handler) preview(c *gin.Context) {
+	if middleware.CurrentUser(c) == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	body, _, err := upload(c)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	defer body.Close()
+	preview, err := h.service.Preview(body)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	response.OK(c, preview)
+}
+
+// importTickets runs an import into the project given by projectId.
+func (h *Handler) importTickets(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	awardXP, _ := strconv.ParseBool(c.DefaultQuery("awardXp", "false"))
+	body, mapping, err := upload(c)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	defer body.Close()
+
+	opts := Options{
+		ProjectID: c.Query("projectId"),
+		Source:    c.DefaultQuery("source", SourceCSV),
+		Mapping:   mapping,
+		DryRun:    dryRun,
+		AwardXP:   awardXP,
+	}
+	actor := Actor{ID: user.ID, OrgID: user.OrgID, Name: user.Name}
+	report, err := h.service.Import(c.Request.Context(), actor, opts, body)
+	if err != nil {
+		writeError(c, err, report)
+		return
+	}
+	if dryRun {
+		response.OK(c, report)
+		return
+	}
+	response.Created(c, report)
+}
+
+func writeError(c *gin.Context, err error, report *Report) {
+	var (
+		tooLarge  *http.MaxBytesError
+		importErr *ImportError
+	)
+	switch {
+	case errors.Is(err, ErrImportRejected):
+		response.ErrorCodeDetails(c, http.StatusUnprocessableEntity, "import_rejected", err.Error(), report)
+	case errors.Is(err, ErrProjectNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.As(err, &tooLarge):
+		response.ErrorCode(c, http.StatusRequestEntityTooLarge, "file_too_large", fmt.Sprintf("the file may be at most %dMB", maxImportBytes>>20))
+	case errors.As(err, &importErr):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -0,0 +1,194 @@
+package imports
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type jiraUser struct {
+	AccountID    string `json:"accountId"`
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type jiraNamed struct {
+	Name string `json:"name"`
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary        string          `json:"summary"`
+		Description    json.RawMessage `json:"description"`
+		Status         *jiraNamed      `json:"status"`
+		Priority       *jiraNamed      `json:"priority"`
+		IssueType      *jiraNamed      `json:"issuetype"`
+		Assignee       *jiraUser       `json:"assignee"`
+		Reporter       *jiraUser       `json:"reporter"`
+		Created        string          `json:"created"`
+		ResolutionDate string          `json:"resolutiondate"`
+		DueDate        string          `json:"duedate"`
+		Parent         *struct {
+			Key string `json:"key"`
+		} `json:"parent"`
+		// the "Epic Link" field of company-managed projects
+		EpicLink string `json:"customfield_10014"`
+		Comment  *struct {
+			Comments []struct {
+				ID      string          `json:"id"`
+				Author  *jiraUser       `json:"author"`
+				Body    json.RawMessage `json:"body"`
+				Created string          `json:"created"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+// parseJira reads a Jira export: the JSON of the issue search API, either
+// {"issues": [...]} or a bare array. Epic issues become epics; other issues
+// link to them through their parent or Epic Link.
+func parseJira(r io.Reader) (*Batch, error) {
+	issues, err := decodeList[jiraIssue](r, "issues")
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) > MaxTickets {
+		return nil, invalid("an import may contain at most %d tickets", MaxTickets)
+	}
+
+	batch := &Batch{}
+	epics := map[string]bool{}
+	for _, issue := range issues {
+		if issue.Fields.IssueType != nil && strings.EqualFold(issue.Fields.IssueType.Name, "epic") {
+			epics[issue.Key] = true
+		}
+	}
+	for _, issue := range issues {
+		f := issue.Fields
+		if epics[issue.Key] {
+			e := Epic{
+				ExternalID:  issue.Key,
+				Title:       f.Summary,
+				Description: jiraText(f.Description),
+				Status:      jiraName(f.Status),
+			}
+			e.Errors = parseDates("", dateField{f.Created, &e.CreatedAt}, dateField{f.DueDate, &e.DueDate})
+			batch.Epics = append(batch.Epics, e)
+			continue
+		}
+		t := Ticket{
+			ExternalID:  issue.Key,
+			Title:       f.Summary,
+			Description: jiraText(f.Description),
+			Status:      jiraName(f.Status),
+			Priority:    jiraName(f.Priority),
+			Type:        jiraName(f.IssueType),
+			Assignee:    jiraPerson(f.Assignee),
+			Reporter:    jiraPerson(f.Reporter),
+		}
+		t.Errors = parseDates("", dateField{f.Created, &t.CreatedAt}, dateField{f.ResolutionDate, &t.ClosedAt}, dateField{f.DueDate, &t.DueDate})
+		switch {
+		case f.Parent != nil && epics[f.Parent.Key]:
+			t.EpicRef = f.Parent.Key
+		case f.EpicLink != "":
+			t.EpicRef = f.EpicLink
+		}
+		if f.Comment != nil {
+			for _, c := range f.Comment.Comments {
+				comment := Comment{ExternalID: issue.Key + "/" + c.ID, Author: jiraPerson(c.Author), Body: jiraText(c.Body)}
+				t.Errors = append(t.Errors, parseDates("", dateField{c.Created, &comment.CreatedAt})...)
+				t.Comments = append(t.Comments, comment)
+			}
+		}
+		batch.Tickets = append(batch.Tickets, t)
+	}
+	return batch, nil
+}
+
+func jiraName(n *jiraNamed) string {
+	if n == nil {
+		return ""
+	}
+	return n.Name
+}
+
+func jiraPerson(u *jiraUser) *Person {
+	if u == nil {
+		return nil
+	}
+	p := &Person{Name: u.DisplayName, Email: u.EmailAddress, Username: u.Name}
+	switch {
+	case u.EmailAddress != "":
+		p.Key = "email:" + strings.ToLower(u.EmailAddress)
+	case u.AccountID != "":
+		p.Key = "jira:" + u.AccountID
+	case u.Name != "":
+		p.Key = "username:" + strings.ToLower(u.Name)
+	default:
+		return nil
+	}
+	return p
+}
+
+// jiraText reads a description or comment body: plain text in API v2,
+// an Atlassian Document Format tree in v3.
+func jiraText(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var doc any
+	if json.Unmarshal(raw, &doc) != nil {
+		return ""
+	}
+	var sb strings.Builder
+	adfText(&sb, doc)
+	return strings.TrimSpace(sb.String())
+}
+
+// adfText appends the text of a document node, ending blocks with newlines.
+func adfText(sb *strings.Builder, node any) {
+	n, ok := node.(map[string]any)
+	if !ok {
+		return
+	}
+	if text, ok := n["text"].(string); ok {
+		sb.WriteString(text)
+	}
+	if n["type"] == "hardBreak" {
+		sb.WriteByte('\n')
+	}
+	children, _ := n["content"].([]any)
+	for _, child := range children {
+		adfText(sb, child)
+	}
+	switch n["type"] {
+	case "paragraph", "heading", "listItem", "codeBlock", "blockquote":
+		sb.WriteByte('\n')
+	}
+}
+
+// decodeList reads a JSON array, or an object holding it under key.
+func decodeList[T any](r io.Reader, key string) ([]T, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, invalid("invalid JSON: %v", err)
+	}
+	var list []T
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &wrapped); err != nil || wrapped[key] == nil {
+		return nil, invalid("expected a JSON array or an object with %q", key)
+	}
+	if err := json.Unmarshal(wrapped[key], &list); err != nil {
+		return nil, invalid("invalid %s: %v", key, err)
+	}
+	return list, nil
+}
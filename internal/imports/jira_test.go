@@ -0,0 +1,104 @@
+package imports
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const jiraExport = `{"issues": [
+  {"key": "APP-1", "fields": {
+    "summary": "Accounts", "issuetype": {"name": "Epic"}, "status": {"name": "In Progress"},
+    "description": "Everything about accounts", "created": "2026-01-02T09:00:00.000+0000", "duedate": "2026-03-31"}},
+  {"key": "APP-2", "fields": {
+    "summary": "Login fails", "issuetype": {"name": "Bug"}, "status": {"name": "Done"}, "priority": {"name": "Highest"},
+    "description": {"type": "doc", "content": [
+      {"type": "paragraph", "content": [{"type": "text", "text": "Steps:"}, {"type": "hardBreak"}, {"type": "text", "text": "open /login"}]},
+      {"type": "paragraph", "content": [{"type": "text", "text": "It fails."}]}]},
+    "assignee": {"accountId": "5b10", "displayName": "Ana Lee", "emailAddress": "Ana@Example.com"},
+    "reporter": {"accountId": "5b11", "displayName": "Bo Chan"},
+    "created": "2026-01-03T10:00:00.000+0100", "resolutiondate": "2026-01-04T18:30:00.000+0100",
+    "parent": {"key": "APP-1"},
+    "comment": {"comments": [
+      {"id": "100", "author": {"name": "carla", "displayName": "Carla Diaz"}, "body": "Reproduced", "created": "2026-01-03T12:00:00.000+0100"}]}}},
+  {"key": "APP-3", "fields": {
+    "summary": "Profile page", "issuetype": {"name": "Story"}, "status": {"name": "To Do"},
+    "customfield_10014": "APP-9", "created": "yesterday"}}
+]}`
+
+func TestParseJira(t *testing.T) {
+	batch, err := parseJira(strings.NewReader(jiraExport))
+	if err != nil {
+		t.Fatalf("parseJira: %v", err)
+	}
+	if len(batch.Epics) != 1 || len(batch.Tickets) != 2 {
+		t.Fatalf("got %d epics and %d tickets, want 1 and 2", len(batch.Epics), len(batch.Tickets))
+	}
+
+	epic := batch.Epics[0]
+	if epic.ExternalID != "APP-1" || epic.Title != "Accounts" || epic.Status != "In Progress" || epic.Description != "Everything about accounts" {
+		t.Errorf("epic = %+v", epic)
+	}
+	if want := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC); epic.DueDate == nil || !epic.DueDate.Equal(want) {
+		t.Errorf("epic due = %v, want %s", epic.DueDate, want)
+	}
+
+	bug := batch.Tickets[0]
+	if bug.ExternalID != "APP-2" || bug.Status != "Done" || bug.Priority != "Highest" || bug.Type != "Bug" || bug.EpicRef != "APP-1" {
+		t.Errorf("bug = %+v", bug)
+	}
+	if want := "Steps:\nopen /login\nIt fails."; bug.Description != want {
+		t.Errorf("description = %q, want %q", bug.Description, want)
+	}
+	if bug.Assignee == nil || bug.Assignee.Key != "email:ana@example.com" || bug.Assignee.Name != "Ana Lee" {
+		t.Errorf("assignee = %+v, want Ana by email", bug.Assignee)
+	}
+	if bug.Reporter == nil || bug.Reporter.Key != "jira:5b11" {
+		t.Errorf("reporter = %+v, want Bo by account id", bug.Reporter)
+	}
+	if want := time.Date(2026, 1, 4, 17, 30, 0, 0, time.UTC); bug.ClosedAt == nil || !bug.ClosedAt.Equal(want) {
+		t.Errorf("closedAt = %v, want %s", bug.ClosedAt, want)
+	}
+	if len(bug.Comments) != 1 {
+		t.Fatalf("comments = %+v, want 1", bug.Comments)
+	}
+	if c := bug.Comments[0]; c.ExternalID != "APP-2/100" || c.Body != "Reproduced" || c.Author == nil || c.Author.Key != "username:carla" {
+		t.Errorf("comment = %+v", c)
+	}
+
+	story := batch.Tickets[1]
+	if story.EpicRef != "APP-9" {
+		t.Errorf("story epic = %q, want the Epic Link APP-9", story.EpicRef)
+	}
+	if len(story.Errors) != 1 || !strings.Contains(story.Errors[0], "yesterday") {
+		t.Errorf("story errors = %v, want the unreadable created date", story.Errors)
+	}
+}
+
+func TestParseJiraShapes(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    string
+		tickets int
+		err     string
+	}{
+		{"bare array", `[{"key": "A-1", "fields": {"summary": "x"}}]`, 1, ""},
+		{"wrapped", `{"issues": [{"key": "A-1", "fields": {"summary": "x"}}], "total": 1}`, 1, ""},
+		{"no issues", `{"total": 0}`, 0, `object with "issues"`},
+		{"not JSON", `issues`, 0, "invalid JSON"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			batch, err := parseJira(strings.NewReader(tc.body))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil || len(batch.Tickets) != tc.tickets {
+				t.Fatalf("parseJira = %+v, %v; want %d tickets", batch, err, tc.tickets)
+			}
+		})
+	}
+}
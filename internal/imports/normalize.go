@@ -0,0 +1,165 @@
+package imports
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Common names other trackers use, lower-cased, for our statuses, priorities
+// and types. A Mapping's Values take precedence.
+var (
+	statusNames = map[string]string{
+		"todo": "todo", "to do": "todo", "open": "todo", "new": "todo", "selected for development": "todo", "reopened": "todo",
+		"in_progress": "in_progress", "in progress": "in_progress", "doing": "in_progress", "started": "in_progress", "active": "in_progress",
+		"review": "review", "in review": "review", "code review": "review", "qa": "review", "testing": "review",
+		"done": "done", "closed": "done", "resolved": "done", "complete": "done", "completed": "done", "fixed": "done",
+		"backlog": "backlog", "icebox": "backlog",
+	}
+	priorityNames = map[string]string{
+		"urgent": "urgent", "highest": "urgent", "blocker": "urgent", "critical": "urgent", "p0": "urgent",
+		"high": "high", "major": "high", "p1": "high",
+		"medium": "medium", "normal": "medium", "p2": "medium",
+		"low": "low", "lowest": "low", "minor": "low", "trivial": "low", "p3": "low", "p4": "low",
+	}
+	typeNames = map[string]string{
+		"feature": "feature", "story": "feature", "new feature": "feature", "improvement": "feature", "enhancement": "feature", "epic": "feature",
+		"bug": "bug", "defect": "bug", "incident": "bug",
+		"chore": "chore", "task": "chore", "sub-task": "chore", "subtask": "chore", "maintenance": "chore", "documentation": "chore",
+	}
+	fieldDefaults = map[string]string{"status": "todo", "priority": "medium", "type": "feature"}
+	fieldNames    = map[string]map[string]string{"status": statusNames, "priority": priorityNames, "type": typeNames}
+)
+
+// normalize translates a source value of field (status, priority or type) to
+// ours. Empty values get the default.
+func (m Mapping) normalize(field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fieldDefaults[field], nil
+	}
+	if v, ok := m.Values[field][value]; ok {
+		value = v
+	}
+	if v, ok := fieldNames[field][strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown %s %q; add it to the mapping's values", field, value)
+}
+
+// known reports whether value is a recognized value of field, for sources
+// like GitHub labels where most values mean something else.
+func (m Mapping) known(field, value string) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+	v, err := m.normalize(field, value)
+	return v, err == nil
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05.000-0700", // Jira
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseDate reads a date in the layout given, or else in an ISO 8601 form.
+// Empty values are nil.
+func parseDate(value, layout string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	layouts := dateLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot read date %q", value)
+}
+
+// dateField is a date value and where its parsed time goes.
+type dateField struct {
+	value string
+	dst   **time.Time
+}
+
+// parseDates parses each field into its destination and returns the
+// problems, so one record can report all its bad dates at once.
+func parseDates(layout string, fields ...dateField) []string {
+	var problems []string
+	for _, f := range fields {
+		t, err := parseDate(f.value, layout)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		*f.dst = t
+	}
+	return problems
+}
+
+// personFrom reads a free-text reference to someone: an email, a username
+// or a display name.
+func personFrom(value string) *Person {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if strings.Contains(value, "@") {
+		return &Person{Key: "email:" + strings.ToLower(value), Email: value}
+	}
+	if !strings.Contains(value, " ") {
+		return &Person{Key: "username:" + strings.ToLower(value), Username: value}
+	}
+	return &Person{Key: "name:" + strings.ToLower(value), Name: value}
+}
+
+var notUsername = regexp.MustCompile(`[^a-zA-Z0-9_.]+`)
+
+// usernameBase derives a valid username (letters first, then letters,
+// digits, _ and .) from what we know about someone, leaving room for a suffix.
+func usernameBase(p Person) string {
+	base := p.Username
+	if base == "" && p.Email != "" {
+		base, _, _ = strings.Cut(p.Email, "@")
+	}
+	if base == "" {
+		base = strings.ReplaceAll(strings.ToLower(p.Name), " ", ".")
+	}
+	base = notUsername.ReplaceAllString(strings.ReplaceAll(base, "-", "_"), "")
+	base = strings.TrimLeft(base, "0123456789_.")
+	if len(base) > 26 {
+		base = base[:26]
+	}
+	for len(base) < 3 {
+		base += "x"
+	}
+	return base
+}
+
+// displayName is the name an imported account gets.
+func displayName(p Person) string {
+	name := p.Name
+	if name == "" {
+		name = p.Username
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(p.Email, "@")
+	}
+	if len(name) > 50 {
+		name = name[:50]
+	}
+	for len(name) < 2 {
+		name += "_"
+	}
+	return name
+}
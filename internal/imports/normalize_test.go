@@ -0,0 +1,112 @@
+package imports
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	mapping := Mapping{Values: map[string]map[string]string{
+		"status":   {"Waiting for QA": "review"},
+		"priority": {"Sev 2": "high"},
+	}}
+	cases := []struct {
+		field, value string
+		want         string
+		ok           bool
+	}{
+		{"status", "", "todo", true},
+		{"status", "Selected for Development", "todo", true},
+		{"status", " Closed ", "done", true},
+		{"status", "Waiting for QA", "review", true},
+		{"status", "Parked", "", false},
+		{"priority", "", "medium", true},
+		{"priority", "Blocker", "urgent", true},
+		{"priority", "Sev 2", "high", true},
+		{"type", "", "feature", true},
+		{"type", "Sub-task", "chore", true},
+		{"type", "Spike", "", false},
+	}
+	for _, tc := range cases {
+		got, err := mapping.normalize(tc.field, tc.value)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("normalize(%s, %q) = %q, %v; want %q, ok %v", tc.field, tc.value, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	cases := []struct {
+		value, layout string
+		want          time.Time
+		ok            bool
+	}{
+		{"2026-04-05T10:20:30+02:00", "", time.Date(2026, 4, 5, 8, 20, 30, 0, time.UTC), true},
+		{"2026-04-05T10:20:30.000+0200", "", time.Date(2026, 4, 5, 8, 20, 30, 0, time.UTC), true},
+		{"2026-04-05 10:20", "", time.Date(2026, 4, 5, 10, 20, 0, 0, time.UTC), true},
+		{"2026-04-05", "", time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC), true},
+		{"05.04.2026", "02.01.2006", time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC), true},
+		{"2026-04-05", "02.01.2006", time.Time{}, false},
+		{"April 5", "", time.Time{}, false},
+	}
+	for _, tc := range cases {
+		got, err := parseDate(tc.value, tc.layout)
+		if (err == nil) != tc.ok {
+			t.Errorf("parseDate(%q, %q) err = %v, want ok %v", tc.value, tc.layout, err, tc.ok)
+			continue
+		}
+		if tc.ok && (got == nil || !got.Equal(tc.want)) {
+			t.Errorf("parseDate(%q, %q) = %v, want %s", tc.value, tc.layout, got, tc.want)
+		}
+	}
+	if got, err := parseDate("  ", ""); got != nil || err != nil {
+		t.Errorf("parseDate of a blank = %v, %v; want nil", got, err)
+	}
+}
+
+func TestPersonNames(t *testing.T) {
+	cases := []struct {
+		ref      string
+		key      string
+		username string
+		name     string
+	}{
+		{"Ana.Lee@Example.com", "email:ana.lee@example.com", "Ana.Lee", "Ana.Lee"},
+		{"bo-chan", "username:bo-chan", "bo_chan", "bo-chan"},
+		{"Carla Diaz", "name:carla diaz", "carla.diaz", "Carla Diaz"},
+		{"42", "username:42", "xxx", "42"},
+	}
+	for _, tc := range cases {
+		p := personFrom(tc.ref)
+		if p == nil || p.Key != tc.key {
+			t.Errorf("personFrom(%q) = %+v, want key %q", tc.ref, p, tc.key)
+			continue
+		}
+		if got := usernameBase(*p); got != tc.username {
+			t.Errorf("usernameBase(%q) = %q, want %q", tc.ref, got, tc.username)
+		}
+		if got := displayName(*p); got != tc.name {
+			t.Errorf("displayName(%q) = %q, want %q", tc.ref, got, tc.name)
+		}
+	}
+	if p := personFrom(" "); p != nil {
+		t.Errorf("personFrom of a blank = %+v, want nil", p)
+	}
+}
+
+func TestEpicStatus(t *testing.T) {
+	cases := []struct {
+		statuses []string
+		want     string
+	}{
+		{nil, "backlog"},
+		{[]string{"done", "done"}, "done"},
+		{[]string{"done", "review"}, "in_progress"},
+		{[]string{"todo", "done"}, "todo"},
+	}
+	for _, tc := range cases {
+		if got := epicStatus(tc.statuses); got != tc.want {
+			t.Errorf("epicStatus(%v) = %q, want %q", tc.statuses, got, tc.want)
+		}
+	}
+}
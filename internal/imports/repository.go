@@ -0,0 +1,308 @@
+package imports
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository reads what an import needs to resolve and writes its records.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// existingUser is an account an imported person matched.
+type existingUser struct {
+	ID    string
+	OrgID string
+}
+
+// plan is everything an import creates, with ids assigned up front so
+// records can refer to each other before they exist.
+type plan struct {
+	orgID     string
+	projectID string
+	source    string
+	actorID   string
+	users     []newUser
+	members   []string
+	epics     []newEpic
+	tickets   []newTicket
+	comments  []newComment
+}
+
+type newUser struct {
+	ID       string
+	Key      string
+	Name     string
+	Username string
+	Email    *string
+}
+
+type newEpic struct {
+	ID          string
+	ExternalID  string
+	Title       string
+	Description string
+	Status      string
+	StartDate   *time.Time
+	DueDate     *time.Time
+	CreatedAt   time.Time
+}
+
+type newTicket struct {
+	ID          string
+	ExternalID  string
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	Type        string
+	EpicID      *string
+	AssigneeID  *string
+	ReporterID  string
+	StartDate   *time.Time
+	DueDate     *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type newComment struct {
+	ID         string
+	ExternalID string
+	TicketID   string
+	AuthorID   string
+	Body       string
+	CreatedAt  time.Time
+}
+
+// ProjectName returns the name of a project of orgID, or "" when there is none.
+func (r *Repository) ProjectName(ctx context.Context, orgID, projectID string) (string, error) {
+	var name string
+	err := r.db.QueryRow(ctx, `SELECT name FROM projects WHERE id::text = $1 AND org_id = $2`, projectID, orgID).Scan(&name)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	return name, err
+}
+
+// kindTables maps the kinds of imported records to their tables.
+var kindTables = map[string]string{
+	"user":    "users",
+	"epic":    "epics",
+	"ticket":  "tickets",
+	"comment": "ticket_comments",
+}
+
+// ExternalIDs returns the records earlier imports created for externalIDs,
+// by external id. Mappings whose record was deleted since are left out, so
+// the record is imported again.
+func (r *Repository) ExternalIDs(ctx context.Context, scopeID, source, kind string, externalIDs []string) (map[string]string, error) {
+	found := map[string]string{}
+	if len(externalIDs) == 0 {
+		return found, nil
+	}
+	query := `
+SELECT m.external_id, m.entity_id::text
+FROM import_external_ids m
+JOIN ` + kindTables[kind] + ` e ON e.id = m.entity_id
+WHERE m.scope_id = $1 AND m.source = $2 AND m.kind = $3 AND m.external_id = ANY($4)`
+	rows, err := r.db.Query(ctx, query, scopeID, source, kind, externalIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var externalID, entityID string
+		if err := rows.Scan(&externalID, &entityID); err != nil {
+			return nil, err
+		}
+		found[externalID] = entityID
+	}
+	return found, rows.Err()
+}
+
+// EpicsByTitle returns the project's epics by lower-cased title.
+func (r *Repository) EpicsByTitle(ctx context.Context, projectID string) (map[string]string, error) {
+	return r.lookup(ctx, `SELECT lower(title), id::text FROM epics WHERE project_id = $1`, projectID)
+}
+
+// UsersByEmail returns the accounts with the given lower-cased emails.
+func (r *Repository) UsersByEmail(ctx context.Context, emails []string) (map[string]existingUser, error) {
+	return r.users(ctx, `SELECT lower(email), id::text, org_id::text FROM users WHERE lower(email) = ANY($1)`, emails)
+}
+
+// UsersByUsername returns the accounts with the given lower-cased usernames.
+func (r *Repository) UsersByUsername(ctx context.Context, usernames []string) (map[string]existingUser, error) {
+	return r.users(ctx, `SELECT lower(username), id::text, org_id::text FROM users WHERE lower(username) = ANY($1)`, usernames)
+}
+
+func (r *Repository) users(ctx context.Context, query string, keys []string) (map[string]existingUser, error) {
+	found := map[string]existingUser{}
+	if len(keys) == 0 {
+		return found, nil
+	}
+	rows, err := r.db.Query(ctx, query, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			key string
+			u   existingUser
+		)
+		if err := rows.Scan(&key, &u.ID, &u.OrgID); err != nil {
+			return nil, err
+		}
+		found[key] = u
+	}
+	return found, rows.Err()
+}
+
+func (r *Repository) lookup(ctx context.Context, query string, args ...any) (map[string]string, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	found := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		found[key] = value
+	}
+	return found, rows.Err()
+}
+
+// Write creates everything in p in one transaction.
+func (r *Repository) Write(ctx context.Context, p *plan) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// "!" is never a valid bcrypt hash: imported people can't sign in
+		// until an admin invites them or they reset their password
+		const userQuery = `
+INSERT INTO users (id, name, username, email, password_hash, role, org_id)
+VALUES ($1, $2, $3, $4, '!', 'developer', $5)`
+		for _, u := range p.users {
+			if _, err := tx.Exec(ctx, userQuery, u.ID, u.Name, u.Username, u.Email, p.orgID); err != nil {
+				return err
+			}
+			if err := mapExternal(ctx, tx, p.orgID, p.source, "user", u.Key, u.ID); err != nil {
+				return err
+			}
+		}
+		const memberQuery = `
+INSERT INTO project_members (project_id, user_id, member_role)
+VALUES ($1, $2, 'member')
+ON CONFLICT (project_id, user_id) DO NOTHING`
+		for _, userID := range p.members {
+			if _, err := tx.Exec(ctx, memberQuery, p.projectID, userID); err != nil {
+				return err
+			}
+		}
+
+		const epicQuery = `
+INSERT INTO epics (id, project_id, title, description, status, start_date, due_date, created_at, updated_at)
+VALUES ($1, $2, $3, NULLIF($4, ''), $5::ticket_status, $6, $7, $8, $8)`
+		for _, e := range p.epics {
+			if _, err := tx.Exec(ctx, epicQuery, e.ID, p.projectID, e.Title, e.Description, e.Status, e.StartDate, e.DueDate, e.CreatedAt); err != nil {
+				return err
+			}
+			if err := mapExternal(ctx, tx, p.projectID, p.source, "epic", e.ExternalID, e.ID); err != nil {
+				return err
+			}
+		}
+
+		const ticketQuery = `
+INSERT INTO tickets (id, project_id, title, description, status, priority, type, reporter_id, epic_id, assignee_id, start_date, due_date, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5::ticket_status, $6::ticket_priority, $7::ticket_type, $8, $9, $10, $11, $12, $13, $14)`
+		const historyQuery = `
+INSERT INTO ticket_history (id, ticket_id, text, actor_id, timestamp)
+VALUES ($1, $2, $3, $4, $5)`
+		const transitionQuery = `
+INSERT INTO ticket_status_transitions (ticket_id, from_status, to_status, actor_id, changed_at)
+VALUES ($1, $2::ticket_status, $3::ticket_status, $4, $5)`
+		for _, t := range p.tickets {
+			if _, err := tx.Exec(ctx, ticketQuery, t.ID, p.projectID, t.Title, t.Description, t.Status, t.Priority, t.Type,
+				t.ReporterID, t.EpicID, t.AssigneeID, t.StartDate, t.DueDate, t.CreatedAt, t.UpdatedAt); err != nil {
+				return err
+			}
+			text := "Imported from " + p.source + " (" + t.ExternalID + ")"
+			if _, err := tx.Exec(ctx, historyQuery, uuid.NewString(), t.ID, text, p.actorID, t.CreatedAt); err != nil {
+				return err
+			}
+			// like the migration backfill: created as todo, moved to its status at the last update
+			if _, err := tx.Exec(ctx, transitionQuery, t.ID, nil, "todo", p.actorID, t.CreatedAt); err != nil {
+				return err
+			}
+			if t.Status != "todo" {
+				if _, err := tx.Exec(ctx, transitionQuery, t.ID, "todo", t.Status, p.actorID, t.UpdatedAt); err != nil {
+					return err
+				}
+			}
+			if err := mapExternal(ctx, tx, p.projectID, p.source, "ticket", t.ExternalID, t.ID); err != nil {
+				return err
+			}
+		}
+
+		const commentQuery = `
+INSERT INTO ticket_comments (id, ticket_id, author_id, text, created_at)
+VALUES ($1, $2, $3, $4, $5)`
+		for _, c := range p.comments {
+			if _, err := tx.Exec(ctx, commentQuery, c.ID, c.TicketID, c.AuthorID, c.Body, c.CreatedAt); err != nil {
+				return err
+			}
+			if err := mapExternal(ctx, tx, p.projectID, p.source, "comment", c.ExternalID, c.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// mapExternal records which record an external id became. A mapping left
+// behind by a deleted record is replaced.
+func mapExternal(ctx context.Context, tx pgx.Tx, scopeID, source, kind, externalID, entityID string) error {
+	const query = `
+INSERT INTO import_external_ids (scope_id, source, kind, external_id, entity_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (scope_id, source, kind, external_id) DO UPDATE
+SET entity_id = EXCLUDED.entity_id, created_at = now()`
+	_, err := tx.Exec(ctx, query, scopeID, source, kind, externalID, entityID)
+	return err
+}
+
+// AddProjectActivity logs a project-level activity entry (best effort).
+func (r *Repository) AddProjectActivity(ctx context.Context, projectID, actorID, message string) {
+	const query = `
+INSERT INTO project_activity (id, project_id, actor_id, message, created_at)
+VALUES ($1, $2, $3, $4, NOW())`
+	_, _ = r.db.Exec(ctx, query, uuid.NewString(), projectID, actorID, message)
+}
+
+// ActorByUsername loads the account cmd/import acts as.
+func (r *Repository) ActorByUsername(ctx context.Context, username string) (*Actor, string, error) {
+	var (
+		a    Actor
+		role string
+	)
+	const query = `SELECT id::text, org_id::text, name, role::text FROM users WHERE lower(username) = $1 AND deactivated_at IS NULL`
+	err := r.db.QueryRow(ctx, query, strings.ToLower(username)).Scan(&a.ID, &a.OrgID, &a.Name, &role)
+	if err == pgx.ErrNoRows {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &a, role, nil
+}
@@ -0,0 +1,430 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/tickets"
+	"github.com/google/uuid"
+)
+
+// MaxTickets caps how many tickets one import may contain.
+const MaxTickets = 5000
+
+var (
+	// ErrProjectNotFound rejects imports into projects outside the actor's organization.
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrImportRejected is returned with the report when a non-dry-run import
+	// has invalid records; nothing is created in that case.
+	ErrImportRejected = errors.New("import has invalid records, nothing was created")
+)
+
+// Service validates imports and writes them.
+type Service struct {
+	repo         *Repository
+	audit        *audit.Service
+	gamification *gamification.Service
+}
+
+func NewService(repo *Repository, audit *audit.Service, gamification *gamification.Service) *Service {
+	return &Service{repo: repo, audit: audit, gamification: gamification}
+}
+
+// Preview reads a CSV's columns and first rows for the mapping step.
+func (s *Service) Preview(r io.Reader) (*Preview, error) {
+	return PreviewCSV(r)
+}
+
+// ActorByUsername loads an account to run an import as, with its role.
+func (s *Service) ActorByUsername(ctx context.Context, username string) (*Actor, string, error) {
+	return s.repo.ActorByUsername(ctx, username)
+}
+
+func parse(source string, r io.Reader, mapping Mapping) (*Batch, error) {
+	switch source {
+	case SourceCSV:
+		return parseCSV(r, mapping)
+	case SourceJira:
+		return parseJira(r)
+	case SourceGitHub:
+		return parseGitHub(r, mapping)
+	}
+	return nil, invalid("unknown source %q; use csv, jira or github", source)
+}
+
+// Import reads a CSV, Jira or GitHub export and creates its users, epics,
+// tickets and comments in opts.ProjectID. Records an earlier import of the
+// same source created are recognized by their external id and left alone,
+// so an import can be run again after fixing its file. A dry run only
+// reports; otherwise any invalid record rejects the whole import.
+func (s *Service) Import(ctx context.Context, actor Actor, opts Options, r io.Reader) (*Report, error) {
+	if _, err := uuid.Parse(opts.ProjectID); err != nil {
+		return nil, ErrProjectNotFound
+	}
+	projectName, err := s.repo.ProjectName(ctx, actor.OrgID, opts.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if projectName == "" {
+		return nil, ErrProjectNotFound
+	}
+	batch, err := parse(opts.Source, r, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &builder{
+		repo:  s.repo,
+		actor: actor,
+		opts:  opts,
+		report: &Report{
+			DryRun:    opts.DryRun,
+			Source:    opts.Source,
+			ProjectID: opts.ProjectID,
+			Items:     []ReportItem{},
+		},
+		plan: &plan{
+			orgID:     actor.OrgID,
+			projectID: opts.ProjectID,
+			source:    opts.Source,
+			actorID:   actor.ID,
+		},
+	}
+	if err := b.build(ctx, batch); err != nil {
+		return nil, err
+	}
+	report := b.report
+	if opts.DryRun {
+		return report, nil
+	}
+	if report.Rejected() {
+		return report, ErrImportRejected
+	}
+	if err := s.repo.Write(ctx, b.plan); err != nil {
+		return nil, err
+	}
+
+	desc := fmt.Sprintf("%s imported %d tickets, %d epics and %d comments from %s into %s",
+		actor.Name, report.Tickets.Create, report.Epics.Create, report.Comments.Create, opts.Source, projectName)
+	if s.audit != nil {
+		entityType := "project"
+		entityID := opts.ProjectID
+		_ = s.audit.Log(ctx, "tickets_imported", desc, &actor.ID, &entityType, &entityID)
+	}
+	s.repo.AddProjectActivity(ctx, opts.ProjectID, actor.ID, desc)
+	if opts.AwardXP {
+		report.XPAwarded = s.awardXP(ctx, b.plan.tickets)
+	}
+	return report, nil
+}
+
+// awardXP grants completion XP for imported done tickets to their assignees,
+// dated when they were closed at the source so old work counts towards
+// lifetime XP but not this week's or this season's leaderboards.
+func (s *Service) awardXP(ctx context.Context, imported []newTicket) int {
+	if s.gamification == nil {
+		return 0
+	}
+	total := 0
+	closers := map[string]bool{}
+	for _, t := range imported {
+		if t.Status != "done" || t.AssigneeID == nil {
+			continue
+		}
+		xp := tickets.XPFor(t.Priority)
+		err := s.gamification.AwardXP(ctx, gamification.AwardInput{
+			UserID:   *t.AssigneeID,
+			TicketID: t.ID,
+			Priority: t.Priority,
+			XP:       xp,
+			Note:     fmt.Sprintf("ticket %s imported as done", t.Title),
+			EarnedAt: &t.UpdatedAt,
+		})
+		if err == nil {
+			total += xp
+			closers[*t.AssigneeID] = true
+		}
+	}
+	for userID := range closers {
+		_ = s.gamification.RefreshClosedCount(ctx, userID)
+	}
+	return total
+}
+
+// builder validates a batch against the database, filling the report and
+// the plan of what to write.
+type builder struct {
+	repo   *Repository
+	actor  Actor
+	opts   Options
+	report *Report
+	plan   *plan
+
+	// users by Person.Key, "" for people that could not be resolved
+	users   map[string]string
+	members map[string]bool
+	// epic ids by external id, for tickets to refer to
+	epics map[string]string
+}
+
+func (b *builder) build(ctx context.Context, batch *Batch) error {
+	if err := b.resolveUsers(ctx, batch); err != nil {
+		return err
+	}
+	if err := b.buildEpics(ctx, batch); err != nil {
+		return err
+	}
+	return b.buildTickets(ctx, batch)
+}
+
+func (b *builder) buildEpics(ctx context.Context, batch *Batch) error {
+	refs := make([]string, 0, len(batch.Epics)+len(batch.Tickets))
+	for _, e := range batch.Epics {
+		refs = append(refs, e.ExternalID)
+	}
+	for _, t := range batch.Tickets {
+		if t.EpicRef != "" {
+			refs = append(refs, t.EpicRef)
+		}
+	}
+	existing, err := b.repo.ExternalIDs(ctx, b.opts.ProjectID, b.opts.Source, "epic", refs)
+	if err != nil {
+		return err
+	}
+	if b.opts.Source == SourceCSV {
+		// CSV epics are named, so the project's epics of that name are theirs
+		byTitle, err := b.repo.EpicsByTitle(ctx, b.opts.ProjectID)
+		if err != nil {
+			return err
+		}
+		for title, id := range byTitle {
+			if _, ok := existing[title]; !ok {
+				existing[title] = id
+			}
+		}
+	}
+
+	b.epics = existing
+	seen := map[string]bool{}
+	for _, e := range batch.Epics {
+		item := ReportItem{Kind: "epic", ExternalID: e.ExternalID, Title: e.Title, Line: e.Line, Errors: e.Errors}
+		if seen[e.ExternalID] {
+			item.Errors = append(item.Errors, "external id appears more than once")
+		}
+		seen[e.ExternalID] = true
+		if id, ok := existing[e.ExternalID]; ok && len(item.Errors) == 0 {
+			item.Action, item.EntityID = "exists", id
+			b.report.Epics.Existing++
+			b.report.Items = append(b.report.Items, item)
+			continue
+		}
+
+		epic := newEpic{
+			ID:          uuid.NewString(),
+			ExternalID:  e.ExternalID,
+			Title:       strings.TrimSpace(e.Title),
+			Description: e.Description,
+			StartDate:   e.StartDate,
+			DueDate:     e.DueDate,
+			CreatedAt:   timeOr(e.CreatedAt, nil),
+		}
+		if epic.Title == "" {
+			item.Errors = append(item.Errors, "title is required")
+		}
+		if e.Status != "" {
+			status, err := b.opts.Mapping.normalize("status", e.Status)
+			if err != nil {
+				item.Errors = append(item.Errors, err.Error())
+			}
+			epic.Status = status
+		}
+		b.add(&item, &b.report.Epics, epic.ID)
+		if len(item.Errors) == 0 {
+			b.epics[e.ExternalID] = epic.ID
+			b.plan.epics = append(b.plan.epics, epic)
+		}
+	}
+	return nil
+}
+
+func (b *builder) buildTickets(ctx context.Context, batch *Batch) error {
+	var ticketIDs, commentIDs []string
+	for _, t := range batch.Tickets {
+		ticketIDs = append(ticketIDs, t.ExternalID)
+		for _, c := range t.Comments {
+			commentIDs = append(commentIDs, c.ExternalID)
+		}
+	}
+	existing, err := b.repo.ExternalIDs(ctx, b.opts.ProjectID, b.opts.Source, "ticket", ticketIDs)
+	if err != nil {
+		return err
+	}
+	existingComments, err := b.repo.ExternalIDs(ctx, b.opts.ProjectID, b.opts.Source, "comment", commentIDs)
+	if err != nil {
+		return err
+	}
+
+	// statuses of the tickets of each new epic without a status of its own
+	epicTickets := map[string][]string{}
+	seen := map[string]bool{}
+	for _, t := range batch.Tickets {
+		item := ReportItem{Kind: "ticket", ExternalID: t.ExternalID, Title: t.Title, Line: t.Line, Errors: t.Errors}
+		if seen[t.ExternalID] {
+			item.Errors = append(item.Errors, "external id appears more than once")
+		}
+		seen[t.ExternalID] = true
+
+		ticketID, exists := existing[t.ExternalID]
+		if exists && len(item.Errors) == 0 {
+			// left as it is, but comments added since the last run are imported
+			item.Action, item.EntityID = "exists", ticketID
+			b.report.Tickets.Existing++
+			b.report.Items = append(b.report.Items, item)
+			b.addComments(t.Comments, ticketID, existingComments)
+			continue
+		}
+
+		created := timeOr(t.CreatedAt, nil)
+		ticket := newTicket{
+			ID:          uuid.NewString(),
+			ExternalID:  t.ExternalID,
+			Title:       strings.TrimSpace(t.Title),
+			Description: t.Description,
+			ReporterID:  b.actor.ID,
+			StartDate:   t.StartDate,
+			DueDate:     t.DueDate,
+			CreatedAt:   created,
+			UpdatedAt:   timeOr(t.ClosedAt, &created),
+		}
+		if ticket.Title == "" {
+			item.Errors = append(item.Errors, "title is required")
+		}
+		for _, f := range []struct {
+			field, value string
+			dst          *string
+		}{
+			{"status", t.Status, &ticket.Status},
+			{"priority", t.Priority, &ticket.Priority},
+			{"type", t.Type, &ticket.Type},
+		} {
+			value, err := b.opts.Mapping.normalize(f.field, f.value)
+			if err != nil {
+				item.Errors = append(item.Errors, err.Error())
+			}
+			*f.dst = value
+		}
+		if t.EpicRef != "" {
+			epicID, ok := b.epics[t.EpicRef]
+			if ok {
+				ticket.EpicID = &epicID
+			} else {
+				item.Errors = append(item.Errors, fmt.Sprintf("epic %q is not in the import or the project", t.EpicRef))
+			}
+		}
+		if t.Assignee != nil {
+			if id := b.users[t.Assignee.Key]; id != "" {
+				ticket.AssigneeID = &id
+			}
+		}
+		if t.Reporter != nil {
+			if id := b.users[t.Reporter.Key]; id != "" {
+				ticket.ReporterID = id
+			}
+		}
+		b.add(&item, &b.report.Tickets, ticket.ID)
+		if len(item.Errors) > 0 {
+			continue
+		}
+		b.plan.tickets = append(b.plan.tickets, ticket)
+		if ticket.EpicID != nil {
+			epicTickets[*ticket.EpicID] = append(epicTickets[*ticket.EpicID], ticket.Status)
+		}
+		b.addComments(t.Comments, ticket.ID, existingComments)
+	}
+
+	for i := range b.plan.epics {
+		e := &b.plan.epics[i]
+		if e.Status == "" {
+			e.Status = epicStatus(epicTickets[e.ID])
+		}
+	}
+	return nil
+}
+
+// addComments plans the comments not imported before.
+func (b *builder) addComments(comments []Comment, ticketID string, existing map[string]string) {
+	for _, c := range comments {
+		if _, ok := existing[c.ExternalID]; ok {
+			b.report.Comments.Existing++
+			continue
+		}
+		if strings.TrimSpace(c.Body) == "" {
+			continue
+		}
+		authorID := b.actor.ID
+		if c.Author != nil && b.users[c.Author.Key] != "" {
+			authorID = b.users[c.Author.Key]
+		}
+		b.plan.comments = append(b.plan.comments, newComment{
+			ID:         uuid.NewString(),
+			ExternalID: c.ExternalID,
+			TicketID:   ticketID,
+			AuthorID:   authorID,
+			Body:       c.Body,
+			CreatedAt:  timeOr(c.CreatedAt, nil),
+		})
+		b.report.Comments.Create++
+	}
+}
+
+// add records item as created or invalid depending on its errors.
+func (b *builder) add(item *ReportItem, counts *Counts, entityID string) {
+	if len(item.Errors) > 0 {
+		item.Action = "invalid"
+		counts.Invalid++
+	} else {
+		item.Action, item.EntityID = "create", entityID
+		counts.Create++
+	}
+	b.report.Items = append(b.report.Items, *item)
+}
+
+// epicStatus follows the tickets of an epic the way status changes do: done
+// when all are, in progress when any is being worked on.
+func epicStatus(statuses []string) string {
+	if len(statuses) == 0 {
+		return "backlog"
+	}
+	done, active := 0, 0
+	for _, s := range statuses {
+		switch s {
+		case "done":
+			done++
+		case "in_progress", "review":
+			active++
+		}
+	}
+	switch {
+	case done == len(statuses):
+		return "done"
+	case active > 0:
+		return "in_progress"
+	}
+	return "todo"
+}
+
+func timeOr(t, fallback *time.Time) time.Time {
+	if t != nil {
+		return *t
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	return time.Now()
+}
@@ -0,0 +1,161 @@
+package imports
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/database/dbtest"
+	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/tickets"
+)
+
+const serviceCSV = "id,title,status,assignee,closed,epic\n" +
+	"T-1,Login,done,ana@example.com,2026-01-09T15:00:00Z,Auth\n" +
+	"T-2,Signup,todo,bo.chan,,Auth\n"
+
+var serviceMapping = Mapping{Columns: map[string]string{
+	"externalId": "id", "title": "title", "status": "status", "assignee": "assignee", "closedAt": "closed", "epic": "epic",
+}}
+
+func count(t *testing.T, db *pgxpool.Pool, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(context.Background(), query, args...).Scan(&n); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func TestImportDryRunAndRerun(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	orgID := dbtest.Org(t, db)
+	adminID := dbtest.User(t, db, orgID, "admin")
+	projectID := dbtest.Project(t, db, orgID, adminID)
+	auditSvc := audit.NewService(audit.NewRepository(db))
+	gamSvc := gamification.NewService(gamification.NewRepository(db), auditSvc)
+	svc := NewService(NewRepository(db), auditSvc, gamSvc)
+	actor := Actor{ID: adminID, OrgID: orgID, Name: "Admin"}
+	opts := Options{ProjectID: projectID, Source: SourceCSV, Mapping: serviceMapping, AwardXP: true}
+	projectTickets := func() int {
+		return count(t, db, `SELECT COUNT(*) FROM tickets WHERE project_id = $1`, projectID)
+	}
+
+	dry := opts
+	dry.DryRun = true
+	report, err := svc.Import(ctx, actor, dry, strings.NewReader(serviceCSV))
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if report.Tickets.Create != 2 || report.Epics.Create != 1 || report.Users.Create != 2 || report.XPAwarded != 0 {
+		t.Fatalf("dry run report = %+v", report)
+	}
+	if n := projectTickets(); n != 0 {
+		t.Fatalf("dry run created %d tickets", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM users WHERE org_id = $1`, orgID); n != 1 {
+		t.Fatalf("dry run created %d users", n-1)
+	}
+
+	report, err = svc.Import(ctx, actor, opts, strings.NewReader(serviceCSV))
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Tickets.Create != 2 || report.Epics.Create != 1 || report.Users.Create != 2 {
+		t.Fatalf("import report = %+v", report)
+	}
+	if want := tickets.XPFor("medium"); report.XPAwarded != want {
+		t.Fatalf("xpAwarded = %d, want %d for the done ticket", report.XPAwarded, want)
+	}
+	if n := projectTickets(); n != 2 {
+		t.Fatalf("import created %d tickets, want 2", n)
+	}
+
+	again, err := svc.Import(ctx, actor, opts, strings.NewReader(serviceCSV))
+	if err != nil {
+		t.Fatalf("re-run: %v", err)
+	}
+	if again.Tickets.Create != 0 || again.Tickets.Existing != 2 || again.Epics.Existing != 1 || again.Users.Existing != 2 || again.XPAwarded != 0 {
+		t.Fatalf("re-run report = %+v, want everything existing", again)
+	}
+	if n := projectTickets(); n != 2 {
+		t.Fatalf("re-run left %d tickets, want 2", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM xp_events e JOIN tickets t ON t.id = e.ticket_id WHERE t.project_id = $1`, projectID); n != 1 {
+		t.Fatalf("%d XP events after the re-run, want 1", n)
+	}
+
+	// a row added to the file is the only thing a later run creates
+	grown := serviceCSV + "T-3,Logout,review,bo.chan,,Auth\n"
+	dry.AwardXP = false
+	report, err = svc.Import(ctx, actor, dry, strings.NewReader(grown))
+	if err != nil {
+		t.Fatalf("dry run of the grown file: %v", err)
+	}
+	if report.Tickets.Create != 1 || report.Tickets.Existing != 2 || report.Users.Create != 0 {
+		t.Fatalf("grown dry run report = %+v", report)
+	}
+}
+
+func TestImportedXPIsDatedWhenClosed(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	orgID := dbtest.Org(t, db)
+	adminID := dbtest.User(t, db, orgID, "admin")
+	projectID := dbtest.Project(t, db, orgID, adminID)
+	auditSvc := audit.NewService(audit.NewRepository(db))
+	gamSvc := gamification.NewService(gamification.NewRepository(db), auditSvc)
+	svc := NewService(NewRepository(db), auditSvc, gamSvc)
+
+	opts := Options{ProjectID: projectID, Source: SourceCSV, Mapping: serviceMapping, AwardXP: true}
+	report, err := svc.Import(ctx, Actor{ID: adminID, OrgID: orgID, Name: "Admin"}, opts, strings.NewReader(serviceCSV))
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	var anaID string
+	for _, item := range report.Items {
+		if item.Kind == "user" && item.ExternalID == "email:ana@example.com" {
+			anaID = item.EntityID
+		}
+	}
+	if anaID == "" {
+		t.Fatalf("ana is not in the report: %+v", report.Items)
+	}
+
+	var earned time.Time
+	if err := db.QueryRow(ctx, `SELECT created_at FROM xp_events WHERE user_id = $1`, anaID).Scan(&earned); err != nil {
+		t.Fatalf("load XP event: %v", err)
+	}
+	if want := time.Date(2026, 1, 9, 15, 0, 0, 0, time.UTC); !earned.Equal(want) {
+		t.Errorf("XP event dated %s, want the closing time %s", earned, want)
+	}
+
+	for _, period := range []string{"week", "all"} {
+		rows, err := gamSvc.Leaderboard(ctx, gamification.LeaderboardFilter{OrgID: orgID, Period: period, Limit: 50})
+		if err != nil {
+			t.Fatalf("%s leaderboard: %v", period, err)
+		}
+		xp := 0
+		for _, r := range rows {
+			if r.ID == anaID {
+				xp = r.XP
+			}
+		}
+		if want := map[string]int{"week": 0, "all": tickets.XPFor("medium")}[period]; xp != want {
+			t.Errorf("%s leaderboard gives ana %d XP, want %d", period, xp, want)
+		}
+	}
+
+	var streak int
+	if err := db.QueryRow(ctx, `SELECT streak_days FROM gamification_user_stats WHERE user_id = $1`, anaID).Scan(&streak); err != nil {
+		t.Fatalf("load stats: %v", err)
+	}
+	if streak != 0 {
+		t.Errorf("streak = %d, want an imported close not to start one", streak)
+	}
+}
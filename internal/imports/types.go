@@ -0,0 +1,149 @@
+package imports
+
+import "time"
+
+// Sources an import can read.
+const (
+	SourceCSV    = "csv"
+	SourceJira   = "jira"
+	SourceGitHub = "github"
+)
+
+// Batch is an import in a source-independent shape. Parsers fill it; the
+// service validates and writes it.
+type Batch struct {
+	Epics   []Epic
+	Tickets []Ticket
+}
+
+// Person is someone a source mentions as assignee, reporter or author. Key
+// identifies them within the batch and across re-runs.
+type Person struct {
+	Key      string
+	Name     string
+	Email    string
+	Username string
+}
+
+// Epic is an epic, Jira epic issue or GitHub milestone.
+type Epic struct {
+	ExternalID  string
+	Title       string
+	Description string
+	Status      string
+	StartDate   *time.Time
+	DueDate     *time.Time
+	CreatedAt   *time.Time
+	// Line and Errors come from parsing.
+	Line   int
+	Errors []string
+}
+
+// Ticket is one issue to import. Status, Priority and Type hold the source's
+// values until the service normalizes them.
+type Ticket struct {
+	ExternalID  string
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	Type        string
+	EpicRef     string
+	Assignee    *Person
+	Reporter    *Person
+	StartDate   *time.Time
+	DueDate     *time.Time
+	CreatedAt   *time.Time
+	ClosedAt    *time.Time
+	Comments    []Comment
+	Line        int
+	Errors      []string
+}
+
+// Comment is a comment on an imported ticket.
+type Comment struct {
+	ExternalID string
+	Author     *Person
+	Body       string
+	CreatedAt  *time.Time
+}
+
+// Mapping tells the CSV parser which column holds which field, and lets any
+// source translate its status, priority and type values. Columns maps field
+// names (externalId, title, description, status, priority, type, epic,
+// assignee, reporter, startDate, dueDate, createdAt, closedAt) to CSV headers.
+// Values maps a field (status, priority, type) to source value -> our value.
+// DateFormat is a Go time layout for CSV dates that aren't ISO 8601.
+type Mapping struct {
+	Columns    map[string]string            `json:"columns"`
+	Values     map[string]map[string]string `json:"values"`
+	DateFormat string                       `json:"dateFormat"`
+}
+
+// Options controls an import.
+type Options struct {
+	ProjectID string
+	Source    string
+	Mapping   Mapping
+	DryRun    bool
+	// AwardXP grants the usual completion XP for tickets imported as done.
+	// Off by default: historical closures shouldn't move today's leaderboards.
+	AwardXP bool
+}
+
+// Actor is who runs the import. Imported tickets without a reporter are
+// reported by them, and the audit log names them.
+type Actor struct {
+	ID    string
+	OrgID string
+	Name  string
+}
+
+// Counts tallies one kind of record in a report.
+type Counts struct {
+	Create   int `json:"create"`
+	Existing int `json:"existing"`
+	Invalid  int `json:"invalid"`
+}
+
+// Report describes what an import did, or would do on a dry run.
+type Report struct {
+	DryRun    bool         `json:"dryRun"`
+	Source    string       `json:"source"`
+	ProjectID string       `json:"projectId"`
+	Users     Counts       `json:"users"`
+	Epics     Counts       `json:"epics"`
+	Tickets   Counts       `json:"tickets"`
+	Comments  Counts       `json:"comments"`
+	XPAwarded int          `json:"xpAwarded"`
+	Items     []ReportItem `json:"items"`
+}
+
+// Rejected reports whether any record is invalid, which keeps the import
+// from being written.
+func (r *Report) Rejected() bool {
+	return r.Users.Invalid+r.Epics.Invalid+r.Tickets.Invalid > 0
+}
+
+// ReportItem is one user, epic or ticket of an import. Action is create,
+// exists (imported before, left as it is) or invalid. Line is the CSV line,
+// counting the header as line 1.
+type ReportItem struct {
+	Kind       string   `json:"kind"`
+	ExternalID string   `json:"externalId"`
+	Title      string   `json:"title"`
+	Line       int      `json:"line,omitempty"`
+	Action     string   `json:"action"`
+	EntityID   string   `json:"entityId,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// Preview is the first step of a CSV import: the columns found, a few rows
+// and a suggested mapping to edit before importing.
+type Preview struct {
+	Headers   []string          `json:"headers"`
+	Sample    [][]string        `json:"sample"`
+	Rows      int               `json:"rows"`
+	Suggested map[string]string `json:"suggested"`
+	Fields    []string          `json:"fields"`
+}
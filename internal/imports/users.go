@@ -0,0 +1,165 @@
+package imports
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxUsernameSuffix bounds the "_2", "_3"... tried when a new account's
+// username is taken.
+const maxUsernameSuffix = 9
+
+// resolveUsers matches everyone the batch mentions to an account: one an
+// earlier import created, else one with the same email, else one of the
+// organization with the same username. Everyone else gets a new account
+// that can't sign in until they are invited. Everyone matched joins the
+// project.
+func (b *builder) resolveUsers(ctx context.Context, batch *Batch) error {
+	b.users = map[string]string{}
+	b.members = map[string]bool{}
+	people := map[string]Person{}
+	var keys []string
+	mention := func(p *Person) {
+		if p == nil || p.Key == "" {
+			return
+		}
+		if _, ok := people[p.Key]; !ok {
+			people[p.Key] = *p
+			keys = append(keys, p.Key)
+		}
+	}
+	for _, t := range batch.Tickets {
+		mention(t.Assignee)
+		mention(t.Reporter)
+		for _, c := range t.Comments {
+			mention(c.Author)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var emails, usernames []string
+	for _, key := range keys {
+		p := people[key]
+		if p.Email != "" {
+			emails = append(emails, strings.ToLower(p.Email))
+		}
+		if p.Username != "" {
+			usernames = append(usernames, strings.ToLower(p.Username))
+		}
+	}
+	imported, err := b.repo.ExternalIDs(ctx, b.actor.OrgID, b.opts.Source, "user", keys)
+	if err != nil {
+		return err
+	}
+	byEmail, err := b.repo.UsersByEmail(ctx, emails)
+	if err != nil {
+		return err
+	}
+	byUsername, err := b.repo.UsersByUsername(ctx, usernames)
+	if err != nil {
+		return err
+	}
+
+	var unmatched []string
+	for _, key := range keys {
+		p := people[key]
+		item := ReportItem{Kind: "user", ExternalID: key, Title: displayName(p)}
+		if id, ok := imported[key]; ok {
+			b.matched(&item, id)
+			continue
+		}
+		if p.Email != "" {
+			if u, ok := byEmail[strings.ToLower(p.Email)]; ok {
+				if u.OrgID != b.actor.OrgID {
+					item.Errors = append(item.Errors, fmt.Sprintf("email %s belongs to an account of another organization", p.Email))
+					b.add(&item, &b.report.Users, "")
+					continue
+				}
+				b.matched(&item, u.ID)
+				continue
+			}
+		}
+		if u, ok := byUsername[strings.ToLower(p.Username)]; ok && p.Username != "" && u.OrgID == b.actor.OrgID {
+			b.matched(&item, u.ID)
+			continue
+		}
+		unmatched = append(unmatched, key)
+	}
+	return b.createUsers(ctx, people, unmatched)
+}
+
+// matched records a person resolved to an existing account.
+func (b *builder) matched(item *ReportItem, userID string) {
+	item.Action, item.EntityID = "exists", userID
+	b.report.Users.Existing++
+	b.report.Items = append(b.report.Items, *item)
+	b.users[item.ExternalID] = userID
+	b.addMember(userID)
+}
+
+func (b *builder) addMember(userID string) {
+	if !b.members[userID] {
+		b.members[userID] = true
+		b.plan.members = append(b.plan.members, userID)
+	}
+}
+
+// createUsers plans accounts for the people no account matched, picking
+// usernames nobody has.
+func (b *builder) createUsers(ctx context.Context, people map[string]Person, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	var candidates []string
+	for _, key := range keys {
+		base := strings.ToLower(usernameBase(people[key]))
+		candidates = append(candidates, base)
+		for i := 2; i <= maxUsernameSuffix; i++ {
+			candidates = append(candidates, fmt.Sprintf("%s_%d", base, i))
+		}
+	}
+	taken, err := b.repo.UsersByUsername(ctx, candidates)
+	if err != nil {
+		return err
+	}
+
+	picked := map[string]bool{}
+	emails := map[string]bool{}
+	for _, key := range keys {
+		p := people[key]
+		item := ReportItem{Kind: "user", ExternalID: key, Title: displayName(p)}
+		base := strings.ToLower(usernameBase(p))
+		username := ""
+		for i := 1; i <= maxUsernameSuffix && username == ""; i++ {
+			candidate := base
+			if i > 1 {
+				candidate = fmt.Sprintf("%s_%d", base, i)
+			}
+			if _, ok := taken[candidate]; !ok && !picked[candidate] {
+				username = candidate
+			}
+		}
+		if username == "" {
+			item.Errors = append(item.Errors, fmt.Sprintf("no free username like %q; create the account first", base))
+			b.add(&item, &b.report.Users, "")
+			continue
+		}
+		picked[username] = true
+
+		u := newUser{ID: uuid.NewString(), Key: key, Name: displayName(p), Username: username}
+		if email := strings.ToLower(p.Email); email != "" && !emails[email] {
+			emails[email] = true
+			u.Email = &p.Email
+		}
+		b.plan.users = append(b.plan.users, u)
+		b.users[key] = u.ID
+		b.addMember(u.ID)
+		b.add(&item, &b.report.Users, u.ID)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/response"
+)
+
+// Handler provides the admin endpoints for background jobs.
+type Handler struct {
+	scheduler *Scheduler
+}
+
+func NewHandler(scheduler *Scheduler) *Handler {
+	return &Handler{scheduler: scheduler}
+}
+
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("", h.list)
+	router.GET("/:name/runs", h.runs)
+	router.POST("/:name/run", h.trigger)
+}
+
+func (h *Handler) list(c *gin.Context) {
+	jobs, err := h.scheduler.Jobs(c.Request.Context())
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, jobs)
+}
+
+func (h *Handler) runs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	runs, err := h.scheduler.Runs(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		writeJobError(c, err)
+		return
+	}
+	response.OK(c, runs)
+}
+
+func (h *Handler) trigger(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	run, err := h.scheduler.Trigger(c.Request.Context(), c.Param("name"), user.ID)
+	if err != nil {
+		writeJobError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": run})
+}
+
+func writeJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrJobRunning):
+		response.ErrorCode(c, http.StatusConflict, "job_running", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -0,0 +1,181 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles job schedules, run history and run locks.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// EnsureSchedule registers a job's schedule. A changed schedule replaces the
+// stored next run; otherwise the stored one is kept.
+func (r *Repository) EnsureSchedule(ctx context.Context, name, schedule string, next time.Time) error {
+	const query = `
+INSERT INTO job_schedules (name, schedule, next_run_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+SET schedule = EXCLUDED.schedule, next_run_at = EXCLUDED.next_run_at
+WHERE job_schedules.schedule <> EXCLUDED.schedule`
+	_, err := r.db.Exec(ctx, query, name, schedule, next)
+	return err
+}
+
+// NextRuns returns the stored next run of every job.
+func (r *Repository) NextRuns(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := r.db.Query(ctx, `SELECT name, next_run_at FROM job_schedules`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	next := map[string]time.Time{}
+	for rows.Next() {
+		var (
+			name string
+			at   time.Time
+		)
+		if err := rows.Scan(&name, &at); err != nil {
+			return nil, err
+		}
+		next[name] = at
+	}
+	return next, rows.Err()
+}
+
+// Lock takes the run lock of a job: a session advisory lock held on a
+// dedicated connection, so only one replica runs the job at a time. It
+// returns nil when another run holds the lock. The caller must Release it.
+func (r *Repository) Lock(ctx context.Context, name string) (*Lock, error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext('job:' || $1))`, name).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, err
+	}
+	if !ok {
+		conn.Release()
+		return nil, nil
+	}
+	return &Lock{conn: conn, name: name}, nil
+}
+
+// Lock is a held job run lock.
+type Lock struct {
+	conn *pgxpool.Conn
+	name string
+}
+
+// Claim moves the job's next run from a due time to next and reports whether
+// it was due. Together with the lock it makes each scheduled run happen once.
+func (l *Lock) Claim(ctx context.Context, now, next time.Time) (bool, error) {
+	tag, err := l.conn.Exec(ctx, `
+UPDATE job_schedules SET next_run_at = $3
+WHERE name = $1 AND next_run_at <= $2`, l.name, now, next)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Release drops the lock and returns the connection to the pool.
+func (l *Lock) Release() {
+	// a fresh context so the unlock still happens after shutdown was requested
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock(hashtext('job:' || $1))`, l.name); err != nil {
+		// the session may still hold the lock; don't hand it to someone else
+		_ = l.conn.Conn().Close(ctx)
+	}
+	l.conn.Release()
+}
+
+const runColumns = `id, job_name, trigger, triggered_by, status, COALESCE(result, ''), COALESCE(error, ''), started_at, finished_at`
+
+func scanRun(row pgx.Row) (*Run, error) {
+	var run Run
+	if err := row.Scan(&run.ID, &run.JobName, &run.Trigger, &run.TriggeredBy, &run.Status, &run.Result, &run.Error, &run.StartedAt, &run.FinishedAt); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *Repository) StartRun(ctx context.Context, name, trigger string, triggeredBy *string) (*Run, error) {
+	query := `
+INSERT INTO job_runs (job_name, trigger, triggered_by)
+VALUES ($1, $2, $3)
+RETURNING ` + runColumns
+	return scanRun(r.db.QueryRow(ctx, query, name, trigger, triggeredBy))
+}
+
+func (r *Repository) FinishRun(ctx context.Context, id, status, result, errMsg string) error {
+	const query = `
+UPDATE job_runs
+SET status = $2, result = NULLIF($3, ''), error = NULLIF($4, ''), finished_at = now()
+WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id, status, result, errMsg)
+	return err
+}
+
+// FailStaleRuns marks runs left "running" by a process that died as failed.
+// A run is stale when nobody holds its job's lock.
+func (r *Repository) FailStaleRuns(ctx context.Context, name string) error {
+	const query = `
+UPDATE job_runs
+SET status = 'failed', error = 'interrupted', finished_at = now()
+WHERE job_name = $1 AND status = 'running'`
+	_, err := r.db.Exec(ctx, query, name)
+	return err
+}
+
+// ListRuns returns a job's runs, newest first.
+func (r *Repository) ListRuns(ctx context.Context, name string, limit int) ([]Run, error) {
+	query := `SELECT ` + runColumns + ` FROM job_runs WHERE job_name = $1 ORDER BY started_at DESC LIMIT $2`
+	rows, err := r.db.Query(ctx, query, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []Run{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// LastRuns returns the latest run of every job that has run.
+func (r *Repository) LastRuns(ctx context.Context) (map[string]*Run, error) {
+	query := `SELECT DISTINCT ON (job_name) ` + runColumns + ` FROM job_runs ORDER BY job_name, started_at DESC`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	last := map[string]*Run{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		last[run.JobName] = run
+	}
+	return last, rows.Err()
+}
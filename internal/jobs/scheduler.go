@@ -0,0 +1,234 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job is already running")
+)
+
+// tick is how often the scheduler looks for due jobs.
+const tick = 30 * time.Second
+
+type entry struct {
+	job      Job
+	schedule *Schedule
+}
+
+// Scheduler runs registered jobs on their cron schedules. Any number of
+// replicas may run one: each scheduled run is claimed once, under the job's
+// advisory lock, and every run is recorded in job_runs.
+type Scheduler struct {
+	repo    *Repository
+	audit   *audit.Service
+	entries []entry
+	byName  map[string]*entry
+	wg      sync.WaitGroup
+}
+
+func NewScheduler(repo *Repository, auditSvc *audit.Service) *Scheduler {
+	return &Scheduler{repo: repo, audit: auditSvc, byName: map[string]*entry{}}
+}
+
+// Register adds a job. It fails on an invalid schedule or a duplicate name.
+func (s *Scheduler) Register(job Job) error {
+	if _, dup := s.byName[job.Name]; dup {
+		return fmt.Errorf("job %q registered twice", job.Name)
+	}
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+	s.entries = append(s.entries, entry{job: job, schedule: schedule})
+	s.byName = make(map[string]*entry, len(s.entries))
+	for i := range s.entries {
+		s.byName[s.entries[i].job.Name] = &s.entries[i]
+	}
+	return nil
+}
+
+// Start runs due jobs until ctx is canceled, then waits for the runs in
+// progress to finish.
+func (s *Scheduler) Start(ctx context.Context) error {
+	now := time.Now()
+	for _, e := range s.entries {
+		if err := s.repo.EnsureSchedule(ctx, e.job.Name, e.schedule.String(), e.schedule.Next(now)); err != nil {
+			return err
+		}
+	}
+	log.Printf("job scheduler started with %d jobs", len(s.entries))
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		s.runDue(ctx)
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue starts every job whose next run has come, each in its own goroutine
+// so a slow job does not hold up the others.
+func (s *Scheduler) runDue(ctx context.Context) {
+	next, err := s.repo.NextRuns(ctx)
+	if err != nil {
+		log.Printf("jobs: loading schedules failed: %v", err)
+		return
+	}
+	now := time.Now()
+	for i := range s.entries {
+		e := &s.entries[i]
+		if at, ok := next[e.job.Name]; !ok || at.After(now) {
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.runScheduled(ctx, e, now)
+		}()
+	}
+}
+
+func (s *Scheduler) runScheduled(ctx context.Context, e *entry, now time.Time) {
+	lock, err := s.repo.Lock(ctx, e.job.Name)
+	if err != nil {
+		log.Printf("jobs: %s: %v", e.job.Name, err)
+		return
+	}
+	if lock == nil {
+		return
+	}
+	defer lock.Release()
+	claimed, err := lock.Claim(ctx, now, e.schedule.Next(now))
+	if err != nil {
+		log.Printf("jobs: %s: %v", e.job.Name, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	run, err := s.begin(ctx, e, "schedule", nil)
+	if err != nil {
+		log.Printf("jobs: %s: %v", e.job.Name, err)
+		return
+	}
+	s.finish(ctx, e, run)
+}
+
+// Trigger starts a run of the named job now, outside its schedule, and
+// returns the run as recorded when it started. The run continues in the
+// background and is not canceled with ctx.
+func (s *Scheduler) Trigger(ctx context.Context, name, actorID string) (*Run, error) {
+	e, ok := s.byName[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	lock, err := s.repo.Lock(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, ErrJobRunning
+	}
+	run, err := s.begin(ctx, e, "manual", &actorID)
+	if err != nil {
+		lock.Release()
+		return nil, err
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("triggered job %s", name)
+		entityType := "job_run"
+		_ = s.audit.Log(ctx, "job_triggered", desc, &actorID, &entityType, &run.ID)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer lock.Release()
+		s.finish(context.Background(), e, run)
+	}()
+	return run, nil
+}
+
+// begin records a run while the caller holds the job's lock.
+func (s *Scheduler) begin(ctx context.Context, e *entry, trigger string, actorID *string) (*Run, error) {
+	// the lock is ours, so runs still marked running were cut short
+	if err := s.repo.FailStaleRuns(ctx, e.job.Name); err != nil {
+		return nil, err
+	}
+	return s.repo.StartRun(ctx, e.job.Name, trigger, actorID)
+}
+
+// finish performs the job and records its outcome.
+func (s *Scheduler) finish(ctx context.Context, e *entry, run *Run) {
+	result, err := safeRun(ctx, e.job.Run)
+	status, errMsg := "succeeded", ""
+	if err != nil {
+		status, errMsg = "failed", err.Error()
+		log.Printf("jobs: %s failed: %v", e.job.Name, err)
+	}
+	// record the outcome even when ctx was canceled mid-run
+	finishCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.FinishRun(finishCtx, run.ID, status, result, errMsg); err != nil {
+		log.Printf("jobs: %s: recording run failed: %v", e.job.Name, err)
+	}
+}
+
+func safeRun(ctx context.Context, fn Func) (result string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Jobs lists the registered jobs with their next and latest runs.
+func (s *Scheduler) Jobs(ctx context.Context) ([]JobInfo, error) {
+	next, err := s.repo.NextRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	last, err := s.repo.LastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]JobInfo, 0, len(s.entries))
+	for _, e := range s.entries {
+		info := JobInfo{
+			Name:        e.job.Name,
+			Description: e.job.Description,
+			Schedule:    e.schedule.String(),
+			LastRun:     last[e.job.Name],
+		}
+		if at, ok := next[e.job.Name]; ok {
+			info.NextRunAt = &at
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// Runs returns the run history of the named job, newest first.
+func (s *Scheduler) Runs(ctx context.Context, name string, limit int) ([]Run, error) {
+	if _, ok := s.byName[name]; !ok {
+		return nil, ErrJobNotFound
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.ListRuns(ctx, name, limit)
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Func does one run of a job and returns a short summary of what it did.
+type Func func(ctx context.Context) (string, error)
+
+// Job is a named piece of periodic work.
+type Job struct {
+	Name        string
+	Description string
+	Schedule    string
+	Run         Func
+}
+
+// Run is one recorded execution of a job. Trigger is "schedule" or
+// "manual"; Status is running, succeeded or failed.
+type Run struct {
+	ID          string     `json:"id"`
+	JobName     string     `json:"jobName"`
+	Trigger     string     `json:"trigger"`
+	TriggeredBy *string    `json:"triggeredBy,omitempty"`
+	Status      string     `json:"status"`
+	Result      string     `json:"result,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+// JobInfo describes a registered job for the admin API.
+type JobInfo struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Schedule    string     `json:"schedule"`
+	NextRunAt   *time.Time `json:"nextRunAt"`
+	LastRun     *Run       `json:"lastRun"`
+}
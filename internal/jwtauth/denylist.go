@@ -0,0 +1,76 @@
+package jwtauth
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Denylist records access tokens that must stop working before they expire.
+// Entries are keyed by token ID (`jti`) or session ID (`sid`); a per-user
+// cutoff revokes every token issued before it.
+type Denylist struct {
+	db *pgxpool.Pool
+}
+
+func NewDenylist(db *pgxpool.Pool) *Denylist {
+	return &Denylist{db: db}
+}
+
+// IsRevoked reports whether a token with the given claims has been revoked.
+// issuedAt is the token's iat, which has whole seconds only, so a token
+// issued in the second of the user's cutoff is revoked: it may have been
+// signed before the cutoff. A token signed just after it, in the same
+// second, is refused too and the client has to sign in again.
+func (d *Denylist) IsRevoked(ctx context.Context, userID, tokenID, sessionID string, issuedAt time.Time) (bool, error) {
+	const query = `
+SELECT EXISTS (
+         SELECT 1 FROM access_token_denylist
+         WHERE token_id = ANY($2::text[]) AND expires_at > NOW()
+       )
+    OR EXISTS (
+         SELECT 1 FROM users
+         WHERE id = $1::uuid AND tokens_revoked_at IS NOT NULL AND tokens_revoked_at >= $3
+       )`
+	ids := make([]string, 0, 2)
+	if tokenID != "" {
+		ids = append(ids, tokenID)
+	}
+	if sessionID != "" {
+		ids = append(ids, sessionID)
+	}
+	var revoked bool
+	if err := d.db.QueryRow(ctx, query, userID, ids, issuedAt).Scan(&revoked); err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+// Revoke denies a single token or session ID until expiresAt, after which
+// the token would be rejected anyway.
+func (d *Denylist) Revoke(ctx context.Context, id, userID string, expiresAt time.Time) error {
+	const query = `
+INSERT INTO access_token_denylist (token_id, user_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (token_id) DO UPDATE SET expires_at = GREATEST(access_token_denylist.expires_at, EXCLUDED.expires_at)`
+	_, err := d.db.Exec(ctx, query, id, userID, expiresAt)
+	return err
+}
+
+// RevokeUser invalidates every access token issued to the user so far.
+func (d *Denylist) RevokeUser(ctx context.Context, userID string) error {
+	const query = `UPDATE users SET tokens_revoked_at = NOW() WHERE id = $1`
+	_, err := d.db.Exec(ctx, query, userID)
+	return err
+}
+
+// Purge drops entries whose tokens have expired and returns how many were removed.
+func (d *Denylist) Purge(ctx context.Context) (int64, error) {
+	const query = `DELETE FROM access_token_denylist WHERE expires_at <= NOW()`
+	tag, err := d.db.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,81 @@
+package jwtauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/database/dbtest"
+)
+
+func TestRevokeUserCutoff(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	userID := dbtest.User(t, db, dbtest.Org(t, db), "developer")
+	d := NewDenylist(db)
+
+	// pin the cutoff inside a second, as RevokeUser's NOW() would be
+	cutoff := time.Date(2026, 3, 1, 10, 0, 0, 700_000_000, time.UTC)
+	dbtest.Exec(t, db, `UPDATE users SET tokens_revoked_at = $2 WHERE id = $1`, userID, cutoff)
+
+	cases := []struct {
+		name     string
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{"issued a second before", cutoff.Truncate(time.Second).Add(-time.Second), true},
+		{"issued in the same second", time.Unix(cutoff.Unix(), 0), true},
+		{"issued after", time.Unix(cutoff.Unix()+1, 0), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			revoked, err := d.IsRevoked(ctx, userID, "", "", tc.issuedAt)
+			if err != nil {
+				t.Fatalf("IsRevoked: %v", err)
+			}
+			if revoked != tc.revoked {
+				t.Fatalf("revoked = %v, want %v", revoked, tc.revoked)
+			}
+		})
+	}
+}
+
+func TestRevokeUserThenSignIn(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	userID := dbtest.User(t, db, dbtest.Org(t, db), "developer")
+	d := NewDenylist(db)
+
+	before := time.Unix(time.Now().Unix()-1, 0)
+	if err := d.RevokeUser(ctx, userID); err != nil {
+		t.Fatalf("RevokeUser: %v", err)
+	}
+	// a token signed in the next second carries the first iat past the cutoff
+	issued := time.Unix(time.Now().Unix()+1, 0)
+
+	if revoked, err := d.IsRevoked(ctx, userID, "", "", issued); err != nil || revoked {
+		t.Fatalf("new token: revoked = %v, err = %v; want it valid", revoked, err)
+	}
+	if revoked, err := d.IsRevoked(ctx, userID, "", "", before); err != nil || !revoked {
+		t.Fatalf("old token: revoked = %v, err = %v; want it revoked", revoked, err)
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	userID := dbtest.User(t, db, dbtest.Org(t, db), "developer")
+	d := NewDenylist(db)
+
+	if err := d.Revoke(ctx, "session-1", userID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	revoked, err := d.IsRevoked(ctx, userID, "token-1", "session-1", time.Now())
+	if err != nil || !revoked {
+		t.Fatalf("revoked = %v, err = %v; want the session revoked", revoked, err)
+	}
+	revoked, err = d.IsRevoked(ctx, userID, "token-2", "session-2", time.Now())
+	if err != nil || revoked {
+		t.Fatalf("revoked = %v, err = %v; want another session valid", revoked, err)
+	}
+}
@@ -0,0 +1,67 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// JWK is the public part of one signing key (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that currently verify tokens. HMAC keys are
+// secrets and are never published, so an HS256-only set yields no keys.
+func (s *KeySet) JWKS() JWKS {
+	now := time.Now()
+	doc := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if !key.usable(now) {
+			continue
+		}
+		jwk := JWK{Kid: key.ID, Alg: key.Algorithm, Use: "sig"}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64(pub.N.Bytes())
+			jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64(pub)
+		default:
+			continue
+		}
+		doc.Keys = append(doc.Keys, jwk)
+	}
+	// active key first, then stable order
+	sort.Slice(doc.Keys, func(i, j int) bool {
+		if (doc.Keys[i].Kid == s.active.ID) != (doc.Keys[j].Kid == s.active.ID) {
+			return doc.Keys[i].Kid == s.active.ID
+		}
+		return doc.Keys[i].Kid < doc.Keys[j].Kid
+	})
+	return doc
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,233 @@
+// Package jwtauth signs and verifies access tokens. Keys are identified by a
+// `kid` header so the signing key can be rotated while tokens signed with the
+// previous keys keep verifying for a grace window.
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// ErrUnknownKey is returned for tokens signed with a key that is not (or no longer) trusted.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Config describes the signing key and the keys still accepted after a rotation.
+type Config struct {
+	// Algorithm is HS256 (default), RS256 or EdDSA.
+	Algorithm string
+	// KeyID is published as the `kid` header of new tokens.
+	KeyID string
+	// Secret is the HMAC secret (JWT_SECRET). With an asymmetric algorithm it is
+	// kept as a verify-only legacy key during the grace window.
+	Secret string
+	// PrivateKeyFile is a PEM encoded RSA or Ed25519 private key for RS256/EdDSA.
+	PrivateKeyFile string
+	// PreviousKeys lists retired keys as comma separated `kid=value` pairs. The
+	// value is an HMAC secret, or `file:<path>` for a PEM private or public key.
+	PreviousKeys string
+	// Grace is how long after startup retired keys keep verifying tokens.
+	Grace time.Duration
+}
+
+// Key is one signing or verification key.
+type Key struct {
+	ID        string
+	Algorithm string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	// notAfter is zero for the active key.
+	notAfter time.Time
+}
+
+func (k *Key) usable(now time.Time) bool {
+	return k.notAfter.IsZero() || now.Before(k.notAfter)
+}
+
+// KeySet holds the active signing key plus the retired keys still trusted.
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+	// legacy verifies tokens issued before `kid` headers existed.
+	legacy *Key
+}
+
+// LoadKeySet builds a KeySet from cfg.
+func LoadKeySet(cfg Config) (*KeySet, error) {
+	if cfg.KeyID == "" {
+		cfg.KeyID = "primary"
+	}
+	alg := cfg.Algorithm
+	if alg == "" {
+		alg = AlgHS256
+	}
+	graceEnd := time.Now().Add(cfg.Grace)
+
+	set := &KeySet{keys: make(map[string]*Key)}
+	switch alg {
+	case AlgHS256:
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("jwt: JWT_SECRET is required for %s", AlgHS256)
+		}
+		set.active = hmacKey(cfg.KeyID, cfg.Secret)
+		set.legacy = set.active
+	case AlgRS256, AlgEdDSA:
+		key, err := loadPEMKey(cfg.KeyID, cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key.signKey == nil {
+			return nil, fmt.Errorf("jwt: %s does not contain a private key", cfg.PrivateKeyFile)
+		}
+		if key.Algorithm != alg {
+			return nil, fmt.Errorf("jwt: key in %s is for %s, not %s", cfg.PrivateKeyFile, key.Algorithm, alg)
+		}
+		set.active = key
+		if cfg.Secret != "" {
+			set.legacy = hmacKey("", cfg.Secret)
+			set.legacy.notAfter = graceEnd
+		}
+	default:
+		return nil, fmt.Errorf("jwt: unsupported algorithm %q", alg)
+	}
+	set.keys[set.active.ID] = set.active
+
+	for _, entry := range strings.Split(cfg.PreviousKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, value, ok := strings.Cut(entry, "=")
+		if !ok || kid == "" || value == "" {
+			return nil, fmt.Errorf("jwt: previous key %q must be kid=value", entry)
+		}
+		if _, exists := set.keys[kid]; exists {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", kid)
+		}
+		var key *Key
+		if path, isFile := strings.CutPrefix(value, "file:"); isFile {
+			var err error
+			if key, err = loadPEMKey(kid, path); err != nil {
+				return nil, err
+			}
+		} else {
+			key = hmacKey(kid, value)
+		}
+		key.signKey = nil
+		key.notAfter = graceEnd
+		set.keys[kid] = key
+	}
+	return set, nil
+}
+
+// Active returns the key new tokens are signed with.
+func (s *KeySet) Active() *Key {
+	return s.active
+}
+
+// Sign signs claims with the active key and sets the `kid` header.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.method, claims)
+	token.Header["kid"] = s.active.ID
+	return token.SignedString(s.active.signKey)
+}
+
+// Parse verifies tokenString against the trusted keys and returns its claims.
+func (s *KeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, s.keyfunc,
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (s *KeySet) keyfunc(t *jwt.Token) (any, error) {
+	var key *Key
+	if kid, _ := t.Header["kid"].(string); kid != "" {
+		key = s.keys[kid]
+	} else {
+		key = s.legacy
+	}
+	if key == nil || !key.usable(time.Now()) {
+		return nil, ErrUnknownKey
+	}
+	// never let a token pick a different algorithm than the key was issued for
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.verifyKey, nil
+}
+
+func hmacKey(kid, secret string) *Key {
+	return &Key{
+		ID:        kid,
+		Algorithm: AlgHS256,
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// loadPEMKey reads an RSA or Ed25519 key. Private keys can sign and verify;
+// public keys (PKIX) only verify, which is enough for retired keys.
+func loadPEMKey(kid, path string) (*Key, error) {
+	if path == "" {
+		return nil, fmt.Errorf("jwt: key file for %q is not set", kid)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: read key %s: %w", path, err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("jwt: %s is not PEM encoded", path)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwt: unsupported PEM block %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwt: parse key %s: %w", path, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: kid, Algorithm: AlgRS256, method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: kid, Algorithm: AlgRS256, method: jwt.SigningMethodRS256, verifyKey: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: kid, Algorithm: AlgEdDSA, method: jwt.SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: kid, Algorithm: AlgEdDSA, method: jwt.SigningMethodEdDSA, verifyKey: k}, nil
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type %T in %s", parsed, path)
+	}
+}
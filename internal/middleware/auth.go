@@ -1,11 +1,14 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+
+	"backend-go-ticketing-gamify/internal/jwtauth"
 )
 
 const contextUserKey = "user"
@@ -15,10 +18,36 @@ type UserContext struct {
 	ID   string
 	Name string
 	Role string
+	// OrgID is the organization the user belongs to; every query is scoped to it.
+	OrgID string
+	// SessionID is the refresh token family the access token was issued for.
+	SessionID string
+	// TokenID is the access token's jti; ExpiresAt is when it stops being valid.
+	TokenID   string
+	ExpiresAt time.Time
+	// APITokenID is set when the request used a personal access or service
+	// account token instead of a JWT; Scopes then limit what it may call.
+	APITokenID string
+	Scopes     []string
+}
+
+// IsAPIToken reports whether the request authenticated with an API token.
+func (u *UserContext) IsAPIToken() bool { return u.APITokenID != "" }
+
+// TokenRevocations is consulted for every request so revoked tokens stop
+// working before they expire.
+type TokenRevocations interface {
+	IsRevoked(ctx context.Context, userID, tokenID, sessionID string, issuedAt time.Time) (bool, error)
+}
+
+// APITokenVerifier resolves personal access and service account tokens.
+// It returns a nil user for unknown, expired or revoked tokens.
+type APITokenVerifier interface {
+	VerifyAPIToken(ctx context.Context, raw, ip string) (*UserContext, error)
 }
 
-// AuthMiddleware enforces bearer token auth.
-func AuthMiddleware(secret string) gin.HandlerFunc {
+// AuthMiddleware enforces bearer token auth. revocations and apiTokens may be nil.
+func AuthMiddleware(keys *jwtauth.KeySet, revocations TokenRevocations, apiTokens APITokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		raw := c.GetHeader("Authorization")
 		if raw == "" || !strings.HasPrefix(strings.ToLower(raw), "bearer ") {
@@ -32,20 +61,25 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
+		// JWTs always have three dot-separated parts; anything else is an API token.
+		if apiTokens != nil && strings.Count(tokenString, ".") != 2 {
+			user, err := apiTokens.VerifyAPIToken(c.Request.Context(), tokenString, c.ClientIP())
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "unable to verify token"})
+				return
 			}
-			return []byte(secret), nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			if user == nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+				return
+			}
+			c.Set(contextUserKey, user)
+			c.Next()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
+		claims, err := keys.Parse(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
@@ -55,13 +89,45 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
+		org, _ := claims["org"].(string)
+		if org == "" {
+			// issued before organizations existed; the client has to sign in again
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing organization"})
+			return
+		}
+
 		name, _ := claims["name"].(string)
 		role, _ := claims["role"].(string)
+		sid, _ := claims["sid"].(string)
+		jti, _ := claims["jti"].(string)
+		var issuedAt, expiresAt time.Time
+		if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+			issuedAt = iat.Time
+		}
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			expiresAt = exp.Time
+		}
+
+		if revocations != nil {
+			revoked, err := revocations.IsRevoked(c.Request.Context(), sub, jti, sid, issuedAt)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "unable to verify token"})
+				return
+			}
+			if revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+				return
+			}
+		}
 
 		c.Set(contextUserKey, &UserContext{
-			ID:   sub,
-			Name: name,
-			Role: role,
+			ID:        sub,
+			Name:      name,
+			Role:      role,
+			OrgID:     org,
+			SessionID: sid,
+			TokenID:   jti,
+			ExpiresAt: expiresAt,
 		})
 		c.Next()
 	}
@@ -91,6 +157,44 @@ func RequireRoles(roles ...string) gin.HandlerFunc {
 	}
 }
 
+// RequireScope limits API tokens to routes of resource they were granted:
+// safe methods need "<resource>:read" (or :write), everything else
+// "<resource>:write". JWT sessions are not restricted by scopes.
+func RequireScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil || !user.IsAPIToken() {
+			c.Next()
+			return
+		}
+		write := resource + ":write"
+		need := write
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			need = resource + ":read"
+		}
+		for _, scope := range user.Scopes {
+			if scope == need || scope == write {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token is missing scope " + need})
+	}
+}
+
+// RequireSession rejects API tokens, for account settings that only a
+// signed-in user may change (passwords, MFA, tokens themselves).
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user := CurrentUser(c); user != nil && user.IsAPIToken() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not available to API tokens"})
+			return
+		}
+		c.Next()
+	}
+}
+
 // CurrentUser fetches the UserContext.
 func CurrentUser(c *gin.Context) *UserContext {
 	if value, ok := c.Get(contextUserKey); ok {
@@ -0,0 +1,142 @@
+package milestones
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/response"
+)
+
+// Handler exposes milestone endpoints.
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/projects/:id/milestones", h.listByProject)
+	router.POST("/projects/:id/milestones", middleware.RequireRoles("admin", "project_manager"), h.create)
+	router.GET("/milestones/:id", h.get)
+	router.PATCH("/milestones/:id", middleware.RequireRoles("admin", "project_manager"), h.update)
+	router.DELETE("/milestones/:id", middleware.RequireRoles("admin", "project_manager"), h.delete)
+}
+
+func (h *Handler) listByProject(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	open, _ := strconv.ParseBool(c.DefaultQuery("open", "false"))
+	milestones, err := h.service.List(c.Request.Context(), Filter{
+		OrgID:     user.OrgID,
+		ProjectID: c.Param("id"),
+		Open:      open,
+	})
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, milestones)
+}
+
+func (h *Handler) create(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload CreateInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	payload.ProjectID = c.Param("id")
+	payload.CreatedBy = user.ID
+	payload.Title = strings.TrimSpace(payload.Title)
+	if payload.Title == "" || payload.DueDate == nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "title and dueDate are required")
+		return
+	}
+	milestone, err := h.service.Create(c.Request.Context(), user.OrgID, payload)
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.Created(c, milestone)
+}
+
+func (h *Handler) get(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	milestone, err := h.service.Get(c.Request.Context(), user.OrgID, c.Param("id"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	if milestone == nil {
+		response.ErrorCode(c, http.StatusNotFound, "not_found", "milestone not found")
+		return
+	}
+	response.OK(c, milestone)
+}
+
+func (h *Handler) update(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload UpdateInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if payload.Title != nil && strings.TrimSpace(*payload.Title) == "" {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "title can't be empty")
+		return
+	}
+	milestone, err := h.service.Update(c.Request.Context(), user.OrgID, c.Param("id"), payload)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	if milestone == nil {
+		response.ErrorCode(c, http.StatusNotFound, "not_found", "milestone not found")
+		return
+	}
+	response.OK(c, milestone)
+}
+
+func (h *Handler) delete(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	deleted, err := h.service.Delete(c.Request.Context(), user.OrgID, c.Param("id"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	if !deleted {
+		response.ErrorCode(c, http.StatusNotFound, "not_found", "milestone not found")
+		return
+	}
+	response.NoContent(c)
+}
@@ -0,0 +1,139 @@
+package milestones
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+const milestoneColumns = `m.id, m.project_id, m.title, m.description, m.due_date, m.completed_at, m.created_by, m.created_at, m.updated_at`
+
+func scanMilestone(row pgx.Row) (*Milestone, error) {
+	var m Milestone
+	if err := row.Scan(&m.ID, &m.ProjectID, &m.Title, &m.Description, &m.DueDate, &m.CompletedAt, &m.CreatedBy, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListByProject returns a project's milestones, soonest first.
+func (r *Repository) ListByProject(ctx context.Context, filter Filter) ([]Milestone, error) {
+	query := `
+SELECT ` + milestoneColumns + `
+FROM milestones m
+JOIN projects p ON p.id = m.project_id
+WHERE m.project_id::text = $1 AND p.org_id = $2`
+	if filter.Open {
+		query += ` AND m.completed_at IS NULL`
+	}
+	query += ` ORDER BY m.due_date ASC, m.created_at ASC`
+
+	rows, err := r.db.Query(ctx, query, filter.ProjectID, filter.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	milestones := []Milestone{}
+	for rows.Next() {
+		m, err := scanMilestone(rows)
+		if err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, *m)
+	}
+	return milestones, rows.Err()
+}
+
+// Get returns a milestone of a project in orgID.
+func (r *Repository) Get(ctx context.Context, orgID, id string) (*Milestone, error) {
+	query := `
+SELECT ` + milestoneColumns + `
+FROM milestones m
+JOIN projects p ON p.id = m.project_id
+WHERE m.id::text = $1 AND p.org_id = $2`
+	m, err := scanMilestone(r.db.QueryRow(ctx, query, id, orgID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return m, err
+}
+
+func (r *Repository) Create(ctx context.Context, input CreateInput) (*Milestone, error) {
+	const query = `
+INSERT INTO milestones AS m (project_id, title, description, due_date, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING ` + milestoneColumns
+	return scanMilestone(r.db.QueryRow(ctx, query, input.ProjectID, input.Title, input.Description, input.DueDate, input.CreatedBy))
+}
+
+// Update changes a milestone of orgID; the caller checked that it exists there.
+func (r *Repository) Update(ctx context.Context, orgID, id string, input UpdateInput) (*Milestone, error) {
+	setParts := []string{}
+	args := []any{}
+	idx := 1
+
+	if input.Title != nil {
+		setParts = append(setParts, fmt.Sprintf("title = $%d", idx))
+		args = append(args, *input.Title)
+		idx++
+	}
+	if input.Description != nil {
+		setParts = append(setParts, fmt.Sprintf("description = $%d", idx))
+		args = append(args, *input.Description)
+		idx++
+	}
+	if input.DueDate != nil {
+		setParts = append(setParts, fmt.Sprintf("due_date = $%d", idx))
+		args = append(args, input.DueDate)
+		idx++
+	}
+	if input.Completed != nil {
+		if *input.Completed {
+			setParts = append(setParts, "completed_at = COALESCE(completed_at, now())")
+		} else {
+			setParts = append(setParts, "completed_at = NULL")
+		}
+	}
+
+	if len(setParts) == 0 {
+		return r.Get(ctx, orgID, id)
+	}
+	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", idx))
+	args = append(args, time.Now())
+	idx++
+	args = append(args, id)
+
+	query := fmt.Sprintf(`UPDATE milestones SET %s WHERE id = $%d`, strings.Join(setParts, ", "), idx)
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, orgID, id)
+}
+
+func (r *Repository) Delete(ctx context.Context, id string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM milestones WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ProjectInOrg reports whether projectID belongs to orgID.
+func (r *Repository) ProjectInOrg(ctx context.Context, orgID, projectID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id::text = $1 AND org_id = $2)`, projectID, orgID).Scan(&ok)
+	return ok, err
+}
@@ -0,0 +1,51 @@
+package milestones
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrProjectNotFound = errors.New("project not found")
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) List(ctx context.Context, filter Filter) ([]Milestone, error) {
+	return s.repo.ListByProject(ctx, filter)
+}
+
+func (s *Service) Get(ctx context.Context, orgID, id string) (*Milestone, error) {
+	return s.repo.Get(ctx, orgID, id)
+}
+
+func (s *Service) Create(ctx context.Context, orgID string, input CreateInput) (*Milestone, error) {
+	ok, err := s.repo.ProjectInOrg(ctx, orgID, input.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+	return s.repo.Create(ctx, input)
+}
+
+func (s *Service) Update(ctx context.Context, orgID, id string, input UpdateInput) (*Milestone, error) {
+	current, err := s.repo.Get(ctx, orgID, id)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, orgID, id, input)
+}
+
+func (s *Service) Delete(ctx context.Context, orgID, id string) (bool, error) {
+	current, err := s.repo.Get(ctx, orgID, id)
+	if err != nil || current == nil {
+		return false, err
+	}
+	return s.repo.Delete(ctx, id)
+}
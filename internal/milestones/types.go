@@ -0,0 +1,40 @@
+package milestones
+
+import "time"
+
+// Milestone is a dated checkpoint of a project.
+type Milestone struct {
+	ID          string     `json:"id"`
+	ProjectID   string     `json:"projectId"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueDate     time.Time  `json:"dueDate"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CreatedBy   *string    `json:"createdBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// Filter for listing milestones by project.
+type Filter struct {
+	OrgID     string
+	ProjectID string
+	// Open keeps milestones that aren't completed.
+	Open bool
+}
+
+type CreateInput struct {
+	ProjectID   string     `json:"projectId"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueDate     *time.Time `json:"dueDate"`
+	CreatedBy   string     `json:"-"`
+}
+
+type UpdateInput struct {
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	DueDate     *time.Time `json:"dueDate"`
+	// Completed marks the milestone reached, or open again with false.
+	Completed *bool `json:"completed"`
+}
@@ -0,0 +1,350 @@
+// Package oidctest is a minimal OpenID Connect provider for tests and local
+// development (cmd/mockoidc). It signs in whoever fills the form on its
+// authorize page; never expose it outside a dev machine.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyID is the kid of the provider's signing key.
+const KeyID = "mock"
+
+// User is who signs in at the authorize endpoint.
+type User struct {
+	Email         string
+	Name          string
+	Groups        []string
+	EmailVerified bool
+}
+
+type grant struct {
+	clientID    string
+	redirectURI string
+	nonce       string
+	challenge   string
+	user        User
+	expires     time.Time
+}
+
+// Provider serves discovery, JWKS, authorize, token and userinfo endpoints.
+type Provider struct {
+	// Issuer is the provider's URL as published in discovery and ID tokens.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// IDTokenClaims, when set, edits the claims of each ID token before it
+	// is signed, e.g. to send a wrong audience or nonce.
+	IDTokenClaims func(jwt.MapClaims)
+
+	key *rsa.PrivateKey
+	mux *http.ServeMux
+
+	mu     sync.Mutex
+	codes  map[string]*grant
+	tokens map[string]*grant
+}
+
+// New creates a provider for issuer that accepts clientID. An empty
+// clientSecret accepts public clients.
+func New(issuer, clientID, clientSecret string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	p := &Provider{
+		Issuer:       strings.TrimRight(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		mux:          http.NewServeMux(),
+		codes:        map[string]*grant{},
+		tokens:       map[string]*grant{},
+	}
+	p.mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	p.mux.HandleFunc("/jwks", p.jwks)
+	p.mux.HandleFunc("/authorize", p.authorize)
+	p.mux.HandleFunc("/token", p.token)
+	p.mux.HandleFunc("/userinfo", p.userinfo)
+	return p, nil
+}
+
+// Start serves a new provider on a local httptest server whose URL is the
+// issuer. Close the server when done.
+func Start(clientID, clientSecret string) (*Provider, *httptest.Server, error) {
+	p, err := New("", clientID, clientSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	srv := httptest.NewServer(p)
+	p.Issuer = srv.URL
+	return p, srv, nil
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+// Login plays the browser: it opens authURL, the relying party's
+// authorization request, signs user in and returns the code and state the
+// provider redirects back with.
+func (p *Provider) Login(authURL string, user User) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	q.Set("email", user.Email)
+	q.Set("name", user.Name)
+	q.Set("groups", strings.Join(user.Groups, ","))
+	q.Set("email_verified", fmt.Sprint(user.EmailVerified))
+	u.RawQuery = q.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		return "", "", fmt.Errorf("authorize returned %d", resp.StatusCode)
+	}
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	code = back.Query().Get("code")
+	if code == "" {
+		return "", "", errors.New("authorize redirect has no code")
+	}
+	return code, back.Query().Get("state"), nil
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"userinfo_endpoint":                     p.Issuer + "/userinfo",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile", "groups"},
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": KeyID,
+		"alg": "RS256",
+		"use": "sig",
+		"n":   b64(pub.N.Bytes()),
+		"e":   b64(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!doctype html>
+<title>Mock OIDC sign-in</title>
+<h1>Mock OIDC sign-in</h1>
+<form method="post">
+  {{range $k, $v := .Query}}<input type="hidden" name="{{$k}}" value="{{index $v 0}}">{{end}}
+  <p><label>Email <input name="email" value="dev@example.com"></label></p>
+  <p><label>Name <input name="name" value="Dev User"></label></p>
+  <p><label>Groups (comma separated) <input name="groups" value="developers"></label></p>
+  <p><label><input type="checkbox" name="email_verified" value="true" checked> Email verified</label></p>
+  <button type="submit">Sign in</button>
+</form>`))
+
+// authorize shows a form on GET and issues a code on POST. Tests can skip
+// the form by sending GET with email (and optional name, groups and
+// email_verified, true by default) in the query.
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.Form
+	if q.Get("client_id") != p.ClientID || q.Get("response_type") != "code" || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid client_id, response_type or redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+	if r.Method == http.MethodGet && q.Get("email") == "" {
+		_ = loginPage.Execute(w, map[string]any{"Query": r.URL.Query()})
+		return
+	}
+
+	verified := q.Get("email_verified") == "true"
+	if r.Method == http.MethodGet {
+		verified = q.Get("email_verified") != "false"
+	}
+	g := &grant{
+		clientID:    q.Get("client_id"),
+		redirectURI: q.Get("redirect_uri"),
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		user:        User{Email: q.Get("email"), Name: q.Get("name"), EmailVerified: verified},
+		expires:     time.Now().Add(time.Minute),
+	}
+	for _, grp := range strings.Split(q.Get("groups"), ",") {
+		if grp = strings.TrimSpace(grp); grp != "" {
+			g.user.Groups = append(g.user.Groups, grp)
+		}
+	}
+	target, err := url.Parse(g.redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = g
+	p.mu.Unlock()
+
+	values := target.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	target.RawQuery = values.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	clientID, secret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.Form.Get("client_id"), r.Form.Get("client_secret")
+	}
+	if clientID != p.ClientID || (p.ClientSecret != "" && secret != p.ClientSecret) {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	code := r.Form.Get("code")
+	p.mu.Lock()
+	g := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+	if g == nil || time.Now().After(g.expires) || g.redirectURI != r.Form.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if b64(sum[:]) != g.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                p.Issuer,
+		"sub":                SubjectFor(g.user.Email),
+		"aud":                g.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              g.nonce,
+		"email":              g.user.Email,
+		"email_verified":     g.user.EmailVerified,
+		"name":               g.user.Name,
+		"preferred_username": strings.SplitN(g.user.Email, "@", 2)[0],
+		"groups":             g.user.Groups,
+	}
+	if p.IDTokenClaims != nil {
+		p.IDTokenClaims(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyID
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	access := randomString()
+	p.mu.Lock()
+	p.tokens[access] = g
+	p.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": access,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) userinfo(w http.ResponseWriter, r *http.Request) {
+	access := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	p.mu.Lock()
+	g := p.tokens[access]
+	p.mu.Unlock()
+	if g == nil {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"sub":            SubjectFor(g.user.Email),
+		"email":          g.user.Email,
+		"email_verified": g.user.EmailVerified,
+		"name":           g.user.Name,
+		"groups":         g.user.Groups,
+	})
+}
+
+// SubjectFor is the subject the provider gives email; it is stable so
+// repeated logins link to the same identity.
+func SubjectFor(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(email)))
+	return b64(sum[:12])
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	buf := make([]byte, 24)
+	_, _ = rand.Read(buf)
+	return b64(buf)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
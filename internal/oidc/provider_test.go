@@ -0,0 +1,229 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"backend-go-ticketing-gamify/internal/oidc"
+	"backend-go-ticketing-gamify/internal/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost:5173/auth/callback"
+
+func start(t *testing.T, secret string) (*oidctest.Provider, *oidc.Provider) {
+	t.Helper()
+	idp, srv, err := oidctest.Start("ticketing", secret)
+	if err != nil {
+		t.Fatalf("start provider: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	rp := oidc.NewProvider(oidc.Config{
+		Issuer:       srv.URL,
+		ClientID:     "ticketing",
+		ClientSecret: secret,
+		RedirectURL:  redirectURL,
+	})
+	return idp, rp
+}
+
+// login runs the authorization-code flow up to the token response.
+func login(t *testing.T, idp *oidctest.Provider, rp *oidc.Provider, user oidctest.User) (*oidc.Tokens, string) {
+	t.Helper()
+	ctx := context.Background()
+	state, nonce, verifier := oidc.NewVerifier(), oidc.NewVerifier(), oidc.NewVerifier()
+	authURL, err := rp.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, gotState, err := idp.Login(authURL, user)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if gotState != state {
+		t.Fatalf("state = %q, want %q", gotState, state)
+	}
+	tokens, err := rp.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	return tokens, nonce
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	_, rp := start(t, "")
+	authURL, err := rp.AuthCodeURL(context.Background(), "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !strings.HasSuffix(u.Path, "/authorize") {
+		t.Errorf("endpoint = %s, want the discovered authorize endpoint", u.Path)
+	}
+	q := u.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "ticketing",
+		"redirect_uri":          redirectURL,
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        oidc.CodeChallenge("verifier-1"),
+		"code_challenge_method": "S256",
+	}
+	for k, v := range want {
+		if got := q.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestCodeExchange(t *testing.T) {
+	for _, secret := range []string{"", "s3cret"} {
+		t.Run("secret="+secret, func(t *testing.T) {
+			idp, rp := start(t, secret)
+			user := oidctest.User{Email: "ana@example.com", Name: "Ana", Groups: []string{"devs", "leads"}, EmailVerified: true}
+			tokens, nonce := login(t, idp, rp, user)
+
+			claims, err := rp.VerifyIDToken(context.Background(), tokens.IDToken, nonce)
+			if err != nil {
+				t.Fatalf("VerifyIDToken: %v", err)
+			}
+			if claims.Subject != oidctest.SubjectFor(user.Email) || claims.Issuer != idp.Issuer {
+				t.Errorf("subject %q from %q", claims.Subject, claims.Issuer)
+			}
+			if claims.Email != user.Email || !claims.EmailVerified || claims.Name != "Ana" || claims.PreferredUsername != "ana" {
+				t.Errorf("claims = %+v", claims)
+			}
+			if strings.Join(claims.Groups, ",") != "devs,leads" {
+				t.Errorf("groups = %v", claims.Groups)
+			}
+		})
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	idp, rp := start(t, "")
+	ctx := context.Background()
+	authURL, err := rp.AuthCodeURL(ctx, "state", "nonce", oidc.NewVerifier())
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _, err := idp.Login(authURL, oidctest.User{Email: "ana@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if _, err := rp.Exchange(ctx, code, oidc.NewVerifier()); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("err = %v, want invalid_grant", err)
+	}
+}
+
+func TestExchangeRejectsReusedCode(t *testing.T) {
+	idp, rp := start(t, "")
+	ctx := context.Background()
+	verifier := oidc.NewVerifier()
+	authURL, err := rp.AuthCodeURL(ctx, "state", "nonce", verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _, err := idp.Login(authURL, oidctest.User{Email: "ana@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if _, err := rp.Exchange(ctx, code, verifier); err != nil {
+		t.Fatalf("first exchange: %v", err)
+	}
+	if _, err := rp.Exchange(ctx, code, verifier); err == nil {
+		t.Fatal("second exchange of the same code succeeded")
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	cases := []struct {
+		name   string
+		edit   func(jwt.MapClaims)
+		nonce  func(string) string
+		reason string
+	}{
+		{name: "wrong nonce", nonce: func(string) string { return "other" }, reason: "nonce"},
+		{name: "missing nonce", edit: func(c jwt.MapClaims) { delete(c, "nonce") }, reason: "nonce"},
+		{name: "wrong audience", edit: func(c jwt.MapClaims) { c["aud"] = "someone-else" }, reason: "audience"},
+		{name: "wrong issuer", edit: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, reason: "issuer"},
+		{name: "expired", edit: func(c jwt.MapClaims) { c["exp"] = c["iat"].(int64) - 3600 }, reason: "expired"},
+		{name: "no subject", edit: func(c jwt.MapClaims) { delete(c, "sub") }, reason: "subject"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			idp, rp := start(t, "")
+			idp.IDTokenClaims = tc.edit
+			tokens, nonce := login(t, idp, rp, oidctest.User{Email: "ana@example.com", EmailVerified: true})
+			if tc.nonce != nil {
+				nonce = tc.nonce(nonce)
+			}
+			_, err := rp.VerifyIDToken(context.Background(), tokens.IDToken, nonce)
+			if err == nil || !strings.Contains(err.Error(), tc.reason) {
+				t.Fatalf("err = %v, want a %s error", err, tc.reason)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRejectsForeignKey(t *testing.T) {
+	other, otherSrv, err := oidctest.Start("ticketing", "")
+	if err != nil {
+		t.Fatalf("start provider: %v", err)
+	}
+	defer otherSrv.Close()
+	idp, rp := start(t, "")
+	// a token signed by another provider's key, claiming to be from idp
+	other.IDTokenClaims = func(c jwt.MapClaims) { c["iss"] = idp.Issuer }
+	otherRP := oidc.NewProvider(oidc.Config{Issuer: other.Issuer, ClientID: "ticketing", RedirectURL: redirectURL})
+	tokens, nonce := login(t, other, otherRP, oidctest.User{Email: "ana@example.com", EmailVerified: true})
+
+	if _, err := rp.VerifyIDToken(context.Background(), tokens.IDToken, nonce); err == nil {
+		t.Fatal("token signed with another key was accepted")
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp, rp := start(t, "")
+	idp.Issuer = "https://elsewhere.example.com"
+	if _, err := rp.AuthCodeURL(context.Background(), "s", "n", "v"); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("err = %v, want an issuer mismatch", err)
+	}
+}
+
+func TestUserInfoFillsMissingClaims(t *testing.T) {
+	idp, rp := start(t, "")
+	// like IdPs that keep email and groups out of the ID token
+	idp.IDTokenClaims = func(c jwt.MapClaims) {
+		delete(c, "email")
+		delete(c, "email_verified")
+		delete(c, "groups")
+	}
+	tokens, nonce := login(t, idp, rp, oidctest.User{Email: "ana@example.com", Groups: []string{"admins"}, EmailVerified: true})
+	ctx := context.Background()
+	claims, err := rp.VerifyIDToken(ctx, tokens.IDToken, nonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Email != "" || len(claims.Groups) != 0 {
+		t.Fatalf("ID token still carries email or groups: %+v", claims)
+	}
+	if err := rp.UserInfo(ctx, tokens.AccessToken, claims); err != nil {
+		t.Fatalf("UserInfo: %v", err)
+	}
+	if claims.Email != "ana@example.com" || !claims.EmailVerified || strings.Join(claims.Groups, ",") != "admins" {
+		t.Fatalf("claims after userinfo = %+v", claims)
+	}
+
+	claims.Subject = "someone-else"
+	if err := rp.UserInfo(ctx, tokens.AccessToken, claims); err == nil {
+		t.Fatal("userinfo for another subject was accepted")
+	}
+}
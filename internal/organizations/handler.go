@@ -0,0 +1,121 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/response"
+)
+
+// Handler wires organization routes.
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes mounts the caller's own organization.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("", h.current)
+	router.PATCH("", middleware.RequireRoles("admin"), h.update)
+}
+
+// RegisterOperatorRoutes mounts management of all organizations, limited to
+// admins of the default organization.
+func (h *Handler) RegisterOperatorRoutes(router *gin.RouterGroup) {
+	router.Use(middleware.RequireRoles("admin"), RequireDefaultOrg())
+	router.GET("", h.list)
+	router.POST("", h.create)
+}
+
+// RequireDefaultOrg limits a route to users of the default organization, who
+// operate the whole installation.
+func RequireDefaultOrg() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user := middleware.CurrentUser(c); user == nil || user.OrgID != DefaultID {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (h *Handler) current(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	org, err := h.service.Get(c.Request.Context(), user.OrgID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.OK(c, org)
+}
+
+func (h *Handler) update(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload UpdateInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	org, err := h.service.Update(c.Request.Context(), user.OrgID, user.ID, payload)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.OK(c, org)
+}
+
+func (h *Handler) list(c *gin.Context) {
+	orgs, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, orgs)
+}
+
+func (h *Handler) create(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload CreateInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	org, err := h.service.Create(c.Request.Context(), user.ID, payload)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.Created(c, org)
+}
+
+func writeError(c *gin.Context, err error) {
+	var validation *ValidationError
+	switch {
+	case errors.As(err, &validation):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	case errors.Is(err, ErrNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrSlugTaken):
+		response.ErrorCode(c, http.StatusConflict, "slug_taken", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
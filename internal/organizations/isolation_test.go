@@ -0,0 +1,420 @@
+package organizations_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/calendar"
+	"backend-go-ticketing-gamify/internal/database/dbtest"
+	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/projects"
+	"backend-go-ticketing-gamify/internal/reports"
+	"backend-go-ticketing-gamify/internal/sla"
+	"backend-go-ticketing-gamify/internal/tickets"
+	"backend-go-ticketing-gamify/internal/users"
+)
+
+// tenant is an organization with one of everything.
+type tenant struct {
+	actor      *middleware.UserContext
+	projectID  string
+	ticketID   string
+	timeOffID  string
+	calendarID string
+}
+
+// seedTenant creates an organization with an admin, a project with an SLA
+// policy and a ticket completed yesterday and due today, time off, an SLA
+// calendar and an audit entry.
+func seedTenant(t *testing.T, db *pgxpool.Pool, auditSvc *audit.Service, slaSvc *sla.Service) tenant {
+	t.Helper()
+	ctx := context.Background()
+	orgID := dbtest.Org(t, db)
+	adminID := dbtest.User(t, db, orgID, "admin")
+	projectID := dbtest.Project(t, db, orgID, adminID)
+	ticketID := dbtest.Ticket(t, db, projectID, adminID)
+	now := time.Now().UTC()
+	dbtest.Exec(t, db, `UPDATE tickets SET status = 'done', due_date = $2, created_at = $3 WHERE id = $1`,
+		ticketID, now.Truncate(24*time.Hour), now.Add(-72*time.Hour))
+	dbtest.Exec(t, db, `
+INSERT INTO ticket_status_transitions (ticket_id, from_status, to_status, changed_at)
+VALUES ($1, NULL, 'todo', $2), ($1, 'todo', 'in_progress', $3), ($1, 'in_progress', 'done', $4)`,
+		ticketID, now.Add(-72*time.Hour), now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+
+	tn := tenant{
+		actor:     &middleware.UserContext{ID: adminID, Name: "Admin", Role: "admin", OrgID: orgID},
+		projectID: projectID,
+		ticketID:  ticketID,
+	}
+	if err := db.QueryRow(ctx, `
+INSERT INTO time_off (user_id, start_date, end_date, created_by) VALUES ($1, $2, $2, $1) RETURNING id::text`,
+		adminID, now.Truncate(24*time.Hour)).Scan(&tn.timeOffID); err != nil {
+		t.Fatalf("insert time off: %v", err)
+	}
+	cal, err := slaSvc.CreateCalendar(ctx, orgID, adminID, sla.CalendarInput{
+		Name: "Office", Timezone: "UTC", WorkDays: []int{1, 2, 3, 4, 5}, DayStart: "09:00", DayEnd: "17:00",
+	})
+	if err != nil {
+		t.Fatalf("create SLA calendar: %v", err)
+	}
+	tn.calendarID = cal.ID
+	enabled, minutes := true, 60
+	if _, err := slaSvc.SavePolicy(ctx, orgID, projectID, adminID, sla.PolicyInput{
+		Enabled: &enabled,
+		Targets: []sla.Target{{Priority: "medium", ResponseMinutes: &minutes, ResolutionMinutes: &minutes}},
+	}); err != nil {
+		t.Fatalf("save SLA policy: %v", err)
+	}
+	entity := "ticket"
+	if err := auditSvc.Log(ctx, "ticket_created", "seeded", &adminID, &entity, &ticketID); err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	return tn
+}
+
+// TestOrganizationIsolation seeds two organizations and checks that every
+// org-scoped read and write of one finds nothing of the other's.
+func TestOrganizationIsolation(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+
+	auditSvc := audit.NewService(audit.NewRepository(db))
+	slaSvc := sla.NewService(sla.NewRepository(db), auditSvc, sla.Options{})
+	gamSvc := gamification.NewService(gamification.NewRepository(db), auditSvc)
+	ticketRepo := tickets.NewRepository(db)
+	ticketSvc := tickets.NewService(ticketRepo, auditSvc, gamSvc, slaSvc)
+
+	a := seedTenant(t, db, auditSvc, slaSvc)
+	b := seedTenant(t, db, auditSvc, slaSvc)
+	orgB := b.actor.OrgID
+
+	t.Run("tickets", func(t *testing.T) {
+		if got, err := ticketSvc.Get(ctx, orgB, a.ticketID); err != nil || got != nil {
+			t.Errorf("Get = %v, %v; want nil", got, err)
+		}
+		list, err := ticketSvc.List(ctx, tickets.Filter{OrgID: orgB, ProjectID: a.projectID})
+		if err != nil || len(list) != 0 {
+			t.Errorf("List by the other project = %d tickets, %v; want none", len(list), err)
+		}
+		list, err = ticketSvc.List(ctx, tickets.Filter{OrgID: orgB})
+		if err != nil || ticketIDs(list) != b.ticketID {
+			t.Errorf("List = %s, %v; want only %s", ticketIDs(list), err, b.ticketID)
+		}
+		many, err := ticketRepo.GetMany(ctx, orgB, []string{a.ticketID, b.ticketID})
+		if err != nil || ticketIDs(many) != b.ticketID {
+			t.Errorf("GetMany = %s, %v; want only %s", ticketIDs(many), err, b.ticketID)
+		}
+		var streamed []tickets.Ticket
+		err = ticketSvc.Stream(ctx, tickets.Filter{OrgID: orgB}, func(tk tickets.Ticket) error {
+			streamed = append(streamed, tk)
+			return nil
+		})
+		if err != nil || ticketIDs(streamed) != b.ticketID {
+			t.Errorf("Stream = %s, %v; want only %s", ticketIDs(streamed), err, b.ticketID)
+		}
+	})
+
+	t.Run("ticket writes", func(t *testing.T) {
+		if got, err := ticketSvc.UpdateStatus(ctx, b.actor, a.ticketID, "todo"); err != nil || got != nil {
+			t.Errorf("UpdateStatus = %v, %v; want nil", got, err)
+		}
+		title := "taken over"
+		if got, err := ticketSvc.UpdateDetails(ctx, b.actor, a.ticketID, tickets.UpdateInput{Title: &title}); err != nil || got != nil {
+			t.Errorf("UpdateDetails = %v, %v; want nil", got, err)
+		}
+		if _, err := ticketSvc.AddComment(ctx, b.actor, a.ticketID, "hello"); !errors.Is(err, tickets.ErrNotFound) {
+			t.Errorf("AddComment err = %v, want ErrNotFound", err)
+		}
+		if _, err := ticketSvc.Watch(ctx, b.actor, a.ticketID, ""); !errors.Is(err, tickets.ErrNotFound) {
+			t.Errorf("Watch err = %v, want ErrNotFound", err)
+		}
+		if _, err := ticketSvc.Watchers(ctx, b.actor, a.ticketID); !errors.Is(err, tickets.ErrNotFound) {
+			t.Errorf("Watchers err = %v, want ErrNotFound", err)
+		}
+		status := "todo"
+		report, err := ticketSvc.Bulk(ctx, b.actor, tickets.BulkInput{IDs: []string{a.ticketID}, Set: &tickets.BulkSet{Status: &status}})
+		if err != nil {
+			t.Fatalf("Bulk: %v", err)
+		}
+		if len(report.Results) != 1 || report.Results[0].Result != "not_found" || report.Updated != 0 {
+			t.Errorf("Bulk report = %+v, want the ticket not found", report)
+		}
+		if err := ticketSvc.Delete(ctx, b.actor, a.ticketID); err != nil {
+			t.Errorf("Delete: %v", err)
+		}
+		if _, err := ticketSvc.Create(ctx, b.actor, tickets.CreateInput{
+			ProjectID: a.projectID, Title: "x", Description: "x", Priority: "low", Type: "chore",
+		}); !errors.Is(err, tickets.ErrProjectNotFound) {
+			t.Errorf("Create in the other project err = %v, want ErrProjectNotFound", err)
+		}
+		if _, err := ticketSvc.Create(ctx, b.actor, tickets.CreateInput{
+			ProjectID: b.projectID, Title: "x", Description: "x", Priority: "low", Type: "chore", AssigneeID: &a.actor.ID,
+		}); !errors.Is(err, tickets.ErrUnknownAssignee) {
+			t.Errorf("Create assigned to the other org err = %v, want ErrUnknownAssignee", err)
+		}
+
+		got, err := ticketRepo.Get(ctx, a.actor.OrgID, a.ticketID)
+		if err != nil || got == nil {
+			t.Fatalf("the other org's ticket is gone: %v", err)
+		}
+		if got.Status != "done" || got.Title == title || len(got.Comments) != 0 {
+			t.Errorf("the other org's ticket changed: status %s, title %q, %d comments", got.Status, got.Title, len(got.Comments))
+		}
+	})
+
+	t.Run("projects", func(t *testing.T) {
+		repo := projects.NewRepository(db)
+		svc := projects.NewService(repo, auditSvc)
+		list, err := repo.List(ctx, orgB, projects.ListFilter{})
+		if err != nil || len(list) != 1 || list[0].ID != b.projectID {
+			t.Errorf("List = %+v, %v; want only %s", list, err, b.projectID)
+		}
+		if got, err := repo.Get(ctx, orgB, a.projectID, nil); err != nil || got != nil {
+			t.Errorf("Get = %v, %v; want nil", got, err)
+		}
+		if ok, err := repo.Exists(ctx, orgB, a.projectID); err != nil || ok {
+			t.Errorf("Exists = %v, %v; want false", ok, err)
+		}
+		if err := svc.AddMember(ctx, b.actor, a.projectID, projects.AddMemberInput{UserID: b.actor.ID}); !errors.Is(err, projects.ErrNotFound) {
+			t.Errorf("AddMember to the other project err = %v, want ErrNotFound", err)
+		}
+		if err := svc.AddMember(ctx, b.actor, b.projectID, projects.AddMemberInput{UserID: a.actor.ID}); !errors.Is(err, projects.ErrUnknownUser) {
+			t.Errorf("AddMember of the other org's user err = %v, want ErrUnknownUser", err)
+		}
+		if _, err := svc.CreateInvite(ctx, b.actor, a.projectID, projects.InviteInput{MaxUses: 1, ExpiryDays: 1}); !errors.Is(err, projects.ErrNotFound) {
+			t.Errorf("CreateInvite err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("users", func(t *testing.T) {
+		svc := users.NewService(users.NewRepository(db), auditSvc, nil)
+		list, _, err := svc.List(ctx, users.ListFilter{OrgID: orgB, Status: "all"})
+		if err != nil || len(list) != 1 || list[0].ID != b.actor.ID {
+			t.Errorf("List = %+v, %v; want only %s", list, err, b.actor.ID)
+		}
+		if got, err := svc.Get(ctx, orgB, a.actor.ID); got != nil {
+			t.Errorf("Get = %v, %v; want nothing", got, err)
+		}
+		if got, err := svc.UpdateRole(ctx, orgB, a.actor.ID, "developer"); got != nil {
+			t.Errorf("UpdateRole = %v, %v; want nothing", got, err)
+		}
+		if got, err := svc.UpdateProfile(ctx, orgB, a.actor.ID, users.UpdateProfileInput{Name: "taken over"}); got != nil {
+			t.Errorf("UpdateProfile = %v, %v; want nothing", got, err)
+		}
+		if _, err := svc.Deactivate(ctx, orgB, b.actor.ID, a.actor.ID, users.TicketHandoff{}); !errors.Is(err, users.ErrNotFound) {
+			t.Errorf("Deactivate err = %v, want ErrNotFound", err)
+		}
+		if _, err := svc.Anonymize(ctx, orgB, b.actor.ID, a.actor.ID, users.TicketHandoff{}); !errors.Is(err, users.ErrNotFound) {
+			t.Errorf("Anonymize err = %v, want ErrNotFound", err)
+		}
+		got, err := svc.Get(ctx, a.actor.OrgID, a.actor.ID)
+		if err != nil || got == nil || got.Role != "admin" || !got.Active || got.Name == "taken over" {
+			t.Errorf("the other org's admin changed: %+v, %v", got, err)
+		}
+	})
+
+	t.Run("reports", func(t *testing.T) {
+		svc := reports.NewService(reports.NewRepository(db))
+		summary, err := svc.GetSummary(ctx, orgB)
+		if err != nil {
+			t.Fatalf("GetSummary: %v", err)
+		}
+		if summary.TotalTickets != 1 || summary.TotalProjects != 1 || summary.TotalUsers != 1 {
+			t.Errorf("summary = %+v, want only the org's own ticket, project and user", summary)
+		}
+		assignees, err := svc.GetAssigneeBreakdown(ctx, orgB, 10)
+		if err != nil {
+			t.Fatalf("GetAssigneeBreakdown: %v", err)
+		}
+		for _, row := range assignees {
+			if row.UserID == a.actor.ID {
+				t.Errorf("assignee breakdown lists the other org's user")
+			}
+		}
+		now := time.Now().UTC()
+		flow := reports.FlowFilter{OrgID: orgB, ProjectID: a.projectID, From: now.AddDate(0, 0, -7), To: now}
+		lead, err := svc.LeadTime(ctx, flow)
+		if err != nil || lead.Stats.Count != 0 {
+			t.Errorf("LeadTime of the other project = %+v, %v; want no tickets", lead, err)
+		}
+		flow.ProjectID = ""
+		cycle, err := svc.CycleTime(ctx, flow)
+		if err != nil {
+			t.Fatalf("CycleTime: %v", err)
+		}
+		if cycle.Stats.Count != 1 || cycle.Slowest[0].TicketID != b.ticketID {
+			t.Errorf("CycleTime = %+v, want only %s", cycle, b.ticketID)
+		}
+		throughput, err := svc.Throughput(ctx, flow, "week")
+		if err != nil {
+			t.Fatalf("Throughput: %v", err)
+		}
+		completed := 0
+		for _, p := range throughput {
+			completed += p.Completed
+		}
+		if completed != 1 {
+			t.Errorf("throughput counts %d tickets, want 1", completed)
+		}
+	})
+
+	t.Run("leaderboards", func(t *testing.T) {
+		rows, err := gamSvc.Leaderboard(ctx, gamification.LeaderboardFilter{OrgID: orgB, Period: "all"})
+		if err != nil || len(rows) != 1 || rows[0].ID != b.actor.ID {
+			t.Errorf("Leaderboard = %+v, %v; want only %s", rows, err, b.actor.ID)
+		}
+		rows, err = gamSvc.Leaderboard(ctx, gamification.LeaderboardFilter{OrgID: orgB, Period: "all", ProjectID: a.projectID})
+		if err != nil || len(rows) != 0 {
+			t.Errorf("Leaderboard of the other project = %+v, %v; want none", rows, err)
+		}
+		rows, err = gamSvc.LeaderboardAround(ctx, gamification.LeaderboardFilter{OrgID: orgB, Period: "all"}, a.actor.ID, 2)
+		if err != nil || len(rows) != 0 {
+			t.Errorf("LeaderboardAround the other org's user = %+v, %v; want none", rows, err)
+		}
+		if stats, err := gamification.NewRepository(db).GetStats(ctx, orgB, a.actor.ID); err != nil || stats != nil {
+			t.Errorf("GetStats = %+v, %v; want nil", stats, err)
+		}
+	})
+
+	t.Run("audit", func(t *testing.T) {
+		entries, _, err := auditSvc.List(ctx, orgB, 200, nil)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(entries) == 0 {
+			t.Fatal("List found none of the org's own entries")
+		}
+		for _, e := range entries {
+			if mentions(e, a) {
+				t.Errorf("List returned the other org's entry %s %s", e.Action, e.Description)
+			}
+		}
+		exported := 0
+		err = auditSvc.Stream(ctx, audit.ExportFilter{OrgID: orgB}, func(e audit.Entry) error {
+			exported++
+			if mentions(e, a) {
+				t.Errorf("export included the other org's entry %s %s", e.Action, e.Description)
+			}
+			return nil
+		})
+		if err != nil || exported != len(entries) {
+			t.Errorf("export had %d entries, %v; want the %d listed", exported, err, len(entries))
+		}
+	})
+
+	t.Run("calendar", func(t *testing.T) {
+		repo := calendar.NewRepository(db)
+		svc := calendar.NewService(repo, auditSvc, calendar.Options{})
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		start, end := today.AddDate(0, 0, -7), today.AddDate(0, 0, 7)
+
+		events, err := svc.GetEvents(ctx, calendar.Filter{OrgID: orgB, StartDate: &start, EndDate: &end, Type: "all"})
+		if err != nil {
+			t.Fatalf("GetEvents: %v", err)
+		}
+		if len(events) == 0 {
+			t.Fatal("GetEvents found none of the org's own events")
+		}
+		for _, e := range events {
+			if e.ID == a.ticketID || e.ID == a.timeOffID || e.ProjectID == a.projectID || e.UserID == a.actor.ID {
+				t.Errorf("GetEvents returned the other org's %s %s", e.Type, e.ID)
+			}
+		}
+		events, err = svc.GetEvents(ctx, calendar.Filter{OrgID: orgB, StartDate: &start, EndDate: &end, ProjectID: a.projectID, Type: "all"})
+		if err != nil || len(events) != 0 {
+			t.Errorf("GetEvents of the other project = %+v, %v; want none", events, err)
+		}
+		events, err = svc.GetEvents(ctx, calendar.Filter{OrgID: orgB, StartDate: &start, EndDate: &end, AssigneeID: a.actor.ID, Type: "all"})
+		if err != nil || len(events) != 0 {
+			t.Errorf("GetEvents of the other org's user = %+v, %v; want none", events, err)
+		}
+		timeOff, err := svc.ListTimeOff(ctx, calendar.TimeOffFilter{OrgID: orgB, Start: start, End: end})
+		if err != nil || len(timeOff) != 1 || timeOff[0].ID != b.timeOffID {
+			t.Errorf("ListTimeOff = %+v, %v; want only %s", timeOff, err, b.timeOffID)
+		}
+		if got, err := repo.GetTimeOff(ctx, orgB, a.timeOffID); err != nil || got != nil {
+			t.Errorf("GetTimeOff = %v, %v; want nil", got, err)
+		}
+		if err := svc.DeleteTimeOff(ctx, b.actor, a.timeOffID); !errors.Is(err, calendar.ErrTimeOffNotFound) {
+			t.Errorf("DeleteTimeOff err = %v, want ErrTimeOffNotFound", err)
+		}
+		if got, err := repo.GetTimeOff(ctx, a.actor.OrgID, a.timeOffID); err != nil || got == nil {
+			t.Errorf("the other org's time off is gone: %v", err)
+		}
+	})
+
+	t.Run("sla", func(t *testing.T) {
+		calendars, err := slaSvc.ListCalendars(ctx, orgB)
+		if err != nil || len(calendars) != 1 || calendars[0].ID != b.calendarID {
+			t.Errorf("ListCalendars = %+v, %v; want only %s", calendars, err, b.calendarID)
+		}
+		input := sla.CalendarInput{Name: "Taken over", Timezone: "UTC", WorkDays: []int{1}, DayStart: "09:00", DayEnd: "10:00"}
+		if _, err := slaSvc.UpdateCalendar(ctx, orgB, b.actor.ID, a.calendarID, input); !errors.Is(err, sla.ErrCalendarNotFound) {
+			t.Errorf("UpdateCalendar err = %v, want ErrCalendarNotFound", err)
+		}
+		if err := slaSvc.DeleteCalendar(ctx, orgB, b.actor.ID, a.calendarID); !errors.Is(err, sla.ErrCalendarNotFound) {
+			t.Errorf("DeleteCalendar err = %v, want ErrCalendarNotFound", err)
+		}
+		if _, err := slaSvc.GetPolicy(ctx, orgB, a.projectID); !errors.Is(err, sla.ErrProjectNotFound) {
+			t.Errorf("GetPolicy err = %v, want ErrProjectNotFound", err)
+		}
+		enabled := false
+		if _, err := slaSvc.SavePolicy(ctx, orgB, a.projectID, b.actor.ID, sla.PolicyInput{Enabled: &enabled}); !errors.Is(err, sla.ErrProjectNotFound) {
+			t.Errorf("SavePolicy err = %v, want ErrProjectNotFound", err)
+		}
+		if _, err := slaSvc.SavePolicy(ctx, orgB, b.projectID, b.actor.ID, sla.PolicyInput{Enabled: &enabled, CalendarID: &a.calendarID}); !errors.Is(err, sla.ErrInvalid) {
+			t.Errorf("SavePolicy with the other org's calendar err = %v, want ErrInvalid", err)
+		}
+
+		now := time.Now().UTC()
+		report, err := slaSvc.Compliance(ctx, sla.ComplianceFilter{OrgID: orgB, ProjectID: a.projectID, From: now.AddDate(0, 0, -7), To: now})
+		if err != nil || report.Tickets != 0 {
+			t.Errorf("Compliance of the other project = %+v, %v; want no tickets", report, err)
+		}
+		report, err = slaSvc.Compliance(ctx, sla.ComplianceFilter{OrgID: orgB, From: now.AddDate(0, 0, -7), To: now})
+		if err != nil {
+			t.Fatalf("Compliance: %v", err)
+		}
+		for _, g := range report.ByProject {
+			if g.Key != b.projectID {
+				t.Errorf("compliance lists project %s", g.Key)
+			}
+		}
+
+		kept, err := slaSvc.ListCalendars(ctx, a.actor.OrgID)
+		if err != nil || len(kept) != 1 || kept[0].Name != "Office" {
+			t.Errorf("the other org's calendar changed: %+v, %v", kept, err)
+		}
+		policy, err := slaSvc.GetPolicy(ctx, a.actor.OrgID, a.projectID)
+		if err != nil || !policy.Enabled {
+			t.Errorf("the other org's policy changed: %+v, %v", policy, err)
+		}
+	})
+}
+
+func ticketIDs(list []tickets.Ticket) string {
+	ids := make([]string, len(list))
+	for i, tk := range list {
+		ids[i] = tk.ID
+	}
+	slices.Sort(ids)
+	return strings.Join(ids, ",")
+}
+
+// mentions reports whether an audit entry is about something of tn.
+func mentions(e audit.Entry, tn tenant) bool {
+	for _, id := range []*string{e.ActorID, e.EntityID} {
+		if id != nil && (*id == tn.actor.ID || *id == tn.ticketID || *id == tn.projectID || *id == tn.calendarID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles organization persistence.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+const orgQuery = `
+SELECT o.id, o.name, o.slug,
+       (SELECT count(*) FROM users u WHERE u.org_id = o.id AND u.deactivated_at IS NULL AND NOT u.is_service_account),
+       (SELECT count(*) FROM projects p WHERE p.org_id = o.id),
+       o.created_at, o.updated_at
+FROM organizations o`
+
+func scanOrganization(row pgx.Row) (*Organization, error) {
+	var o Organization
+	if err := row.Scan(&o.ID, &o.Name, &o.Slug, &o.Members, &o.Projects, &o.CreatedAt, &o.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id string) (*Organization, error) {
+	return scanOrganization(r.db.QueryRow(ctx, orgQuery+` WHERE o.id = $1`, id))
+}
+
+func (r *Repository) List(ctx context.Context) ([]Organization, error) {
+	rows, err := r.db.Query(ctx, orgQuery+` ORDER BY o.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []Organization{}
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, *o)
+	}
+	return orgs, rows.Err()
+}
+
+func (r *Repository) Create(ctx context.Context, name, slug string) (*Organization, error) {
+	const query = `
+INSERT INTO organizations (name, slug)
+VALUES ($1, $2)
+RETURNING id, name, slug, 0, 0, created_at, updated_at`
+	o, err := scanOrganization(r.db.QueryRow(ctx, query, name, slug))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrSlugTaken
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+func (r *Repository) UpdateName(ctx context.Context, id, name string) (*Organization, error) {
+	tag, err := r.db.Exec(ctx, `UPDATE organizations SET name = $2, updated_at = NOW() WHERE id = $1`, id, name)
+	if err != nil || tag.RowsAffected() == 0 {
+		return nil, err
+	}
+	return r.Get(ctx, id)
+}
+
+// DeleteEmpty removes an organization that has no users or projects yet.
+func (r *Repository) DeleteEmpty(ctx context.Context, id string) error {
+	const query = `
+DELETE FROM organizations o
+WHERE o.id = $1
+  AND NOT EXISTS (SELECT 1 FROM users u WHERE u.org_id = o.id)
+  AND NOT EXISTS (SELECT 1 FROM projects p WHERE p.org_id = o.id)`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
@@ -0,0 +1,145 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"backend-go-ticketing-gamify/internal/audit"
+)
+
+var (
+	ErrNotFound  = errors.New("organization not found")
+	ErrSlugTaken = errors.New("slug already in use")
+)
+
+// ValidationError reports bad input and maps to 400.
+type ValidationError struct{ Message string }
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(format string, args ...any) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,48}[a-z0-9]$`)
+
+// AdminInviter sends the invitation that makes someone the first admin of a
+// new organization and returns the sign-up link. Problems with the address
+// are reported as a *ValidationError.
+type AdminInviter interface {
+	InviteOrgAdmin(ctx context.Context, orgID, actorID, email, name string) (string, error)
+}
+
+// Service manages organizations.
+type Service struct {
+	repo    *Repository
+	audit   *audit.Service
+	inviter AdminInviter
+}
+
+func NewService(repo *Repository, auditSvc *audit.Service, inviter AdminInviter) *Service {
+	return &Service{repo: repo, audit: auditSvc, inviter: inviter}
+}
+
+// Get returns the caller's organization.
+func (s *Service) Get(ctx context.Context, orgID string) (*Organization, error) {
+	org, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrNotFound
+	}
+	return org, nil
+}
+
+// Update renames the caller's organization. The slug never changes.
+func (s *Service) Update(ctx context.Context, orgID, actorID string, input UpdateInput) (*Organization, error) {
+	name, err := validateName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.repo.UpdateName(ctx, orgID, name)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrNotFound
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("organization renamed to %s", org.Name)
+		entityType := "organization"
+		_ = s.audit.Log(ctx, "organization_updated", desc, &actorID, &entityType, &org.ID)
+	}
+	return org, nil
+}
+
+// List returns every organization, for operators of the deployment.
+func (s *Service) List(ctx context.Context) ([]Organization, error) {
+	return s.repo.List(ctx)
+}
+
+// Create adds an organization and invites its first admin. The slug defaults
+// to the slugged name.
+func (s *Service) Create(ctx context.Context, actorID string, input CreateInput) (*Created, error) {
+	name, err := validateName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+	slug := strings.ToLower(strings.TrimSpace(input.Slug))
+	if slug == "" {
+		slug = slugify(name)
+	}
+	if !slugPattern.MatchString(slug) {
+		return nil, invalid("slug must be 3-50 lowercase letters, digits or '-'")
+	}
+	input.AdminEmail = strings.TrimSpace(input.AdminEmail)
+	if _, err := mail.ParseAddress(input.AdminEmail); err != nil {
+		return nil, invalid("invalid admin email")
+	}
+
+	org, err := s.repo.Create(ctx, name, slug)
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.inviter.InviteOrgAdmin(ctx, org.ID, actorID, input.AdminEmail, strings.TrimSpace(input.AdminName))
+	if err != nil {
+		// an organization nobody can sign in to is useless; undo it
+		_ = s.repo.DeleteEmpty(ctx, org.ID)
+		return nil, err
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("organization %s created, %s invited as admin", org.Slug, input.AdminEmail)
+		entityType := "organization"
+		_ = s.audit.Log(ctx, "organization_created", desc, &actorID, &entityType, &org.ID)
+	}
+	return &Created{Organization: *org, AdminInviteURL: link}, nil
+}
+
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if len(name) < 2 || len(name) > 100 {
+		return "", invalid("name must be between 2 and 100 characters")
+	}
+	return name, nil
+}
+
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
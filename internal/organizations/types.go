@@ -0,0 +1,40 @@
+package organizations
+
+import "time"
+
+// DefaultID is the organization created with the schema. Self-registered and
+// SSO-provisioned users join it, and its admins operate the deployment:
+// they are the only ones who can create further organizations.
+const DefaultID = "00000000-0000-0000-0000-000000000001"
+
+// Organization is a tenant. Users, projects and everything below them belong
+// to exactly one organization and are invisible to the others.
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Members   int       `json:"members"`
+	Projects  int       `json:"projects"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CreateInput describes a new organization and the person invited as its first admin.
+type CreateInput struct {
+	Name       string `json:"name" binding:"required"`
+	Slug       string `json:"slug"`
+	AdminEmail string `json:"adminEmail" binding:"required"`
+	AdminName  string `json:"adminName"`
+}
+
+// UpdateInput captures editable organization fields.
+type UpdateInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// Created is returned to the operator. AdminInviteURL lets them share the
+// first admin's sign-up link by hand when email delivery is not configured.
+type Created struct {
+	Organization
+	AdminInviteURL string `json:"adminInviteUrl"`
+}
@@ -127,6 +127,10 @@ func (h *Handler) create(c *gin.Context) {
 
 	project, err := h.service.Create(c.Request.Context(), user, payload)
 	if err != nil {
+		if errors.Is(err, ErrUnknownUser) {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
@@ -145,7 +149,14 @@ func (h *Handler) addMember(c *gin.Context) {
 		return
 	}
 	if err := h.service.AddMember(c.Request.Context(), user, c.Param("id"), payload); err != nil {
-		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+		case errors.Is(err, ErrUnknownUser):
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		default:
+			response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		}
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -164,6 +175,10 @@ func (h *Handler) createInvite(c *gin.Context) {
 	}
 	invite, err := h.service.CreateInvite(c.Request.Context(), user, c.Param("id"), payload)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
@@ -20,7 +20,7 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) List(ctx context.Context, filter ListFilter) ([]Project, error) {
+func (r *Repository) List(ctx context.Context, orgID string, filter ListFilter) ([]Project, error) {
 	if filter.Limit <= 0 || filter.Limit > 200 {
 		filter.Limit = 50
 	}
@@ -34,7 +34,9 @@ FROM projects p
 LEFT JOIN LATERAL (
   SELECT COUNT(*)::int AS cnt FROM tickets t WHERE t.project_id = p.id
 ) tc ON true
-WHERE 1=1`)
+WHERE p.org_id = $1`)
+	args = append(args, orgID)
+	idx++
 	if filter.Status != "" {
 		sb.WriteString(fmt.Sprintf(" AND p.status = $%d", idx))
 		args = append(args, filter.Status)
@@ -71,7 +73,7 @@ WHERE 1=1`)
 	return projects, rows.Err()
 }
 
-func (r *Repository) ListForMember(ctx context.Context, userID string, filter ListFilter) ([]Project, error) {
+func (r *Repository) ListForMember(ctx context.Context, orgID, userID string, filter ListFilter) ([]Project, error) {
 	if filter.Limit <= 0 || filter.Limit > 200 {
 		filter.Limit = 50
 	}
@@ -86,9 +88,9 @@ JOIN projects p ON p.id = pm.project_id
 LEFT JOIN LATERAL (
   SELECT COUNT(*)::int AS cnt FROM tickets t WHERE t.project_id = p.id
 ) tc ON true
-WHERE pm.user_id = $1`)
-	args = append(args, userID)
-	idx++
+WHERE pm.user_id = $1 AND p.org_id = $2`)
+	args = append(args, userID, orgID)
+	idx += 2
 	if filter.Status != "" {
 		sb.WriteString(fmt.Sprintf(" AND p.status = $%d", idx))
 		args = append(args, filter.Status)
@@ -125,16 +127,16 @@ WHERE pm.user_id = $1`)
 	return projects, rows.Err()
 }
 
-func (r *Repository) Get(ctx context.Context, id string, activityFilter *ActivityFilter) (*Detail, error) {
+func (r *Repository) Get(ctx context.Context, orgID, id string, activityFilter *ActivityFilter) (*Detail, error) {
 	const query = `
 SELECT p.id, p.name, p.description, p.status, COALESCE(tc.cnt, 0), p.created_at
 FROM projects p
 LEFT JOIN LATERAL (
   SELECT COUNT(*)::int AS cnt FROM tickets t WHERE t.project_id = p.id
 ) tc ON true
-WHERE p.id = $1`
+WHERE p.id = $1 AND p.org_id = $2`
 	var detail Detail
-	if err := r.db.QueryRow(ctx, query, id).Scan(&detail.ID, &detail.Name, &detail.Description, &detail.Status, &detail.TicketsCount, &detail.CreatedAt); err != nil {
+	if err := r.db.QueryRow(ctx, query, id, orgID).Scan(&detail.ID, &detail.Name, &detail.Description, &detail.Status, &detail.TicketsCount, &detail.CreatedAt); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
@@ -247,7 +249,21 @@ VALUES ($1, $2, $3, $4, NOW())`
 	return err
 }
 
-func (r *Repository) Create(ctx context.Context, creatorID string, input CreateInput) (*Detail, error) {
+// Exists reports whether id is a project of orgID.
+func (r *Repository) Exists(ctx context.Context, orgID, id string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND org_id = $2)`, id, orgID).Scan(&exists)
+	return exists, err
+}
+
+// CountOrgUsers counts how many of ids are users of orgID.
+func (r *Repository) CountOrgUsers(ctx context.Context, orgID string, ids []string) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE id::text = ANY($1) AND org_id = $2`, ids, orgID).Scan(&n)
+	return n, err
+}
+
+func (r *Repository) Create(ctx context.Context, orgID, creatorID string, input CreateInput) (*Detail, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, err
@@ -257,9 +273,9 @@ func (r *Repository) Create(ctx context.Context, creatorID string, input CreateI
 	projectID := uuid.NewString()
 	now := time.Now()
 	const insertProject = `
-INSERT INTO projects (id, name, description, status, created_by, created_at, updated_at)
-VALUES ($1, $2, $3, 'Active', $4, $5, $5)`
-	if _, err := tx.Exec(ctx, insertProject, projectID, input.Name, input.Description, creatorID, now); err != nil {
+INSERT INTO projects (id, org_id, name, description, status, created_by, created_at, updated_at)
+VALUES ($1, $2, $3, $4, 'Active', $5, $6, $6)`
+	if _, err := tx.Exec(ctx, insertProject, projectID, orgID, input.Name, input.Description, creatorID, now); err != nil {
 		return nil, err
 	}
 
@@ -286,7 +302,7 @@ ON CONFLICT (project_id, user_id) DO NOTHING`
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
-	return r.Get(ctx, projectID, nil)
+	return r.Get(ctx, orgID, projectID, nil)
 }
 
 func (r *Repository) AddMember(ctx context.Context, projectID, userID, role string) error {
@@ -334,7 +350,9 @@ RETURNING code, max_uses, uses, expires_at`
 	return &invite, nil
 }
 
-func (r *Repository) JoinByCode(ctx context.Context, code, userID, role string) (*Detail, error) {
+// JoinByCode redeems an invite code. Codes of other organizations' projects
+// are reported as not found.
+func (r *Repository) JoinByCode(ctx context.Context, orgID, code, userID, role string) (*Detail, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, err
@@ -342,17 +360,18 @@ func (r *Repository) JoinByCode(ctx context.Context, code, userID, role string)
 	defer tx.Rollback(ctx)
 
 	const inviteQuery = `
-SELECT project_id, max_uses, uses, expires_at
-FROM project_invites
-WHERE code = $1
-FOR UPDATE`
+SELECT i.project_id, i.max_uses, i.uses, i.expires_at
+FROM project_invites i
+JOIN projects p ON p.id = i.project_id
+WHERE i.code = $1 AND p.org_id = $2
+FOR UPDATE OF i`
 	var (
 		projectID string
 		maxUses   int
 		uses      int
 		expires   time.Time
 	)
-	if err := tx.QueryRow(ctx, inviteQuery, code).Scan(&projectID, &maxUses, &uses, &expires); err != nil {
+	if err := tx.QueryRow(ctx, inviteQuery, code, orgID).Scan(&projectID, &maxUses, &uses, &expires); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, fmt.Errorf("invite not found")
 		}
@@ -381,5 +400,5 @@ ON CONFLICT (project_id, user_id) DO NOTHING`
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
-	return r.Get(ctx, projectID, nil)
+	return r.Get(ctx, orgID, projectID, nil)
 }
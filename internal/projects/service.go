@@ -24,8 +24,10 @@ func NewService(repo *Repository, audit *audit.Service) *Service {
 }
 
 var (
-	ErrForbidden = errors.New("forbidden")
-	ErrNotMember = errors.New("not_member")
+	ErrForbidden   = errors.New("forbidden")
+	ErrNotMember   = errors.New("not_member")
+	ErrNotFound    = errors.New("project not found")
+	ErrUnknownUser = errors.New("user not found in this organization")
 )
 
 func (s *Service) List(ctx context.Context, actor *middleware.UserContext, filter ListFilter) ([]Project, error) {
@@ -36,16 +38,16 @@ func (s *Service) List(ctx context.Context, actor *middleware.UserContext, filte
 		filter.Limit = 50
 	}
 	if isElevated(actor.Role) {
-		return s.repo.List(ctx, filter)
+		return s.repo.List(ctx, actor.OrgID, filter)
 	}
-	return s.repo.ListForMember(ctx, actor.ID, filter)
+	return s.repo.ListForMember(ctx, actor.OrgID, actor.ID, filter)
 }
 
 func (s *Service) Get(ctx context.Context, actor *middleware.UserContext, id string, activityFilter *ActivityFilter) (*Detail, error) {
 	if actor == nil {
 		return nil, ErrForbidden
 	}
-	project, err := s.repo.Get(ctx, id, activityFilter)
+	project, err := s.repo.Get(ctx, actor.OrgID, id, activityFilter)
 	if err != nil || project == nil {
 		return project, err
 	}
@@ -63,7 +65,16 @@ func (s *Service) Get(ctx context.Context, actor *middleware.UserContext, id str
 }
 
 func (s *Service) Create(ctx context.Context, actor *middleware.UserContext, input CreateInput) (*Detail, error) {
-	project, err := s.repo.Create(ctx, actor.ID, input)
+	var members []string
+	for _, id := range input.Members {
+		if id != "" && id != actor.ID {
+			members = append(members, id)
+		}
+	}
+	if err := s.requireOrgUsers(ctx, actor.OrgID, members); err != nil {
+		return nil, err
+	}
+	project, err := s.repo.Create(ctx, actor.OrgID, actor.ID, input)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +88,12 @@ func (s *Service) Create(ctx context.Context, actor *middleware.UserContext, inp
 }
 
 func (s *Service) AddMember(ctx context.Context, actor *middleware.UserContext, projectID string, input AddMemberInput) error {
+	if err := s.requireProject(ctx, actor.OrgID, projectID); err != nil {
+		return err
+	}
+	if err := s.requireOrgUsers(ctx, actor.OrgID, []string{input.UserID}); err != nil {
+		return err
+	}
 	role := normalizeMemberRole(input.Role)
 	if err := s.repo.AddMember(ctx, projectID, input.UserID, role); err != nil {
 		return err
@@ -99,6 +116,9 @@ func (s *Service) CreateInvite(ctx context.Context, actor *middleware.UserContex
 	if input.ExpiryDays <= 0 {
 		return nil, fmt.Errorf("expiryDays must be positive")
 	}
+	if err := s.requireProject(ctx, actor.OrgID, projectID); err != nil {
+		return nil, err
+	}
 	code := generateInviteCode()
 	expires := time.Now().Add(time.Duration(input.ExpiryDays) * 24 * time.Hour)
 	invite, err := s.repo.CreateInvite(ctx, projectID, code, input.MaxUses, expires)
@@ -117,7 +137,7 @@ func (s *Service) CreateInvite(ctx context.Context, actor *middleware.UserContex
 }
 
 func (s *Service) JoinByCode(ctx context.Context, actor *middleware.UserContext, code string) (*Detail, error) {
-	project, err := s.repo.JoinByCode(ctx, code, actor.ID, "member")
+	project, err := s.repo.JoinByCode(ctx, actor.OrgID, code, actor.ID, "member")
 	if err != nil {
 		return nil, err
 	}
@@ -156,6 +176,44 @@ func (s *Service) Leave(ctx context.Context, actor *middleware.UserContext, proj
 	return nil
 }
 
+func (s *Service) requireProject(ctx context.Context, orgID, projectID string) error {
+	if _, err := uuid.Parse(projectID); err != nil {
+		return ErrNotFound
+	}
+	exists, err := s.repo.Exists(ctx, orgID, projectID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// requireOrgUsers rejects user IDs from other organizations, so a project
+// never gains members its organization cannot see.
+func (s *Service) requireOrgUsers(ctx context.Context, orgID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	unique := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	list := make([]string, 0, len(unique))
+	for id := range unique {
+		list = append(list, id)
+	}
+	n, err := s.repo.CountOrgUsers(ctx, orgID, list)
+	if err != nil {
+		return err
+	}
+	if n != len(list) {
+		return ErrUnknownUser
+	}
+	return nil
+}
+
 func generateInviteCode() string {
 	code := strings.ReplaceAll(uuid.NewString(), "-", "")
 	if len(code) > 8 {
@@ -0,0 +1,69 @@
+package reminders
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/response"
+)
+
+// Handler exposes the due policy endpoints.
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/projects/:id/due-policy", h.getPolicy)
+	router.PUT("/projects/:id/due-policy", middleware.RequireRoles("admin", "project_manager"), h.savePolicy)
+}
+
+func (h *Handler) getPolicy(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	policy, err := h.service.GetPolicy(c.Request.Context(), user.OrgID, c.Param("id"))
+	if err != nil {
+		writePolicyError(c, err)
+		return
+	}
+	response.OK(c, policy)
+}
+
+func (h *Handler) savePolicy(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload PolicyInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	policy, err := h.service.SavePolicy(c.Request.Context(), user.OrgID, c.Param("id"), user.ID, payload)
+	if err != nil {
+		writePolicyError(c, err)
+		return
+	}
+	response.OK(c, policy)
+}
+
+func writePolicyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrProjectNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrInvalidPolicy):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
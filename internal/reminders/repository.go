@@ -0,0 +1,175 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles due policies and the notices the job sent.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// GetPolicy returns a project's policy, or the defaults when it has none.
+func (r *Repository) GetPolicy(ctx context.Context, projectID string) (*Policy, error) {
+	const query = `
+SELECT reminder_days, escalate_urgent, auto_raise_after_days, on_time_bonus_xp, updated_at
+FROM due_policies WHERE project_id = $1`
+	p := DefaultPolicy(projectID)
+	var updatedAt time.Time
+	err := r.db.QueryRow(ctx, query, projectID).Scan(&p.ReminderDays, &p.EscalateUrgent, &p.AutoRaiseAfterDays, &p.OnTimeBonusXP, &updatedAt)
+	if err == pgx.ErrNoRows {
+		return &p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.UpdatedAt = &updatedAt
+	return &p, nil
+}
+
+// SavePolicy writes a project's policy.
+func (r *Repository) SavePolicy(ctx context.Context, p Policy, actorID string) error {
+	const query = `
+INSERT INTO due_policies (project_id, reminder_days, escalate_urgent, auto_raise_after_days, on_time_bonus_xp, updated_by, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+ON CONFLICT (project_id) DO UPDATE
+SET reminder_days = EXCLUDED.reminder_days,
+    escalate_urgent = EXCLUDED.escalate_urgent,
+    auto_raise_after_days = EXCLUDED.auto_raise_after_days,
+    on_time_bonus_xp = EXCLUDED.on_time_bonus_xp,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = EXCLUDED.updated_at`
+	_, err := r.db.Exec(ctx, query, p.ProjectID, p.ReminderDays, p.EscalateUrgent, p.AutoRaiseAfterDays, p.OnTimeBonusXP, actorID)
+	return err
+}
+
+// ProjectInOrg reports whether projectID belongs to orgID.
+func (r *Repository) ProjectInOrg(ctx context.Context, orgID, projectID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id::text = $1 AND org_id = $2)`, projectID, orgID).Scan(&ok)
+	return ok, err
+}
+
+// DueTickets returns the open tickets of active projects due between from
+// and to, with their project's policy.
+func (r *Repository) DueTickets(ctx context.Context, from, to time.Time) ([]dueTicket, error) {
+	const query = `
+SELECT t.id, p.org_id::text, t.title, t.project_id, p.name, t.priority::text, t.due_date, t.assignee_id, COALESCE(a.name, ''),
+       COALESCE(dp.reminder_days, $3::int[]), COALESCE(dp.escalate_urgent, $4), dp.auto_raise_after_days
+FROM tickets t
+JOIN projects p ON p.id = t.project_id
+LEFT JOIN users a ON a.id = t.assignee_id
+LEFT JOIN due_policies dp ON dp.project_id = t.project_id
+WHERE t.status <> 'done'
+  AND t.due_date IS NOT NULL
+  AND t.due_date BETWEEN $1::date AND $2::date
+  AND p.status = 'Active'
+ORDER BY t.due_date ASC`
+	defaults := DefaultPolicy("")
+	rows, err := r.db.Query(ctx, query, from, to, defaults.ReminderDays, defaults.EscalateUrgent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []dueTicket
+	for rows.Next() {
+		var t dueTicket
+		if err := rows.Scan(&t.ID, &t.OrgID, &t.Title, &t.ProjectID, &t.ProjectName, &t.Priority, &t.DueDate, &t.AssigneeID, &t.AssigneeName,
+			&t.Policy.ReminderDays, &t.Policy.EscalateUrgent, &t.Policy.AutoRaiseAfterDays); err != nil {
+			return nil, err
+		}
+		t.Policy.ProjectID = t.ProjectID
+		tickets = append(tickets, t)
+	}
+	return tickets, rows.Err()
+}
+
+// Recipients returns the assignee and watchers of a ticket who can get email.
+func (r *Repository) Recipients(ctx context.Context, ticketID string, assigneeID *string) ([]person, error) {
+	const query = `
+SELECT u.id, u.email, u.name
+FROM users u
+WHERE (u.id = $2 OR u.id IN (SELECT user_id FROM ticket_watchers WHERE ticket_id = $1))
+  AND u.deactivated_at IS NULL
+  AND COALESCE(u.email, '') <> ''
+ORDER BY u.name`
+	return r.people(ctx, query, ticketID, assigneeID)
+}
+
+// Leads returns the leads of a project, or its creator when it has none.
+func (r *Repository) Leads(ctx context.Context, projectID string) ([]person, error) {
+	const query = `
+WITH leads AS (
+    SELECT user_id FROM project_members WHERE project_id = $1 AND member_role = 'lead'
+)
+SELECT u.id, u.email, u.name
+FROM users u
+WHERE (u.id IN (SELECT user_id FROM leads)
+       OR (NOT EXISTS (SELECT 1 FROM leads) AND u.id = (SELECT created_by FROM projects WHERE id = $1)))
+  AND u.deactivated_at IS NULL
+  AND COALESCE(u.email, '') <> ''
+ORDER BY u.name`
+	return r.people(ctx, query, projectID)
+}
+
+func (r *Repository) people(ctx context.Context, query string, args ...any) ([]person, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var people []person
+	for rows.Next() {
+		var p person
+		if err := rows.Scan(&p.ID, &p.Email, &p.Name); err != nil {
+			return nil, err
+		}
+		people = append(people, p)
+	}
+	return people, rows.Err()
+}
+
+// Claim records that a notice of kind went out for a ticket's due date. It
+// reports false when one already did, so each is sent once.
+func (r *Repository) Claim(ctx context.Context, ticketID string, dueDate time.Time, kind string, offset int) (bool, error) {
+	const query = `
+INSERT INTO ticket_due_notices (ticket_id, due_date, kind, offset_days)
+VALUES ($1, $2::date, $3, $4)
+ON CONFLICT DO NOTHING`
+	tag, err := r.db.Exec(ctx, query, ticketID, dueDate, kind, offset)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RaisePriority moves a ticket still open at priority from to priority to,
+// with a history entry. It reports false when the ticket changed meanwhile.
+func (r *Repository) RaisePriority(ctx context.Context, ticketID, from, to string, dueDate time.Time) (bool, error) {
+	raised := false
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		const update = `
+UPDATE tickets SET priority = $3::ticket_priority, updated_at = now()
+WHERE id = $1 AND priority = $2::ticket_priority AND status <> 'done'`
+		tag, err := tx.Exec(ctx, update, ticketID, from, to)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		raised = true
+		text := fmt.Sprintf("Priority raised to %s: overdue since %s", to, dueDate.Format("2006-01-02"))
+		_, err = tx.Exec(ctx, `INSERT INTO ticket_history (ticket_id, text, actor_id) VALUES ($1, $2, NULL)`, ticketID, text)
+		return err
+	})
+	return raised, err
+}
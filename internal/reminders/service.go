@@ -0,0 +1,258 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/email"
+	"backend-go-ticketing-gamify/internal/tickets"
+)
+
+const (
+	// MaxReminderDays caps how many reminders a policy may send per due date.
+	MaxReminderDays = 10
+	// reminderRange bounds the offsets a policy may use, in days.
+	reminderRange = 14
+	// maxAutoRaise bounds AutoRaiseAfterDays; the job looks back no further.
+	maxAutoRaise = 30
+	maxBonusXP   = 100
+
+	kindReminder   = "reminder"
+	kindEscalation = "escalation"
+	kindRaise      = "raise"
+)
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrInvalidPolicy wraps what is wrong with a due policy.
+	ErrInvalidPolicy = errors.New("invalid due policy")
+)
+
+// priorityOrder is what auto-raising steps through.
+var priorityOrder = []string{"low", "medium", "high", "urgent"}
+
+// DefaultPolicy is the policy of projects that never saved one.
+func DefaultPolicy(projectID string) Policy {
+	return Policy{
+		ProjectID:      projectID,
+		ReminderDays:   []int{-1, 0, 1},
+		EscalateUrgent: true,
+		OnTimeBonusXP:  tickets.DefaultOnTimeBonusXP,
+	}
+}
+
+// Mailer sends the due date emails.
+type Mailer interface {
+	SendDueReminder(to email.Recipient, ticketID, ticketTitle, projectName string, dueDate time.Time, overdue bool) error
+	SendDueEscalation(to email.Recipient, ticketID, ticketTitle, projectName, assigneeName string, dueDate time.Time) error
+}
+
+type Service struct {
+	repo   *Repository
+	mailer Mailer
+	audit  *audit.Service
+}
+
+func NewService(repo *Repository, mailer Mailer, auditSvc *audit.Service) *Service {
+	return &Service{repo: repo, mailer: mailer, audit: auditSvc}
+}
+
+// GetPolicy returns the due policy of a project of orgID.
+func (s *Service) GetPolicy(ctx context.Context, orgID, projectID string) (*Policy, error) {
+	ok, err := s.repo.ProjectInOrg(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+	return s.repo.GetPolicy(ctx, projectID)
+}
+
+// SavePolicy replaces the due policy of a project of orgID.
+func (s *Service) SavePolicy(ctx context.Context, orgID, projectID, actorID string, input PolicyInput) (*Policy, error) {
+	ok, err := s.repo.ProjectInOrg(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+	policy, err := buildPolicy(projectID, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SavePolicy(ctx, policy, actorID); err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		entityType := "project"
+		_ = s.audit.Log(ctx, "due_policy_updated", fmt.Sprintf("Due policy updated: reminders on days %v", policy.ReminderDays), &actorID, &entityType, &projectID)
+	}
+	return s.repo.GetPolicy(ctx, projectID)
+}
+
+func buildPolicy(projectID string, input PolicyInput) (Policy, error) {
+	policy := DefaultPolicy(projectID)
+	if input.ReminderDays != nil {
+		days := []int{}
+		for _, d := range input.ReminderDays {
+			if d < -reminderRange || d > reminderRange {
+				return policy, fmt.Errorf("%w: reminder days must be between -%d and %d", ErrInvalidPolicy, reminderRange, reminderRange)
+			}
+			if !slices.Contains(days, d) {
+				days = append(days, d)
+			}
+		}
+		if len(days) > MaxReminderDays {
+			return policy, fmt.Errorf("%w: at most %d reminder days", ErrInvalidPolicy, MaxReminderDays)
+		}
+		slices.Sort(days)
+		policy.ReminderDays = days
+	}
+	if input.EscalateUrgent != nil {
+		policy.EscalateUrgent = *input.EscalateUrgent
+	}
+	if n := input.AutoRaiseAfterDays; n != nil {
+		if *n < 1 || *n > maxAutoRaise {
+			return policy, fmt.Errorf("%w: autoRaiseAfterDays must be between 1 and %d", ErrInvalidPolicy, maxAutoRaise)
+		}
+		policy.AutoRaiseAfterDays = n
+	}
+	if xp := input.OnTimeBonusXP; xp != nil {
+		if *xp < 0 || *xp > maxBonusXP {
+			return policy, fmt.Errorf("%w: onTimeBonusXp must be between 0 and %d", ErrInvalidPolicy, maxBonusXP)
+		}
+		policy.OnTimeBonusXP = *xp
+	}
+	return policy, nil
+}
+
+// Run sends the reminders and escalations that are due at now and raises
+// the priority of tickets late enough. Every notice is recorded per due
+// date, so running again, or after the due date moved, does not repeat one.
+// A run that was missed catches up for one day.
+func (s *Service) Run(ctx context.Context, now time.Time) (RunResult, error) {
+	var result RunResult
+	y, m, d := now.UTC().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	due, err := s.repo.DueTickets(ctx, today.AddDate(0, 0, -(maxAutoRaise+1)), today.AddDate(0, 0, reminderRange))
+	if err != nil {
+		return result, err
+	}
+
+	var errs []error
+	for _, t := range due {
+		y, m, d := t.DueDate.Date()
+		lateDays := int(today.Sub(time.Date(y, m, d, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+
+		if n := t.Policy.AutoRaiseAfterDays; n != nil && lateDays >= *n {
+			raised, err := s.raise(ctx, &t, lateDays)
+			if err != nil {
+				errs = append(errs, err)
+			} else if raised {
+				result.Raised++
+			}
+		}
+		if offset, ok := reminderOffset(t.Policy.ReminderDays, lateDays); ok {
+			sent, err := s.remind(ctx, t, offset)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			result.Reminders += sent
+		}
+		if t.Policy.EscalateUrgent && t.Priority == "urgent" && lateDays >= 1 {
+			sent, err := s.escalate(ctx, t)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			result.Escalations += sent
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// reminderOffset picks the reminder day that is due lateDays after the due
+// date: today's, or yesterday's if that run was missed.
+func reminderOffset(days []int, lateDays int) (int, bool) {
+	for i := len(days) - 1; i >= 0; i-- {
+		if days[i] <= lateDays {
+			return days[i], lateDays-days[i] <= 1
+		}
+	}
+	return 0, false
+}
+
+func (s *Service) raise(ctx context.Context, t *dueTicket, lateDays int) (bool, error) {
+	i := slices.Index(priorityOrder, t.Priority)
+	if i < 0 || i == len(priorityOrder)-1 {
+		return false, nil
+	}
+	claimed, err := s.repo.Claim(ctx, t.ID, t.DueDate, kindRaise, 0)
+	if err != nil || !claimed {
+		return false, err
+	}
+	to := priorityOrder[i+1]
+	raised, err := s.repo.RaisePriority(ctx, t.ID, t.Priority, to, t.DueDate)
+	if err != nil || !raised {
+		return false, err
+	}
+	if s.audit != nil {
+		entityType := "ticket"
+		_ = s.audit.LogSystem(ctx, t.OrgID, "ticket_priority_raised", fmt.Sprintf("Ticket %q raised from %s to %s after %d days overdue", t.Title, t.Priority, to, lateDays), &entityType, &t.ID)
+	}
+	// an urgent ticket escalates in the same run
+	t.Priority = to
+	return true, nil
+}
+
+func (s *Service) remind(ctx context.Context, t dueTicket, offset int) (int, error) {
+	claimed, err := s.repo.Claim(ctx, t.ID, t.DueDate, kindReminder, offset)
+	if err != nil || !claimed {
+		return 0, err
+	}
+	people, err := s.repo.Recipients(ctx, t.ID, t.AssigneeID)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, p := range people {
+		to := email.Recipient{Email: p.Email, Name: p.Name}
+		if err := s.mailer.SendDueReminder(to, t.ID, t.Title, t.ProjectName, t.DueDate, offset > 0); err != nil {
+			log.Printf("due reminder for ticket %s to %s: %v", t.ID, p.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *Service) escalate(ctx context.Context, t dueTicket) (int, error) {
+	claimed, err := s.repo.Claim(ctx, t.ID, t.DueDate, kindEscalation, 0)
+	if err != nil || !claimed {
+		return 0, err
+	}
+	leads, err := s.repo.Leads(ctx, t.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	assignee := t.AssigneeName
+	if assignee == "" {
+		assignee = "nobody"
+	}
+	sent := 0
+	for _, p := range leads {
+		to := email.Recipient{Email: p.Email, Name: p.Name}
+		if err := s.mailer.SendDueEscalation(to, t.ID, t.Title, t.ProjectName, assignee, t.DueDate); err != nil {
+			log.Printf("due escalation for ticket %s to %s: %v", t.ID, p.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
@@ -0,0 +1,66 @@
+package reminders_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/database/dbtest"
+	"backend-go-ticketing-gamify/internal/email"
+	"backend-go-ticketing-gamify/internal/reminders"
+)
+
+type nopMailer struct{}
+
+func (nopMailer) SendDueReminder(email.Recipient, string, string, string, time.Time, bool) error {
+	return nil
+}
+
+func (nopMailer) SendDueEscalation(email.Recipient, string, string, string, string, time.Time) error {
+	return nil
+}
+
+func TestRaiseIsLoggedInTheTicketsOrg(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	orgID := dbtest.Org(t, db)
+	userID := dbtest.User(t, db, orgID, "admin")
+	projectID := dbtest.Project(t, db, orgID, userID)
+	ticketID := dbtest.Ticket(t, db, projectID, userID)
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	dbtest.Exec(t, db, `UPDATE tickets SET priority = 'medium', due_date = $2 WHERE id = $1`, ticketID, now.AddDate(0, 0, -3))
+	dbtest.Exec(t, db, `INSERT INTO due_policies (project_id, reminder_days, auto_raise_after_days) VALUES ($1, '{}', 2)`, projectID)
+
+	auditSvc := audit.NewService(audit.NewRepository(db))
+	svc := reminders.NewService(reminders.NewRepository(db), nopMailer{}, auditSvc)
+	result, err := svc.Run(ctx, now)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Raised != 1 {
+		t.Fatalf("raised %d tickets, want 1", result.Raised)
+	}
+
+	entries, _, err := auditSvc.List(ctx, orgID, 50, nil)
+	if err != nil {
+		t.Fatalf("audit list: %v", err)
+	}
+	found := false
+	for _, e := range entries {
+		if e.Action == "ticket_priority_raised" && e.EntityID != nil && *e.EntityID == ticketID {
+			found = true
+			if e.ActorID != nil {
+				t.Errorf("actor = %s, want none", *e.ActorID)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("ticket_priority_raised is not in the organization's audit log: %+v", entries)
+	}
+
+	other, _, err := auditSvc.List(ctx, dbtest.Org(t, db), 50, nil)
+	if err != nil || len(other) != 0 {
+		t.Fatalf("another organization sees %d entries, %v; want none", len(other), err)
+	}
+}
@@ -0,0 +1,58 @@
+package reminders
+
+import "time"
+
+// Policy is a project's due date rules.
+type Policy struct {
+	ProjectID string `json:"projectId"`
+	// ReminderDays are the days relative to the due date reminders go out
+	// on: -1 the day before, 0 on the day, 1 a day late.
+	ReminderDays []int `json:"reminderDays"`
+	// EscalateUrgent tells the project leads when an urgent ticket is overdue.
+	EscalateUrgent bool `json:"escalateUrgent"`
+	// AutoRaiseAfterDays raises an overdue ticket's priority one step once it
+	// is this many days late; nil leaves priority alone.
+	AutoRaiseAfterDays *int `json:"autoRaiseAfterDays"`
+	// OnTimeBonusXP is added to the completion XP of tickets done by their
+	// due date.
+	OnTimeBonusXP int `json:"onTimeBonusXp"`
+	// UpdatedAt is nil while the project uses the defaults.
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// PolicyInput replaces a project's policy. Omitted fields take the default.
+type PolicyInput struct {
+	ReminderDays       []int `json:"reminderDays"`
+	EscalateUrgent     *bool `json:"escalateUrgent"`
+	AutoRaiseAfterDays *int  `json:"autoRaiseAfterDays"`
+	OnTimeBonusXP      *int  `json:"onTimeBonusXp"`
+}
+
+// RunResult counts what one pass of the due date job did.
+type RunResult struct {
+	Reminders   int `json:"reminders"`
+	Escalations int `json:"escalations"`
+	Raised      int `json:"raised"`
+}
+
+// dueTicket is an open ticket with a due date near today, with its
+// project's policy.
+type dueTicket struct {
+	ID           string
+	OrgID        string
+	Title        string
+	ProjectID    string
+	ProjectName  string
+	Priority     string
+	DueDate      time.Time
+	AssigneeID   *string
+	AssigneeName string
+	Policy       Policy
+}
+
+// person is someone a notice goes to.
+type person struct {
+	ID    string
+	Email string
+	Name  string
+}
@@ -0,0 +1,220 @@
+package reports
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// maxSlowest bounds the tickets listed with a cycle or lead time.
+	maxSlowest = 100
+)
+
+// flowStatuses orders the statuses of the time-in-status report.
+var flowStatuses = []string{"backlog", "todo", "in_progress", "review", "done"}
+
+// CycleTime measures the tickets completed in the filter's range from their
+// first move to in_progress, or to review when they skipped it, to their
+// last move to done. Tickets never worked on are left out.
+func (s *Service) CycleTime(ctx context.Context, f FlowFilter) (*FlowTime, error) {
+	tickets, err := s.repo.CompletedTickets(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	var durations []TicketDuration
+	for _, t := range tickets {
+		if started, ok := t.started(); ok {
+			durations = append(durations, ticketDuration(t, started))
+		}
+	}
+	return flowTime(durations), nil
+}
+
+// LeadTime measures the tickets completed in the filter's range from their
+// creation to their last move to done.
+func (s *Service) LeadTime(ctx context.Context, f FlowFilter) (*FlowTime, error) {
+	tickets, err := s.repo.CompletedTickets(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	durations := make([]TicketDuration, len(tickets))
+	for i, t := range tickets {
+		durations[i] = ticketDuration(t, t.CreatedAt)
+	}
+	return flowTime(durations), nil
+}
+
+// TimeInStatus measures how long the tickets completed in the filter's
+// range spent in each status before their completion, adding up every
+// visit. A ticket counts for a status only when it was ever in it.
+func (s *Service) TimeInStatus(ctx context.Context, f FlowFilter) ([]StatusTime, error) {
+	tickets, err := s.repo.CompletedTickets(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	hours := map[string][]float64{}
+	for _, t := range tickets {
+		spent := map[string]time.Duration{}
+		for i, step := range t.Steps {
+			end := t.CompletedAt
+			if i+1 < len(t.Steps) {
+				end = t.Steps[i+1].At
+			}
+			if end.After(t.CompletedAt) {
+				end = t.CompletedAt
+			}
+			if _, ok := spent[step.Status]; !ok {
+				spent[step.Status] = 0
+			}
+			if end.After(step.At) {
+				spent[step.Status] += end.Sub(step.At)
+			}
+		}
+		for status, d := range spent {
+			if status != "done" {
+				hours[status] = append(hours[status], d.Hours())
+			}
+		}
+	}
+	result := make([]StatusTime, 0, len(flowStatuses)-1)
+	for _, status := range flowStatuses {
+		if status == "done" {
+			continue
+		}
+		result = append(result, StatusTime{Status: status, Stats: durationStats(hours[status])})
+	}
+	return result, nil
+}
+
+// CumulativeFlow counts the filter's tickets by status at the end of every
+// day of its range.
+func (s *Service) CumulativeFlow(ctx context.Context, f FlowFilter) ([]CumulativeFlowDay, error) {
+	counts, err := s.repo.StatusCounts(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	var result []CumulativeFlowDay
+	for day := f.From; day.Before(f.To); day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		c := counts[date]
+		result = append(result, CumulativeFlowDay{
+			Date:       date,
+			Backlog:    c["backlog"],
+			Todo:       c["todo"],
+			InProgress: c["in_progress"],
+			Review:     c["review"],
+			Done:       c["done"],
+		})
+	}
+	return result, nil
+}
+
+// Throughput counts the filter's tickets moved to done per day or per week
+// of its range. Weeks start on Monday; the first may start before From.
+func (s *Service) Throughput(ctx context.Context, f FlowFilter, interval string) ([]ThroughputPeriod, error) {
+	counts, err := s.repo.Throughput(ctx, f, interval)
+	if err != nil {
+		return nil, err
+	}
+	start, step := f.From, 1
+	if interval == "week" {
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		step = 7
+	}
+	var result []ThroughputPeriod
+	for period := start; period.Before(f.To); period = period.AddDate(0, 0, step) {
+		date := period.Format(dateLayout)
+		result = append(result, ThroughputPeriod{Start: date, Completed: counts[date]})
+	}
+	return result, nil
+}
+
+// started returns when work on the ticket began: its first move to
+// in_progress, else to review.
+func (t flowTicket) started() (time.Time, bool) {
+	var review *time.Time
+	for i, step := range t.Steps {
+		switch step.Status {
+		case "in_progress":
+			return step.At, true
+		case "review":
+			if review == nil {
+				review = &t.Steps[i].At
+			}
+		}
+	}
+	if review == nil {
+		return time.Time{}, false
+	}
+	return *review, true
+}
+
+func ticketDuration(t flowTicket, from time.Time) TicketDuration {
+	d := t.CompletedAt.Sub(from)
+	if d < 0 {
+		d = 0
+	}
+	return TicketDuration{
+		TicketID:    t.ID,
+		Title:       t.Title,
+		StartedAt:   from,
+		CompletedAt: t.CompletedAt,
+		Hours:       roundHours(d.Hours()),
+	}
+}
+
+// flowTime summarizes durations and lists the slowest first.
+func flowTime(durations []TicketDuration) *FlowTime {
+	hours := make([]float64, len(durations))
+	for i, d := range durations {
+		hours[i] = d.Hours
+	}
+	sort.SliceStable(durations, func(i, j int) bool { return durations[i].Hours > durations[j].Hours })
+	if len(durations) > maxSlowest {
+		durations = durations[:maxSlowest]
+	}
+	if durations == nil {
+		durations = []TicketDuration{}
+	}
+	return &FlowTime{Stats: durationStats(hours), Slowest: durations}
+}
+
+// durationStats summarizes hours; percentiles interpolate between the
+// nearest values.
+func durationStats(hours []float64) DurationStats {
+	if len(hours) == 0 {
+		return DurationStats{}
+	}
+	sorted := append([]float64(nil), hours...)
+	sort.Float64s(sorted)
+	var total float64
+	for _, h := range sorted {
+		total += h
+	}
+	return DurationStats{
+		Count:   len(sorted),
+		Average: roundHours(total / float64(len(sorted))),
+		P50:     roundHours(percentile(sorted, 50)),
+		P75:     roundHours(percentile(sorted, 75)),
+		P85:     roundHours(percentile(sorted, 85)),
+		P95:     roundHours(percentile(sorted, 95)),
+		Max:     roundHours(sorted[len(sorted)-1]),
+	}
+}
+
+// percentile returns the p-th percentile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	if lo+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (rank-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+func roundHours(h float64) float64 {
+	return math.Round(h*100) / 100
+}
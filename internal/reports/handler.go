@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"backend-go-ticketing-gamify/internal/export"
+	"backend-go-ticketing-gamify/internal/middleware"
 	"backend-go-ticketing-gamify/internal/response"
 )
 
@@ -28,10 +31,21 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/tickets/by-assignee", h.getByAssignee)
 	router.GET("/team-performance", h.getTeamPerformance)
 	router.GET("/tickets/trend", h.getTicketTrend)
+	router.GET("/export/:dataset", h.export)
+	router.GET("/flow/cycle-time", h.getCycleTime)
+	router.GET("/flow/lead-time", h.getLeadTime)
+	router.GET("/flow/time-in-status", h.getTimeInStatus)
+	router.GET("/flow/cumulative-flow", h.getCumulativeFlow)
+	router.GET("/flow/throughput", h.getThroughput)
 }
 
 func (h *Handler) getSummary(c *gin.Context) {
-	summary, err := h.service.GetSummary(c.Request.Context())
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	summary, err := h.service.GetSummary(c.Request.Context(), user.OrgID)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -40,7 +54,12 @@ func (h *Handler) getSummary(c *gin.Context) {
 }
 
 func (h *Handler) getByStatus(c *gin.Context) {
-	breakdown, err := h.service.GetStatusBreakdown(c.Request.Context())
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	breakdown, err := h.service.GetStatusBreakdown(c.Request.Context(), user.OrgID)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -49,7 +68,12 @@ func (h *Handler) getByStatus(c *gin.Context) {
 }
 
 func (h *Handler) getByPriority(c *gin.Context) {
-	breakdown, err := h.service.GetPriorityBreakdown(c.Request.Context())
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	breakdown, err := h.service.GetPriorityBreakdown(c.Request.Context(), user.OrgID)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -58,8 +82,13 @@ func (h *Handler) getByPriority(c *gin.Context) {
 }
 
 func (h *Handler) getByAssignee(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	breakdown, err := h.service.GetAssigneeBreakdown(c.Request.Context(), limit)
+	breakdown, err := h.service.GetAssigneeBreakdown(c.Request.Context(), user.OrgID, limit)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -68,8 +97,13 @@ func (h *Handler) getByAssignee(c *gin.Context) {
 }
 
 func (h *Handler) getTeamPerformance(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	performance, err := h.service.GetTeamPerformance(c.Request.Context(), limit)
+	performance, err := h.service.GetTeamPerformance(c.Request.Context(), user.OrgID, limit)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -78,8 +112,13 @@ func (h *Handler) getTeamPerformance(c *gin.Context) {
 }
 
 func (h *Handler) getTicketTrend(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
-	trend, err := h.service.GetTicketTrend(c.Request.Context(), days)
+	trend, err := h.service.GetTicketTrend(c.Request.Context(), user.OrgID, days)
 	if err != nil {
 		fmt.Printf("GetTicketTrend error: %v\n", err)
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
@@ -87,3 +126,254 @@ func (h *Handler) getTicketTrend(c *gin.Context) {
 	}
 	response.OK(c, trend)
 }
+
+// export writes one report dataset as CSV, XLSX or NDJSON. Datasets: status,
+// priority, assignee, team-performance and trend; limit and days work as on
+// the JSON endpoints.
+func (h *Handler) export(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	dataset := c.Param("dataset")
+	columns, rows, err := h.dataset(c, dataset, user.OrgID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	if columns == nil {
+		response.ErrorCode(c, http.StatusNotFound, "not_found", "unknown report dataset")
+		return
+	}
+	w, err := export.Start(c, format, "report-"+dataset, columns)
+	for i := 0; err == nil && i < len(rows); i++ {
+		err = w.Row(rows[i]...)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		export.Fail(c, "report", err)
+	}
+}
+
+// dataset loads a report as export rows. Reports are aggregates, small
+// enough to load whole. Unknown names return nil columns.
+func (h *Handler) dataset(c *gin.Context, name, orgID string) ([]export.Column, [][]any, error) {
+	ctx := c.Request.Context()
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	var rows [][]any
+	switch name {
+	case "status":
+		breakdown, err := h.service.GetStatusBreakdown(ctx, orgID)
+		for _, b := range breakdown {
+			rows = append(rows, []any{b.Status, b.Count})
+		}
+		return []export.Column{{Key: "status", Title: "Status"}, {Key: "count", Title: "Tickets"}}, rows, err
+	case "priority":
+		breakdown, err := h.service.GetPriorityBreakdown(ctx, orgID)
+		for _, b := range breakdown {
+			rows = append(rows, []any{b.Priority, b.Count})
+		}
+		return []export.Column{{Key: "priority", Title: "Priority"}, {Key: "count", Title: "Tickets"}}, rows, err
+	case "assignee":
+		breakdown, err := h.service.GetAssigneeBreakdown(ctx, orgID, limit)
+		for _, b := range breakdown {
+			rows = append(rows, []any{b.UserID, b.UserName, b.TicketCount, b.OpenCount, b.ClosedCount})
+		}
+		return []export.Column{
+			{Key: "userId", Title: "User ID"},
+			{Key: "userName", Title: "User"},
+			{Key: "ticketCount", Title: "Tickets"},
+			{Key: "openCount", Title: "Open"},
+			{Key: "closedCount", Title: "Closed"},
+		}, rows, err
+	case "team-performance":
+		performance, err := h.service.GetTeamPerformance(ctx, orgID, limit)
+		for _, p := range performance {
+			rows = append(rows, []any{p.UserID, p.UserName, p.TotalXP, p.Level, p.TicketsClosed, p.CurrentStreak, p.LastActiveAt})
+		}
+		return []export.Column{
+			{Key: "userId", Title: "User ID"},
+			{Key: "userName", Title: "User"},
+			{Key: "totalXp", Title: "Total XP"},
+			{Key: "level", Title: "Level"},
+			{Key: "ticketsClosed", Title: "Tickets closed"},
+			{Key: "currentStreak", Title: "Current streak"},
+			{Key: "lastActiveAt", Title: "Last active at"},
+		}, rows, err
+	case "trend":
+		days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+		trend, err := h.service.GetTicketTrend(ctx, orgID, days)
+		for _, t := range trend {
+			rows = append(rows, []any{t.Date, t.Created, t.Closed})
+		}
+		return []export.Column{{Key: "date", Title: "Date"}, {Key: "created", Title: "Created"}, {Key: "closed", Title: "Closed"}}, rows, err
+	}
+	return nil, nil, nil
+}
+
+const (
+	// defaultFlowDays is the range of the flow metrics without from.
+	defaultFlowDays = 30
+	maxFlowDays     = 366
+)
+
+// flowFilter reads the flow metrics filter: projectId, epicId, assigneeId
+// ("me" for the caller), type, and from and to, YYYY-MM-DD and inclusive,
+// the last 30 days by default. It answers 400 itself when one is invalid.
+func flowFilter(c *gin.Context, orgID, userID string) (FlowFilter, bool) {
+	f := FlowFilter{
+		OrgID:      orgID,
+		ProjectID:  c.Query("projectId"),
+		EpicID:     c.Query("epicId"),
+		AssigneeID: c.Query("assigneeId"),
+		Type:       c.Query("type"),
+	}
+	if f.AssigneeID == "me" {
+		f.AssigneeID = userID
+	}
+	switch f.Type {
+	case "", "feature", "bug", "chore":
+	default:
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "type must be feature, bug or chore")
+		return f, false
+	}
+	now := time.Now().UTC()
+	to, ok := dateQuery(c, "to", time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	if !ok {
+		return f, false
+	}
+	from, ok := dateQuery(c, "from", to.AddDate(0, 0, 1-defaultFlowDays))
+	if !ok {
+		return f, false
+	}
+	f.From, f.To = from, to.AddDate(0, 0, 1)
+	if !f.From.Before(f.To) {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "from must not be after to")
+		return f, false
+	}
+	if f.To.Sub(f.From) > maxFlowDays*24*time.Hour {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", fmt.Sprintf("the range is limited to %d days", maxFlowDays))
+		return f, false
+	}
+	return f, true
+}
+
+// dateQuery parses a YYYY-MM-DD query parameter, def when absent. It
+// answers 400 itself when the value is malformed.
+func dateQuery(c *gin.Context, name string, def time.Time) (time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, true
+	}
+	t, err := time.Parse(dateLayout, raw)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", name+" must be a date like 2006-01-02")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (h *Handler) getCycleTime(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	f, ok := flowFilter(c, user.OrgID, user.ID)
+	if !ok {
+		return
+	}
+	result, err := h.service.CycleTime(c.Request.Context(), f)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, result)
+}
+
+func (h *Handler) getLeadTime(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	f, ok := flowFilter(c, user.OrgID, user.ID)
+	if !ok {
+		return
+	}
+	result, err := h.service.LeadTime(c.Request.Context(), f)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, result)
+}
+
+func (h *Handler) getTimeInStatus(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	f, ok := flowFilter(c, user.OrgID, user.ID)
+	if !ok {
+		return
+	}
+	result, err := h.service.TimeInStatus(c.Request.Context(), f)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, result)
+}
+
+func (h *Handler) getCumulativeFlow(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	f, ok := flowFilter(c, user.OrgID, user.ID)
+	if !ok {
+		return
+	}
+	result, err := h.service.CumulativeFlow(c.Request.Context(), f)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, result)
+}
+
+// getThroughput counts completed tickets per interval: day (default) or
+// week.
+func (h *Handler) getThroughput(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	interval := c.DefaultQuery("interval", "day")
+	if interval != "day" && interval != "week" {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "interval must be day or week")
+		return
+	}
+	f, ok := flowFilter(c, user.OrgID, user.ID)
+	if !ok {
+		return
+	}
+	result, err := h.service.Throughput(c.Request.Context(), f, interval)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, result)
+}
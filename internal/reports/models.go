@@ -51,3 +51,65 @@ type TicketTrend struct {
 	Created int    `json:"created"`
 	Closed  int    `json:"closed"`
 }
+
+// FlowFilter narrows the flow metrics to tickets of a project, epic,
+// assignee and type. From and To bound the dates measured; To is exclusive.
+type FlowFilter struct {
+	OrgID      string
+	ProjectID  string
+	EpicID     string
+	AssigneeID string
+	Type       string
+	From       time.Time
+	To         time.Time
+}
+
+// DurationStats summarizes durations in hours.
+type DurationStats struct {
+	Count   int     `json:"count"`
+	Average float64 `json:"averageHours"`
+	P50     float64 `json:"p50Hours"`
+	P75     float64 `json:"p75Hours"`
+	P85     float64 `json:"p85Hours"`
+	P95     float64 `json:"p95Hours"`
+	Max     float64 `json:"maxHours"`
+}
+
+// TicketDuration is the cycle or lead time of one completed ticket.
+type TicketDuration struct {
+	TicketID    string    `json:"ticketId"`
+	Title       string    `json:"title"`
+	StartedAt   time.Time `json:"startedAt"`
+	CompletedAt time.Time `json:"completedAt"`
+	Hours       float64   `json:"hours"`
+}
+
+// FlowTime is the cycle or lead time of the tickets completed in a range,
+// with the slowest of them.
+type FlowTime struct {
+	Stats   DurationStats    `json:"stats"`
+	Slowest []TicketDuration `json:"slowest"`
+}
+
+// StatusTime is how long the tickets completed in a range spent in a status.
+type StatusTime struct {
+	Status string        `json:"status"`
+	Stats  DurationStats `json:"stats"`
+}
+
+// CumulativeFlowDay counts tickets by status at the end of a day (UTC).
+type CumulativeFlowDay struct {
+	Date       string `json:"date"`
+	Backlog    int    `json:"backlog"`
+	Todo       int    `json:"todo"`
+	InProgress int    `json:"inProgress"`
+	Review     int    `json:"review"`
+	Done       int    `json:"done"`
+}
+
+// ThroughputPeriod counts the tickets moved to done in a day or a week
+// starting on Monday.
+type ThroughputPeriod struct {
+	Start     string `json:"start"`
+	Completed int    `json:"completed"`
+}
@@ -2,6 +2,8 @@ package reports
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -16,41 +18,44 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-// GetSummary returns overall dashboard metrics.
-func (r *Repository) GetSummary(ctx context.Context) (*Summary, error) {
+// orgTickets limits a ticket query aliased t to the projects of the organization in $1.
+const orgTickets = `t.project_id IN (SELECT id FROM projects WHERE org_id = $1)`
+
+// GetSummary returns the dashboard metrics of an organization.
+func (r *Repository) GetSummary(ctx context.Context, orgID string) (*Summary, error) {
 	var s Summary
 
 	// Total tickets
-	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM tickets`).Scan(&s.TotalTickets)
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM tickets t WHERE `+orgTickets, orgID).Scan(&s.TotalTickets)
 	if err != nil {
 		return nil, err
 	}
 
 	// Open vs Closed tickets
-	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM tickets WHERE status != 'done'`).Scan(&s.OpenTickets)
+	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM tickets t WHERE t.status != 'done' AND `+orgTickets, orgID).Scan(&s.OpenTickets)
 	if err != nil {
 		return nil, err
 	}
 	s.ClosedTickets = s.TotalTickets - s.OpenTickets
 
 	// Projects
-	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM projects`).Scan(&s.TotalProjects)
+	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM projects WHERE org_id = $1`, orgID).Scan(&s.TotalProjects)
 	if err != nil {
 		return nil, err
 	}
-	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM projects WHERE status = 'Active'`).Scan(&s.ActiveProjects)
+	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM projects WHERE org_id = $1 AND status = 'Active'`, orgID).Scan(&s.ActiveProjects)
 	if err != nil {
 		return nil, err
 	}
 
 	// Users
-	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM users`).Scan(&s.TotalUsers)
+	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE org_id = $1`, orgID).Scan(&s.TotalUsers)
 	if err != nil {
 		return nil, err
 	}
 
 	// Epics
-	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM epics`).Scan(&s.TotalEpics)
+	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM epics WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)`, orgID).Scan(&s.TotalEpics)
 	if err != nil {
 		return nil, err
 	}
@@ -59,14 +64,15 @@ func (r *Repository) GetSummary(ctx context.Context) (*Summary, error) {
 }
 
 // GetStatusBreakdown returns ticket count per status.
-func (r *Repository) GetStatusBreakdown(ctx context.Context) ([]StatusBreakdown, error) {
+func (r *Repository) GetStatusBreakdown(ctx context.Context, orgID string) ([]StatusBreakdown, error) {
 	const query = `
-		SELECT status, COUNT(*) as count
-		FROM tickets
-		GROUP BY status
+		SELECT t.status, COUNT(*) as count
+		FROM tickets t
+		WHERE ` + orgTickets + `
+		GROUP BY t.status
 		ORDER BY count DESC`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -84,14 +90,15 @@ func (r *Repository) GetStatusBreakdown(ctx context.Context) ([]StatusBreakdown,
 }
 
 // GetPriorityBreakdown returns ticket count per priority.
-func (r *Repository) GetPriorityBreakdown(ctx context.Context) ([]PriorityBreakdown, error) {
+func (r *Repository) GetPriorityBreakdown(ctx context.Context, orgID string) ([]PriorityBreakdown, error) {
 	const query = `
-		SELECT priority, COUNT(*) as count
-		FROM tickets
-		GROUP BY priority
+		SELECT t.priority, COUNT(*) as count
+		FROM tickets t
+		WHERE ` + orgTickets + `
+		GROUP BY t.priority
 		ORDER BY count DESC`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +116,7 @@ func (r *Repository) GetPriorityBreakdown(ctx context.Context) ([]PriorityBreakd
 }
 
 // GetAssigneeBreakdown returns ticket count per assignee.
-func (r *Repository) GetAssigneeBreakdown(ctx context.Context, limit int) ([]AssigneeBreakdown, error) {
+func (r *Repository) GetAssigneeBreakdown(ctx context.Context, orgID string, limit int) ([]AssigneeBreakdown, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 20
 	}
@@ -122,13 +129,14 @@ func (r *Repository) GetAssigneeBreakdown(ctx context.Context, limit int) ([]Ass
 			COUNT(t.id) FILTER (WHERE t.status = 'done') as closed_count,
 			COUNT(t.id) FILTER (WHERE t.status != 'done') as open_count
 		FROM users u
-		LEFT JOIN tickets t ON t.assignee_id = u.id
+		LEFT JOIN tickets t ON t.assignee_id = u.id AND ` + orgTickets + `
+		WHERE u.org_id = $1
 		GROUP BY u.id, u.name
 		HAVING COUNT(t.id) > 0
 		ORDER BY ticket_count DESC
-		LIMIT $1`
+		LIMIT $2`
 
-	rows, err := r.db.Query(ctx, query, limit)
+	rows, err := r.db.Query(ctx, query, orgID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -146,7 +154,7 @@ func (r *Repository) GetAssigneeBreakdown(ctx context.Context, limit int) ([]Ass
 }
 
 // GetTeamPerformance returns team performance metrics.
-func (r *Repository) GetTeamPerformance(ctx context.Context, limit int) ([]TeamPerformance, error) {
+func (r *Repository) GetTeamPerformance(ctx context.Context, orgID string, limit int) ([]TeamPerformance, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 20
 	}
@@ -162,10 +170,11 @@ func (r *Repository) GetTeamPerformance(ctx context.Context, limit int) ([]TeamP
 			gs.last_ticket_closed_at
 		FROM users u
 		LEFT JOIN gamification_user_stats gs ON gs.user_id = u.id
+		WHERE u.org_id = $1
 		ORDER BY total_xp DESC
-		LIMIT $1`
+		LIMIT $2`
 
-	rows, err := r.db.Query(ctx, query, limit)
+	rows, err := r.db.Query(ctx, query, orgID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -183,26 +192,29 @@ func (r *Repository) GetTeamPerformance(ctx context.Context, limit int) ([]TeamP
 }
 
 // GetTicketTrend returns ticket creation/closure trend for last N days.
-func (r *Repository) GetTicketTrend(ctx context.Context, days int) ([]TicketTrend, error) {
+func (r *Repository) GetTicketTrend(ctx context.Context, orgID string, days int) ([]TicketTrend, error) {
 	if days <= 0 || days > 90 {
 		days = 30
 	}
 
+	// closed counts the tickets moved to done on the day, from the status
+	// transitions rather than updated_at, which any later edit moves
 	const query = `
 		WITH date_series AS (
 			SELECT (CURRENT_DATE - i) as date
-			FROM generate_series($1::integer - 1, 0, -1) as i
+			FROM generate_series($2::integer - 1, 0, -1) as i
 		)
-		SELECT 
+		SELECT
 			ds.date::text,
-			COALESCE(COUNT(t.id) FILTER (WHERE t.created_at::date = ds.date), 0) as created,
-			COALESCE(COUNT(t.id) FILTER (WHERE t.status = 'done' AND t.updated_at::date = ds.date), 0) as closed
+			(SELECT COUNT(*) FROM tickets t WHERE t.created_at::date = ds.date AND ` + orgTickets + `) as created,
+			(SELECT COUNT(DISTINCT tr.ticket_id)
+			 FROM ticket_status_transitions tr
+			 JOIN tickets t ON t.id = tr.ticket_id
+			 WHERE tr.to_status = 'done' AND tr.changed_at::date = ds.date AND ` + orgTickets + `) as closed
 		FROM date_series ds
-		LEFT JOIN tickets t ON t.created_at::date = ds.date OR (t.status = 'done' AND t.updated_at::date = ds.date)
-		GROUP BY ds.date
 		ORDER BY ds.date`
 
-	rows, err := r.db.Query(ctx, query, days)
+	rows, err := r.db.Query(ctx, query, orgID, days)
 	if err != nil {
 		return nil, err
 	}
@@ -218,3 +230,150 @@ func (r *Repository) GetTicketTrend(ctx context.Context, days int) ([]TicketTren
 	}
 	return result, rows.Err()
 }
+
+// flowConditions returns the filter's project, epic, assignee and type
+// conditions on tickets aliased t, with their values appended to args.
+func flowConditions(f FlowFilter, args []any) (string, []any) {
+	var sql string
+	for _, c := range []struct{ column, value string }{
+		{"t.project_id::text", f.ProjectID},
+		{"t.epic_id::text", f.EpicID},
+		{"t.assignee_id::text", f.AssigneeID},
+		{"t.type::text", f.Type},
+	} {
+		if c.value == "" {
+			continue
+		}
+		args = append(args, c.value)
+		sql += fmt.Sprintf(" AND %s = $%d", c.column, len(args))
+	}
+	return sql, args
+}
+
+// flowTicket is a completed ticket with its status transitions.
+type flowTicket struct {
+	ID          string
+	Title       string
+	CreatedAt   time.Time
+	CompletedAt time.Time
+	Steps       []flowStep
+}
+
+type flowStep struct {
+	Status string
+	At     time.Time
+}
+
+// CompletedTickets returns the tickets done now whose last move to done
+// falls in the filter's range, with their transitions oldest first.
+func (r *Repository) CompletedTickets(ctx context.Context, f FlowFilter) ([]flowTicket, error) {
+	conditions, args := flowConditions(f, []any{f.OrgID, f.From, f.To})
+	query := `
+WITH completed AS (
+    SELECT t.id, t.title, t.created_at, d.completed_at
+    FROM tickets t
+    JOIN LATERAL (
+        SELECT MAX(x.changed_at) AS completed_at
+        FROM ticket_status_transitions x
+        WHERE x.ticket_id = t.id AND x.to_status = 'done'
+    ) d ON true
+    WHERE t.status = 'done' AND d.completed_at >= $2 AND d.completed_at < $3 AND ` + orgTickets + conditions + `
+)
+SELECT c.id, c.title, c.created_at, c.completed_at, tr.to_status::text, tr.changed_at
+FROM completed c
+JOIN ticket_status_transitions tr ON tr.ticket_id = c.id
+ORDER BY c.completed_at, c.id, tr.changed_at`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []flowTicket
+	for rows.Next() {
+		var t flowTicket
+		var step flowStep
+		if err := rows.Scan(&t.ID, &t.Title, &t.CreatedAt, &t.CompletedAt, &step.Status, &step.At); err != nil {
+			return nil, err
+		}
+		if n := len(tickets); n == 0 || tickets[n-1].ID != t.ID {
+			tickets = append(tickets, t)
+		}
+		last := &tickets[len(tickets)-1]
+		last.Steps = append(last.Steps, step)
+	}
+	return tickets, rows.Err()
+}
+
+// StatusCounts returns, for each day of the filter's range, how many
+// tickets were in each status at its end (UTC).
+func (r *Repository) StatusCounts(ctx context.Context, f FlowFilter) (map[string]map[string]int, error) {
+	conditions, args := flowConditions(f, []any{f.OrgID, f.From, f.To})
+	query := `
+WITH days AS (
+    SELECT d::date AS day
+    FROM generate_series($2::timestamptz AT TIME ZONE 'UTC', $3::timestamptz AT TIME ZONE 'UTC' - interval '1 day', interval '1 day') d
+)
+SELECT days.day::text, s.status, COUNT(*)
+FROM days
+JOIN LATERAL (
+    SELECT DISTINCT ON (tr.ticket_id) tr.to_status::text AS status
+    FROM ticket_status_transitions tr
+    JOIN tickets t ON t.id = tr.ticket_id
+    WHERE tr.changed_at < (days.day + 1)::timestamp AT TIME ZONE 'UTC' AND ` + orgTickets + conditions + `
+    ORDER BY tr.ticket_id, tr.changed_at DESC
+) s ON true
+GROUP BY days.day, s.status`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]map[string]int{}
+	for rows.Next() {
+		var (
+			day, status string
+			n           int
+		)
+		if err := rows.Scan(&day, &status, &n); err != nil {
+			return nil, err
+		}
+		if counts[day] == nil {
+			counts[day] = map[string]int{}
+		}
+		counts[day][status] = n
+	}
+	return counts, rows.Err()
+}
+
+// Throughput counts the tickets moved to done in the filter's range by day
+// or by week, keyed by the first day (UTC).
+func (r *Repository) Throughput(ctx context.Context, f FlowFilter, interval string) (map[string]int, error) {
+	conditions, args := flowConditions(f, []any{f.OrgID, f.From, f.To})
+	args = append(args, interval)
+	query := `
+SELECT date_trunc($` + fmt.Sprint(len(args)) + `, tr.changed_at AT TIME ZONE 'UTC')::date::text AS period, COUNT(DISTINCT tr.ticket_id)
+FROM ticket_status_transitions tr
+JOIN tickets t ON t.id = tr.ticket_id
+WHERE tr.to_status = 'done' AND tr.changed_at >= $2 AND tr.changed_at < $3 AND ` + orgTickets + conditions + `
+GROUP BY 1`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var (
+			period string
+			n      int
+		)
+		if err := rows.Scan(&period, &n); err != nil {
+			return nil, err
+		}
+		counts[period] = n
+	}
+	return counts, rows.Err()
+}
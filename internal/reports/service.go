@@ -12,32 +12,32 @@ func NewService(repo *Repository) *Service {
 	return &Service{repo: repo}
 }
 
-// GetSummary returns overall dashboard metrics.
-func (s *Service) GetSummary(ctx context.Context) (*Summary, error) {
-	return s.repo.GetSummary(ctx)
+// GetSummary returns the dashboard metrics of an organization.
+func (s *Service) GetSummary(ctx context.Context, orgID string) (*Summary, error) {
+	return s.repo.GetSummary(ctx, orgID)
 }
 
 // GetStatusBreakdown returns ticket count per status.
-func (s *Service) GetStatusBreakdown(ctx context.Context) ([]StatusBreakdown, error) {
-	return s.repo.GetStatusBreakdown(ctx)
+func (s *Service) GetStatusBreakdown(ctx context.Context, orgID string) ([]StatusBreakdown, error) {
+	return s.repo.GetStatusBreakdown(ctx, orgID)
 }
 
 // GetPriorityBreakdown returns ticket count per priority.
-func (s *Service) GetPriorityBreakdown(ctx context.Context) ([]PriorityBreakdown, error) {
-	return s.repo.GetPriorityBreakdown(ctx)
+func (s *Service) GetPriorityBreakdown(ctx context.Context, orgID string) ([]PriorityBreakdown, error) {
+	return s.repo.GetPriorityBreakdown(ctx, orgID)
 }
 
 // GetAssigneeBreakdown returns ticket count per assignee.
-func (s *Service) GetAssigneeBreakdown(ctx context.Context, limit int) ([]AssigneeBreakdown, error) {
-	return s.repo.GetAssigneeBreakdown(ctx, limit)
+func (s *Service) GetAssigneeBreakdown(ctx context.Context, orgID string, limit int) ([]AssigneeBreakdown, error) {
+	return s.repo.GetAssigneeBreakdown(ctx, orgID, limit)
 }
 
 // GetTeamPerformance returns team performance metrics.
-func (s *Service) GetTeamPerformance(ctx context.Context, limit int) ([]TeamPerformance, error) {
-	return s.repo.GetTeamPerformance(ctx, limit)
+func (s *Service) GetTeamPerformance(ctx context.Context, orgID string, limit int) ([]TeamPerformance, error) {
+	return s.repo.GetTeamPerformance(ctx, orgID, limit)
 }
 
 // GetTicketTrend returns ticket creation/closure trend.
-func (s *Service) GetTicketTrend(ctx context.Context, days int) ([]TicketTrend, error) {
-	return s.repo.GetTicketTrend(ctx, days)
+func (s *Service) GetTicketTrend(ctx context.Context, orgID string, days int) ([]TicketTrend, error) {
+	return s.repo.GetTicketTrend(ctx, orgID, days)
 }
@@ -435,6 +435,9 @@ SET title = EXCLUDED.title, description = EXCLUDED.description, status = EXCLUDE
 		if err != nil {
 			return err
 		}
+		if err := seedTransitions(ctx, db, t.ID.String(), t.Status, t.CreatedAt, &t.StartDate, t.UpdatedAt); err != nil {
+			return err
+		}
 	}
 
 	history := []sampleHistory{
@@ -511,10 +514,10 @@ ON CONFLICT (id) DO NOTHING`,
 
 	for _, s := range stats {
 		_, err := db.Exec(ctx, `
-INSERT INTO gamification_user_stats (user_id, xp_total, level, next_level_threshold, tickets_closed_count, streak_days, last_ticket_closed_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO gamification_user_stats (user_id, xp_total, level, next_level_threshold, tickets_closed_count, streak_days, last_ticket_closed_at, streak_through)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7::date)
 ON CONFLICT (user_id) DO UPDATE
-SET xp_total = EXCLUDED.xp_total, level = EXCLUDED.level, next_level_threshold = EXCLUDED.next_level_threshold, tickets_closed_count = EXCLUDED.tickets_closed_count, streak_days = EXCLUDED.streak_days, last_ticket_closed_at = EXCLUDED.last_ticket_closed_at`,
+SET xp_total = EXCLUDED.xp_total, level = EXCLUDED.level, next_level_threshold = EXCLUDED.next_level_threshold, tickets_closed_count = EXCLUDED.tickets_closed_count, streak_days = EXCLUDED.streak_days, last_ticket_closed_at = EXCLUDED.last_ticket_closed_at, streak_through = EXCLUDED.streak_through`,
 			s.UserID, s.Total, s.Level, s.NextThreshold, s.TicketsClosedCount, s.Streak, s.LastClosedAt)
 		if err != nil {
 			return err
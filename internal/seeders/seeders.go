@@ -201,6 +201,39 @@ ON CONFLICT (id) DO NOTHING`, ticketID, project.ID, title, desc, status, priorit
 		_, _ = db.Exec(ctx, `
 INSERT INTO ticket_history (id, ticket_id, text, actor_id, timestamp)
 VALUES ($1, $2, $3, $4, NOW())`, uuid.NewString(), ticketID, fmt.Sprintf("Ticket created (%s)", status), reporter)
+		if err := seedTransitions(ctx, db, ticketID, status, createdAt, nil, updatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedTransitions replaces the status transitions of a seeded ticket: created
+// as todo, started at startedAt when given, and moved to status at updatedAt.
+func seedTransitions(ctx context.Context, db *pgxpool.Pool, ticketID, status string, createdAt time.Time, startedAt *time.Time, updatedAt time.Time) error {
+	if _, err := db.Exec(ctx, `DELETE FROM ticket_status_transitions WHERE ticket_id = $1`, ticketID); err != nil {
+		return err
+	}
+	type step struct {
+		status string
+		at     time.Time
+	}
+	steps := []step{{"todo", createdAt}}
+	if startedAt != nil && status != "todo" && status != "backlog" {
+		steps = append(steps, step{"in_progress", *startedAt})
+	}
+	if last := steps[len(steps)-1]; last.status != status {
+		steps = append(steps, step{status, updatedAt})
+	}
+	var from *string
+	for _, st := range steps {
+		_, err := db.Exec(ctx, `
+INSERT INTO ticket_status_transitions (ticket_id, from_status, to_status, changed_at)
+VALUES ($1, $2::ticket_status, $3::ticket_status, $4)`, ticketID, from, st.status, st.at)
+		if err != nil {
+			return err
+		}
+		from = &st.status
 	}
 	return nil
 }
@@ -264,13 +297,14 @@ func seedGamificationStats(ctx context.Context, db *pgxpool.Pool, users []seedUs
 		lastActive := time.Now().AddDate(0, 0, -gofakeit.Number(0, 5))
 
 		_, err := db.Exec(ctx, `
-INSERT INTO gamification_user_stats (user_id, xp_total, level, next_level_threshold, tickets_closed_count, streak_days, last_ticket_closed_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO gamification_user_stats (user_id, xp_total, level, next_level_threshold, tickets_closed_count, streak_days, last_ticket_closed_at, streak_through)
+VALUES ($1, $2, $3, $4, $5, $6, $7, CASE WHEN $6 > 0 THEN $7::date END)
 ON CONFLICT (user_id) DO UPDATE SET 
 	xp_total = EXCLUDED.xp_total,
 	level = EXCLUDED.level,
 	tickets_closed_count = EXCLUDED.tickets_closed_count,
-	streak_days = EXCLUDED.streak_days
+	streak_days = EXCLUDED.streak_days,
+	streak_through = EXCLUDED.streak_through
 `, u.ID, xp, level, (level+1)*1000, closed, streak, lastActive)
 		if err != nil {
 			return err
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/auth"
+	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/jobs"
+	"backend-go-ticketing-gamify/internal/reminders"
+	"backend-go-ticketing-gamify/internal/sla"
+)
+
+// newScheduler registers the background jobs. Schedules are in UTC.
+// Challenges need no rollover job: their week and progress are computed per
+// request from the current week's bounds.
+func (s *Server) newScheduler(auditSvc *audit.Service, gamSvc *gamification.Service, authSvc *auth.Service, reminderSvc *reminders.Service, slaSvc *sla.Service) (*jobs.Scheduler, error) {
+	scheduler := jobs.NewScheduler(jobs.NewRepository(s.pool), auditSvc)
+	all := []jobs.Job{
+		{
+			Name:        "streak-expiry",
+			Description: "Spends streak freezes or breaks streaks after a missed day in the user's timezone",
+			Schedule:    "@hourly",
+			Run: func(ctx context.Context) (string, error) {
+				frozen, broken, err := gamSvc.ExpireStreaks(ctx)
+				return fmt.Sprintf("%d frozen, %d broken", frozen, broken), err
+			},
+		},
+		{
+			Name:        "season-end",
+			Description: "Ends seasons whose planned end has passed and archives their standings",
+			Schedule:    "*/5 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := gamSvc.EndDueSeasons(ctx, time.Now())
+				return fmt.Sprintf("%d ended", n), err
+			},
+		},
+		{
+			Name:        "token-denylist-purge",
+			Description: "Removes revoked access tokens that have expired anyway",
+			Schedule:    "30 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := s.denylist.Purge(ctx)
+				return fmt.Sprintf("%d removed", n), err
+			},
+		},
+		{
+			Name:        "refresh-token-purge",
+			Description: "Deletes expired refresh tokens",
+			Schedule:    "15 3 * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := authSvc.PurgeExpiredSessions(ctx)
+				return fmt.Sprintf("%d deleted", n), err
+			},
+		},
+		{
+			Name:        "invite-cleanup",
+			Description: "Deletes invitations that expired or were revoked over 30 days ago",
+			Schedule:    "45 3 * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := authSvc.PurgeStaleInvites(ctx)
+				return fmt.Sprintf("%d deleted", n), err
+			},
+		},
+		{
+			Name:        "due-reminders",
+			Description: "Sends due date reminders and overdue escalations and raises the priority of overdue tickets",
+			Schedule:    "5 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				res, err := reminderSvc.Run(ctx, time.Now())
+				return fmt.Sprintf("%d reminders, %d escalations, %d raised", res.Reminders, res.Escalations, res.Raised), err
+			},
+		},
+		{
+			Name:        "sla-breaches",
+			Description: "Records SLA timers that breached, logs an sla_breached event for each and posts them to the policies' webhooks",
+			Schedule:    "*/5 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := slaSvc.ScanBreaches(ctx, time.Now())
+				return fmt.Sprintf("%d breaches", n), err
+			},
+		},
+	}
+	for _, job := range all {
+		if err := scheduler.Register(job); err != nil {
+			return nil, err
+		}
+	}
+	return scheduler, nil
+}
+
+// RunJobs runs the background jobs until ctx is canceled, without serving
+// HTTP. It is what cmd/worker runs.
+func (s *Server) RunJobs(ctx context.Context) error {
+	s.routes()
+	defer s.closeEmail()
+	return s.scheduler.Start(ctx)
+}
@@ -3,8 +3,10 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +14,7 @@ import (
 
 	"backend-go-ticketing-gamify/internal/achievements"
 	"backend-go-ticketing-gamify/internal/activity"
+	"backend-go-ticketing-gamify/internal/apitokens"
 	"backend-go-ticketing-gamify/internal/audit"
 	"backend-go-ticketing-gamify/internal/auth"
 	"backend-go-ticketing-gamify/internal/calendar"
@@ -20,10 +23,18 @@ import (
 	"backend-go-ticketing-gamify/internal/email"
 	"backend-go-ticketing-gamify/internal/epics"
 	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/imports"
+	"backend-go-ticketing-gamify/internal/jobs"
+	"backend-go-ticketing-gamify/internal/jwtauth"
 	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/milestones"
+	"backend-go-ticketing-gamify/internal/oidc"
+	"backend-go-ticketing-gamify/internal/organizations"
 	"backend-go-ticketing-gamify/internal/projects"
+	"backend-go-ticketing-gamify/internal/reminders"
 	"backend-go-ticketing-gamify/internal/reports"
 	"backend-go-ticketing-gamify/internal/seeders"
+	"backend-go-ticketing-gamify/internal/sla"
 	"backend-go-ticketing-gamify/internal/team"
 	"backend-go-ticketing-gamify/internal/tickets"
 	"backend-go-ticketing-gamify/internal/users"
@@ -33,13 +44,97 @@ const serviceVersion = "0.1.0"
 
 // Server exposes HTTP endpoints for the ticketing service.
 type Server struct {
-	cfg  config.Config
-	pool *pgxpool.Pool
+	cfg      config.Config
+	pool     *pgxpool.Pool
+	email    *email.Service
+	keys     *jwtauth.KeySet
+	denylist *jwtauth.Denylist
+	sso      auth.SSOOptions
+	// scheduler is built with the other services in routes.
+	scheduler *jobs.Scheduler
 }
 
 // New builds a Server with the provided Config and db pool.
-func New(cfg config.Config, pool *pgxpool.Pool) *Server {
-	return &Server{cfg: cfg, pool: pool}
+func New(cfg config.Config, pool *pgxpool.Pool) (*Server, error) {
+	emailSvc, err := email.NewService(emailConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	keys, err := jwtauth.LoadKeySet(KeyConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	sso, err := ssoOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		cfg:      cfg,
+		pool:     pool,
+		email:    emailSvc,
+		keys:     keys,
+		denylist: jwtauth.NewDenylist(pool),
+		sso:      sso,
+	}, nil
+}
+
+// KeyConfig maps the JWT settings to the signing key configuration.
+func KeyConfig(cfg config.Config) jwtauth.Config {
+	return jwtauth.Config{
+		Algorithm:      cfg.JWTAlgorithm,
+		KeyID:          cfg.JWTKeyID,
+		Secret:         cfg.JWTSecret,
+		PrivateKeyFile: cfg.JWTPrivateKeyFile,
+		PreviousKeys:   cfg.JWTPreviousKeys,
+		Grace:          cfg.JWTKeyGrace,
+	}
+}
+
+// ssoOptions builds the OIDC login settings; SSO stays off unless an issuer and client ID are set.
+func ssoOptions(cfg config.Config) (auth.SSOOptions, error) {
+	if cfg.OIDCIssuer == "" || cfg.OIDCClientID == "" {
+		return auth.SSOOptions{}, nil
+	}
+	mapping, err := auth.ParseRoleMapping(cfg.OIDCRoleMapping)
+	if err != nil {
+		return auth.SSOOptions{}, err
+	}
+	if cfg.OIDCDefaultRole != "" && !auth.IsAllowedRole(cfg.OIDCDefaultRole) {
+		return auth.SSOOptions{}, fmt.Errorf("invalid OIDC_DEFAULT_ROLE %q", cfg.OIDCDefaultRole)
+	}
+	return auth.SSOOptions{
+		Provider: oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       strings.Fields(cfg.OIDCScopes),
+			GroupsClaim:  cfg.OIDCGroupsClaim,
+		}),
+		AutoProvision: cfg.OIDCAutoProvision,
+		RoleMapping:   mapping,
+		DefaultRole:   cfg.OIDCDefaultRole,
+	}, nil
+}
+
+func emailConfig(cfg config.Config) email.Config {
+	return email.Config{
+		Transport:     cfg.EmailTransport,
+		FromEmail:     cfg.EmailFromAddress,
+		FromName:      cfg.EmailFromName,
+		FrontendURL:   cfg.FrontendURL,
+		DefaultLocale: cfg.EmailDefaultLocale,
+		ResendAPIKey:  cfg.ResendAPIKey,
+		SMTPHost:      cfg.SMTPHost,
+		SMTPPort:      cfg.SMTPPort,
+		SMTPUsername:  cfg.SMTPUsername,
+		SMTPPassword:  cfg.SMTPPassword,
+		OutboxDir:     cfg.EmailOutboxDir,
+		QueueSize:     cfg.EmailQueueSize,
+		Workers:       cfg.EmailWorkers,
+		MaxAttempts:   cfg.EmailMaxAttempts,
+		RetryDelay:    cfg.EmailRetryDelay,
+	}
 }
 
 // Start runs the HTTP server until context is canceled.
@@ -61,8 +156,17 @@ func (s *Server) Start(ctx context.Context) error {
 		if err := srv.Shutdown(shutdownCtx); err != nil {
 			log.Printf("graceful shutdown failed: %v", err)
 		}
+		s.closeEmail()
 	}()
 
+	if s.cfg.JobsEnabled {
+		go func() {
+			if err := s.scheduler.Start(ctx); err != nil {
+				log.Printf("job scheduler stopped: %v", err)
+			}
+		}()
+	}
+
 	log.Printf("HTTP server listening on %s", srv.Addr)
 	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
@@ -70,6 +174,15 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
+// closeEmail drains the email queue before the process exits.
+func (s *Server) closeEmail() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := s.email.Close(ctx); err != nil {
+		log.Printf("email queue drain failed: %v", err)
+	}
+}
+
 func (s *Server) routes() *gin.Engine {
 	if s.cfg.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -101,6 +214,11 @@ func (s *Server) routes() *gin.Engine {
 		c.JSON(http.StatusOK, gin.H{"version": serviceVersion})
 	})
 
+	engine.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, s.keys.JWKS())
+	})
+
 	// Temporary seed endpoint
 	engine.GET("/seed", func(c *gin.Context) {
 		options := seeders.Options{
@@ -125,42 +243,87 @@ func (s *Server) routes() *gin.Engine {
 	auditHandler := audit.NewHandler(auditSvc)
 
 	gamRepo := gamification.NewRepository(s.pool)
-	gamSvc := gamification.NewService(gamRepo)
+	gamSvc := gamification.NewService(gamRepo, auditSvc)
 	gamHandler := gamification.NewHandler(gamSvc)
 
 	authRepo := auth.NewRepository(s.pool)
-	emailSvc := email.NewService()
-	authSvc := auth.NewService(authRepo, gamSvc, auditSvc, emailSvc, s.cfg.JWTSecret, s.cfg.FrontendURL)
+	authSvc := auth.NewService(authRepo, gamSvc, auditSvc, s.email, auth.Options{
+		Keys:                s.keys,
+		Denylist:            s.denylist,
+		FrontendURL:         s.cfg.FrontendURL,
+		AccessTTL:           s.cfg.AccessTokenTTL,
+		RefreshTTL:          s.cfg.RefreshTokenTTL,
+		PasswordResetTTL:    s.cfg.PasswordResetTTL,
+		PasswordResetLimit:  s.cfg.PasswordResetLimit,
+		PasswordResetWindow: s.cfg.PasswordResetWindow,
+		InviteTTL:           s.cfg.UserInviteTTL,
+		MFAEncryptionKey:    s.cfg.MFAEncryptionKey,
+		MFAIssuer:           s.cfg.MFAIssuer,
+		Lockout: auth.LockoutPolicy{
+			MaxFailures:   s.cfg.LoginMaxFailures,
+			IPMaxFailures: s.cfg.LoginIPMaxFailures,
+			Duration:      s.cfg.LoginLockout,
+			Window:        s.cfg.LoginFailureWindow,
+		},
+		SSO:                  s.sso,
+		DisablePasswordLogin: !s.cfg.PasswordLoginEnabled,
+	})
 	authHandler := auth.NewHandler(authSvc)
 	authHandler.RegisterRoutes(api.Group("/auth"))
 
+	orgRepo := organizations.NewRepository(s.pool)
+	orgSvc := organizations.NewService(orgRepo, auditSvc, authSvc)
+	orgHandler := organizations.NewHandler(orgSvc)
+
 	userRepo := users.NewRepository(s.pool)
-	userSvc := users.NewService(userRepo, auditSvc)
+	userSvc := users.NewService(userRepo, auditSvc, s.denylist)
 	userHandler := users.NewHandler(userSvc)
 
 	projectRepo := projects.NewRepository(s.pool)
 	projectSvc := projects.NewService(projectRepo, auditSvc)
 	projectHandler := projects.NewHandler(projectSvc)
 
+	slaRepo := sla.NewRepository(s.pool)
+	slaSvc := sla.NewService(slaRepo, auditSvc, sla.Options{
+		WebhookSecret:        s.cfg.SLAWebhookSecret,
+		AllowPrivateWebhooks: s.cfg.SLAWebhookAllowPrivate,
+	})
+	slaHandler := sla.NewHandler(slaSvc)
+
 	ticketRepo := tickets.NewRepository(s.pool)
-	ticketSvc := tickets.NewService(ticketRepo, auditSvc, gamSvc)
+	ticketSvc := tickets.NewService(ticketRepo, auditSvc, gamSvc, slaSvc)
 	ticketHandler := tickets.NewHandler(ticketSvc)
 
+	importRepo := imports.NewRepository(s.pool)
+	importSvc := imports.NewService(importRepo, auditSvc, gamSvc)
+	importHandler := imports.NewHandler(importSvc)
+
 	epicRepo := epics.NewRepository(s.pool)
 	epicSvc := epics.NewService(epicRepo)
 	epicHandler := epics.NewHandler(epicSvc)
 
+	milestoneRepo := milestones.NewRepository(s.pool)
+	milestoneSvc := milestones.NewService(milestoneRepo)
+	milestoneHandler := milestones.NewHandler(milestoneSvc)
+
+	reminderRepo := reminders.NewRepository(s.pool)
+	reminderSvc := reminders.NewService(reminderRepo, s.email, auditSvc)
+	reminderHandler := reminders.NewHandler(reminderSvc)
+
 	// New modules
 	reportsRepo := reports.NewRepository(s.pool)
 	reportsSvc := reports.NewService(reportsRepo)
 	reportsHandler := reports.NewHandler(reportsSvc)
 
 	calendarRepo := calendar.NewRepository(s.pool)
-	calendarSvc := calendar.NewService(calendarRepo)
+	calendarSvc := calendar.NewService(calendarRepo, auditSvc, calendar.Options{
+		FrontendURL: s.cfg.FrontendURL,
+		PublicURL:   s.cfg.PublicURL,
+	})
 	calendarHandler := calendar.NewHandler(calendarSvc)
 
 	teamRepo := team.NewRepository(s.pool)
-	teamSvc := team.NewService(teamRepo)
+	teamSvc := team.NewService(teamRepo, auditSvc)
 	teamHandler := team.NewHandler(teamSvc)
 
 	achievementsRepo := achievements.NewRepository(s.pool)
@@ -175,29 +338,65 @@ func (s *Server) routes() *gin.Engine {
 	activitySvc := activity.NewService(activityRepo)
 	activityHandler := activity.NewHandler(activitySvc)
 
-	protected := api.Group("/")
-	protected.Use(middleware.AuthMiddleware(s.cfg.JWTSecret))
+	apiTokenRepo := apitokens.NewRepository(s.pool)
+	apiTokenSvc := apitokens.NewService(apiTokenRepo, auditSvc)
+	apiTokenHandler := apitokens.NewHandler(apiTokenSvc)
 
-	projectHandler.RegisterRoutes(protected.Group("/projects"))
-	epicHandler.RegisterRoutes(protected)
-	ticketHandler.RegisterRoutes(protected.Group("/tickets"))
-	gamHandler.RegisterRoutes(protected.Group("/gamification"))
+	scheduler, err := s.newScheduler(auditSvc, gamSvc, authSvc, reminderSvc, slaSvc)
+	if err != nil {
+		// only a bad schedule in newScheduler gets here
+		log.Fatalf("failed to register jobs: %v", err)
+	}
+	s.scheduler = scheduler
+	jobsHandler := jobs.NewHandler(scheduler)
+
+	// Calendar apps can't send the API key or a session, so the .ics feeds
+	// sit outside /api/v1; the token in the URL authenticates them.
+	calendarHandler.RegisterFeedRoutes(engine.Group("/calendar"))
+
+	protected := api.Group("/")
+	protected.Use(middleware.AuthMiddleware(s.keys, s.denylist, apiTokenSvc))
+
+	// API tokens only reach the routes their scopes cover; JWT sessions are unaffected.
+	projectHandler.RegisterRoutes(protected.Group("/projects", middleware.RequireScope("projects")))
+	epicHandler.RegisterRoutes(protected.Group("/", middleware.RequireScope("projects")))
+	milestoneHandler.RegisterRoutes(protected.Group("/", middleware.RequireScope("projects")))
+	reminderHandler.RegisterRoutes(protected.Group("/", middleware.RequireScope("projects")))
+	slaHandler.RegisterRoutes(protected.Group("/", middleware.RequireScope("projects")))
+	ticketHandler.RegisterRoutes(protected.Group("/tickets", middleware.RequireScope("tickets")))
+	gamHandler.RegisterRoutes(protected.Group("/gamification", middleware.RequireScope("gamification")))
+	importGroup := protected.Group("/imports", middleware.RequireScope("tickets"))
+	importGroup.Use(middleware.RequireRoles("admin", "project_manager"))
+	importHandler.RegisterRoutes(importGroup)
 
 	// Register new module routes
-	reportsHandler.RegisterRoutes(protected.Group("/reports"))
-	calendarHandler.RegisterRoutes(protected.Group("/calendar"))
-	teamHandler.RegisterRoutes(protected.Group("/team"))
-	achievementsHandler.RegisterRoutes(protected.Group("/achievements"))
-	challengesHandler.RegisterRoutes(protected.Group("/challenges"))
-	activityHandler.RegisterRoutes(protected.Group("/activity"))
-
-	authProtected := protected.Group("/auth")
+	reportsGroup := protected.Group("/reports", middleware.RequireScope("reports"))
+	reportsHandler.RegisterRoutes(reportsGroup)
+	slaHandler.RegisterReportRoutes(reportsGroup)
+	calendarHandler.RegisterRoutes(protected.Group("/calendar", middleware.RequireScope("tickets")))
+	teamHandler.RegisterRoutes(protected.Group("/team", middleware.RequireScope("projects")))
+	achievementsHandler.RegisterRoutes(protected.Group("/achievements", middleware.RequireScope("gamification")))
+	challengesHandler.RegisterRoutes(protected.Group("/challenges", middleware.RequireScope("gamification")))
+	activityHandler.RegisterRoutes(protected.Group("/activity", middleware.RequireScope("reports")))
+
+	authProtected := protected.Group("/auth", middleware.RequireSession())
 	authHandler.RegisterProtected(authProtected)
 
-	usersGroup := protected.Group("/users")
+	orgHandler.RegisterRoutes(protected.Group("/organization", middleware.RequireSession()))
+	orgHandler.RegisterOperatorRoutes(protected.Group("/organizations", middleware.RequireSession()))
+
+	apiTokenHandler.RegisterRoutes(protected.Group("/tokens", middleware.RequireSession()))
+	serviceAccounts := protected.Group("/service-accounts", middleware.RequireSession(), middleware.RequireRoles("admin"))
+	apiTokenHandler.RegisterServiceAccountRoutes(serviceAccounts)
+
+	usersGroup := protected.Group("/users", middleware.RequireScope("users"))
 	userHandler.RegisterRoutes(usersGroup)
+	authHandler.RegisterAdminRoutes(usersGroup)
+
+	jobsGroup := protected.Group("/admin/jobs", middleware.RequireSession(), middleware.RequireRoles("admin"), organizations.RequireDefaultOrg())
+	jobsHandler.RegisterRoutes(jobsGroup)
 
-	auditGroup := protected.Group("/audit")
+	auditGroup := protected.Group("/audit", middleware.RequireScope("audit"))
 	auditGroup.Use(middleware.RequireRoles("admin", "project_manager"))
 	auditHandler.RegisterRoutes(auditGroup)
 
@@ -0,0 +1,121 @@
+package sla_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/database/dbtest"
+	"backend-go-ticketing-gamify/internal/sla"
+)
+
+type delivery struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+func TestScanBreachesLogsAndPostsWebhook(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	orgID := dbtest.Org(t, db)
+	userID := dbtest.User(t, db, orgID, "admin")
+	projectID := dbtest.Project(t, db, orgID, userID)
+	ticketID := dbtest.Ticket(t, db, projectID, userID)
+	now := time.Now().UTC().Truncate(time.Second)
+	created := now.Add(-2 * time.Hour)
+	dbtest.Exec(t, db, `UPDATE tickets SET priority = 'urgent', created_at = $2, updated_at = $2 WHERE id = $1`, ticketID, created)
+	dbtest.Exec(t, db, `INSERT INTO ticket_status_transitions (ticket_id, to_status, changed_at) VALUES ($1, 'todo', $2)`, ticketID, created)
+
+	deliveries := make(chan delivery, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- delivery{event: r.Header.Get("X-Webhook-Event"), signature: r.Header.Get("X-Webhook-Signature"), body: body}
+	}))
+	defer hook.Close()
+
+	auditSvc := audit.NewService(audit.NewRepository(db))
+	svc := sla.NewService(sla.NewRepository(db), auditSvc, sla.Options{WebhookSecret: "hook-secret", AllowPrivateWebhooks: true})
+	minutes := 30
+	if _, err := svc.SavePolicy(ctx, orgID, projectID, userID, sla.PolicyInput{
+		Targets:    []sla.Target{{Priority: "urgent", ResponseMinutes: &minutes}},
+		WebhookURL: &hook.URL,
+	}); err != nil {
+		t.Fatalf("SavePolicy: %v", err)
+	}
+
+	found, err := svc.ScanBreaches(ctx, now)
+	if err != nil {
+		t.Fatalf("ScanBreaches: %v", err)
+	}
+	if found != 1 {
+		t.Fatalf("found %d breaches, want 1", found)
+	}
+
+	var d delivery
+	select {
+	case d = <-deliveries:
+	default:
+		t.Fatal("the webhook got nothing")
+	}
+	if d.event != sla.EventBreached {
+		t.Errorf("X-Webhook-Event = %q", d.event)
+	}
+	if want := sla.Signature("hook-secret", d.body); d.signature != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", d.signature, want)
+	}
+	var event sla.BreachEvent
+	if err := json.Unmarshal(d.body, &event); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	wantAt := created.Add(time.Duration(minutes) * time.Minute)
+	if event.Event != sla.EventBreached || event.OrgID != orgID || event.TicketID != ticketID || event.ProjectID != projectID ||
+		event.Kind != sla.KindResponse || event.TargetMinutes != minutes || !event.BreachedAt.Equal(wantAt) {
+		t.Errorf("event = %+v, want the response breach of %s at %s", event, ticketID, wantAt)
+	}
+
+	entries, _, err := auditSvc.List(ctx, orgID, 50, nil)
+	if err != nil {
+		t.Fatalf("audit list: %v", err)
+	}
+	logged := 0
+	for _, e := range entries {
+		if e.Action == "sla_breached" && e.EntityID != nil && *e.EntityID == ticketID {
+			logged++
+		}
+	}
+	if logged != 1 {
+		t.Fatalf("sla_breached entries in the organization's audit log = %d, want 1", logged)
+	}
+
+	// a breach is recorded, logged and posted once
+	if found, err := svc.ScanBreaches(ctx, now.Add(time.Minute)); err != nil || found != 0 {
+		t.Fatalf("second scan found %d, %v; want nothing new", found, err)
+	}
+	select {
+	case d := <-deliveries:
+		t.Fatalf("second scan posted %s", d.body)
+	default:
+	}
+}
+
+func TestSavePolicyRejectsWebhookURL(t *testing.T) {
+	db := dbtest.Open(t)
+	orgID := dbtest.Org(t, db)
+	userID := dbtest.User(t, db, orgID, "admin")
+	projectID := dbtest.Project(t, db, orgID, userID)
+	svc := sla.NewService(sla.NewRepository(db), nil, sla.Options{})
+
+	for _, target := range []string{"ftp://example.com/hook", "example.com/hook", "https://"} {
+		_, err := svc.SavePolicy(context.Background(), orgID, projectID, userID, sla.PolicyInput{WebhookURL: &target})
+		if !errors.Is(err, sla.ErrInvalid) {
+			t.Errorf("webhookUrl %q: err = %v, want ErrInvalid", target, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package sla
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/response"
+)
+
+// defaultComplianceDays is the range of the compliance report without from.
+const defaultComplianceDays = 30
+
+// Handler exposes SLA endpoints.
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes attaches the calendar and policy endpoints.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/sla/calendars", h.listCalendars)
+	router.POST("/sla/calendars", middleware.RequireRoles("admin", "project_manager"), h.createCalendar)
+	router.PUT("/sla/calendars/:id", middleware.RequireRoles("admin", "project_manager"), h.updateCalendar)
+	router.DELETE("/sla/calendars/:id", middleware.RequireRoles("admin", "project_manager"), h.deleteCalendar)
+	router.GET("/projects/:id/sla-policy", h.getPolicy)
+	router.PUT("/projects/:id/sla-policy", middleware.RequireRoles("admin", "project_manager"), h.savePolicy)
+}
+
+// RegisterReportRoutes attaches the compliance report to the reports group.
+func (h *Handler) RegisterReportRoutes(router *gin.RouterGroup) {
+	router.GET("/sla-compliance", h.compliance)
+}
+
+func (h *Handler) listCalendars(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	calendars, err := h.service.ListCalendars(c.Request.Context(), user.OrgID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	response.OK(c, calendars)
+}
+
+func (h *Handler) createCalendar(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload CalendarInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	calendar, err := h.service.CreateCalendar(c.Request.Context(), user.OrgID, user.ID, payload)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.Created(c, calendar)
+}
+
+func (h *Handler) updateCalendar(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload CalendarInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	calendar, err := h.service.UpdateCalendar(c.Request.Context(), user.OrgID, user.ID, c.Param("id"), payload)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.OK(c, calendar)
+}
+
+func (h *Handler) deleteCalendar(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	if err := h.service.DeleteCalendar(c.Request.Context(), user.OrgID, user.ID, c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+	response.NoContent(c)
+}
+
+func (h *Handler) getPolicy(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	policy, err := h.service.GetPolicy(c.Request.Context(), user.OrgID, c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.OK(c, policy)
+}
+
+func (h *Handler) savePolicy(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload PolicyInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	policy, err := h.service.SavePolicy(c.Request.Context(), user.OrgID, c.Param("id"), user.ID, payload)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.OK(c, policy)
+}
+
+// compliance reports SLA compliance of the tickets created from from to to,
+// both YYYY-MM-DD and inclusive; the last 30 days by default.
+func (h *Handler) compliance(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	now := time.Now().UTC()
+	to, ok := dateQuery(c, "to", time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	if !ok {
+		return
+	}
+	from, ok := dateQuery(c, "from", to.AddDate(0, 0, 1-defaultComplianceDays))
+	if !ok {
+		return
+	}
+	report, err := h.service.Compliance(c.Request.Context(), ComplianceFilter{
+		OrgID:     user.OrgID,
+		ProjectID: c.Query("projectId"),
+		Priority:  c.Query("priority"),
+		Type:      c.Query("type"),
+		From:      from,
+		To:        to.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	response.OK(c, report)
+}
+
+// dateQuery parses a YYYY-MM-DD query parameter, def when absent. It
+// answers 400 itself when the value is malformed.
+func dateQuery(c *gin.Context, name string, def time.Time) (time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, true
+	}
+	t, err := time.Parse(dateLayout, raw)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", name+" must be a date like 2006-01-02")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrProjectNotFound), errors.Is(err, ErrCalendarNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrCalendarInUse):
+		response.ErrorCode(c, http.StatusConflict, "calendar_in_use", err.Error())
+	case errors.Is(err, ErrInvalid):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -0,0 +1,119 @@
+package sla
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	clockLayout = "15:04"
+	dateLayout  = "2006-01-02"
+	// maxCalendarDays bounds how far ahead a due time is looked for.
+	maxCalendarDays = 3660
+)
+
+// prepare fills the parsed fields of a calendar loaded from the database.
+func (c *Calendar) prepare() error {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return fmt.Errorf("calendar %s: %w", c.ID, err)
+	}
+	c.loc = loc
+	c.DayStart, c.DayEnd = clock(c.startMinute), clock(c.endMinute)
+	c.Holidays = make([]string, len(c.holidays))
+	for i, h := range c.holidays {
+		c.Holidays[i] = h.Format(dateLayout)
+	}
+	return nil
+}
+
+// clock formats minutes after midnight as "15:04"; 1440 is "24:00".
+func clock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// parseClock reads "15:04", or "24:00" for the end of the day.
+func parseClock(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time of day (HH:MM)", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// hours returns the business hours of the day starting at midnight day, in
+// the calendar's timezone. ok is false on days off.
+func (c *Calendar) hours(day time.Time) (open, close time.Time, ok bool) {
+	if !slices.Contains(c.WorkDays, int(day.Weekday())) {
+		return open, close, false
+	}
+	y, m, d := day.Date()
+	for _, h := range c.holidays {
+		if hy, hm, hd := h.Date(); hy == y && hm == m && hd == d {
+			return open, close, false
+		}
+	}
+	open = time.Date(y, m, d, 0, c.startMinute, 0, 0, c.loc)
+	close = time.Date(y, m, d, 0, c.endMinute, 0, 0, c.loc)
+	return open, close, true
+}
+
+func (c *Calendar) midnight(t time.Time) time.Time {
+	y, m, d := t.In(c.loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, c.loc)
+}
+
+// span returns the business time between from and to. A nil calendar is
+// open around the clock.
+func (c *Calendar) span(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	if c == nil {
+		return to.Sub(from)
+	}
+	var total time.Duration
+	for day := c.midnight(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		open, close, ok := c.hours(day)
+		if !ok {
+			continue
+		}
+		if open.Before(from) {
+			open = from
+		}
+		if close.After(to) {
+			close = to
+		}
+		if close.After(open) {
+			total += close.Sub(open)
+		}
+	}
+	return total
+}
+
+// add returns the moment d of business time after from.
+func (c *Calendar) add(from time.Time, d time.Duration) time.Time {
+	if c == nil {
+		return from.Add(d)
+	}
+	day := c.midnight(from)
+	for i := 0; i < maxCalendarDays; i++ {
+		if open, close, ok := c.hours(day); ok {
+			if open.Before(from) {
+				open = from
+			}
+			if left := close.Sub(open); left >= d {
+				return open.Add(d)
+			} else if left > 0 {
+				d -= left
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	// only a calendar of nothing but holidays gets here
+	return from.Add(d)
+}
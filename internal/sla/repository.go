@@ -0,0 +1,324 @@
+package sla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles SLA calendars, policies and breaches.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+const calendarColumns = `c.id, c.name, c.timezone, c.work_days, c.start_minute, c.end_minute, c.holidays, c.created_at, c.updated_at`
+
+func scanCalendar(row pgx.Row) (*Calendar, error) {
+	var c Calendar
+	if err := row.Scan(&c.ID, &c.Name, &c.Timezone, &c.WorkDays, &c.startMinute, &c.endMinute, &c.holidays, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := c.prepare(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *Repository) calendars(ctx context.Context, query string, args ...any) ([]Calendar, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	calendars := []Calendar{}
+	for rows.Next() {
+		c, err := scanCalendar(rows)
+		if err != nil {
+			return nil, err
+		}
+		calendars = append(calendars, *c)
+	}
+	return calendars, rows.Err()
+}
+
+// ListCalendars returns the business hours calendars of an organization.
+func (r *Repository) ListCalendars(ctx context.Context, orgID string) ([]Calendar, error) {
+	return r.calendars(ctx, `SELECT `+calendarColumns+` FROM sla_calendars c WHERE c.org_id = $1 ORDER BY c.name`, orgID)
+}
+
+// GetCalendar returns a calendar of orgID, or nil.
+func (r *Repository) GetCalendar(ctx context.Context, orgID, id string) (*Calendar, error) {
+	c, err := scanCalendar(r.db.QueryRow(ctx, `SELECT `+calendarColumns+` FROM sla_calendars c WHERE c.id::text = $1 AND c.org_id = $2`, id, orgID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (r *Repository) CreateCalendar(ctx context.Context, orgID string, c Calendar) (*Calendar, error) {
+	const query = `
+INSERT INTO sla_calendars (org_id, name, timezone, work_days, start_minute, end_minute, holidays)
+VALUES ($1, $2, $3, $4, $5, $6, $7::text[]::date[])
+RETURNING id`
+	var id string
+	if err := r.db.QueryRow(ctx, query, orgID, c.Name, c.Timezone, c.WorkDays, c.startMinute, c.endMinute, c.Holidays).Scan(&id); err != nil {
+		return nil, err
+	}
+	return r.GetCalendar(ctx, orgID, id)
+}
+
+// UpdateCalendar replaces a calendar of orgID; nil when there is none.
+func (r *Repository) UpdateCalendar(ctx context.Context, orgID, id string, c Calendar) (*Calendar, error) {
+	const query = `
+UPDATE sla_calendars
+SET name = $3, timezone = $4, work_days = $5, start_minute = $6, end_minute = $7, holidays = $8::text[]::date[], updated_at = now()
+WHERE id::text = $1 AND org_id = $2`
+	tag, err := r.db.Exec(ctx, query, id, orgID, c.Name, c.Timezone, c.WorkDays, c.startMinute, c.endMinute, c.Holidays)
+	if err != nil || tag.RowsAffected() == 0 {
+		return nil, err
+	}
+	return r.GetCalendar(ctx, orgID, id)
+}
+
+// DeleteCalendar deletes a calendar of orgID and reports whether there was one.
+func (r *Repository) DeleteCalendar(ctx context.Context, orgID, id string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM sla_calendars WHERE id::text = $1 AND org_id = $2`, id, orgID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CalendarInUse reports whether a policy uses a calendar.
+func (r *Repository) CalendarInUse(ctx context.Context, id string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM sla_policies WHERE calendar_id::text = $1)`, id).Scan(&ok)
+	return ok, err
+}
+
+// ProjectInOrg reports whether projectID belongs to orgID.
+func (r *Repository) ProjectInOrg(ctx context.Context, orgID, projectID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id::text = $1 AND org_id = $2)`, projectID, orgID).Scan(&ok)
+	return ok, err
+}
+
+// Policies returns the saved policies of the projects among projectIDs,
+// with their targets and calendars.
+func (r *Repository) Policies(ctx context.Context, projectIDs []string) (map[string]*Policy, error) {
+	policies := map[string]*Policy{}
+	if len(projectIDs) == 0 {
+		return policies, nil
+	}
+	const policyQuery = `
+SELECT p.project_id, p.enabled, p.calendar_id, COALESCE(c.name, ''), p.paused_statuses::text[], p.webhook_url, p.updated_at
+FROM sla_policies p
+LEFT JOIN sla_calendars c ON c.id = p.calendar_id
+WHERE p.project_id = ANY($1::uuid[])`
+	rows, err := r.db.Query(ctx, policyQuery, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	var calendarIDs []string
+	for rows.Next() {
+		p := &Policy{Targets: []Target{}}
+		var updatedAt time.Time
+		if err := rows.Scan(&p.ProjectID, &p.Enabled, &p.CalendarID, &p.CalendarName, &p.PausedStatuses, &p.WebhookURL, &updatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.UpdatedAt = &updatedAt
+		if p.CalendarID != nil {
+			calendarIDs = append(calendarIDs, *p.CalendarID)
+		}
+		policies[p.ProjectID] = p
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const targetQuery = `
+SELECT project_id, priority::text, COALESCE(type::text, ''), response_minutes, resolution_minutes
+FROM sla_targets
+WHERE project_id = ANY($1::uuid[])
+ORDER BY priority, type NULLS FIRST`
+	rows, err = r.db.Query(ctx, targetQuery, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var projectID string
+		var t Target
+		if err := rows.Scan(&projectID, &t.Priority, &t.Type, &t.ResponseMinutes, &t.ResolutionMinutes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if p := policies[projectID]; p != nil {
+			p.Targets = append(p.Targets, t)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(calendarIDs) == 0 {
+		return policies, nil
+	}
+	calendars, err := r.calendars(ctx, `SELECT `+calendarColumns+` FROM sla_calendars c WHERE c.id = ANY($1::uuid[])`, calendarIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range policies {
+		for i := range calendars {
+			if p.CalendarID != nil && calendars[i].ID == *p.CalendarID {
+				p.calendar = &calendars[i]
+			}
+		}
+	}
+	return policies, nil
+}
+
+// SavePolicy writes a project's policy and replaces its targets.
+func (r *Repository) SavePolicy(ctx context.Context, p Policy, actorID string) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		const upsert = `
+INSERT INTO sla_policies (project_id, enabled, calendar_id, paused_statuses, webhook_url, updated_by, updated_at)
+VALUES ($1, $2, $3, $4::text[]::ticket_status[], $6, $5, now())
+ON CONFLICT (project_id) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    calendar_id = EXCLUDED.calendar_id,
+    paused_statuses = EXCLUDED.paused_statuses,
+    webhook_url = EXCLUDED.webhook_url,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = EXCLUDED.updated_at`
+		if _, err := tx.Exec(ctx, upsert, p.ProjectID, p.Enabled, p.CalendarID, p.PausedStatuses, actorID, p.WebhookURL); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM sla_targets WHERE project_id = $1`, p.ProjectID); err != nil {
+			return err
+		}
+		const insert = `
+INSERT INTO sla_targets (project_id, priority, type, response_minutes, resolution_minutes)
+VALUES ($1, $2::ticket_priority, NULLIF($3, '')::ticket_type, $4, $5)`
+		for _, t := range p.Targets {
+			if _, err := tx.Exec(ctx, insert, p.ProjectID, t.Priority, t.Type, t.ResponseMinutes, t.ResolutionMinutes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Transitions returns the status changes of tickets, oldest first.
+func (r *Repository) Transitions(ctx context.Context, ticketIDs []string) (map[string][]transition, error) {
+	history := map[string][]transition{}
+	if len(ticketIDs) == 0 {
+		return history, nil
+	}
+	const query = `
+SELECT ticket_id, to_status::text, changed_at
+FROM ticket_status_transitions
+WHERE ticket_id = ANY($1::uuid[])
+ORDER BY changed_at ASC`
+	rows, err := r.db.Query(ctx, query, ticketIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			ticketID string
+			tr       transition
+		)
+		if err := rows.Scan(&ticketID, &tr.Status, &tr.At); err != nil {
+			return nil, err
+		}
+		history[ticketID] = append(history[ticketID], tr)
+	}
+	return history, rows.Err()
+}
+
+const ticketColumns = `t.id, p.org_id::text, t.project_id, t.title, t.status::text, t.priority::text, t.type::text, t.created_at, t.updated_at`
+
+func (r *Repository) tickets(ctx context.Context, query string, args ...any) ([]TicketRef, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []TicketRef
+	for rows.Next() {
+		var t TicketRef
+		if err := rows.Scan(&t.ID, &t.OrgID, &t.ProjectID, &t.Title, &t.Status, &t.Priority, &t.Type, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, t)
+	}
+	return tickets, rows.Err()
+}
+
+// ComplianceTickets returns the tickets created in the filter's range in
+// projects with an enabled policy.
+func (r *Repository) ComplianceTickets(ctx context.Context, filter ComplianceFilter) ([]TicketRef, error) {
+	query := `
+SELECT ` + ticketColumns + `
+FROM tickets t
+JOIN projects p ON p.id = t.project_id
+JOIN sla_policies sp ON sp.project_id = t.project_id AND sp.enabled
+WHERE p.org_id = $1 AND t.created_at >= $2 AND t.created_at < $3`
+	args := []any{filter.OrgID, filter.From, filter.To}
+	if filter.ProjectID != "" {
+		args = append(args, filter.ProjectID)
+		query += fmt.Sprintf(" AND t.project_id::text = $%d", len(args))
+	}
+	if filter.Priority != "" {
+		args = append(args, filter.Priority)
+		query += fmt.Sprintf(" AND t.priority::text = $%d", len(args))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		query += fmt.Sprintf(" AND t.type::text = $%d", len(args))
+	}
+	query += " ORDER BY t.created_at ASC"
+	return r.tickets(ctx, query, args...)
+}
+
+// WatchedTickets returns the tickets whose timers may still breach: those
+// open, or done since the given time, in projects with an enabled policy.
+// Tickets that breached both timers already are left out.
+func (r *Repository) WatchedTickets(ctx context.Context, doneSince time.Time) ([]TicketRef, error) {
+	const query = `
+SELECT ` + ticketColumns + `
+FROM tickets t
+JOIN projects p ON p.id = t.project_id
+JOIN sla_policies sp ON sp.project_id = t.project_id AND sp.enabled
+WHERE (t.status <> 'done' OR t.updated_at >= $1)
+  AND (SELECT COUNT(*) FROM sla_breaches b WHERE b.ticket_id = t.id) < 2`
+	return r.tickets(ctx, query, doneSince)
+}
+
+// RecordBreach stores a breach and reports whether it is new.
+func (r *Repository) RecordBreach(ctx context.Context, ticketID, kind string, at time.Time) (bool, error) {
+	const query = `
+INSERT INTO sla_breaches (ticket_id, kind, breached_at)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING`
+	tag, err := r.db.Exec(ctx, query, ticketID, kind, at)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,466 @@
+package sla
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/audit"
+)
+
+const (
+	// MaxHolidays caps the holidays of one calendar.
+	MaxHolidays = 366
+	// maxTargetMinutes is a year.
+	maxTargetMinutes = 365 * 24 * 60
+	// MaxComplianceDays bounds the range of the compliance report.
+	MaxComplianceDays = 366
+	// maxReportBreaches caps the breaches the compliance report lists.
+	maxReportBreaches = 100
+	// breachLookback is how long after completion a ticket is still checked
+	// for a breach the last scan missed.
+	breachLookback = 24 * time.Hour
+	// maxWebhookURL bounds the length of a policy's webhook URL.
+	maxWebhookURL = 2048
+)
+
+var (
+	ErrProjectNotFound  = errors.New("project not found")
+	ErrCalendarNotFound = errors.New("SLA calendar not found")
+	ErrCalendarInUse    = errors.New("SLA calendar is used by a policy")
+	// ErrInvalid wraps what is wrong with a calendar, policy or report request.
+	ErrInvalid = errors.New("invalid SLA settings")
+)
+
+var (
+	priorities = []string{"low", "medium", "high", "urgent"}
+	types      = []string{"feature", "bug", "chore"}
+	// pausable are the statuses a policy may pause timers in.
+	pausable = []string{"backlog", "todo", "in_progress", "review"}
+)
+
+// Options configures the SLA service.
+type Options struct {
+	// WebhookSecret, when set, signs breach webhooks with an HMAC-SHA256 of
+	// the body in X-Webhook-Signature.
+	WebhookSecret string
+	// AllowPrivateWebhooks lets webhooks reach loopback, link-local and
+	// private addresses, for development against a local receiver.
+	AllowPrivateWebhooks bool
+}
+
+type Service struct {
+	repo   *Repository
+	audit  *audit.Service
+	opts   Options
+	client *http.Client
+}
+
+func NewService(repo *Repository, auditSvc *audit.Service, opts Options) *Service {
+	return &Service{repo: repo, audit: auditSvc, opts: opts, client: webhookClient(opts.AllowPrivateWebhooks)}
+}
+
+func (s *Service) ListCalendars(ctx context.Context, orgID string) ([]Calendar, error) {
+	return s.repo.ListCalendars(ctx, orgID)
+}
+
+func (s *Service) CreateCalendar(ctx context.Context, orgID, actorID string, input CalendarInput) (*Calendar, error) {
+	c, err := buildCalendar(input)
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.repo.CreateCalendar(ctx, orgID, c)
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx, actorID, "sla_calendar_created", fmt.Sprintf("SLA calendar %q created", created.Name), "sla_calendar", created.ID)
+	return created, nil
+}
+
+func (s *Service) UpdateCalendar(ctx context.Context, orgID, actorID, id string, input CalendarInput) (*Calendar, error) {
+	c, err := buildCalendar(input)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.UpdateCalendar(ctx, orgID, id, c)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrCalendarNotFound
+	}
+	s.log(ctx, actorID, "sla_calendar_updated", fmt.Sprintf("SLA calendar %q updated", updated.Name), "sla_calendar", updated.ID)
+	return updated, nil
+}
+
+// DeleteCalendar deletes a calendar no policy uses.
+func (s *Service) DeleteCalendar(ctx context.Context, orgID, actorID, id string) error {
+	current, err := s.repo.GetCalendar(ctx, orgID, id)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return ErrCalendarNotFound
+	}
+	inUse, err := s.repo.CalendarInUse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrCalendarInUse
+	}
+	if _, err := s.repo.DeleteCalendar(ctx, orgID, id); err != nil {
+		return err
+	}
+	s.log(ctx, actorID, "sla_calendar_deleted", fmt.Sprintf("SLA calendar %q deleted", current.Name), "sla_calendar", id)
+	return nil
+}
+
+func buildCalendar(input CalendarInput) (Calendar, error) {
+	c := Calendar{
+		Name:     strings.TrimSpace(input.Name),
+		Timezone: cmp.Or(strings.TrimSpace(input.Timezone), "UTC"),
+		WorkDays: []int{1, 2, 3, 4, 5},
+		Holidays: []string{},
+	}
+	if c.Name == "" || len(c.Name) > 100 {
+		return c, fmt.Errorf("%w: name is required, up to 100 characters", ErrInvalid)
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return c, fmt.Errorf("%w: unknown timezone %q", ErrInvalid, c.Timezone)
+	}
+	if input.WorkDays != nil {
+		c.WorkDays = []int{}
+		for _, d := range input.WorkDays {
+			if d < 0 || d > 6 {
+				return c, fmt.Errorf("%w: work days are 0 (Sunday) to 6 (Saturday)", ErrInvalid)
+			}
+			if !slices.Contains(c.WorkDays, d) {
+				c.WorkDays = append(c.WorkDays, d)
+			}
+		}
+		if len(c.WorkDays) == 0 {
+			return c, fmt.Errorf("%w: at least one work day is required", ErrInvalid)
+		}
+		slices.Sort(c.WorkDays)
+	}
+	var err error
+	if c.startMinute, err = parseClock(cmp.Or(input.DayStart, "09:00")); err != nil {
+		return c, fmt.Errorf("%w: dayStart: %v", ErrInvalid, err)
+	}
+	if c.endMinute, err = parseClock(cmp.Or(input.DayEnd, "17:00")); err != nil {
+		return c, fmt.Errorf("%w: dayEnd: %v", ErrInvalid, err)
+	}
+	if c.startMinute >= c.endMinute {
+		return c, fmt.Errorf("%w: dayStart must be before dayEnd", ErrInvalid)
+	}
+	for _, h := range input.Holidays {
+		if _, err := time.Parse(dateLayout, h); err != nil {
+			return c, fmt.Errorf("%w: holiday %q is not a date (YYYY-MM-DD)", ErrInvalid, h)
+		}
+		if !slices.Contains(c.Holidays, h) {
+			c.Holidays = append(c.Holidays, h)
+		}
+	}
+	if len(c.Holidays) > MaxHolidays {
+		return c, fmt.Errorf("%w: at most %d holidays", ErrInvalid, MaxHolidays)
+	}
+	slices.Sort(c.Holidays)
+	return c, nil
+}
+
+// GetPolicy returns the SLA policy of a project of orgID. Projects that
+// never saved one have an empty, disabled policy.
+func (s *Service) GetPolicy(ctx context.Context, orgID, projectID string) (*Policy, error) {
+	ok, err := s.repo.ProjectInOrg(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+	policies, err := s.repo.Policies(ctx, []string{projectID})
+	if err != nil {
+		return nil, err
+	}
+	if p := policies[projectID]; p != nil {
+		return p, nil
+	}
+	return &Policy{ProjectID: projectID, PausedStatuses: []string{}, Targets: []Target{}}, nil
+}
+
+// SavePolicy replaces the SLA policy of a project of orgID.
+func (s *Service) SavePolicy(ctx context.Context, orgID, projectID, actorID string, input PolicyInput) (*Policy, error) {
+	ok, err := s.repo.ProjectInOrg(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+	policy, err := buildPolicy(projectID, input)
+	if err != nil {
+		return nil, err
+	}
+	if policy.CalendarID != nil {
+		c, err := s.repo.GetCalendar(ctx, orgID, *policy.CalendarID)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, fmt.Errorf("%w: unknown calendar", ErrInvalid)
+		}
+	}
+	if err := s.repo.SavePolicy(ctx, policy, actorID); err != nil {
+		return nil, err
+	}
+	s.log(ctx, actorID, "sla_policy_updated", fmt.Sprintf("SLA policy updated: %d targets, enabled %t", len(policy.Targets), policy.Enabled), "project", projectID)
+	return s.GetPolicy(ctx, orgID, projectID)
+}
+
+func buildPolicy(projectID string, input PolicyInput) (Policy, error) {
+	p := Policy{ProjectID: projectID, Enabled: true, PausedStatuses: []string{}, Targets: []Target{}}
+	if input.Enabled != nil {
+		p.Enabled = *input.Enabled
+	}
+	if input.CalendarID != nil && *input.CalendarID != "" {
+		p.CalendarID = input.CalendarID
+	}
+	if input.WebhookURL != nil && *input.WebhookURL != "" {
+		u, err := url.Parse(*input.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(*input.WebhookURL) > maxWebhookURL {
+			return p, fmt.Errorf("%w: webhookUrl must be an http or https URL", ErrInvalid)
+		}
+		p.WebhookURL = input.WebhookURL
+	}
+	for _, status := range input.PausedStatuses {
+		if !slices.Contains(pausable, status) {
+			return p, fmt.Errorf("%w: timers can pause in %s", ErrInvalid, strings.Join(pausable, ", "))
+		}
+		if !slices.Contains(p.PausedStatuses, status) {
+			p.PausedStatuses = append(p.PausedStatuses, status)
+		}
+	}
+	for _, t := range input.Targets {
+		if !slices.Contains(priorities, t.Priority) {
+			return p, fmt.Errorf("%w: unknown priority %q", ErrInvalid, t.Priority)
+		}
+		if t.Type != "" && !slices.Contains(types, t.Type) {
+			return p, fmt.Errorf("%w: unknown type %q", ErrInvalid, t.Type)
+		}
+		if t.ResponseMinutes == nil && t.ResolutionMinutes == nil {
+			return p, fmt.Errorf("%w: a target needs responseMinutes or resolutionMinutes", ErrInvalid)
+		}
+		for _, m := range []*int{t.ResponseMinutes, t.ResolutionMinutes} {
+			if m != nil && (*m <= 0 || *m > maxTargetMinutes) {
+				return p, fmt.Errorf("%w: target minutes must be between 1 and %d", ErrInvalid, maxTargetMinutes)
+			}
+		}
+		if slices.ContainsFunc(p.Targets, func(o Target) bool { return o.Priority == t.Priority && o.Type == t.Type }) {
+			return p, fmt.Errorf("%w: more than one target for %s %s", ErrInvalid, t.Priority, cmp.Or(t.Type, "tickets"))
+		}
+		p.Targets = append(p.Targets, t)
+	}
+	return p, nil
+}
+
+// Evaluate computes the SLA of tickets, keyed by ticket id. Tickets of
+// projects without an enabled policy, or that no target applies to, are
+// left out.
+func (s *Service) Evaluate(ctx context.Context, tickets []TicketRef) (map[string]*Status, error) {
+	return s.evaluate(ctx, tickets, time.Now())
+}
+
+func (s *Service) evaluate(ctx context.Context, tickets []TicketRef, now time.Time) (map[string]*Status, error) {
+	statuses := map[string]*Status{}
+	var projectIDs []string
+	for _, t := range tickets {
+		if !slices.Contains(projectIDs, t.ProjectID) {
+			projectIDs = append(projectIDs, t.ProjectID)
+		}
+	}
+	policies, err := s.repo.Policies(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	var ticketIDs []string
+	for _, t := range tickets {
+		if p := policies[t.ProjectID]; p != nil && p.Enabled {
+			ticketIDs = append(ticketIDs, t.ID)
+		}
+	}
+	history, err := s.repo.Transitions(ctx, ticketIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tickets {
+		if status := policies[t.ProjectID].evaluate(t, history[t.ID], now); status != nil {
+			statuses[t.ID] = status
+		}
+	}
+	return statuses, nil
+}
+
+// Compliance reports how the tickets created in a range kept their SLA.
+func (s *Service) Compliance(ctx context.Context, filter ComplianceFilter) (*Compliance, error) {
+	if filter.Priority != "" && !slices.Contains(priorities, filter.Priority) {
+		return nil, fmt.Errorf("%w: unknown priority %q", ErrInvalid, filter.Priority)
+	}
+	if filter.Type != "" && !slices.Contains(types, filter.Type) {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalid, filter.Type)
+	}
+	if !filter.From.Before(filter.To) || filter.To.Sub(filter.From) > MaxComplianceDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: from must be before to, at most %d days apart", ErrInvalid, MaxComplianceDays)
+	}
+	tickets, err := s.repo.ComplianceTickets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := s.Evaluate(ctx, tickets)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Compliance{
+		From:     filter.From.Format(time.RFC3339),
+		To:       filter.To.Format(time.RFC3339),
+		Breaches: []Breach{},
+	}
+	groups := map[string]map[string]*ComplianceGroup{"priority": {}, "type": {}, "project": {}}
+	for _, t := range tickets {
+		status := statuses[t.ID]
+		if status == nil {
+			continue
+		}
+		report.Tickets++
+		count(&report.Response, status.Response)
+		count(&report.Resolution, status.Resolution)
+		for by, key := range map[string]string{"priority": t.Priority, "type": t.Type, "project": t.ProjectID} {
+			g := groups[by][key]
+			if g == nil {
+				g = &ComplianceGroup{Key: key}
+				groups[by][key] = g
+			}
+			g.Tickets++
+			count(&g.Response, status.Response)
+			count(&g.Resolution, status.Resolution)
+		}
+		for kind, timer := range map[string]*Timer{KindResponse: status.Response, KindResolution: status.Resolution} {
+			if breached(timer) {
+				report.Breaches = append(report.Breaches, Breach{
+					TicketID:   t.ID,
+					Title:      t.Title,
+					ProjectID:  t.ProjectID,
+					Priority:   t.Priority,
+					Type:       t.Type,
+					Kind:       kind,
+					BreachedAt: *timer.BreachedAt,
+				})
+			}
+		}
+	}
+
+	percent(&report.Response)
+	percent(&report.Resolution)
+	report.ByPriority = sortedGroups(groups["priority"], priorities)
+	report.ByType = sortedGroups(groups["type"], types)
+	report.ByProject = sortedGroups(groups["project"], nil)
+	slices.SortFunc(report.Breaches, func(a, b Breach) int {
+		return cmp.Or(b.BreachedAt.Compare(a.BreachedAt), cmp.Compare(a.TicketID, b.TicketID), cmp.Compare(a.Kind, b.Kind))
+	})
+	if len(report.Breaches) > maxReportBreaches {
+		report.Breaches = report.Breaches[:maxReportBreaches]
+	}
+	return report, nil
+}
+
+func count(stats *ComplianceStats, timer *Timer) {
+	switch {
+	case timer == nil:
+	case timer.State == StateBreached:
+		stats.Breached++
+	case timer.State == StateMet:
+		stats.Met++
+	default:
+		stats.Running++
+	}
+}
+
+func percent(stats *ComplianceStats) {
+	if total := stats.Met + stats.Breached; total > 0 {
+		pct := float64(stats.Met) * 100 / float64(total)
+		stats.CompliancePercent = &pct
+	}
+}
+
+// sortedGroups orders groups by order, or by key when order is nil.
+func sortedGroups(groups map[string]*ComplianceGroup, order []string) []ComplianceGroup {
+	out := []ComplianceGroup{}
+	for _, g := range groups {
+		percent(&g.Response)
+		percent(&g.Resolution)
+		out = append(out, *g)
+	}
+	slices.SortFunc(out, func(a, b ComplianceGroup) int {
+		if order != nil {
+			return cmp.Compare(slices.Index(order, a.Key), slices.Index(order, b.Key))
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return out
+}
+
+// ScanBreaches records the timers that breached since the last scan, logs
+// an sla_breached audit event for each in the ticket's organization and
+// posts them to the policies' webhooks. It returns how many it found.
+func (s *Service) ScanBreaches(ctx context.Context, now time.Time) (int, error) {
+	tickets, err := s.repo.WatchedTickets(ctx, now.Add(-breachLookback))
+	if err != nil {
+		return 0, err
+	}
+	statuses, err := s.evaluate(ctx, tickets, now)
+	if err != nil {
+		return 0, err
+	}
+	var events []BreachEvent
+	defer func() { s.notify(ctx, events) }()
+	for _, t := range tickets {
+		status := statuses[t.ID]
+		if status == nil || !status.Breached {
+			continue
+		}
+		for kind, timer := range map[string]*Timer{KindResponse: status.Response, KindResolution: status.Resolution} {
+			if !breached(timer) {
+				continue
+			}
+			added, err := s.repo.RecordBreach(ctx, t.ID, kind, *timer.BreachedAt)
+			if err != nil {
+				return len(events), err
+			}
+			if !added {
+				continue
+			}
+			events = append(events, breachEvent(t, kind, timer))
+			if s.audit != nil {
+				desc := fmt.Sprintf("Ticket %q breached its %s SLA (%s, %d minutes) at %s", t.Title, kind, t.Priority, timer.TargetMinutes, timer.BreachedAt.UTC().Format(time.RFC3339))
+				entityType := "ticket"
+				_ = s.audit.LogSystem(ctx, t.OrgID, "sla_breached", desc, &entityType, &t.ID)
+			}
+		}
+	}
+	return len(events), nil
+}
+
+// log writes an audit entry of actorID.
+func (s *Service) log(ctx context.Context, actorID, action, desc, entityType, entityID string) {
+	if s.audit == nil {
+		return
+	}
+	_ = s.audit.Log(ctx, action, desc, &actorID, &entityType, &entityID)
+}
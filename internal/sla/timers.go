@@ -0,0 +1,142 @@
+package sla
+
+import (
+	"slices"
+	"time"
+)
+
+// segment is a stretch of time a ticket spent in one status.
+type segment struct {
+	from, to time.Time
+	status   string
+}
+
+// segments replays a ticket's status changes, oldest first, up to now.
+// Tickets start in todo. When the history misses the change to the current
+// status, it is taken to have happened at the last update.
+func segments(t TicketRef, history []transition, now time.Time) []segment {
+	status, at := "todo", t.CreatedAt
+	var out []segment
+	for _, tr := range history {
+		if tr.Status == status {
+			continue
+		}
+		if tr.At.Before(at) {
+			tr.At = at
+		}
+		out = append(out, segment{from: at, to: tr.At, status: status})
+		status, at = tr.Status, tr.At
+	}
+	if status != t.Status {
+		changed := t.UpdatedAt
+		if changed.Before(at) {
+			changed = at
+		}
+		out = append(out, segment{from: at, to: changed, status: status})
+		status, at = t.Status, changed
+	}
+	if now.Before(at) {
+		now = at
+	}
+	return append(out, segment{from: at, to: now, status: status})
+}
+
+// target returns the policy's target for a ticket: the one for its type
+// when there is one, else the one for any type.
+func (p *Policy) target(priority, typ string) *Target {
+	var found *Target
+	for i, t := range p.Targets {
+		if t.Priority != priority {
+			continue
+		}
+		if t.Type == typ {
+			return &p.Targets[i]
+		}
+		if t.Type == "" {
+			found = &p.Targets[i]
+		}
+	}
+	return found
+}
+
+// evaluate computes a ticket's SLA. It returns nil when the policy is off
+// or sets no target for the ticket.
+//
+// The response timer runs until the ticket first moves to in_progress,
+// review or done. The resolution timer runs while the ticket is not done,
+// so reopening a ticket starts it again where it stopped. Neither counts
+// time in the paused statuses or outside business hours.
+func (p *Policy) evaluate(t TicketRef, history []transition, now time.Time) *Status {
+	if p == nil || !p.Enabled {
+		return nil
+	}
+	target := p.target(t.Priority, t.Type)
+	if target == nil || (target.ResponseMinutes == nil && target.ResolutionMinutes == nil) {
+		return nil
+	}
+	segs := segments(t, history, now)
+	status := &Status{}
+	if target.ResponseMinutes != nil {
+		counted, stop := segs, (*time.Time)(nil)
+		for i, s := range segs {
+			if s.status == "in_progress" || s.status == "review" || s.status == "done" {
+				counted, stop = segs[:i], &segs[i].from
+				break
+			}
+		}
+		status.Response = p.measure(counted, *target.ResponseMinutes, stop, now, p.counts)
+	}
+	if target.ResolutionMinutes != nil {
+		var stop *time.Time
+		if last := segs[len(segs)-1]; last.status == "done" {
+			stop = &last.from
+		}
+		status.Resolution = p.measure(segs, *target.ResolutionMinutes, stop, now, func(s string) bool {
+			return s != "done" && p.counts(s)
+		})
+	}
+	status.Breached = breached(status.Response) || breached(status.Resolution)
+	return status
+}
+
+// counts reports whether time in status runs the timers.
+func (p *Policy) counts(status string) bool {
+	return !slices.Contains(p.PausedStatuses, status)
+}
+
+// measure adds up the business time of the segments counts accepts against
+// a target of minutes. stop is when the timer stopped, nil while it runs.
+func (p *Policy) measure(segs []segment, minutes int, stop *time.Time, now time.Time, counts func(string) bool) *Timer {
+	target := time.Duration(minutes) * time.Minute
+	timer := &Timer{TargetMinutes: minutes, StoppedAt: stop}
+	var elapsed time.Duration
+	for _, s := range segs {
+		if !counts(s.status) {
+			continue
+		}
+		d := p.calendar.span(s.from, s.to)
+		if timer.BreachedAt == nil && elapsed+d > target {
+			at := p.calendar.add(s.from, target-elapsed)
+			timer.BreachedAt = &at
+		}
+		elapsed += d
+	}
+	timer.ElapsedMinutes = int(elapsed / time.Minute)
+	switch {
+	case timer.BreachedAt != nil:
+		timer.State = StateBreached
+	case stop != nil:
+		timer.State = StateMet
+	case len(segs) > 0 && !counts(segs[len(segs)-1].status):
+		timer.State = StatePaused
+	default:
+		timer.State = StateRunning
+		due := p.calendar.add(now, target-elapsed)
+		timer.DueAt = &due
+	}
+	return timer
+}
+
+func breached(t *Timer) bool {
+	return t != nil && t.State == StateBreached
+}
@@ -0,0 +1,172 @@
+package sla
+
+import "time"
+
+// Timer kinds.
+const (
+	KindResponse   = "response"
+	KindResolution = "resolution"
+)
+
+// Timer states.
+const (
+	StateRunning  = "running"
+	StatePaused   = "paused"
+	StateMet      = "met"
+	StateBreached = "breached"
+)
+
+// Calendar is the business hours SLA timers count in.
+type Calendar struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+	// WorkDays are 0 (Sunday) to 6 (Saturday).
+	WorkDays []int `json:"workDays"`
+	// DayStart and DayEnd are "15:04" in Timezone.
+	DayStart  string    `json:"dayStart"`
+	DayEnd    string    `json:"dayEnd"`
+	Holidays  []string  `json:"holidays"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	startMinute int
+	endMinute   int
+	holidays    []time.Time
+	loc         *time.Location
+}
+
+// CalendarInput creates or replaces a calendar. Omitted fields take the
+// defaults: UTC, Monday to Friday, 09:00 to 17:00, no holidays.
+type CalendarInput struct {
+	Name     string   `json:"name"`
+	Timezone string   `json:"timezone"`
+	WorkDays []int    `json:"workDays"`
+	DayStart string   `json:"dayStart"`
+	DayEnd   string   `json:"dayEnd"`
+	Holidays []string `json:"holidays"`
+}
+
+// Target is what a policy promises tickets of a priority and, optionally,
+// a type. Either time may be nil.
+type Target struct {
+	Priority          string `json:"priority"`
+	Type              string `json:"type,omitempty"`
+	ResponseMinutes   *int   `json:"responseMinutes"`
+	ResolutionMinutes *int   `json:"resolutionMinutes"`
+}
+
+// Policy is a project's SLA policy.
+type Policy struct {
+	ProjectID    string  `json:"projectId"`
+	Enabled      bool    `json:"enabled"`
+	CalendarID   *string `json:"calendarId"`
+	CalendarName string  `json:"calendarName,omitempty"`
+	// WebhookURL gets a POST for every breach of the project's tickets.
+	WebhookURL *string `json:"webhookUrl"`
+	// PausedStatuses stop the timers while a ticket sits in them.
+	PausedStatuses []string   `json:"pausedStatuses"`
+	Targets        []Target   `json:"targets"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+
+	calendar *Calendar
+}
+
+// PolicyInput replaces a project's policy.
+type PolicyInput struct {
+	Enabled        *bool    `json:"enabled"`
+	CalendarID     *string  `json:"calendarId"`
+	PausedStatuses []string `json:"pausedStatuses"`
+	Targets        []Target `json:"targets"`
+	WebhookURL     *string  `json:"webhookUrl"`
+}
+
+// Timer is where one SLA target of a ticket stands.
+type Timer struct {
+	TargetMinutes  int    `json:"targetMinutes"`
+	ElapsedMinutes int    `json:"elapsedMinutes"`
+	State          string `json:"state"`
+	// DueAt is when a running timer breaches.
+	DueAt      *time.Time `json:"dueAt,omitempty"`
+	StoppedAt  *time.Time `json:"stoppedAt,omitempty"`
+	BreachedAt *time.Time `json:"breachedAt,omitempty"`
+}
+
+// Status is the SLA of a ticket.
+type Status struct {
+	Response   *Timer `json:"response,omitempty"`
+	Resolution *Timer `json:"resolution,omitempty"`
+	Breached   bool   `json:"breached"`
+}
+
+// TicketRef is what computing a ticket's SLA needs.
+type TicketRef struct {
+	ID        string
+	OrgID     string
+	ProjectID string
+	Title     string
+	Status    string
+	Priority  string
+	Type      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// transition is a status change read from the ticket history.
+type transition struct {
+	At     time.Time
+	Status string
+}
+
+// ComplianceFilter narrows the SLA compliance report.
+type ComplianceFilter struct {
+	OrgID     string
+	ProjectID string
+	Priority  string
+	Type      string
+	From      time.Time
+	To        time.Time
+}
+
+// ComplianceStats counts the timers of one kind.
+type ComplianceStats struct {
+	Met      int `json:"met"`
+	Breached int `json:"breached"`
+	// Running counts open timers that have not breached yet.
+	Running int `json:"running"`
+	// CompliancePercent is met out of met and breached; nil with neither.
+	CompliancePercent *float64 `json:"compliancePercent"`
+}
+
+// ComplianceGroup is the compliance of the tickets sharing a key.
+type ComplianceGroup struct {
+	Key        string          `json:"key"`
+	Tickets    int             `json:"tickets"`
+	Response   ComplianceStats `json:"response"`
+	Resolution ComplianceStats `json:"resolution"`
+}
+
+// Breach is one breached timer in the compliance report.
+type Breach struct {
+	TicketID   string    `json:"ticketId"`
+	Title      string    `json:"title"`
+	ProjectID  string    `json:"projectId"`
+	Priority   string    `json:"priority"`
+	Type       string    `json:"type"`
+	Kind       string    `json:"kind"`
+	BreachedAt time.Time `json:"breachedAt"`
+}
+
+// Compliance is the SLA compliance report of the tickets created in a
+// date range.
+type Compliance struct {
+	From       string            `json:"from"`
+	To         string            `json:"to"`
+	Tickets    int               `json:"tickets"`
+	Response   ComplianceStats   `json:"response"`
+	Resolution ComplianceStats   `json:"resolution"`
+	ByPriority []ComplianceGroup `json:"byPriority"`
+	ByType     []ComplianceGroup `json:"byType"`
+	ByProject  []ComplianceGroup `json:"byProject"`
+	Breaches   []Breach          `json:"breaches"`
+}
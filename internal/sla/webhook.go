@@ -0,0 +1,197 @@
+package sla
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// EventBreached names the breach webhook in its body and X-Webhook-Event.
+	EventBreached = "sla.breached"
+	// webhookTimeout bounds one webhook delivery.
+	webhookTimeout = 10 * time.Second
+	// webhookBudget bounds all deliveries of one scan, which runs while the
+	// job holds its lock.
+	webhookBudget = 30 * time.Second
+	// webhookWorkers is how many deliveries run at once.
+	webhookWorkers = 8
+)
+
+// ErrBlockedAddress is returned for webhook deliveries to addresses inside
+// the network, which a policy could otherwise make the server call.
+var ErrBlockedAddress = errors.New("webhook address is not public")
+
+// webhookClient posts webhooks without following redirects or proxies.
+// Unless allowPrivate is set, it refuses to connect to anything but public
+// addresses; the check runs on the resolved address of every connection, so
+// a hostname can't point it inside the network either.
+func webhookClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if addr, err := netip.ParseAddr(host); err != nil || !publicAddr(addr) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConns:        webhookWorkers,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// cgnat is the shared address space carriers and clouds use internally.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr reports whether addr is routable on the internet: not
+// loopback, link-local (which includes cloud metadata endpoints), private,
+// shared, multicast or unspecified.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !cgnat.Contains(addr)
+}
+
+// BreachEvent is the JSON body posted to a policy's webhook when a ticket of
+// the project breaches a timer.
+type BreachEvent struct {
+	Event string `json:"event"`
+	OrgID string `json:"orgId"`
+	Breach
+	TargetMinutes int `json:"targetMinutes"`
+}
+
+func breachEvent(t TicketRef, kind string, timer *Timer) BreachEvent {
+	return BreachEvent{
+		Event: EventBreached,
+		OrgID: t.OrgID,
+		Breach: Breach{
+			TicketID:   t.ID,
+			Title:      t.Title,
+			ProjectID:  t.ProjectID,
+			Priority:   t.Priority,
+			Type:       t.Type,
+			Kind:       kind,
+			BreachedAt: timer.BreachedAt.UTC(),
+		},
+		TargetMinutes: timer.TargetMinutes,
+	}
+}
+
+// notify posts each event to the webhook of its project's policy, if it has
+// one, webhookWorkers at a time and within webhookBudget, so slow receivers
+// can't hold up the job. Delivery is best effort: the breach is already
+// recorded and in the audit log, so a failed delivery is logged and not
+// retried.
+func (s *Service) notify(ctx context.Context, events []BreachEvent) {
+	if len(events) == 0 {
+		return
+	}
+	var projectIDs []string
+	for _, e := range events {
+		if !slices.Contains(projectIDs, e.ProjectID) {
+			projectIDs = append(projectIDs, e.ProjectID)
+		}
+	}
+	policies, err := s.repo.Policies(ctx, projectIDs)
+	if err != nil {
+		log.Printf("SLA webhooks: load policies: %v", err)
+		return
+	}
+	var posts []webhookPost
+	for _, e := range events {
+		if p := policies[e.ProjectID]; p != nil && p.WebhookURL != nil {
+			posts = append(posts, webhookPost{target: *p.WebhookURL, event: e})
+		}
+	}
+	s.deliverAll(ctx, posts)
+}
+
+// webhookPost is one event and the URL it goes to.
+type webhookPost struct {
+	target string
+	event  BreachEvent
+}
+
+// deliverAll runs the posts webhookWorkers at a time, giving up on those
+// still running after webhookBudget.
+func (s *Service) deliverAll(ctx context.Context, posts []webhookPost) {
+	ctx, cancel := context.WithTimeout(ctx, webhookBudget)
+	defer cancel()
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, webhookWorkers)
+	for _, p := range posts {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(p webhookPost) {
+			defer func() { <-slots; wg.Done() }()
+			if err := s.deliver(ctx, p.target, p.event); err != nil {
+				log.Printf("SLA webhook for ticket %s: %v", p.event.TicketID, err)
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+// deliver posts event to target, signed when a secret is configured.
+func (s *Service) deliver(ctx context.Context, target string, event BreachEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event.Event)
+	if s.opts.WebhookSecret != "" {
+		req.Header.Set("X-Webhook-Signature", Signature(s.opts.WebhookSecret, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Signature is the X-Webhook-Signature of body: "sha256=" and the hex
+// HMAC-SHA256 of it keyed with secret. Receivers recompute it to check a
+// delivery came from this server.
+func Signature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
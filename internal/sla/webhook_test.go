@@ -0,0 +1,108 @@
+package sla
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPublicAddr(t *testing.T) {
+	cases := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:4700::6810:84e5", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.9", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, tc := range cases {
+		if got := publicAddr(netip.MustParseAddr(tc.addr)); got != tc.public {
+			t.Errorf("publicAddr(%s) = %v, want %v", tc.addr, got, tc.public)
+		}
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits.Add(1) }))
+	defer srv.Close()
+	localhost := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	for _, target := range []string{srv.URL, localhost} {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, target, nil)
+		_, err := webhookClient(false).Do(req)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("POST %s: err = %v, want ErrBlockedAddress", target, err)
+		}
+	}
+	if n := hits.Load(); n != 0 {
+		t.Fatalf("the receiver got %d requests", n)
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, nil)
+	resp, err := webhookClient(true).Do(req)
+	if err != nil {
+		t.Fatalf("POST with private addresses allowed: %v", err)
+	}
+	resp.Body.Close()
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("the receiver got %d requests, want 1", n)
+	}
+}
+
+func TestWebhookClientDoesNotFollowRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, nil)
+	resp, err := webhookClient(true).Do(req)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("status = %d, want the redirect itself", resp.StatusCode)
+	}
+}
+
+func TestDeliverAllRunsInParallel(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	s := NewService(nil, nil, Options{AllowPrivateWebhooks: true})
+	posts := make([]webhookPost, 2*webhookWorkers)
+	for i := range posts {
+		posts[i] = webhookPost{target: srv.URL, event: BreachEvent{Event: EventBreached}}
+	}
+	start := time.Now()
+	s.deliverAll(context.Background(), posts)
+	// one after another they would take 16 × 200ms
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("deliveries took %s, want them %d at a time", elapsed, webhookWorkers)
+	}
+	if n := hits.Load(); int(n) != len(posts) {
+		t.Fatalf("the receiver got %d requests, want %d", n, len(posts))
+	}
+}
@@ -1,6 +1,7 @@
 package team
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -24,6 +25,16 @@ func NewHandler(service *Service) *Handler {
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/members", h.getMembers)
 	router.GET("/projects/:projectId/members", h.getProjectMembers)
+
+	router.GET("/teams", h.listTeams)
+	router.POST("/teams", h.createTeam)
+	router.GET("/teams/:teamId", h.getTeam)
+	router.PATCH("/teams/:teamId", h.updateTeam)
+	router.DELETE("/teams/:teamId", h.deleteTeam)
+	router.POST("/teams/:teamId/members", h.addTeamMember)
+	router.DELETE("/teams/:teamId/members/:userId", h.removeTeamMember)
+	router.PUT("/teams/:teamId/projects/:projectId", h.assignProject)
+	router.DELETE("/teams/:teamId/projects/:projectId", h.unassignProject)
 }
 
 func (h *Handler) getMembers(c *gin.Context) {
@@ -34,7 +45,7 @@ func (h *Handler) getMembers(c *gin.Context) {
 	}
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-	members, err := h.service.GetMembers(c.Request.Context(), user.ID, user.Role, limit)
+	members, err := h.service.GetMembers(c.Request.Context(), user.OrgID, user.ID, user.Role, limit)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -46,12 +57,17 @@ func (h *Handler) getMembers(c *gin.Context) {
 }
 
 func (h *Handler) getProjectMembers(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
 	projectID := c.Param("projectId")
 	if projectID == "" {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "projectId is required")
 		return
 	}
-	members, err := h.service.GetProjectMembers(c.Request.Context(), projectID)
+	members, err := h.service.GetProjectMembers(c.Request.Context(), user.OrgID, projectID)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -61,3 +77,158 @@ func (h *Handler) getProjectMembers(c *gin.Context) {
 	}
 	response.OK(c, members)
 }
+
+func (h *Handler) listTeams(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
+	teams, err := h.service.ListTeams(c.Request.Context(), user)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+	response.OK(c, teams)
+}
+
+func (h *Handler) getTeam(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
+	team, err := h.service.GetTeam(c.Request.Context(), user, c.Param("teamId"))
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+	response.OK(c, team)
+}
+
+func (h *Handler) createTeam(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
+	var payload CreateTeamInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	team, err := h.service.CreateTeam(c.Request.Context(), user, payload)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+	response.Created(c, team)
+}
+
+func (h *Handler) updateTeam(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
+	var payload UpdateTeamInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	team, err := h.service.UpdateTeam(c.Request.Context(), user, c.Param("teamId"), payload)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+	response.OK(c, team)
+}
+
+func (h *Handler) deleteTeam(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
+	if err := h.service.DeleteTeam(c.Request.Context(), user, c.Param("teamId")); err != nil {
+		writeTeamError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) addTeamMember(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
+	var payload struct {
+		UserID string `json:"userId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	team, err := h.service.AddTeamMember(c.Request.Context(), user, c.Param("teamId"), payload.UserID)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+	response.OK(c, team)
+}
+
+func (h *Handler) removeTeamMember(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
+	if err := h.service.RemoveTeamMember(c.Request.Context(), user, c.Param("teamId"), c.Param("userId")); err != nil {
+		writeTeamError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) assignProject(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
+	team, err := h.service.AssignProject(c.Request.Context(), user, c.Param("teamId"), c.Param("projectId"))
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+	response.OK(c, team)
+}
+
+func (h *Handler) unassignProject(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "login required")
+		return
+	}
+	if err := h.service.UnassignProject(c.Request.Context(), user, c.Param("teamId"), c.Param("projectId")); err != nil {
+		writeTeamError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeTeamError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		response.ErrorCode(c, http.StatusForbidden, "forbidden", "forbidden")
+	case errors.Is(err, ErrTeamNotFound), errors.Is(err, ErrProjectNotFound), errors.Is(err, ErrNotTeamMember):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrTeamNameTaken):
+		response.ErrorCode(c, http.StatusConflict, "team_name_taken", err.Error())
+	case errors.Is(err, ErrUnknownUser), errors.Is(err, ErrInvalidTeamName):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -24,3 +24,52 @@ type ProjectMember struct {
 	ProjectName string `json:"projectName"`
 	Role        string `json:"role"`
 }
+
+// Team is a named group of users with an optional lead, assignable to projects.
+// XP is the sum of the members' XP events earned while they were on the team.
+type Team struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	LeadID      *string       `json:"leadId"`
+	LeadName    *string       `json:"leadName,omitempty"`
+	MemberCount int           `json:"memberCount"`
+	XP          int           `json:"xp"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	UpdatedAt   time.Time     `json:"updatedAt"`
+	Members     []TeamMember  `json:"members,omitempty"`
+	Projects    []TeamProject `json:"projects,omitempty"`
+}
+
+// TeamMember is a user on a team with the XP they earned since joining it.
+type TeamMember struct {
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	Username  string    `json:"username"`
+	AvatarURL *string   `json:"avatarUrl,omitempty"`
+	IsLead    bool      `json:"isLead"`
+	XP        int       `json:"xp"`
+	JoinedAt  time.Time `json:"joinedAt"`
+}
+
+// TeamProject is a project a team is assigned to.
+type TeamProject struct {
+	ProjectID   string    `json:"projectId"`
+	ProjectName string    `json:"projectName"`
+	AssignedAt  time.Time `json:"assignedAt"`
+}
+
+// CreateTeamInput is the payload to create a team. The lead is added as a member.
+type CreateTeamInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	LeadID      *string  `json:"leadId"`
+	MemberIDs   []string `json:"memberIds"`
+}
+
+// UpdateTeamInput changes a team; an empty leadId removes the lead.
+type UpdateTeamInput struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	LeadID      *string `json:"leadId"`
+}
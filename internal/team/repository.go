@@ -2,7 +2,12 @@ package team
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,8 +21,8 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-// GetMembers returns all team members with their stats.
-func (r *Repository) GetMembers(ctx context.Context, limit int) ([]Member, error) {
+// GetMembers returns all members of an organization with their stats.
+func (r *Repository) GetMembers(ctx context.Context, orgID string, limit int) ([]Member, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 50
 	}
@@ -36,10 +41,11 @@ func (r *Repository) GetMembers(ctx context.Context, limit int) ([]Member, error
 			u.created_at
 		FROM users u
 		LEFT JOIN gamification_user_stats gs ON gs.user_id = u.id
+		WHERE u.deactivated_at IS NULL AND u.org_id = $1
 		ORDER BY u.name ASC
-		LIMIT $1`
+		LIMIT $2`
 
-	rows, err := r.db.Query(ctx, query, limit)
+	rows, err := r.db.Query(ctx, query, orgID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +78,7 @@ func (r *Repository) GetTeammates(ctx context.Context, userID string, limit int)
 			u.created_at
 		FROM users u
 		LEFT JOIN gamification_user_stats gs ON gs.user_id = u.id
-		WHERE EXISTS (
+		WHERE u.deactivated_at IS NULL AND EXISTS (
 			SELECT 1 
 			FROM project_members pm_me
 			JOIN project_members pm_them ON pm_them.project_id = pm_me.project_id
@@ -99,17 +105,17 @@ func (r *Repository) GetTeammates(ctx context.Context, userID string, limit int)
 	return members, rows.Err()
 }
 
-// GetProjectMembers returns members of a specific project.
-func (r *Repository) GetProjectMembers(ctx context.Context, projectID string) ([]ProjectMember, error) {
+// GetProjectMembers returns members of a project in orgID.
+func (r *Repository) GetProjectMembers(ctx context.Context, orgID, projectID string) ([]ProjectMember, error) {
 	const query = `
-		SELECT pm.user_id, u.name, pm.project_id, p.name, pm.role
+		SELECT pm.user_id, u.name, pm.project_id, p.name, pm.member_role
 		FROM project_members pm
 		JOIN users u ON u.id = pm.user_id
 		JOIN projects p ON p.id = pm.project_id
-		WHERE pm.project_id = $1
+		WHERE pm.project_id = $1 AND p.org_id = $2
 		ORDER BY u.name ASC`
 
-	rows, err := r.db.Query(ctx, query, projectID)
+	rows, err := r.db.Query(ctx, query, projectID, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -125,3 +131,252 @@ func (r *Repository) GetProjectMembers(ctx context.Context, projectID string) ([
 	}
 	return members, rows.Err()
 }
+
+// teamXP sums the XP events of a team's members earned while on the team.
+const teamXP = `COALESCE((
+	SELECT SUM(x.xp_value)
+	FROM team_members tm
+	JOIN xp_events x ON x.user_id = tm.user_id AND x.created_at >= tm.joined_at
+	WHERE tm.team_id = t.id), 0)::int`
+
+const teamQuery = `
+SELECT t.id, t.name, COALESCE(t.description, ''), t.lead_id, l.name,
+       (SELECT COUNT(*) FROM team_members tm WHERE tm.team_id = t.id)::int,
+       ` + teamXP + `,
+       t.created_at, t.updated_at
+FROM teams t
+LEFT JOIN users l ON l.id = t.lead_id
+WHERE t.org_id = $1`
+
+func scanTeam(row pgx.Row) (*Team, error) {
+	var t Team
+	if err := row.Scan(&t.ID, &t.Name, &t.Description, &t.LeadID, &t.LeadName, &t.MemberCount, &t.XP, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTeams returns the teams of an organization by name.
+func (r *Repository) ListTeams(ctx context.Context, orgID string) ([]Team, error) {
+	rows, err := r.db.Query(ctx, teamQuery+` ORDER BY t.name`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []Team{}
+	for rows.Next() {
+		t, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, *t)
+	}
+	return teams, rows.Err()
+}
+
+// GetTeam returns a team of orgID with its members and projects.
+func (r *Repository) GetTeam(ctx context.Context, orgID, id string) (*Team, error) {
+	t, err := scanTeam(r.db.QueryRow(ctx, teamQuery+` AND t.id = $2`, orgID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Members, err = r.teamMembers(ctx, t.ID); err != nil {
+		return nil, err
+	}
+	if t.Projects, err = r.teamProjects(ctx, t.ID); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *Repository) teamMembers(ctx context.Context, teamID string) ([]TeamMember, error) {
+	const query = `
+SELECT u.id, u.name, u.username, u.avatar_url, t.lead_id IS NOT DISTINCT FROM u.id,
+       COALESCE((SELECT SUM(x.xp_value) FROM xp_events x WHERE x.user_id = u.id AND x.created_at >= tm.joined_at), 0)::int,
+       tm.joined_at
+FROM team_members tm
+JOIN teams t ON t.id = tm.team_id
+JOIN users u ON u.id = tm.user_id
+WHERE tm.team_id = $1
+ORDER BY u.name`
+	rows, err := r.db.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []TeamMember{}
+	for rows.Next() {
+		var m TeamMember
+		if err := rows.Scan(&m.UserID, &m.Name, &m.Username, &m.AvatarURL, &m.IsLead, &m.XP, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (r *Repository) teamProjects(ctx context.Context, teamID string) ([]TeamProject, error) {
+	const query = `
+SELECT p.id, p.name, pt.assigned_at
+FROM project_teams pt
+JOIN projects p ON p.id = pt.project_id
+WHERE pt.team_id = $1
+ORDER BY p.name`
+	rows, err := r.db.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []TeamProject{}
+	for rows.Next() {
+		var p TeamProject
+		if err := rows.Scan(&p.ProjectID, &p.ProjectName, &p.AssignedAt); err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+var errTeamNameTaken = errors.New("team name already in use")
+
+// CreateTeam inserts a team with its members; memberIDs must include the lead.
+func (r *Repository) CreateTeam(ctx context.Context, orgID string, input CreateTeamInput, memberIDs []string) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var id string
+	err = tx.QueryRow(ctx, `
+INSERT INTO teams (org_id, name, description, lead_id)
+VALUES ($1, $2, NULLIF($3, ''), $4)
+RETURNING id`, orgID, input.Name, input.Description, input.LeadID).Scan(&id)
+	if err != nil {
+		return "", nameTaken(err)
+	}
+	if len(memberIDs) > 0 {
+		if _, err := tx.Exec(ctx, `INSERT INTO team_members (team_id, user_id) SELECT $1, unnest($2::uuid[])`, id, memberIDs); err != nil {
+			return "", err
+		}
+	}
+	return id, tx.Commit(ctx)
+}
+
+// UpdateTeam applies the set fields of input. A new lead joins the team if
+// they are not a member yet.
+func (r *Repository) UpdateTeam(ctx context.Context, id string, input UpdateTeamInput) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	setParts := []string{"updated_at = NOW()"}
+	args := []any{id}
+	idx := 2
+	if input.Name != nil {
+		setParts = append(setParts, fmt.Sprintf("name = $%d", idx))
+		args = append(args, *input.Name)
+		idx++
+	}
+	if input.Description != nil {
+		setParts = append(setParts, fmt.Sprintf("description = NULLIF($%d, '')", idx))
+		args = append(args, *input.Description)
+		idx++
+	}
+	if input.LeadID != nil {
+		setParts = append(setParts, fmt.Sprintf("lead_id = NULLIF($%d, '')::uuid", idx))
+		args = append(args, *input.LeadID)
+	}
+	query := `UPDATE teams SET ` + strings.Join(setParts, ", ") + ` WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return nameTaken(err)
+	}
+	if input.LeadID != nil && *input.LeadID != "" {
+		if _, err := tx.Exec(ctx, `INSERT INTO team_members (team_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, id, *input.LeadID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func nameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return errTeamNameTaken
+	}
+	return err
+}
+
+// DeleteTeam removes a team of orgID; memberships and project assignments go with it.
+func (r *Repository) DeleteTeam(ctx context.Context, orgID, id string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM teams WHERE id = $1 AND org_id = $2`, id, orgID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// AddTeamMember adds a user to a team and reports whether they were new to it.
+func (r *Repository) AddTeamMember(ctx context.Context, teamID, userID string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `INSERT INTO team_members (team_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, teamID, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RemoveTeamMember takes a user off a team, clearing the lead if it was them.
+func (r *Repository) RemoveTeamMember(ctx context.Context, teamID, userID string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`, teamID, userID)
+	if err != nil || tag.RowsAffected() == 0 {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE teams SET lead_id = NULL, updated_at = NOW() WHERE id = $1 AND lead_id = $2`, teamID, userID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// AssignProject links a team to a project.
+func (r *Repository) AssignProject(ctx context.Context, teamID, projectID string) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO project_teams (project_id, team_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, projectID, teamID)
+	return err
+}
+
+// UnassignProject removes a team from a project and reports whether it was assigned.
+func (r *Repository) UnassignProject(ctx context.Context, teamID, projectID string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM project_teams WHERE project_id = $1 AND team_id = $2`, projectID, teamID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountOrgUsers counts how many of ids are active users of orgID.
+func (r *Repository) CountOrgUsers(ctx context.Context, orgID string, ids []string) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE id = ANY($1::uuid[]) AND org_id = $2 AND deactivated_at IS NULL`, ids, orgID).Scan(&n)
+	return n, err
+}
+
+// ProjectInOrg reports whether projectID belongs to orgID.
+func (r *Repository) ProjectInOrg(ctx context.Context, orgID, projectID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND org_id = $2)`, projectID, orgID).Scan(&ok)
+	return ok, err
+}
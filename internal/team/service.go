@@ -1,28 +1,304 @@
 package team
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"backend-go-ticketing-gamify/internal/audit"
+	"backend-go-ticketing-gamify/internal/middleware"
+)
+
+var (
+	ErrForbidden       = errors.New("forbidden")
+	ErrTeamNotFound    = errors.New("team not found")
+	ErrTeamNameTaken   = errTeamNameTaken
+	ErrUnknownUser     = errors.New("user not found in this organization")
+	ErrProjectNotFound = errors.New("project not found")
+	ErrNotTeamMember   = errors.New("user is not a member of this team")
+	ErrInvalidTeamName = errors.New("name must be between 2 and 80 characters")
+)
 
 // Service provides business logic for team.
 type Service struct {
-	repo *Repository
+	repo  *Repository
+	audit *audit.Service
 }
 
 // NewService creates a new team service.
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *Repository, auditSvc *audit.Service) *Service {
+	return &Service{repo: repo, audit: auditSvc}
 }
 
 // GetMembers returns all team members, filtered by scope if necessary.
-func (s *Service) GetMembers(ctx context.Context, userID, role string, limit int) ([]Member, error) {
-	// Admin and Project Manager can see everyone
-	if role == "admin" || role == "project_manager" {
-		return s.repo.GetMembers(ctx, limit)
+func (s *Service) GetMembers(ctx context.Context, orgID, userID, role string, limit int) ([]Member, error) {
+	// Admin and Project Manager can see everyone in the organization
+	if isManager(role) {
+		return s.repo.GetMembers(ctx, orgID, limit)
 	}
 	// Developers (and others) see only teammates
 	return s.repo.GetTeammates(ctx, userID, limit)
 }
 
 // GetProjectMembers returns members of a specific project.
-func (s *Service) GetProjectMembers(ctx context.Context, projectID string) ([]ProjectMember, error) {
-	return s.repo.GetProjectMembers(ctx, projectID)
+func (s *Service) GetProjectMembers(ctx context.Context, orgID, projectID string) ([]ProjectMember, error) {
+	return s.repo.GetProjectMembers(ctx, orgID, projectID)
+}
+
+// ListTeams returns the teams of the actor's organization.
+func (s *Service) ListTeams(ctx context.Context, actor *middleware.UserContext) ([]Team, error) {
+	return s.repo.ListTeams(ctx, actor.OrgID)
+}
+
+// GetTeam returns a team with its members and projects.
+func (s *Service) GetTeam(ctx context.Context, actor *middleware.UserContext, id string) (*Team, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrTeamNotFound
+	}
+	team, err := s.repo.GetTeam(ctx, actor.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// CreateTeam creates a team. Only admins and project managers manage teams.
+func (s *Service) CreateTeam(ctx context.Context, actor *middleware.UserContext, input CreateTeamInput) (*Team, error) {
+	if !isManager(actor.Role) {
+		return nil, ErrForbidden
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if err := validateTeamName(input.Name); err != nil {
+		return nil, err
+	}
+	input.Description = strings.TrimSpace(input.Description)
+	if input.LeadID != nil && *input.LeadID == "" {
+		input.LeadID = nil
+	}
+
+	seen := map[string]bool{}
+	members := []string{}
+	if input.LeadID != nil {
+		seen[*input.LeadID] = true
+		members = append(members, *input.LeadID)
+	}
+	for _, id := range input.MemberIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			members = append(members, id)
+		}
+	}
+	if err := s.requireOrgUsers(ctx, actor.OrgID, members); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.CreateTeam(ctx, actor.OrgID, input, members)
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx, actor.ID, "team_created", fmt.Sprintf("created team %s", input.Name), id)
+	return s.GetTeam(ctx, actor, id)
+}
+
+// UpdateTeam renames a team, changes its description or its lead.
+func (s *Service) UpdateTeam(ctx context.Context, actor *middleware.UserContext, id string, input UpdateTeamInput) (*Team, error) {
+	if !isManager(actor.Role) {
+		return nil, ErrForbidden
+	}
+	team, err := s.GetTeam(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if err := validateTeamName(name); err != nil {
+			return nil, err
+		}
+		input.Name = &name
+	}
+	if input.Description != nil {
+		desc := strings.TrimSpace(*input.Description)
+		input.Description = &desc
+	}
+	if input.LeadID != nil && *input.LeadID != "" {
+		if err := s.requireOrgUsers(ctx, actor.OrgID, []string{*input.LeadID}); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.UpdateTeam(ctx, team.ID, input); err != nil {
+		return nil, err
+	}
+	s.log(ctx, actor.ID, "team_updated", fmt.Sprintf("updated team %s", team.Name), team.ID)
+	return s.GetTeam(ctx, actor, team.ID)
+}
+
+// DeleteTeam removes a team. Its members keep the XP they earned.
+func (s *Service) DeleteTeam(ctx context.Context, actor *middleware.UserContext, id string) error {
+	if !isManager(actor.Role) {
+		return ErrForbidden
+	}
+	team, err := s.GetTeam(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.repo.DeleteTeam(ctx, actor.OrgID, team.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrTeamNotFound
+	}
+	s.log(ctx, actor.ID, "team_deleted", fmt.Sprintf("deleted team %s", team.Name), team.ID)
+	return nil
+}
+
+// AddTeamMember puts a user on a team. The team lead may manage members too.
+func (s *Service) AddTeamMember(ctx context.Context, actor *middleware.UserContext, teamID, userID string) (*Team, error) {
+	team, err := s.manageableTeam(ctx, actor, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireOrgUsers(ctx, actor.OrgID, []string{userID}); err != nil {
+		return nil, err
+	}
+	added, err := s.repo.AddTeamMember(ctx, team.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if added {
+		s.log(ctx, actor.ID, "team_member_added", fmt.Sprintf("added a member to team %s", team.Name), team.ID)
+	}
+	return s.GetTeam(ctx, actor, team.ID)
+}
+
+// RemoveTeamMember takes a user off a team; removing the lead leaves the team without one.
+func (s *Service) RemoveTeamMember(ctx context.Context, actor *middleware.UserContext, teamID, userID string) error {
+	team, err := s.manageableTeam(ctx, actor, teamID)
+	if err != nil {
+		return err
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrNotTeamMember
+	}
+	removed, err := s.repo.RemoveTeamMember(ctx, team.ID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotTeamMember
+	}
+	s.log(ctx, actor.ID, "team_member_removed", fmt.Sprintf("removed a member from team %s", team.Name), team.ID)
+	return nil
+}
+
+// AssignProject makes a team responsible for a project.
+func (s *Service) AssignProject(ctx context.Context, actor *middleware.UserContext, teamID, projectID string) (*Team, error) {
+	if !isManager(actor.Role) {
+		return nil, ErrForbidden
+	}
+	team, err := s.GetTeam(ctx, actor, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireProject(ctx, actor.OrgID, projectID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.AssignProject(ctx, team.ID, projectID); err != nil {
+		return nil, err
+	}
+	s.log(ctx, actor.ID, "team_project_assigned", fmt.Sprintf("assigned team %s to a project", team.Name), team.ID)
+	return s.GetTeam(ctx, actor, team.ID)
+}
+
+// UnassignProject removes a team from a project.
+func (s *Service) UnassignProject(ctx context.Context, actor *middleware.UserContext, teamID, projectID string) error {
+	if !isManager(actor.Role) {
+		return ErrForbidden
+	}
+	team, err := s.GetTeam(ctx, actor, teamID)
+	if err != nil {
+		return err
+	}
+	if _, err := uuid.Parse(projectID); err != nil {
+		return ErrProjectNotFound
+	}
+	removed, err := s.repo.UnassignProject(ctx, team.ID, projectID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrProjectNotFound
+	}
+	s.log(ctx, actor.ID, "team_project_unassigned", fmt.Sprintf("removed team %s from a project", team.Name), team.ID)
+	return nil
+}
+
+// manageableTeam loads a team whose membership the actor may change.
+func (s *Service) manageableTeam(ctx context.Context, actor *middleware.UserContext, teamID string) (*Team, error) {
+	team, err := s.GetTeam(ctx, actor, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if !isManager(actor.Role) && (team.LeadID == nil || *team.LeadID != actor.ID) {
+		return nil, ErrForbidden
+	}
+	return team, nil
+}
+
+func (s *Service) requireOrgUsers(ctx context.Context, orgID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return ErrUnknownUser
+		}
+	}
+	n, err := s.repo.CountOrgUsers(ctx, orgID, ids)
+	if err != nil {
+		return err
+	}
+	if n != len(ids) {
+		return ErrUnknownUser
+	}
+	return nil
+}
+
+func (s *Service) requireProject(ctx context.Context, orgID, projectID string) error {
+	if _, err := uuid.Parse(projectID); err != nil {
+		return ErrProjectNotFound
+	}
+	ok, err := s.repo.ProjectInOrg(ctx, orgID, projectID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrProjectNotFound
+	}
+	return nil
+}
+
+func (s *Service) log(ctx context.Context, actorID, action, desc, teamID string) {
+	if s.audit == nil {
+		return
+	}
+	entityType := "team"
+	_ = s.audit.Log(ctx, action, desc, &actorID, &entityType, &teamID)
+}
+
+func isManager(role string) bool {
+	return role == "admin" || role == "project_manager"
+}
+
+func validateTeamName(name string) error {
+	if len(name) < 2 || len(name) > 80 {
+		return ErrInvalidTeamName
+	}
+	return nil
 }
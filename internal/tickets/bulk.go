@@ -0,0 +1,359 @@
+package tickets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"backend-go-ticketing-gamify/internal/gamification"
+	"backend-go-ticketing-gamify/internal/middleware"
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxBulk caps how many tickets one bulk change may touch.
+const MaxBulk = 500
+
+var (
+	// ErrBulkInput wraps what is wrong with a bulk request as a whole.
+	ErrBulkInput = errors.New("invalid bulk request")
+	// ErrBulkRejected is returned with the report when an all-or-nothing
+	// change can't be applied to every ticket; nothing is changed then.
+	ErrBulkRejected = errors.New("some tickets can't take the change, nothing was changed")
+)
+
+func (in BulkInput) check() error {
+	switch {
+	case len(in.IDs) > 0 && in.Filter != nil:
+		return fmt.Errorf("%w: give ids or a filter, not both", ErrBulkInput)
+	case len(in.IDs) == 0 && in.Filter == nil:
+		return fmt.Errorf("%w: ids or a filter is required", ErrBulkInput)
+	case len(in.IDs) > MaxBulk:
+		return fmt.Errorf("%w: at most %d ids", ErrBulkInput, MaxBulk)
+	case in.Filter != nil && *in.Filter == BulkFilter{}:
+		return fmt.Errorf("%w: the filter needs at least one criterion", ErrBulkInput)
+	case in.Delete && in.Set != nil:
+		return fmt.Errorf("%w: give set or delete, not both", ErrBulkInput)
+	case !in.Delete && (in.Set == nil || in.Set.empty()):
+		return fmt.Errorf("%w: set a field or delete", ErrBulkInput)
+	}
+	return nil
+}
+
+func (s *BulkSet) empty() bool {
+	return s.Status == nil && s.AssigneeID == nil && s.Priority == nil && s.EpicID == nil &&
+		len(s.AddLabels) == 0 && len(s.RemoveLabels) == 0
+}
+
+// bulkChange is one ticket of a bulk change, before and after.
+type bulkChange struct {
+	before  Ticket
+	after   Ticket
+	history []string
+}
+
+// Bulk applies a status, assignee, priority, epic or label change, or a
+// delete, to many tickets in one transaction. Each ticket goes through the
+// same permission check as a single update and gets the same history, audit
+// and XP side effects; tickets that fail it are reported and skipped, or
+// reject the whole change with AllOrNothing.
+func (s *Service) Bulk(ctx context.Context, actor *middleware.UserContext, input BulkInput) (*BulkReport, error) {
+	if err := input.check(); err != nil {
+		return nil, err
+	}
+	set := input.Set
+	var epicProject, epicTitle, assigneeName string
+	if set != nil {
+		if err := s.checkAssignee(ctx, actor.OrgID, set.AssigneeID); err != nil {
+			return nil, err
+		}
+		if set.AssigneeID != nil && *set.AssigneeID != "" {
+			name, err := s.repo.UserName(ctx, *set.AssigneeID)
+			if err != nil {
+				return nil, err
+			}
+			assigneeName = name
+		}
+		if set.EpicID != nil && *set.EpicID != "" {
+			var err error
+			epicProject, epicTitle, err = s.repo.EpicInfo(ctx, *set.EpicID)
+			if err != nil {
+				return nil, err
+			}
+			if epicProject == "" {
+				return nil, fmt.Errorf("%w: epic not found", ErrBulkInput)
+			}
+		}
+	}
+
+	targets, order, err := s.bulkTargets(ctx, actor.OrgID, input)
+	if err != nil {
+		return nil, err
+	}
+	report := &BulkReport{Results: []BulkResult{}}
+	var changes []bulkChange
+	for _, id := range order {
+		result := BulkResult{ID: id}
+		t, ok := targets[id]
+		switch {
+		case !ok:
+			result.Result, result.Error = "not_found", "ticket not found"
+		case !canModify(actor, &t):
+			result.Result, result.Error = "forbidden", "forbidden"
+		case epicProject != "" && epicProject != t.ProjectID:
+			result.Result, result.Error = "invalid", "epic must belong to the same project"
+		case input.Delete:
+			result.Result = "deleted"
+			changes = append(changes, bulkChange{before: t})
+		default:
+			after, history := applyBulkSet(t, set, assigneeName, epicTitle)
+			if len(after.Labels) > maxLabels {
+				result.Result, result.Error = "invalid", errLabelLimit.Error()
+				break
+			}
+			if len(history) == 0 {
+				result.Result = "unchanged"
+				break
+			}
+			result.Result = "updated"
+			changes = append(changes, bulkChange{before: t, after: after, history: history})
+		}
+		switch result.Result {
+		case "deleted":
+			report.Deleted++
+		case "updated":
+			report.Updated++
+		case "unchanged":
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+		if ok {
+			report.Matched++
+		}
+		report.Results = append(report.Results, result)
+	}
+	if input.AllOrNothing && report.Failed > 0 {
+		return report, ErrBulkRejected
+	}
+	if len(changes) == 0 {
+		return report, nil
+	}
+
+	closers := map[string]bool{}
+	err = s.repo.InTx(ctx, func(tx pgx.Tx) error {
+		epics := map[string]bool{}
+		for _, c := range changes {
+			if c.before.EpicID != nil {
+				epics[*c.before.EpicID] = true
+			}
+			if input.Delete {
+				if err := s.repo.DeleteTx(ctx, tx, c.before.ID); err != nil {
+					return err
+				}
+				continue
+			}
+			if c.after.EpicID != nil {
+				epics[*c.after.EpicID] = true
+			}
+			if err := s.repo.SaveBulk(ctx, tx, &c.after, actor.ID, c.history); err != nil {
+				return err
+			}
+			userID, err := s.bulkXP(ctx, tx, actor, c.before, c.after)
+			if err != nil {
+				return err
+			}
+			if err := s.settleOnTimeBonus(ctx, tx, c.before, c.after, userID); err != nil {
+				return err
+			}
+			if userID != "" {
+				closers[userID] = true
+			}
+		}
+		for epicID := range epics {
+			if err := s.repo.UpdateEpicStatusTx(ctx, tx, epicID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logBulk(ctx, actor, changes, input.Delete)
+	for userID := range closers {
+		_ = s.gamification.RefreshClosedCount(ctx, userID)
+	}
+	return report, nil
+}
+
+// bulkTargets loads the tickets a bulk change names, by id, and the order
+// to report them in.
+func (s *Service) bulkTargets(ctx context.Context, orgID string, input BulkInput) (map[string]Ticket, []string, error) {
+	var (
+		found []Ticket
+		order []string
+		err   error
+	)
+	if input.Filter != nil {
+		f := input.Filter
+		found, err = s.repo.Match(ctx, Filter{
+			OrgID:      orgID,
+			ProjectID:  f.ProjectID,
+			AssigneeID: f.AssigneeID,
+			Status:     f.Status,
+			EpicID:     f.EpicID,
+			Label:      f.Label,
+			Search:     f.Search,
+		}, MaxBulk+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(found) > MaxBulk {
+			return nil, nil, fmt.Errorf("%w: the filter matches more than %d tickets; narrow it down", ErrBulkInput, MaxBulk)
+		}
+		for _, t := range found {
+			order = append(order, t.ID)
+		}
+	} else {
+		seen := map[string]bool{}
+		for _, id := range input.IDs {
+			if !seen[id] {
+				seen[id] = true
+				order = append(order, id)
+			}
+		}
+		found, err = s.repo.GetMany(ctx, orgID, order)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	targets := make(map[string]Ticket, len(found))
+	for _, t := range found {
+		targets[t.ID] = t
+	}
+	return targets, order, nil
+}
+
+// applyBulkSet returns t with set applied and a history line per change.
+func applyBulkSet(t Ticket, set *BulkSet, assigneeName, epicTitle string) (Ticket, []string) {
+	after := t
+	var history []string
+	if set.Status != nil && *set.Status != t.Status {
+		after.Status = *set.Status
+		history = append(history, fmt.Sprintf("Status changed to %s", after.Status))
+	}
+	if set.AssigneeID != nil && *set.AssigneeID != deref(t.AssigneeID) {
+		if *set.AssigneeID == "" {
+			after.AssigneeID = nil
+			history = append(history, "Unassigned")
+		} else {
+			id := *set.AssigneeID
+			after.AssigneeID = &id
+			history = append(history, fmt.Sprintf("Assigned to %s", assigneeName))
+		}
+	}
+	if set.Priority != nil && *set.Priority != t.Priority {
+		after.Priority = *set.Priority
+		history = append(history, fmt.Sprintf("Priority changed to %s", after.Priority))
+	}
+	if set.EpicID != nil && *set.EpicID != deref(t.EpicID) {
+		if *set.EpicID == "" {
+			after.EpicID = nil
+			history = append(history, "Removed from epic")
+		} else {
+			id := *set.EpicID
+			after.EpicID = &id
+			history = append(history, fmt.Sprintf("Moved to epic %s", epicTitle))
+		}
+	}
+
+	labels := slices.Clone(t.Labels)
+	var added, removed []string
+	for _, l := range set.AddLabels {
+		if !slices.Contains(labels, l) {
+			labels = append(labels, l)
+			added = append(added, l)
+		}
+	}
+	for _, l := range set.RemoveLabels {
+		if i := slices.Index(labels, l); i >= 0 {
+			labels = slices.Delete(labels, i, i+1)
+			removed = append(removed, l)
+		}
+	}
+	after.Labels = labels
+	if len(added) > 0 {
+		history = append(history, "Labels added: "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		history = append(history, "Labels removed: "+strings.Join(removed, ", "))
+	}
+	return after, history
+}
+
+// bulkXP awards XP when a change completes a ticket and takes it back when
+// it reopens one, like UpdateStatus. It returns whose closed count changed.
+func (s *Service) bulkXP(ctx context.Context, tx pgx.Tx, actor *middleware.UserContext, before, after Ticket) (string, error) {
+	wasDone, isDone := before.Status == "done", after.Status == "done"
+	if wasDone == isDone {
+		return "", nil
+	}
+	// completion XP goes to the new assignee; a reopen takes it from the one
+	// who earned it
+	owner := after
+	input := gamification.AdjustInput{
+		XP:          XPFor(after.Priority),
+		Note:        fmt.Sprintf("ticket %s completed", after.Title),
+		ClosedDelta: 1,
+	}
+	if wasDone {
+		owner = before
+		input.XP = -XPFor(before.Priority)
+		input.Note = fmt.Sprintf("ticket %s reopened", before.Title)
+		input.ClosedDelta = -1
+	}
+	input.UserID = actor.ID
+	if owner.AssigneeID != nil && *owner.AssigneeID != "" {
+		input.UserID = *owner.AssigneeID
+	}
+	input.TicketID, input.Priority = owner.ID, owner.Priority
+	return input.UserID, s.gamification.AdjustXPTx(ctx, tx, input)
+}
+
+// logBulk writes the audit entries of each ticket and one activity entry per
+// project.
+func (s *Service) logBulk(ctx context.Context, actor *middleware.UserContext, changes []bulkChange, deleted bool) {
+	actorID := actor.ID
+	entityType := "ticket"
+	perProject := map[string]int{}
+	for _, c := range changes {
+		entityID := c.before.ID
+		perProject[c.before.ProjectID]++
+		if s.audit == nil {
+			continue
+		}
+		if deleted {
+			desc := fmt.Sprintf("%s menghapus tiket %s", actor.Name, c.before.ID)
+			_ = s.audit.Log(ctx, "ticket_deleted", desc, &actorID, &entityType, &entityID)
+			continue
+		}
+		if c.after.Status != c.before.Status {
+			desc := fmt.Sprintf("%s memindahkan tiket %s ke %s", actor.Name, c.after.Title, formatStatusLabel(c.after.Status))
+			_ = s.audit.Log(ctx, "ticket_status", desc, &actorID, &entityType, &entityID)
+		}
+		if len(c.history) > 1 || c.after.Status == c.before.Status {
+			desc := fmt.Sprintf("%s memperbarui tiket %s", actor.Name, c.after.Title)
+			_ = s.audit.Log(ctx, "ticket_updated", desc, &actorID, &entityType, &entityID)
+		}
+	}
+	for projectID, n := range perProject {
+		desc := fmt.Sprintf("%s memperbarui %d tiket sekaligus", actor.Name, n)
+		if deleted {
+			desc = fmt.Sprintf("%s menghapus %d tiket sekaligus", actor.Name, n)
+		}
+		s.repo.AddProjectActivity(ctx, projectID, &actorID, desc)
+	}
+}
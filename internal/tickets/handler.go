@@ -2,6 +2,7 @@ package tickets
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"backend-go-ticketing-gamify/internal/export"
 	"backend-go-ticketing-gamify/internal/middleware"
 	"backend-go-ticketing-gamify/internal/response"
 )
@@ -24,22 +26,24 @@ func NewHandler(service *Service) *Handler {
 
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("", h.list)
+	router.GET("/export", h.export)
 	router.POST("", h.create)
+	router.POST("/bulk", h.bulk)
 	router.GET("/:id", h.get)
 	router.PATCH("/:id/status", h.updateStatus)
 	router.PATCH("/:id/details", h.updateDetails)
 	router.PATCH("/:id/epic", h.updateEpic)
 	router.POST("/:id/comments", h.addComment)
+	router.GET("/:id/watchers", h.listWatchers)
+	router.POST("/:id/watchers", h.watch)
+	router.DELETE("/:id/watchers/:userId", h.unwatch)
 	router.PATCH("/comments/:commentId", h.updateComment)
 	router.DELETE("/comments/:commentId", h.deleteComment)
 	router.DELETE("/:id", h.delete)
 }
 
-func (h *Handler) list(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-	if limit <= 0 || limit > 200 {
-		limit = 50
-	}
+// listFilter reads the list filters shared by list and export.
+func listFilter(c *gin.Context, orgID string) Filter {
 	projectID := c.Query("projectId")
 	if strings.EqualFold(projectID, "all") {
 		projectID = ""
@@ -50,15 +54,30 @@ func (h *Handler) list(c *gin.Context) {
 			cursorPtr = &ts
 		}
 	}
-	filter := Filter{
+	return Filter{
+		OrgID:      orgID,
 		ProjectID:  projectID,
 		AssigneeID: c.Query("assigneeId"),
 		Status:     c.Query("status"),
 		EpicID:     c.Query("epicId"),
+		Label:      c.Query("label"),
 		Search:     c.Query("q"),
 		Cursor:     cursorPtr,
-		Limit:      limit,
 	}
+}
+
+func (h *Handler) list(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	filter := listFilter(c, user.OrgID)
+	filter.Limit = limit
 	tickets, err := h.service.List(c.Request.Context(), filter)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
@@ -73,6 +92,52 @@ func (h *Handler) list(c *gin.Context) {
 	response.WithMeta(c, http.StatusOK, tickets, meta)
 }
 
+var exportColumns = []export.Column{
+	{Key: "id", Title: "ID"},
+	{Key: "projectId", Title: "Project ID"},
+	{Key: "title", Title: "Title"},
+	{Key: "description", Title: "Description"},
+	{Key: "status", Title: "Status"},
+	{Key: "priority", Title: "Priority"},
+	{Key: "type", Title: "Type"},
+	{Key: "labels", Title: "Labels"},
+	{Key: "reporterId", Title: "Reporter ID"},
+	{Key: "epicId", Title: "Epic ID"},
+	{Key: "assigneeId", Title: "Assignee ID"},
+	{Key: "assigneeName", Title: "Assignee"},
+	{Key: "startDate", Title: "Start date"},
+	{Key: "dueDate", Title: "Due date"},
+	{Key: "createdAt", Title: "Created at"},
+	{Key: "updatedAt", Title: "Updated at"},
+}
+
+// export streams every ticket matching the list filters as CSV, XLSX or NDJSON.
+func (h *Handler) export(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	w, err := export.Start(c, format, "tickets", exportColumns)
+	if err == nil {
+		err = h.service.Stream(c.Request.Context(), listFilter(c, user.OrgID), func(t Ticket) error {
+			return w.Row(t.ID, t.ProjectID, t.Title, t.Description, t.Status, t.Priority, t.Type, strings.Join(t.Labels, ", "), t.ReporterID,
+				t.EpicID, t.AssigneeID, t.AssigneeName, t.StartDate, t.DueDate, t.CreatedAt, t.UpdatedAt)
+		})
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		export.Fail(c, "tickets", err)
+	}
+}
+
 func (h *Handler) create(c *gin.Context) {
 	user := middleware.CurrentUser(c)
 	if user == nil {
@@ -88,6 +153,12 @@ func (h *Handler) create(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
+	labels, err := normalizeLabels(payload.Labels)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	payload.Labels = labels
 	payload.ReporterID = user.ID
 	ticket, err := h.service.Create(c.Request.Context(), user, payload)
 	if err != nil {
@@ -95,14 +166,70 @@ func (h *Handler) create(c *gin.Context) {
 			response.ErrorCode(c, http.StatusBadRequest, "validation_error", "epic must belong to the same project")
 			return
 		}
+		if errors.Is(err, ErrProjectNotFound) || errors.Is(err, ErrUnknownAssignee) {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	response.Created(c, ticket)
 }
 
+// bulk applies one change to many tickets and reports the outcome per ticket.
+func (h *Handler) bulk(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload BulkInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if set := payload.Set; set != nil {
+		if err := validateTicketEnums(deref(set.Status), deref(set.Priority), ""); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+		var err error
+		if set.AddLabels, err = normalizeLabels(set.AddLabels); err == nil {
+			set.RemoveLabels, err = normalizeLabels(set.RemoveLabels)
+		}
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+	}
+	if f := payload.Filter; f != nil {
+		if err := validateTicketEnums(f.Status, "", ""); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+	}
+	report, err := h.service.Bulk(c.Request.Context(), user, payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrBulkRejected):
+			response.ErrorCodeDetails(c, http.StatusUnprocessableEntity, "bulk_rejected", err.Error(), report)
+		case errors.Is(err, ErrBulkInput), errors.Is(err, ErrUnknownAssignee):
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		default:
+			response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+		}
+		return
+	}
+	response.OK(c, report)
+}
+
 func (h *Handler) get(c *gin.Context) {
-	ticket, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	ticket, err := h.service.Get(c.Request.Context(), user.OrgID, c.Param("id"))
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -160,6 +287,14 @@ func (h *Handler) updateDetails(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
+	if payload.Labels != nil {
+		labels, err := normalizeLabels(payload.Labels)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+		payload.Labels = labels
+	}
 	if payload.EpicID != nil && *payload.EpicID == "" {
 		payload.EpicID = nil
 	}
@@ -169,6 +304,10 @@ func (h *Handler) updateDetails(c *gin.Context) {
 			response.ErrorCode(c, http.StatusForbidden, "forbidden", "forbidden")
 			return
 		}
+		if errors.Is(err, ErrUnknownAssignee) {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
 		if errors.Is(err, ErrEpicProjectMismatch) {
 			response.ErrorCode(c, http.StatusBadRequest, "validation_error", "epic must belong to the same project")
 			return
@@ -229,6 +368,10 @@ func (h *Handler) addComment(c *gin.Context) {
 	}
 	comment, err := h.service.AddComment(c.Request.Context(), user, c.Param("id"), payload.Text)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "not_found", "ticket not found")
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
@@ -300,6 +443,67 @@ func (h *Handler) delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+func (h *Handler) listWatchers(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	watchers, err := h.service.Watchers(c.Request.Context(), user, c.Param("id"))
+	if err != nil {
+		writeWatcherError(c, err)
+		return
+	}
+	response.OK(c, watchers)
+}
+
+func (h *Handler) watch(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload WatchInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+	}
+	watchers, err := h.service.Watch(c.Request.Context(), user, c.Param("id"), payload.UserID)
+	if err != nil {
+		writeWatcherError(c, err)
+		return
+	}
+	response.OK(c, watchers)
+}
+
+func (h *Handler) unwatch(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	if err := h.service.Unwatch(c.Request.Context(), user, c.Param("id"), c.Param("userId")); err != nil {
+		writeWatcherError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeWatcherError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", "ticket not found")
+	case errors.Is(err, ErrForbidden):
+		response.ErrorCode(c, http.StatusForbidden, "forbidden", "only admins and project managers manage other watchers")
+	case errors.Is(err, ErrUnknownAssignee):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "user not found in this organization")
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
 func validateTicketEnums(status, priority, typ string) error {
 	if status != "" {
 		switch status {
@@ -325,6 +529,36 @@ func validateTicketEnums(status, priority, typ string) error {
 	return nil
 }
 
+// maxLabels bounds the labels of a ticket; labels are at most maxLabelLength
+// characters.
+const (
+	maxLabels      = 20
+	maxLabelLength = 50
+)
+
+var errLabelLimit = fmt.Errorf("a ticket may have at most %d labels", maxLabels)
+
+// normalizeLabels trims labels and drops empty and repeated ones.
+func normalizeLabels(labels []string) ([]string, error) {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, l := range labels {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[l] {
+			continue
+		}
+		if len([]rune(l)) > maxLabelLength {
+			return nil, fmt.Errorf("labels may be at most %d characters", maxLabelLength)
+		}
+		seen[l] = true
+		out = append(out, l)
+	}
+	if len(out) > maxLabels {
+		return nil, errLabelLimit
+	}
+	return out, nil
+}
+
 func deref(s *string) string {
 	if s == nil {
 		return ""
@@ -0,0 +1,60 @@
+package tickets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend-go-ticketing-gamify/internal/gamification"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultOnTimeBonusXP is the bonus for finishing a ticket by its due date
+// in projects without a due policy.
+const DefaultOnTimeBonusXP = 10
+
+// settleOnTimeBonus grants the project's on-time bonus to userID when a
+// change completes a ticket no later than its due date (UTC), and takes a
+// granted bonus back when a change reopens one.
+func (s *Service) settleOnTimeBonus(ctx context.Context, tx pgx.Tx, before, after Ticket, userID string) error {
+	wasDone, isDone := before.Status == "done", after.Status == "done"
+	switch {
+	case isDone && !wasDone:
+		if userID == "" || after.DueDate == nil || onTimeDay(time.Now()).After(onTimeDay(*after.DueDate)) {
+			return nil
+		}
+		xp, err := s.repo.OnTimeBonusXP(ctx, tx, after.ProjectID)
+		if err != nil || xp <= 0 {
+			return err
+		}
+		if err := s.repo.RecordOnTimeBonus(ctx, tx, after.ID, userID, xp); err != nil {
+			return err
+		}
+		return s.gamification.AdjustXPTx(ctx, tx, gamification.AdjustInput{
+			UserID:   userID,
+			TicketID: after.ID,
+			Priority: after.Priority,
+			XP:       xp,
+			Note:     fmt.Sprintf("ticket %s completed on time", after.Title),
+		})
+	case wasDone && !isDone:
+		owner, xp, err := s.repo.TakeOnTimeBonus(ctx, tx, before.ID)
+		if err != nil || xp == 0 {
+			return err
+		}
+		return s.gamification.AdjustXPTx(ctx, tx, gamification.AdjustInput{
+			UserID:   owner,
+			TicketID: before.ID,
+			Priority: before.Priority,
+			XP:       -xp,
+			Note:     fmt.Sprintf("on-time bonus of ticket %s taken back", before.Title),
+		})
+	}
+	return nil
+}
+
+// onTimeDay is the UTC date of t.
+func onTimeDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
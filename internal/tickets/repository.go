@@ -8,9 +8,17 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// querier is what the pool and a transaction have in common, for writes
+// that run on their own or as part of a bulk change.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // Repository interacts with tickets table.
 type Repository struct {
 	db *pgxpool.Pool
@@ -25,12 +33,35 @@ func (r *Repository) List(ctx context.Context, filter Filter) ([]Ticket, error)
 		filter.Limit = 50
 	}
 
+	query, args := listQuery(filter)
+	query += fmt.Sprintf(" ORDER BY t.created_at DESC LIMIT $%d", len(args)+1)
+	args = append(args, filter.Limit)
+
+	var tickets []Ticket
+	err := r.scanList(ctx, query, args, func(t Ticket) error {
+		tickets = append(tickets, t)
+		return nil
+	})
+	return tickets, err
+}
+
+// Stream calls fn for every ticket matching filter, newest first, without
+// holding them all in memory. filter.Limit is ignored.
+func (r *Repository) Stream(ctx context.Context, filter Filter, fn func(Ticket) error) error {
+	query, args := listQuery(filter)
+	return r.scanList(ctx, query+" ORDER BY t.created_at DESC", args, fn)
+}
+
+// listQuery builds the SELECT and WHERE of a ticket list.
+func listQuery(filter Filter) (string, []any) {
 	var (
 		args []any
 		idx  = 1
 		sb   strings.Builder
 	)
-	sb.WriteString(`SELECT t.id, t.project_id, t.title, t.description, t.status, t.priority, t.type, t.reporter_id, t.epic_id, t.assignee_id, assignee.name, t.start_date, t.due_date, t.created_at, t.updated_at FROM tickets t LEFT JOIN users assignee ON assignee.id = t.assignee_id WHERE 1=1`)
+	sb.WriteString(ticketSelect + ` WHERE t.project_id IN (SELECT id FROM projects WHERE org_id = $1)`)
+	args = append(args, filter.OrgID)
+	idx++
 	if filter.ProjectID != "" {
 		sb.WriteString(fmt.Sprintf(" AND project_id = $%d", idx))
 		args = append(args, filter.ProjectID)
@@ -51,6 +82,11 @@ func (r *Repository) List(ctx context.Context, filter Filter) ([]Ticket, error)
 		args = append(args, filter.Status)
 		idx++
 	}
+	if filter.Label != "" {
+		sb.WriteString(fmt.Sprintf(" AND $%d = ANY(t.labels)", idx))
+		args = append(args, filter.Label)
+		idx++
+	}
 	if filter.Search != "" {
 		sb.WriteString(fmt.Sprintf(" AND (title ILIKE $%d OR description ILIKE $%d)", idx, idx+1))
 		pat := "%" + filter.Search + "%"
@@ -60,36 +96,52 @@ func (r *Repository) List(ctx context.Context, filter Filter) ([]Ticket, error)
 	if filter.Cursor != nil {
 		sb.WriteString(fmt.Sprintf(" AND t.created_at < $%d", idx))
 		args = append(args, *filter.Cursor)
-		idx++
 	}
-	sb.WriteString(fmt.Sprintf(" ORDER BY t.created_at DESC LIMIT $%d", idx))
-	args = append(args, filter.Limit)
+	return sb.String(), args
+}
 
-	rows, err := r.db.Query(ctx, sb.String(), args...)
+func (r *Repository) scanList(ctx context.Context, query string, args []any, fn func(Ticket) error) error {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var tickets []Ticket
 	for rows.Next() {
 		var t Ticket
-		if err := rows.Scan(&t.ID, &t.ProjectID, &t.Title, &t.Description, &t.Status, &t.Priority, &t.Type, &t.ReporterID, &t.EpicID, &t.AssigneeID, &t.AssigneeName, &t.StartDate, &t.DueDate, &t.CreatedAt, &t.UpdatedAt); err != nil {
-			return nil, err
+		if err := rows.Scan(&t.ID, &t.ProjectID, &t.Title, &t.Description, &t.Status, &t.Priority, &t.Type, &t.Labels, &t.ReporterID, &t.EpicID, &t.AssigneeID, &t.AssigneeName, &t.StartDate, &t.DueDate, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
 		}
-		tickets = append(tickets, t)
 	}
-	return tickets, rows.Err()
+	return rows.Err()
 }
 
-func (r *Repository) Get(ctx context.Context, id string) (*Ticket, error) {
-	const query = `
-SELECT t.id, t.project_id, t.title, t.description, t.status, t.priority, t.type, t.reporter_id, t.epic_id, t.assignee_id, assignee.name, t.start_date, t.due_date, t.created_at, t.updated_at
+// ticketSelect selects tickets with their assignee's name, in the order
+// scanList and get read them.
+const ticketSelect = `
+SELECT t.id, t.project_id, t.title, t.description, t.status, t.priority, t.type, t.labels, t.reporter_id, t.epic_id, t.assignee_id, assignee.name, t.start_date, t.due_date, t.created_at, t.updated_at
 FROM tickets t
-LEFT JOIN users assignee ON assignee.id = t.assignee_id
+LEFT JOIN users assignee ON assignee.id = t.assignee_id`
+
+const ticketQuery = ticketSelect + `
 WHERE t.id = $1`
+
+// Get returns a ticket of a project in orgID.
+func (r *Repository) Get(ctx context.Context, orgID, id string) (*Ticket, error) {
+	return r.get(ctx, ticketQuery+` AND t.project_id IN (SELECT id FROM projects WHERE org_id = $2)`, id, orgID)
+}
+
+// reload re-reads a ticket after a write whose caller already checked the organization.
+func (r *Repository) reload(ctx context.Context, id string) (*Ticket, error) {
+	return r.get(ctx, ticketQuery, id)
+}
+
+func (r *Repository) get(ctx context.Context, query string, args ...any) (*Ticket, error) {
 	var t Ticket
-	if err := r.db.QueryRow(ctx, query, id).Scan(&t.ID, &t.ProjectID, &t.Title, &t.Description, &t.Status, &t.Priority, &t.Type, &t.ReporterID, &t.EpicID, &t.AssigneeID, &t.AssigneeName, &t.StartDate, &t.DueDate, &t.CreatedAt, &t.UpdatedAt); err != nil {
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&t.ID, &t.ProjectID, &t.Title, &t.Description, &t.Status, &t.Priority, &t.Type, &t.Labels, &t.ReporterID, &t.EpicID, &t.AssigneeID, &t.AssigneeName, &t.StartDate, &t.DueDate, &t.CreatedAt, &t.UpdatedAt); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
@@ -103,18 +155,19 @@ WHERE t.id = $1`
 
 func (r *Repository) Create(ctx context.Context, input CreateInput) (*Ticket, error) {
 	const query = `
-INSERT INTO tickets (id, project_id, title, description, status, priority, type, reporter_id, epic_id, assignee_id, start_date, due_date, created_at, updated_at)
-VALUES ($1, $2, $3, $4, 'todo', $5, $6, $7, $8, $9, $10, $11, $12, $12)
+INSERT INTO tickets (id, project_id, title, description, status, priority, type, reporter_id, epic_id, assignee_id, start_date, due_date, created_at, updated_at, labels)
+VALUES ($1, $2, $3, $4, 'todo', $5, $6, $7, $8, $9, $10, $11, $12, $12, $13)
 RETURNING id, project_id, title, description, status, priority, type, reporter_id, epic_id, assignee_id, start_date, due_date, created_at, updated_at`
 	now := time.Now()
 	var t Ticket
 	ticketID := uuid.NewString()
-	if err := r.db.QueryRow(ctx, query, ticketID, input.ProjectID, input.Title, input.Description, input.Priority, input.Type, input.ReporterID, input.EpicID, input.AssigneeID, input.StartDate, input.DueDate, now).
+	if err := r.db.QueryRow(ctx, query, ticketID, input.ProjectID, input.Title, input.Description, input.Priority, input.Type, input.ReporterID, input.EpicID, input.AssigneeID, input.StartDate, input.DueDate, now, labelsOrEmpty(input.Labels)).
 		Scan(&t.ID, &t.ProjectID, &t.Title, &t.Description, &t.Status, &t.Priority, &t.Type, &t.ReporterID, &t.EpicID, &t.AssigneeID, &t.StartDate, &t.DueDate, &t.CreatedAt, &t.UpdatedAt); err != nil {
 		return nil, err
 	}
 	_ = r.addHistory(ctx, t.ID, "Ticket created", nil)
-	ticket, err := r.Get(ctx, t.ID)
+	_ = recordTransition(ctx, r.db, t.ID, t.Status, &input.ReporterID, now)
+	ticket, err := r.reload(ctx, t.ID)
 	if err == nil && ticket != nil && ticket.EpicID != nil {
 		_ = r.UpdateEpicStatusByTickets(ctx, *ticket.EpicID)
 	}
@@ -123,6 +176,10 @@ RETURNING id, project_id, title, description, status, priority, type, reporter_i
 
 // UpdateEpicStatusByTickets recalculates epic status based on linked tickets.
 func (r *Repository) UpdateEpicStatusByTickets(ctx context.Context, epicID string) error {
+	return updateEpicStatus(ctx, r.db, epicID)
+}
+
+func updateEpicStatus(ctx context.Context, q querier, epicID string) error {
 	if epicID == "" {
 		return nil
 	}
@@ -134,7 +191,7 @@ SELECT
 FROM tickets
 WHERE epic_id = $1`
 	var total, doneCount, inProgress int
-	if err := r.db.QueryRow(ctx, countsQuery, epicID).Scan(&total, &doneCount, &inProgress); err != nil {
+	if err := q.QueryRow(ctx, countsQuery, epicID).Scan(&total, &doneCount, &inProgress); err != nil {
 		return err
 	}
 	if total == 0 {
@@ -147,13 +204,13 @@ WHERE epic_id = $1`
 		newStatus = "in_progress"
 	}
 	const updateEpic = `UPDATE epics SET status = $2, updated_at = NOW() WHERE id = $1`
-	if _, err := r.db.Exec(ctx, updateEpic, epicID, newStatus); err != nil {
+	if _, err := q.Exec(ctx, updateEpic, epicID, newStatus); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *Repository) UpdateStatus(ctx context.Context, ticketID string, status string) (*Ticket, error) {
+func (r *Repository) UpdateStatus(ctx context.Context, ticketID string, status string, actorID string) (*Ticket, error) {
 	const query = `
 UPDATE tickets
 SET status = $2, updated_at = $3
@@ -170,7 +227,8 @@ RETURNING id, project_id, title, description, status, priority, type, reporter_i
 		return nil, err
 	}
 	_ = r.addHistory(ctx, t.ID, fmt.Sprintf("Status changed to %s", status), nil)
-	ticket, err := r.Get(ctx, t.ID)
+	_ = recordTransition(ctx, r.db, t.ID, status, &actorID, now)
+	ticket, err := r.reload(ctx, t.ID)
 	if err == nil && ticket != nil && ticket.EpicID != nil {
 		_ = r.UpdateEpicStatusByTickets(ctx, *ticket.EpicID)
 	}
@@ -211,6 +269,11 @@ func (r *Repository) UpdateFields(ctx context.Context, ticketID string, input Up
 			idx++
 		}
 	}
+	if input.Labels != nil {
+		setParts = append(setParts, fmt.Sprintf("labels = $%d", idx))
+		args = append(args, input.Labels)
+		idx++
+	}
 	if input.StartDate != nil {
 		setParts = append(setParts, fmt.Sprintf("start_date = $%d", idx))
 		args = append(args, input.StartDate)
@@ -236,7 +299,7 @@ func (r *Repository) UpdateFields(ctx context.Context, ticketID string, input Up
 	}
 
 	if len(setParts) == 0 {
-		return r.Get(ctx, ticketID)
+		return r.reload(ctx, ticketID)
 	}
 
 	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", idx))
@@ -260,13 +323,27 @@ RETURNING id, project_id, title, description, status, priority, type, reporter_i
 		}
 		return nil, err
 	}
-	ticket, err := r.Get(ctx, t.ID)
+	ticket, err := r.reload(ctx, t.ID)
 	if err == nil && ticket != nil && ticket.EpicID != nil {
 		_ = r.UpdateEpicStatusByTickets(ctx, *ticket.EpicID)
 	}
 	return ticket, err
 }
 
+// ProjectInOrg reports whether projectID belongs to orgID.
+func (r *Repository) ProjectInOrg(ctx context.Context, orgID, projectID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id::text = $1 AND org_id = $2)`, projectID, orgID).Scan(&ok)
+	return ok, err
+}
+
+// UserInOrg reports whether userID belongs to orgID.
+func (r *Repository) UserInOrg(ctx context.Context, orgID, userID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id::text = $1 AND org_id = $2)`, userID, orgID).Scan(&ok)
+	return ok, err
+}
+
 // EpicBelongsToProject checks whether the epic is associated with the project.
 func (r *Repository) EpicBelongsToProject(ctx context.Context, epicID, projectID string) (bool, error) {
 	if epicID == "" || projectID == "" {
@@ -336,9 +413,25 @@ func (r *Repository) DeleteComment(ctx context.Context, commentID, authorID stri
 }
 
 func (r *Repository) Delete(ctx context.Context, ticketID string) error {
-	const query = `DELETE FROM tickets WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, ticketID)
-	return err
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return deleteTicket(ctx, tx, ticketID)
+	})
+}
+
+// deleteTicket removes a ticket with its history and comments. XP events
+// keep the XP earned but no longer point at the ticket.
+func deleteTicket(ctx context.Context, q querier, ticketID string) error {
+	for _, query := range []string{
+		`DELETE FROM ticket_history WHERE ticket_id = $1`,
+		`DELETE FROM ticket_comments WHERE ticket_id = $1`,
+		`UPDATE xp_events SET ticket_id = NULL WHERE ticket_id = $1`,
+		`DELETE FROM tickets WHERE id = $1`,
+	} {
+		if _, err := q.Exec(ctx, query, ticketID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // AddProjectActivity logs a project-level activity entry (best effort).
@@ -350,10 +443,28 @@ VALUES ($1, $2, $3, $4, NOW())`
 }
 
 func (r *Repository) addHistory(ctx context.Context, ticketID, text string, actorID *string) error {
+	return insertHistory(ctx, r.db, ticketID, text, actorID)
+}
+
+func insertHistory(ctx context.Context, q querier, ticketID, text string, actorID *string) error {
 	const query = `
 INSERT INTO ticket_history (id, ticket_id, text, actor_id, timestamp)
 VALUES ($1, $2, $3, $4, NOW())`
-	_, err := r.db.Exec(ctx, query, uuid.NewString(), ticketID, text, actorID)
+	_, err := q.Exec(ctx, query, uuid.NewString(), ticketID, text, actorID)
+	return err
+}
+
+// recordTransition stores a change of a ticket to status, unless status is
+// what its last transition already moved it to.
+func recordTransition(ctx context.Context, q querier, ticketID, status string, actorID *string, at time.Time) error {
+	const query = `
+WITH last AS (
+    SELECT to_status FROM ticket_status_transitions WHERE ticket_id = $1 ORDER BY changed_at DESC LIMIT 1
+)
+INSERT INTO ticket_status_transitions (ticket_id, from_status, to_status, actor_id, changed_at)
+SELECT $1, (SELECT to_status FROM last), $2::ticket_status, $3, $4
+WHERE (SELECT to_status FROM last) IS DISTINCT FROM $2::ticket_status`
+	_, err := q.Exec(ctx, query, ticketID, status, actorID, at)
 	return err
 }
 
@@ -399,3 +510,160 @@ ORDER BY tc.created_at ASC`
 	}
 	return cRows.Err()
 }
+
+// labelsOrEmpty stores missing labels as an empty array, as the column
+// doesn't allow NULL.
+func labelsOrEmpty(labels []string) []string {
+	if labels == nil {
+		return []string{}
+	}
+	return labels
+}
+
+// GetMany returns the tickets of orgID among ids, without history or
+// comments. Unknown ids are left out.
+func (r *Repository) GetMany(ctx context.Context, orgID string, ids []string) ([]Ticket, error) {
+	query := ticketSelect + `
+WHERE t.id::text = ANY($1) AND t.project_id IN (SELECT id FROM projects WHERE org_id = $2)`
+	var tickets []Ticket
+	err := r.scanList(ctx, query, []any{ids, orgID}, func(t Ticket) error {
+		tickets = append(tickets, t)
+		return nil
+	})
+	return tickets, err
+}
+
+// Match returns up to limit tickets matching filter, newest first, without
+// history or comments. filter.Limit and filter.Cursor are ignored.
+func (r *Repository) Match(ctx context.Context, filter Filter, limit int) ([]Ticket, error) {
+	filter.Cursor = nil
+	query, args := listQuery(filter)
+	query += fmt.Sprintf(" ORDER BY t.created_at DESC LIMIT $%d", len(args)+1)
+	args = append(args, limit)
+	var tickets []Ticket
+	err := r.scanList(ctx, query, args, func(t Ticket) error {
+		tickets = append(tickets, t)
+		return nil
+	})
+	return tickets, err
+}
+
+// EpicInfo returns an epic's project and title, or empty strings when there
+// is no such epic.
+func (r *Repository) EpicInfo(ctx context.Context, epicID string) (projectID, title string, err error) {
+	const query = `SELECT project_id::text, title FROM epics WHERE id::text = $1`
+	err = r.db.QueryRow(ctx, query, epicID).Scan(&projectID, &title)
+	if err == pgx.ErrNoRows {
+		return "", "", nil
+	}
+	return projectID, title, err
+}
+
+// UserName returns a user's display name.
+func (r *Repository) UserName(ctx context.Context, userID string) (string, error) {
+	var name string
+	err := r.db.QueryRow(ctx, `SELECT name FROM users WHERE id::text = $1`, userID).Scan(&name)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	return name, err
+}
+
+// InTx runs fn in a transaction.
+func (r *Repository) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return pgx.BeginFunc(ctx, r.db, fn)
+}
+
+// SaveBulk writes a bulk change to t (status, assignee, priority, epic and
+// labels) with a history entry for each line of history.
+func (r *Repository) SaveBulk(ctx context.Context, tx pgx.Tx, t *Ticket, actorID string, history []string) error {
+	const query = `
+UPDATE tickets
+SET status = $2, assignee_id = $3, priority = $4, epic_id = $5, labels = $6, updated_at = NOW()
+WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, t.ID, t.Status, t.AssigneeID, t.Priority, t.EpicID, labelsOrEmpty(t.Labels)); err != nil {
+		return err
+	}
+	for _, text := range history {
+		if err := insertHistory(ctx, tx, t.ID, text, &actorID); err != nil {
+			return err
+		}
+	}
+	return recordTransition(ctx, tx, t.ID, t.Status, &actorID, time.Now())
+}
+
+// DeleteTx deletes a ticket within tx.
+func (r *Repository) DeleteTx(ctx context.Context, tx pgx.Tx, ticketID string) error {
+	return deleteTicket(ctx, tx, ticketID)
+}
+
+// UpdateEpicStatusTx is UpdateEpicStatusByTickets within tx.
+func (r *Repository) UpdateEpicStatusTx(ctx context.Context, tx pgx.Tx, epicID string) error {
+	return updateEpicStatus(ctx, tx, epicID)
+}
+
+// Watchers lists a ticket's watchers by name.
+func (r *Repository) Watchers(ctx context.Context, ticketID string) ([]Watcher, error) {
+	const query = `
+SELECT w.user_id, u.name, w.created_at
+FROM ticket_watchers w
+JOIN users u ON u.id = w.user_id
+WHERE w.ticket_id = $1
+ORDER BY u.name ASC`
+	rows, err := r.db.Query(ctx, query, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	watchers := []Watcher{}
+	for rows.Next() {
+		var w Watcher
+		if err := rows.Scan(&w.UserID, &w.Name, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, w)
+	}
+	return watchers, rows.Err()
+}
+
+func (r *Repository) AddWatcher(ctx context.Context, ticketID, userID string) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO ticket_watchers (ticket_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, ticketID, userID)
+	return err
+}
+
+func (r *Repository) RemoveWatcher(ctx context.Context, ticketID, userID string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM ticket_watchers WHERE ticket_id = $1 AND user_id::text = $2`, ticketID, userID)
+	return err
+}
+
+// OnTimeBonusXP returns the on-time bonus of a project's due policy.
+func (r *Repository) OnTimeBonusXP(ctx context.Context, q querier, projectID string) (int, error) {
+	var xp int
+	err := q.QueryRow(ctx, `SELECT COALESCE((SELECT on_time_bonus_xp FROM due_policies WHERE project_id = $1), $2)`, projectID, DefaultOnTimeBonusXP).Scan(&xp)
+	return xp, err
+}
+
+// RecordOnTimeBonus remembers the bonus a ticket's completion earned.
+func (r *Repository) RecordOnTimeBonus(ctx context.Context, q querier, ticketID, userID string, xp int) error {
+	const query = `
+INSERT INTO ticket_on_time_bonus (ticket_id, user_id, xp)
+VALUES ($1, $2, $3)
+ON CONFLICT (ticket_id) DO UPDATE SET user_id = EXCLUDED.user_id, xp = EXCLUDED.xp, created_at = now()`
+	_, err := q.Exec(ctx, query, ticketID, userID, xp)
+	return err
+}
+
+// TakeOnTimeBonus forgets a ticket's on-time bonus and returns who earned
+// it and how much; 0 when it earned none.
+func (r *Repository) TakeOnTimeBonus(ctx context.Context, q querier, ticketID string) (string, int, error) {
+	var (
+		userID string
+		xp     int
+	)
+	err := q.QueryRow(ctx, `DELETE FROM ticket_on_time_bonus WHERE ticket_id = $1 RETURNING user_id, xp`, ticketID).Scan(&userID, &xp)
+	if err == pgx.ErrNoRows {
+		return "", 0, nil
+	}
+	return userID, xp, err
+}
@@ -8,6 +8,7 @@ import (
 	"backend-go-ticketing-gamify/internal/audit"
 	"backend-go-ticketing-gamify/internal/gamification"
 	"backend-go-ticketing-gamify/internal/middleware"
+	"backend-go-ticketing-gamify/internal/sla"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -23,8 +24,19 @@ var (
 	ErrNotFound  = errors.New("not_found")
 	// ErrEpicProjectMismatch when epic does not belong to the ticket's project.
 	ErrEpicProjectMismatch = errors.New("epic_project_mismatch")
+	// ErrProjectNotFound and ErrUnknownAssignee reject references outside the caller's organization.
+	ErrProjectNotFound = errors.New("project not found")
+	ErrUnknownAssignee = errors.New("assignee not found in this organization")
 )
 
+// XPFor is the XP completing a ticket of the given priority earns.
+func XPFor(priority string) int {
+	if xp, ok := priorityXP[priority]; ok {
+		return xp
+	}
+	return priorityXP["medium"]
+}
+
 func canModify(actor *middleware.UserContext, ticket *Ticket) bool {
 	if actor == nil || ticket == nil {
 		return false
@@ -47,10 +59,11 @@ type Service struct {
 	repo         *Repository
 	audit        *audit.Service
 	gamification *gamification.Service
+	sla          *sla.Service
 }
 
-func NewService(repo *Repository, audit *audit.Service, gamification *gamification.Service) *Service {
-	return &Service{repo: repo, audit: audit, gamification: gamification}
+func NewService(repo *Repository, audit *audit.Service, gamification *gamification.Service, sla *sla.Service) *Service {
+	return &Service{repo: repo, audit: audit, gamification: gamification, sla: sla}
 }
 
 func formatStatusLabel(status string) string {
@@ -71,14 +84,69 @@ func formatStatusLabel(status string) string {
 }
 
 func (s *Service) List(ctx context.Context, filter Filter) ([]Ticket, error) {
-	return s.repo.List(ctx, filter)
+	tickets, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return tickets, s.attachSLA(ctx, tickets)
 }
 
-func (s *Service) Get(ctx context.Context, id string) (*Ticket, error) {
-	return s.repo.Get(ctx, id)
+// Stream calls fn for every ticket matching filter; exports use it.
+func (s *Service) Stream(ctx context.Context, filter Filter, fn func(Ticket) error) error {
+	return s.repo.Stream(ctx, filter, fn)
+}
+
+func (s *Service) Get(ctx context.Context, orgID, id string) (*Ticket, error) {
+	ticket, err := s.repo.Get(ctx, orgID, id)
+	if err != nil || ticket == nil {
+		return ticket, err
+	}
+	tickets := []Ticket{*ticket}
+	if err := s.attachSLA(ctx, tickets); err != nil {
+		return nil, err
+	}
+	return &tickets[0], nil
+}
+
+// attachSLA sets the SLA of tickets whose project has a policy for them.
+func (s *Service) attachSLA(ctx context.Context, tickets []Ticket) error {
+	if s.sla == nil || len(tickets) == 0 {
+		return nil
+	}
+	refs := make([]sla.TicketRef, len(tickets))
+	for i, t := range tickets {
+		refs[i] = sla.TicketRef{
+			ID:        t.ID,
+			ProjectID: t.ProjectID,
+			Title:     t.Title,
+			Status:    t.Status,
+			Priority:  t.Priority,
+			Type:      t.Type,
+			CreatedAt: t.CreatedAt,
+			UpdatedAt: t.UpdatedAt,
+		}
+	}
+	statuses, err := s.sla.Evaluate(ctx, refs)
+	if err != nil {
+		return err
+	}
+	for i := range tickets {
+		tickets[i].SLA = statuses[tickets[i].ID]
+	}
+	return nil
 }
 
 func (s *Service) Create(ctx context.Context, actor *middleware.UserContext, input CreateInput) (*Ticket, error) {
+	ok, err := s.repo.ProjectInOrg(ctx, actor.OrgID, input.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+	if err := s.checkAssignee(ctx, actor.OrgID, input.AssigneeID); err != nil {
+		return nil, err
+	}
 	if input.EpicID != nil && *input.EpicID != "" {
 		ok, err := s.repo.EpicBelongsToProject(ctx, *input.EpicID, input.ProjectID)
 		if err != nil {
@@ -103,7 +171,7 @@ func (s *Service) Create(ctx context.Context, actor *middleware.UserContext, inp
 }
 
 func (s *Service) UpdateStatus(ctx context.Context, actor *middleware.UserContext, ticketID, status string) (*Ticket, error) {
-	current, err := s.repo.Get(ctx, ticketID)
+	current, err := s.repo.Get(ctx, actor.OrgID, ticketID)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +182,7 @@ func (s *Service) UpdateStatus(ctx context.Context, actor *middleware.UserContex
 	if !canModify(actor, current) {
 		return nil, ErrForbidden
 	}
-	ticket, err := s.repo.UpdateStatus(ctx, ticketID, status)
+	ticket, err := s.repo.UpdateStatus(ctx, ticketID, status, actor.ID)
 	if err != nil || ticket == nil {
 		return ticket, err
 	}
@@ -129,10 +197,7 @@ func (s *Service) UpdateStatus(ctx context.Context, actor *middleware.UserContex
 	if ticket.AssigneeID != nil && *ticket.AssigneeID != "" {
 		userID = *ticket.AssigneeID
 	}
-	xp := priorityXP[ticket.Priority]
-	if xp == 0 {
-		xp = priorityXP["medium"]
-	}
+	xp := XPFor(ticket.Priority)
 	if status == "done" && !wasDone {
 		_ = s.gamification.AdjustXP(ctx, gamification.AdjustInput{
 			UserID:      userID,
@@ -155,11 +220,16 @@ func (s *Service) UpdateStatus(ctx context.Context, actor *middleware.UserContex
 		})
 		_ = s.gamification.RefreshClosedCount(ctx, userID)
 	}
+	if wasDone != (status == "done") {
+		_ = s.repo.InTx(ctx, func(tx pgx.Tx) error {
+			return s.settleOnTimeBonus(ctx, tx, *current, *ticket, userID)
+		})
+	}
 	return ticket, nil
 }
 
 func (s *Service) UpdateDetails(ctx context.Context, actor *middleware.UserContext, ticketID string, input UpdateInput) (*Ticket, error) {
-	current, err := s.repo.Get(ctx, ticketID)
+	current, err := s.repo.Get(ctx, actor.OrgID, ticketID)
 	if err != nil {
 		return nil, err
 	}
@@ -169,6 +239,9 @@ func (s *Service) UpdateDetails(ctx context.Context, actor *middleware.UserConte
 	if !canModify(actor, current) {
 		return nil, ErrForbidden
 	}
+	if err := s.checkAssignee(ctx, actor.OrgID, input.AssigneeID); err != nil {
+		return nil, err
+	}
 
 	if input.EpicID != nil && *input.EpicID != "" {
 		ok, err := s.repo.EpicBelongsToProject(ctx, *input.EpicID, current.ProjectID)
@@ -206,6 +279,13 @@ func (s *Service) AddComment(ctx context.Context, actor *middleware.UserContext,
 	if actor == nil {
 		return nil, ErrForbidden
 	}
+	tk, err := s.repo.Get(ctx, actor.OrgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if tk == nil {
+		return nil, ErrNotFound
+	}
 	comment, err := s.repo.AddComment(ctx, ticketID, actor.ID, text)
 	if err != nil {
 		return nil, err
@@ -218,11 +298,8 @@ func (s *Service) AddComment(ctx context.Context, actor *middleware.UserContext,
 		_ = s.audit.Log(ctx, "ticket_commented", desc, &actorID, &entityType, &entityID)
 	}
 	// add comment activity
-	tk, _ := s.repo.Get(ctx, ticketID)
-	if tk != nil {
-		desc := fmt.Sprintf("%s menambahkan komentar pada tiket %s", actor.Name, tk.Title)
-		s.repo.AddProjectActivity(ctx, tk.ProjectID, &actor.ID, desc)
-	}
+	desc := fmt.Sprintf("%s menambahkan komentar pada tiket %s", actor.Name, tk.Title)
+	s.repo.AddProjectActivity(ctx, tk.ProjectID, &actor.ID, desc)
 	return comment, nil
 }
 
@@ -257,7 +334,7 @@ func (s *Service) DeleteComment(ctx context.Context, actor *middleware.UserConte
 }
 
 func (s *Service) Delete(ctx context.Context, actor *middleware.UserContext, ticketID string) error {
-	current, err := s.repo.Get(ctx, ticketID)
+	current, err := s.repo.Get(ctx, actor.OrgID, ticketID)
 	if err != nil {
 		return err
 	}
@@ -283,3 +360,18 @@ func (s *Service) Delete(ctx context.Context, actor *middleware.UserContext, tic
 	}
 	return nil
 }
+
+// checkAssignee rejects assignees outside orgID. An empty ID unassigns.
+func (s *Service) checkAssignee(ctx context.Context, orgID string, assigneeID *string) error {
+	if assigneeID == nil || *assigneeID == "" {
+		return nil
+	}
+	ok, err := s.repo.UserInOrg(ctx, orgID, *assigneeID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUnknownAssignee
+	}
+	return nil
+}
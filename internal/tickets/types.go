@@ -1,6 +1,10 @@
 package tickets
 
-import "time"
+import (
+	"time"
+
+	"backend-go-ticketing-gamify/internal/sla"
+)
 
 // Ticket base model.
 type Ticket struct {
@@ -11,6 +15,7 @@ type Ticket struct {
 	Status       string         `json:"status"`
 	Priority     string         `json:"priority"`
 	Type         string         `json:"type"`
+	Labels       []string       `json:"labels"`
 	ReporterID   string         `json:"reporterId"`
 	EpicID       *string        `json:"epicId,omitempty"`
 	AssigneeID   *string        `json:"assigneeId,omitempty"`
@@ -21,14 +26,18 @@ type Ticket struct {
 	UpdatedAt    time.Time      `json:"updatedAt"`
 	History      []HistoryEntry `json:"history"`
 	Comments     []Comment      `json:"comments"`
+	// SLA is set when the project's SLA policy has a target for the ticket.
+	SLA *sla.Status `json:"sla,omitempty"`
 }
 
 // Filter query params for listing.
 type Filter struct {
+	OrgID      string
 	ProjectID  string
 	AssigneeID string
 	Status     string
 	EpicID     string
+	Label      string
 	Search     string
 	// Cursor is a "created_at" RFC3339 value for keyset pagination (created_at < cursor)
 	Cursor *time.Time
@@ -42,6 +51,7 @@ type CreateInput struct {
 	Description string     `json:"description" binding:"required"`
 	Priority    string     `json:"priority" binding:"required"`
 	Type        string     `json:"type" binding:"required"`
+	Labels      []string   `json:"labels"`
 	ReporterID  string     `json:"reporterId"`
 	EpicID      *string    `json:"epicId"`
 	AssigneeID  *string    `json:"assigneeId"`
@@ -60,6 +70,7 @@ type UpdateInput struct {
 	Description  *string    `json:"description"`
 	Priority     *string    `json:"priority"`
 	Type         *string    `json:"type"`
+	Labels       []string   `json:"labels"`
 	EpicID       *string    `json:"epicId"`
 	AssigneeID   *string    `json:"assigneeId"`
 	StartDate    *time.Time `json:"startDate"`
@@ -96,3 +107,65 @@ type HistoryEntry struct {
 type CommentInput struct {
 	Text string `json:"text" binding:"required"`
 }
+
+// BulkInput is one change applied to many tickets: those listed in IDs or
+// those matching Filter. Either Set or Delete says what to do.
+type BulkInput struct {
+	IDs    []string    `json:"ids"`
+	Filter *BulkFilter `json:"filter"`
+	Set    *BulkSet    `json:"set"`
+	Delete bool        `json:"delete"`
+	// AllOrNothing rejects the whole change when any ticket can't take it.
+	AllOrNothing bool `json:"allOrNothing"`
+}
+
+// BulkFilter selects tickets like the list filters do.
+type BulkFilter struct {
+	ProjectID  string `json:"projectId"`
+	AssigneeID string `json:"assigneeId"`
+	Status     string `json:"status"`
+	EpicID     string `json:"epicId"`
+	Label      string `json:"label"`
+	Search     string `json:"q"`
+}
+
+// BulkSet lists the fields to change. An empty AssigneeID unassigns and an
+// empty EpicID removes the epic.
+type BulkSet struct {
+	Status       *string  `json:"status"`
+	AssigneeID   *string  `json:"assigneeId"`
+	Priority     *string  `json:"priority"`
+	EpicID       *string  `json:"epicId"`
+	AddLabels    []string `json:"addLabels"`
+	RemoveLabels []string `json:"removeLabels"`
+}
+
+// BulkResult is the outcome for one ticket: updated, deleted, unchanged,
+// forbidden, not_found or invalid.
+type BulkResult struct {
+	ID     string `json:"id"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkReport sums up a bulk change.
+type BulkReport struct {
+	Matched   int          `json:"matched"`
+	Updated   int          `json:"updated"`
+	Deleted   int          `json:"deleted"`
+	Unchanged int          `json:"unchanged"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// Watcher gets a ticket's due date reminders besides its assignee.
+type Watcher struct {
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WatchInput adds a watcher; without userId the caller watches.
+type WatchInput struct {
+	UserID string `json:"userId"`
+}
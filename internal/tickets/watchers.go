@@ -0,0 +1,70 @@
+package tickets
+
+import (
+	"context"
+
+	"backend-go-ticketing-gamify/internal/middleware"
+)
+
+// canManageWatchers reports whether actor may add and remove other people
+// as watchers.
+func canManageWatchers(actor *middleware.UserContext) bool {
+	return actor.Role == "admin" || actor.Role == "project_manager"
+}
+
+// Watchers lists who watches a ticket of the caller's organization.
+func (s *Service) Watchers(ctx context.Context, actor *middleware.UserContext, ticketID string) ([]Watcher, error) {
+	ticket, err := s.repo.Get(ctx, actor.OrgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket == nil {
+		return nil, ErrNotFound
+	}
+	return s.repo.Watchers(ctx, ticket.ID)
+}
+
+// Watch adds userID, or the caller when empty, to a ticket's watchers.
+// Only admins and project managers add others.
+func (s *Service) Watch(ctx context.Context, actor *middleware.UserContext, ticketID, userID string) ([]Watcher, error) {
+	ticket, err := s.repo.Get(ctx, actor.OrgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket == nil {
+		return nil, ErrNotFound
+	}
+	if userID == "" || userID == "me" {
+		userID = actor.ID
+	}
+	if userID != actor.ID {
+		if !canManageWatchers(actor) {
+			return nil, ErrForbidden
+		}
+		if err := s.checkAssignee(ctx, actor.OrgID, &userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.AddWatcher(ctx, ticket.ID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.Watchers(ctx, ticket.ID)
+}
+
+// Unwatch removes userID ("me" for the caller) from a ticket's watchers.
+func (s *Service) Unwatch(ctx context.Context, actor *middleware.UserContext, ticketID, userID string) error {
+	ticket, err := s.repo.Get(ctx, actor.OrgID, ticketID)
+	if err != nil {
+		return err
+	}
+	if ticket == nil {
+		return ErrNotFound
+	}
+	if userID == "me" {
+		userID = actor.ID
+	}
+	if userID != actor.ID && !canManageWatchers(actor) {
+		return ErrForbidden
+	}
+	return s.repo.RemoveWatcher(ctx, ticket.ID, userID)
+}
@@ -1,10 +1,15 @@
 package users
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"backend-go-ticketing-gamify/internal/middleware"
 	"backend-go-ticketing-gamify/internal/response"
@@ -25,19 +30,74 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.PATCH("/me", h.updateMe)
 	router.GET("/:id", middleware.RequireRoles("admin", "project_manager"), h.get)
 	router.PATCH("/:id/role", middleware.RequireRoles("admin"), h.updateRole)
+	router.POST("/:id/deactivate", middleware.RequireRoles("admin"), h.deactivate)
+	router.POST("/:id/reactivate", middleware.RequireRoles("admin"), h.reactivate)
+	router.DELETE("/:id", middleware.RequireRoles("admin"), h.anonymize)
 }
 
+// list supports ?q= search, ?role=, ?status=active|deactivated|all and
+// keyset pagination through the opaque ?cursor= returned as nextCursor.
 func (h *Handler) list(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	if limit <= 0 || limit > 200 {
 		limit = 50
 	}
-	users, err := h.service.List(c.Request.Context(), limit)
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	filter := ListFilter{
+		OrgID:  user.OrgID,
+		Limit:  limit,
+		Search: strings.TrimSpace(c.Query("q")),
+		Role:   c.Query("role"),
+		Status: c.DefaultQuery("status", "active"),
+	}
+	switch filter.Status {
+	case "active", "deactivated", "all":
+	default:
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "status must be active, deactivated or all")
+		return
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", "invalid cursor")
+			return
+		}
+		filter.After = cursor
+	}
+	users, next, err := h.service.List(c.Request.Context(), filter)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
-	response.WithMeta(c, http.StatusOK, users, gin.H{"limit": limit})
+	meta := gin.H{"limit": limit}
+	if next != nil {
+		meta["nextCursor"] = encodeCursor(next)
+	}
+	response.WithMeta(c, http.StatusOK, users, meta)
+}
+
+func encodeCursor(cursor *ListCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (*ListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor ListCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(cursor.ID); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
 }
 
 func (h *Handler) me(c *gin.Context) {
@@ -46,7 +106,7 @@ func (h *Handler) me(c *gin.Context) {
 		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
 		return
 	}
-	user, err := h.service.Get(c.Request.Context(), userCtx.ID)
+	user, err := h.service.Get(c.Request.Context(), userCtx.OrgID, userCtx.ID)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -69,7 +129,11 @@ func (h *Handler) updateMe(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
-	user, err := h.service.UpdateProfile(c.Request.Context(), userCtx.ID, payload)
+	user, err := h.service.UpdateProfile(c.Request.Context(), userCtx.OrgID, userCtx.ID, payload)
+	if errors.Is(err, ErrInvalidTimezone) {
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -82,7 +146,12 @@ func (h *Handler) updateMe(c *gin.Context) {
 }
 
 func (h *Handler) get(c *gin.Context) {
-	user, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	userCtx := middleware.CurrentUser(c)
+	if userCtx == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	user, err := h.service.Get(c.Request.Context(), userCtx.OrgID, c.Param("id"))
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -95,6 +164,11 @@ func (h *Handler) get(c *gin.Context) {
 }
 
 func (h *Handler) updateRole(c *gin.Context) {
+	actor := middleware.CurrentUser(c)
+	if actor == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
 	var payload struct {
 		Role string `json:"role" binding:"required"`
 	}
@@ -103,10 +177,10 @@ func (h *Handler) updateRole(c *gin.Context) {
 		return
 	}
 	if payload.Role != "" && !isAllowedRole(payload.Role) {
-		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "invalid role")
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", "role must be developer, project_manager or admin")
 		return
 	}
-	user, err := h.service.UpdateRole(c.Request.Context(), c.Param("id"), payload.Role)
+	user, err := h.service.UpdateRole(c.Request.Context(), actor.OrgID, c.Param("id"), payload.Role)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
 		return
@@ -118,9 +192,81 @@ func (h *Handler) updateRole(c *gin.Context) {
 	response.OK(c, user)
 }
 
+func (h *Handler) deactivate(c *gin.Context) {
+	actor := middleware.CurrentUser(c)
+	if actor == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload TicketHandoff
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+	}
+	result, err := h.service.Deactivate(c.Request.Context(), actor.OrgID, actor.ID, c.Param("id"), payload)
+	if err != nil {
+		writeLifecycleError(c, err)
+		return
+	}
+	response.OK(c, result)
+}
+
+func (h *Handler) reactivate(c *gin.Context) {
+	actor := middleware.CurrentUser(c)
+	if actor == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	user, err := h.service.Reactivate(c.Request.Context(), actor.OrgID, actor.ID, c.Param("id"))
+	if err != nil {
+		writeLifecycleError(c, err)
+		return
+	}
+	response.OK(c, user)
+}
+
+func (h *Handler) anonymize(c *gin.Context) {
+	actor := middleware.CurrentUser(c)
+	if actor == nil {
+		response.ErrorCode(c, http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+		return
+	}
+	var payload TicketHandoff
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+	}
+	result, err := h.service.Anonymize(c.Request.Context(), actor.OrgID, actor.ID, c.Param("id"), payload)
+	if err != nil {
+		writeLifecycleError(c, err)
+		return
+	}
+	response.OK(c, result)
+}
+
+func writeLifecycleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		response.ErrorCode(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrAlreadyDeactivated), errors.Is(err, ErrNotDeactivated):
+		response.ErrorCode(c, http.StatusConflict, "user_state_conflict", err.Error())
+	case errors.Is(err, ErrSelfDeactivation), errors.Is(err, ErrLastAdmin):
+		response.ErrorCode(c, http.StatusForbidden, "forbidden", err.Error())
+	case errors.Is(err, ErrInvalidReassignment):
+		response.ErrorCode(c, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+// isAllowedRole reports whether role is a value of the user_role enum.
 func isAllowedRole(role string) bool {
 	switch role {
-	case "admin", "project_manager", "developer", "viewer":
+	case "admin", "project_manager", "developer":
 		return true
 	default:
 		return false
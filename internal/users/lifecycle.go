@@ -0,0 +1,141 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound            = errors.New("user not found")
+	ErrAlreadyDeactivated  = errors.New("user is already deactivated")
+	ErrNotDeactivated      = errors.New("user is not deactivated or was anonymized")
+	ErrSelfDeactivation    = errors.New("you cannot deactivate or delete your own account")
+	ErrLastAdmin           = errors.New("cannot remove the last active admin")
+	ErrInvalidReassignment = errors.New("tickets can only be reassigned to another active user")
+)
+
+// TokenRevoker cuts off access tokens that are already issued.
+type TokenRevoker interface {
+	RevokeUser(ctx context.Context, userID string) error
+}
+
+// Deactivate blocks login, refresh and API tokens for a user and optionally
+// hands their unfinished tickets to someone else.
+func (s *Service) Deactivate(ctx context.Context, orgID, actorID, id string, tickets TicketHandoff) (*LifecycleResult, error) {
+	user, err := s.checkRemovable(ctx, orgID, actorID, id, tickets)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrAlreadyDeactivated
+	}
+	updated, moved, err := s.repo.Deactivate(ctx, orgID, id, actorID, tickets)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrAlreadyDeactivated
+	}
+	if err := s.revokeAccess(ctx, id); err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("%s deactivated, %d open tickets handed off", updated.Name, moved)
+		entityType := "user"
+		_ = s.audit.Log(ctx, "user_deactivated", desc, &actorID, &entityType, &id)
+	}
+	return &LifecycleResult{User: updated, TicketsHandedOff: moved}, nil
+}
+
+// Reactivate lets a deactivated user sign in again.
+func (s *Service) Reactivate(ctx context.Context, orgID, actorID, id string) (*User, error) {
+	user, err := s.repo.Reactivate(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		existing, err := s.repo.Get(ctx, orgID, id)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, ErrNotFound
+		}
+		return nil, ErrNotDeactivated
+	}
+	if s.audit != nil {
+		desc := fmt.Sprintf("%s reactivated", user.Name)
+		entityType := "user"
+		_ = s.audit.Log(ctx, "user_reactivated", desc, &actorID, &entityType, &id)
+	}
+	return user, nil
+}
+
+// Anonymize erases a user's personal data (GDPR delete). The row stays so
+// XP history, audit entries and tickets keep a valid reference.
+func (s *Service) Anonymize(ctx context.Context, orgID, actorID, id string, tickets TicketHandoff) (*LifecycleResult, error) {
+	if _, err := s.checkRemovable(ctx, orgID, actorID, id, tickets); err != nil {
+		return nil, err
+	}
+	updated, moved, err := s.repo.Anonymize(ctx, orgID, id, actorID, tickets)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrNotFound
+	}
+	if err := s.revokeAccess(ctx, id); err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		// the description must not repeat the personal data that was just erased
+		desc := fmt.Sprintf("user %s anonymized, %d open tickets handed off", id, moved)
+		entityType := "user"
+		_ = s.audit.Log(ctx, "user_anonymized", desc, &actorID, &entityType, &id)
+	}
+	return &LifecycleResult{User: updated, TicketsHandedOff: moved}, nil
+}
+
+// checkRemovable loads the target and validates a deactivation or anonymization request.
+func (s *Service) checkRemovable(ctx context.Context, orgID, actorID, id string, tickets TicketHandoff) (*User, error) {
+	if actorID == id {
+		return nil, ErrSelfDeactivation
+	}
+	user, err := s.repo.Get(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.AnonymizedAt != nil {
+		return nil, ErrNotFound
+	}
+	if user.Role == "admin" && user.Active {
+		admins, err := s.repo.CountActiveAdmins(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		if admins <= 1 {
+			return nil, ErrLastAdmin
+		}
+	}
+	if tickets.ReassignTo != nil {
+		if *tickets.ReassignTo == id {
+			return nil, ErrInvalidReassignment
+		}
+		target, err := s.repo.Get(ctx, orgID, *tickets.ReassignTo)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil || !target.Active {
+			return nil, ErrInvalidReassignment
+		}
+	}
+	return user, nil
+}
+
+func (s *Service) revokeAccess(ctx context.Context, id string) error {
+	if s.tokens == nil {
+		return nil
+	}
+	return s.tokens.RevokeUser(ctx, id)
+}
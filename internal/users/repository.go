@@ -2,11 +2,30 @@ package users
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// userColumns is the column list scanned by scanUser.
+const userColumns = `id, name, username, COALESCE(email, ''), email_verified, role, COALESCE(avatar_url, ''),
+       COALESCE(badges, ARRAY[]::text[]), COALESCE(bio, ''), timezone, created_at, deactivated_at, anonymized_at`
+
+func scanUser(row pgx.Row) (*User, error) {
+	var u User
+	if err := row.Scan(&u.ID, &u.Name, &u.Username, &u.Email, &u.EmailVerified, &u.Role, &u.AvatarURL, &u.Badges, &u.Bio, &u.Timezone, &u.CreatedAt, &u.DeactivatedAt, &u.AnonymizedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	u.Active = u.DeactivatedAt == nil
+	return &u, nil
+}
+
 // Repository handles persistence for users.
 type Repository struct {
 	db *pgxpool.Pool
@@ -16,79 +35,254 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) List(ctx context.Context, limit int) ([]User, error) {
-	const query = `
-SELECT id, name, username, COALESCE(email, ''), email_verified, role, COALESCE(avatar_url, ''), COALESCE(badges, ARRAY[]::text[]), COALESCE(bio, ''), created_at
+// List returns one page of users and the cursor of the next page, if any.
+func (r *Repository) List(ctx context.Context, filter ListFilter) ([]User, *ListCursor, error) {
+	var (
+		args []any
+		idx  = 1
+		sb   strings.Builder
+	)
+	sb.WriteString(`SELECT ` + userColumns + `
 FROM users
-ORDER BY name
-LIMIT $1`
-	rows, err := r.db.Query(ctx, query, limit)
+WHERE org_id = $1`)
+	args = append(args, filter.OrgID)
+	idx++
+	switch filter.Status {
+	case "all":
+	case "deactivated":
+		sb.WriteString(" AND deactivated_at IS NOT NULL")
+	default:
+		sb.WriteString(" AND deactivated_at IS NULL")
+	}
+	if filter.Role != "" {
+		sb.WriteString(fmt.Sprintf(" AND role::text = $%d", idx))
+		args = append(args, filter.Role)
+		idx++
+	}
+	if filter.Search != "" {
+		sb.WriteString(fmt.Sprintf(" AND (name ILIKE $%d OR username ILIKE $%d OR email ILIKE $%d)", idx, idx, idx))
+		args = append(args, "%"+likeEscaper.Replace(filter.Search)+"%")
+		idx++
+	}
+	if filter.After != nil {
+		sb.WriteString(fmt.Sprintf(" AND (name, id) > ($%d, $%d::uuid)", idx, idx+1))
+		args = append(args, filter.After.Name, filter.After.ID)
+		idx += 2
+	}
+	// fetch one extra row to know whether there is a next page
+	sb.WriteString(fmt.Sprintf(" ORDER BY name, id LIMIT $%d", idx))
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.db.Query(ctx, sb.String(), args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var users []User
+	users := []User{}
 	for rows.Next() {
-		var u User
-		if err := rows.Scan(&u.ID, &u.Name, &u.Username, &u.Email, &u.EmailVerified, &u.Role, &u.AvatarURL, &u.Badges, &u.Bio, &u.CreatedAt); err != nil {
-			return nil, err
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, nil, err
 		}
-		users = append(users, u)
+		users = append(users, *u)
 	}
-	return users, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var next *ListCursor
+	if len(users) > filter.Limit {
+		users = users[:filter.Limit]
+		last := users[len(users)-1]
+		next = &ListCursor{Name: last.Name, ID: last.ID}
+	}
+	return users, next, nil
 }
 
-func (r *Repository) Get(ctx context.Context, id string) (*User, error) {
-	const query = `
-SELECT id, name, username, COALESCE(email, ''), email_verified, role, COALESCE(avatar_url, ''), COALESCE(badges, ARRAY[]::text[]), COALESCE(bio, ''), created_at
+// likeEscaper escapes LIKE wildcards in user-supplied search terms.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *Repository) Get(ctx context.Context, orgID, id string) (*User, error) {
+	query := `
+SELECT ` + userColumns + `
 FROM users
-WHERE id = $1`
-	var u User
-	if err := r.db.QueryRow(ctx, query, id).Scan(&u.ID, &u.Name, &u.Username, &u.Email, &u.EmailVerified, &u.Role, &u.AvatarURL, &u.Badges, &u.Bio, &u.CreatedAt); err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &u, nil
+WHERE id = $1 AND org_id = $2`
+	return scanUser(r.db.QueryRow(ctx, query, id, orgID))
 }
 
-func (r *Repository) UpdateProfile(ctx context.Context, id string, input UpdateProfileInput) (*User, error) {
-	const query = `
+func (r *Repository) UpdateProfile(ctx context.Context, orgID, id string, input UpdateProfileInput) (*User, error) {
+	query := `
 UPDATE users
 SET name = COALESCE(NULLIF($2, ''), name),
     bio = $3,
     avatar_url = COALESCE(NULLIF($4, ''), avatar_url),
+    timezone = COALESCE(NULLIF($6, ''), timezone),
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, username, COALESCE(email, ''), email_verified, role, COALESCE(avatar_url, ''), COALESCE(badges, ARRAY[]::text[]), COALESCE(bio, ''), created_at`
-	var u User
-	if err := r.db.QueryRow(ctx, query, id, input.Name, input.Bio, input.AvatarURL).Scan(
-		&u.ID, &u.Name, &u.Username, &u.Email, &u.EmailVerified, &u.Role, &u.AvatarURL, &u.Badges, &u.Bio, &u.CreatedAt,
-	); err != nil {
+WHERE id = $1 AND org_id = $5
+RETURNING ` + userColumns
+	return scanUser(r.db.QueryRow(ctx, query, id, input.Name, input.Bio, input.AvatarURL, orgID, input.Timezone))
+}
+
+func (r *Repository) UpdateRole(ctx context.Context, orgID, id, role string) (*User, error) {
+	query := `
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1 AND org_id = $3
+RETURNING ` + userColumns
+	return scanUser(r.db.QueryRow(ctx, query, id, role, orgID))
+}
+
+// CountActiveAdmins counts the organization's admins that can still sign in.
+func (r *Repository) CountActiveAdmins(ctx context.Context, orgID string) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE org_id = $1 AND role = 'admin' AND deactivated_at IS NULL`, orgID).Scan(&n)
+	return n, err
+}
+
+// Deactivate blocks the user, revokes their refresh and API tokens and hands
+// off open tickets, all in one transaction. It returns nil if the user was
+// already deactivated or does not exist in orgID.
+func (r *Repository) Deactivate(ctx context.Context, orgID, id, actorID string, tickets TicketHandoff) (*User, int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+UPDATE users
+SET deactivated_at = NOW(), deactivated_by = $2, updated_at = NOW()
+WHERE id = $1 AND org_id = $3 AND deactivated_at IS NULL
+RETURNING ` + userColumns
+	user, err := scanUser(tx.QueryRow(ctx, query, id, actorID, orgID))
+	if err != nil || user == nil {
+		return nil, 0, err
+	}
+	if err := revokeCredentials(ctx, tx, id); err != nil {
+		return nil, 0, err
+	}
+	moved, err := handOffTickets(ctx, tx, id, actorID, user.Name, tickets)
+	if err != nil {
+		return nil, 0, err
+	}
+	return user, moved, tx.Commit(ctx)
+}
+
+// Reactivate lifts a deactivation. Anonymized users cannot come back.
+func (r *Repository) Reactivate(ctx context.Context, orgID, id string) (*User, error) {
+	query := `
+UPDATE users
+SET deactivated_at = NULL, deactivated_by = NULL, updated_at = NOW()
+WHERE id = $1 AND org_id = $2 AND deactivated_at IS NOT NULL AND anonymized_at IS NULL
+RETURNING ` + userColumns
+	return scanUser(r.db.QueryRow(ctx, query, id, orgID))
+}
+
+// Anonymize scrubs personal data while keeping the row, so xp_events,
+// audit_log, tickets and comments still reference a valid user. It returns
+// nil if the user does not exist in orgID or was already anonymized.
+func (r *Repository) Anonymize(ctx context.Context, orgID, id, actorID string, tickets TicketHandoff) (*User, int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var username string
+	if err := tx.QueryRow(ctx, `SELECT username FROM users WHERE id = $1 AND org_id = $2 AND anonymized_at IS NULL FOR UPDATE`, id, orgID).Scan(&username); err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, nil
+			return nil, 0, nil
 		}
-		return nil, err
+		return nil, 0, err
+	}
+	moved, err := handOffTickets(ctx, tx, id, actorID, "a deleted user", tickets)
+	if err != nil {
+		return nil, 0, err
 	}
-	return &u, nil
-}
 
-func (r *Repository) UpdateRole(ctx context.Context, id, role string) (*User, error) {
-	const query = `
+	query := `
 UPDATE users
-SET role = $2, updated_at = NOW()
+SET name = 'Deleted user',
+    username = 'deleted-' || left(replace(id::text, '-', ''), 12),
+    email = NULL,
+    email_verified = false,
+    verification_token = NULL,
+    verification_token_expires = NULL,
+    password_reset_token_hash = NULL,
+    password_reset_expires = NULL,
+    password_hash = '!',
+    mfa_enabled = false,
+    mfa_secret = NULL,
+    avatar_url = NULL,
+    bio = NULL,
+    badges = ARRAY[]::text[],
+    deactivated_at = COALESCE(deactivated_at, NOW()),
+    deactivated_by = COALESCE(deactivated_by, $2),
+    anonymized_at = NOW(),
+    updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, username, COALESCE(email, ''), email_verified, role, COALESCE(avatar_url, ''), COALESCE(badges, ARRAY[]::text[]), COALESCE(bio, ''), created_at`
-	var u User
-	if err := r.db.QueryRow(ctx, query, id, role).Scan(
-		&u.ID, &u.Name, &u.Username, &u.Email, &u.EmailVerified, &u.Role, &u.AvatarURL, &u.Badges, &u.Bio, &u.CreatedAt,
-	); err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
+RETURNING ` + userColumns
+	user, err := scanUser(tx.QueryRow(ctx, query, id, actorID))
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := revokeCredentials(ctx, tx, id); err != nil {
+		return nil, 0, err
+	}
+	scrub := []struct {
+		sql string
+		arg string
+	}{
+		{`UPDATE ticket_comments SET text = '[comment removed]' WHERE author_id = $1`, id},
+		{`DELETE FROM project_members WHERE user_id = $1`, id},
+		{`DELETE FROM team_members WHERE user_id = $1`, id},
+		{`UPDATE teams SET lead_id = NULL WHERE lead_id = $1`, id},
+		{`DELETE FROM user_identities WHERE user_id = $1`, id},
+		{`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, id},
+		{`DELETE FROM mfa_challenges WHERE user_id = $1`, id},
+		{`DELETE FROM login_failures WHERE key = 'user:' || lower($1)`, username},
+	}
+	for _, q := range scrub {
+		if _, err := tx.Exec(ctx, q.sql, q.arg); err != nil {
+			return nil, 0, err
 		}
-		return nil, err
 	}
-	return &u, nil
+	return user, moved, tx.Commit(ctx)
+}
+
+func revokeCredentials(ctx context.Context, tx pgx.Tx, userID string) error {
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `UPDATE api_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	return err
+}
+
+// handOffTickets reassigns or unassigns the user's unfinished tickets and
+// records it in each ticket's history. It returns how many tickets moved.
+func handOffTickets(ctx context.Context, tx pgx.Tx, userID, actorID, fromName string, tickets TicketHandoff) (int64, error) {
+	if tickets.ReassignTo == nil && !tickets.Unassign {
+		return 0, nil
+	}
+	note := "Unassigned because " + fromName + " left the workspace"
+	if tickets.ReassignTo != nil {
+		var toName string
+		if err := tx.QueryRow(ctx, `SELECT name FROM users WHERE id = $1`, *tickets.ReassignTo).Scan(&toName); err != nil {
+			return 0, err
+		}
+		note = "Reassigned from " + fromName + " to " + toName + " because they left the workspace"
+	}
+	const query = `
+WITH moved AS (
+  UPDATE tickets SET assignee_id = $2, updated_at = $5
+  WHERE assignee_id = $1 AND status <> 'done'
+  RETURNING id
+)
+INSERT INTO ticket_history (ticket_id, text, actor_id, timestamp)
+SELECT id, $3, $4, $5 FROM moved`
+	tag, err := tx.Exec(ctx, query, userID, tickets.ReassignTo, note, actorID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
 }
@@ -2,31 +2,46 @@ package users
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"backend-go-ticketing-gamify/internal/audit"
 )
 
 // Service exposes user use-cases.
 type Service struct {
-	repo  *Repository
-	audit *audit.Service
+	repo   *Repository
+	audit  *audit.Service
+	tokens TokenRevoker
 }
 
-func NewService(repo *Repository, auditSvc *audit.Service) *Service {
-	return &Service{repo: repo, audit: auditSvc}
+// NewService wires the user service. tokens may be nil.
+func NewService(repo *Repository, auditSvc *audit.Service, tokens TokenRevoker) *Service {
+	return &Service{repo: repo, audit: auditSvc, tokens: tokens}
 }
 
-func (s *Service) List(ctx context.Context, limit int) ([]User, error) {
-	return s.repo.List(ctx, limit)
+func (s *Service) List(ctx context.Context, filter ListFilter) ([]User, *ListCursor, error) {
+	return s.repo.List(ctx, filter)
 }
 
-func (s *Service) Get(ctx context.Context, id string) (*User, error) {
-	return s.repo.Get(ctx, id)
+func (s *Service) Get(ctx context.Context, orgID, id string) (*User, error) {
+	return s.repo.Get(ctx, orgID, id)
 }
 
-func (s *Service) UpdateProfile(ctx context.Context, id string, input UpdateProfileInput) (*User, error) {
-	user, err := s.repo.UpdateProfile(ctx, id, input)
+// ErrInvalidTimezone is returned for a profile timezone that is not a known IANA zone.
+var ErrInvalidTimezone = errors.New("timezone must be an IANA zone name such as Europe/Berlin")
+
+func (s *Service) UpdateProfile(ctx context.Context, orgID, id string, input UpdateProfileInput) (*User, error) {
+	input.Timezone = strings.TrimSpace(input.Timezone)
+	if input.Timezone != "" {
+		// time.LoadLocation also accepts "Local", which means the server's zone.
+		if _, err := time.LoadLocation(input.Timezone); err != nil || input.Timezone == "Local" {
+			return nil, ErrInvalidTimezone
+		}
+	}
+	user, err := s.repo.UpdateProfile(ctx, orgID, id, input)
 	if err != nil || user == nil {
 		return user, err
 	}
@@ -40,8 +55,8 @@ func (s *Service) UpdateProfile(ctx context.Context, id string, input UpdateProf
 	return user, nil
 }
 
-func (s *Service) UpdateRole(ctx context.Context, id, role string) (*User, error) {
-	user, err := s.repo.UpdateRole(ctx, id, role)
+func (s *Service) UpdateRole(ctx context.Context, orgID, id, role string) (*User, error) {
+	user, err := s.repo.UpdateRole(ctx, orgID, id, role)
 	if err != nil || user == nil {
 		return user, err
 	}
@@ -4,16 +4,40 @@ import "time"
 
 // User represents a workspace user profile.
 type User struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	Username      string    `json:"username"`
-	Email         string    `json:"email"`
-	EmailVerified bool      `json:"emailVerified"`
-	Role          string    `json:"role"`
-	AvatarURL     string    `json:"avatarUrl"`
-	Badges        []string  `json:"badges"`
-	Bio           string    `json:"bio"`
-	CreatedAt     time.Time `json:"createdAt"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Username      string   `json:"username"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"emailVerified"`
+	Role          string   `json:"role"`
+	AvatarURL     string   `json:"avatarUrl"`
+	Badges        []string `json:"badges"`
+	Bio           string   `json:"bio"`
+	// Timezone is the IANA zone used to decide which calendar day the user
+	// is on, e.g. for streaks.
+	Timezone  string    `json:"timezone"`
+	CreatedAt time.Time `json:"createdAt"`
+	Active    bool      `json:"active"`
+	// DeactivatedAt is set while the user cannot sign in; AnonymizedAt once
+	// their personal data was erased.
+	DeactivatedAt *time.Time `json:"deactivatedAt,omitempty"`
+	AnonymizedAt  *time.Time `json:"anonymizedAt,omitempty"`
+}
+
+// ListFilter narrows and pages the user list. Results are ordered by name, id.
+type ListFilter struct {
+	OrgID  string
+	Limit  int
+	Search string // matches name, username or email
+	Role   string
+	Status string      // active (default), deactivated or all
+	After  *ListCursor // keyset cursor: return users after this one
+}
+
+// ListCursor is the position of the last user on a page.
+type ListCursor struct {
+	Name string `json:"n"`
+	ID   string `json:"i"`
 }
 
 // UpdateProfileInput captures editable profile fields.
@@ -21,4 +45,19 @@ type UpdateProfileInput struct {
 	Name      string `json:"name"`
 	Bio       string `json:"bio"`
 	AvatarURL string `json:"avatarUrl"`
+	// Timezone is an IANA zone name such as "Europe/Berlin"; empty keeps the current one.
+	Timezone string `json:"timezone"`
+}
+
+// TicketHandoff says what happens to a leaving user's unfinished tickets:
+// reassigned to ReassignTo, unassigned, or (both empty) left as they are.
+type TicketHandoff struct {
+	ReassignTo *string `json:"reassignTo"`
+	Unassign   bool    `json:"unassignTickets"`
+}
+
+// LifecycleResult reports a deactivation or anonymization.
+type LifecycleResult struct {
+	User             *User `json:"user"`
+	TicketsHandedOff int64 `json:"ticketsHandedOff"`
 }
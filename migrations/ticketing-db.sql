@@ -1,157 +0,0 @@
--- WARNING: This schema is for context only and is not meant to be run.
--- Table order and constraints may not be valid for execution.
-
-CREATE TABLE public.audit_log (
-  id uuid NOT NULL DEFAULT gen_random_uuid(),
-  action character varying NOT NULL,
-  description text,
-  actor_id uuid,
-  entity_type character varying,
-  entity_id uuid,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT audit_log_pkey PRIMARY KEY (id),
-  CONSTRAINT audit_log_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES public.users(id)
-);
-CREATE TABLE public.epics (
-  id uuid NOT NULL DEFAULT gen_random_uuid(),
-  project_id uuid NOT NULL,
-  title character varying NOT NULL,
-  description text,
-  status USER-DEFINED NOT NULL DEFAULT 'backlog'::ticket_status,
-  start_date timestamp with time zone,
-  due_date timestamp with time zone,
-  owner_id uuid,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  updated_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT epics_pkey PRIMARY KEY (id),
-  CONSTRAINT epics_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id),
-  CONSTRAINT epics_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES public.users(id)
-);
-CREATE TABLE public.gamification_user_stats (
-  user_id uuid NOT NULL,
-  xp_total integer NOT NULL DEFAULT 0,
-  level integer NOT NULL DEFAULT 1,
-  next_level_threshold integer NOT NULL DEFAULT 100,
-  tickets_closed_count integer NOT NULL DEFAULT 0,
-  streak_days integer NOT NULL DEFAULT 0,
-  last_ticket_closed_at timestamp with time zone,
-  CONSTRAINT gamification_user_stats_pkey PRIMARY KEY (user_id),
-  CONSTRAINT gamification_user_stats_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id)
-);
-CREATE TABLE public.project_activity (
-  id uuid NOT NULL DEFAULT gen_random_uuid(),
-  project_id uuid NOT NULL,
-  actor_id uuid,
-  message text NOT NULL,
-  meta_json jsonb,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT project_activity_pkey PRIMARY KEY (id),
-  CONSTRAINT project_activity_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id),
-  CONSTRAINT project_activity_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES public.users(id)
-);
-CREATE TABLE public.project_invites (
-  code character varying NOT NULL,
-  project_id uuid NOT NULL,
-  max_uses integer NOT NULL DEFAULT 1,
-  uses integer NOT NULL DEFAULT 0,
-  expires_at timestamp with time zone,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT project_invites_pkey PRIMARY KEY (code),
-  CONSTRAINT project_invites_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id)
-);
-CREATE TABLE public.project_members (
-  project_id uuid NOT NULL,
-  user_id uuid NOT NULL,
-  member_role USER-DEFINED NOT NULL DEFAULT 'member'::project_member_role,
-  joined_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT project_members_pkey PRIMARY KEY (project_id, user_id),
-  CONSTRAINT project_members_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id),
-  CONSTRAINT project_members_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id)
-);
-CREATE TABLE public.projects (
-  id uuid NOT NULL DEFAULT gen_random_uuid(),
-  name character varying NOT NULL,
-  description text,
-  status USER-DEFINED NOT NULL DEFAULT 'Active'::project_status,
-  created_by uuid NOT NULL,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  updated_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT projects_pkey PRIMARY KEY (id),
-  CONSTRAINT projects_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id)
-);
-CREATE TABLE public.refresh_tokens (
-  id uuid NOT NULL,
-  user_id uuid NOT NULL,
-  token_hash text NOT NULL,
-  expires_at timestamp with time zone NOT NULL,
-  revoked_at timestamp with time zone,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT refresh_tokens_pkey PRIMARY KEY (id),
-  CONSTRAINT refresh_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id)
-);
-CREATE TABLE public.ticket_comments (
-  id uuid NOT NULL DEFAULT gen_random_uuid(),
-  ticket_id uuid NOT NULL,
-  author_id uuid NOT NULL,
-  text text NOT NULL,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT ticket_comments_pkey PRIMARY KEY (id),
-  CONSTRAINT ticket_comments_ticket_id_fkey FOREIGN KEY (ticket_id) REFERENCES public.tickets(id),
-  CONSTRAINT ticket_comments_author_id_fkey FOREIGN KEY (author_id) REFERENCES public.users(id)
-);
-CREATE TABLE public.ticket_history (
-  id uuid NOT NULL DEFAULT gen_random_uuid(),
-  ticket_id uuid NOT NULL,
-  text text NOT NULL,
-  actor_id uuid,
-  timestamp timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT ticket_history_pkey PRIMARY KEY (id),
-  CONSTRAINT ticket_history_ticket_id_fkey FOREIGN KEY (ticket_id) REFERENCES public.tickets(id),
-  CONSTRAINT ticket_history_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES public.users(id)
-);
-CREATE TABLE public.tickets (
-  id uuid NOT NULL DEFAULT gen_random_uuid(),
-  project_id uuid NOT NULL,
-  title character varying NOT NULL,
-  description text,
-  status USER-DEFINED NOT NULL DEFAULT 'todo'::ticket_status,
-  priority USER-DEFINED NOT NULL DEFAULT 'medium'::ticket_priority,
-  type USER-DEFINED NOT NULL DEFAULT 'feature'::ticket_type,
-  reporter_id uuid NOT NULL,
-  assignee_id uuid,
-  due_date date,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  updated_at timestamp with time zone NOT NULL DEFAULT now(),
-  start_date timestamp with time zone,
-  epic_id uuid,
-  CONSTRAINT tickets_pkey PRIMARY KEY (id),
-  CONSTRAINT tickets_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id),
-  CONSTRAINT tickets_reporter_id_fkey FOREIGN KEY (reporter_id) REFERENCES public.users(id),
-  CONSTRAINT tickets_assignee_id_fkey FOREIGN KEY (assignee_id) REFERENCES public.users(id),
-  CONSTRAINT tickets_epic_id_fkey FOREIGN KEY (epic_id) REFERENCES public.epics(id)
-);
-CREATE TABLE public.users (
-  id uuid NOT NULL DEFAULT gen_random_uuid(),
-  name character varying NOT NULL,
-  username character varying NOT NULL UNIQUE,
-  password_hash character varying NOT NULL,
-  role USER-DEFINED NOT NULL DEFAULT 'developer'::user_role,
-  avatar_url character varying,
-  badges ARRAY NOT NULL DEFAULT ARRAY[]::text[],
-  bio text,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  updated_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT users_pkey PRIMARY KEY (id)
-);
-CREATE TABLE public.xp_events (
-  id uuid NOT NULL DEFAULT gen_random_uuid(),
-  user_id uuid NOT NULL,
-  ticket_id uuid,
-  priority USER-DEFINED,
-  xp_value integer NOT NULL,
-  note text,
-  created_at timestamp with time zone NOT NULL DEFAULT now(),
-  CONSTRAINT xp_events_pkey PRIMARY KEY (id),
-  CONSTRAINT xp_events_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id),
-  CONSTRAINT xp_events_ticket_id_fkey FOREIGN KEY (ticket_id) REFERENCES public.tickets(id)
-);
\ No newline at end of file